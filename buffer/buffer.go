@@ -0,0 +1,210 @@
+// Package buffer computes the area within a distance of geometries and the
+// offset curves of lines.
+//
+// A positive distance grows the geometry, a negative one shrinks polygons
+// and leaves nothing of points and lines. Curves are approximated with
+// QuadrantSegments segments per quarter circle. Results are polygonal, an
+// empty buffer is an empty GeometryCollection, and coordinates are 2D.
+package buffer
+
+import (
+	"math"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/internal/topology"
+	"github.com/flywave/go-geom/overlay"
+)
+
+type CapStyle int
+
+const (
+	CapRound CapStyle = iota
+	CapFlat
+	CapSquare
+)
+
+type JoinStyle int
+
+const (
+	JoinRound JoinStyle = iota
+	JoinMitre
+	JoinBevel
+)
+
+// BufferOptions controls the shape of the buffer. A mitre longer than
+// MitreLimit times the distance is bevelled.
+type BufferOptions struct {
+	QuadrantSegments int
+	CapStyle         CapStyle
+	JoinStyle        JoinStyle
+	MitreLimit       float64
+}
+
+func DefaultBufferOptions() *BufferOptions {
+	return &BufferOptions{
+		QuadrantSegments: 8,
+		CapStyle:         CapRound,
+		JoinStyle:        JoinRound,
+		MitreLimit:       5,
+	}
+}
+
+// Buffer returns the area within distance of g, opt may be nil for the
+// default options.
+func Buffer(g *geom.GeometryData, distance float64, opt *BufferOptions) *geom.GeometryData {
+	if g == nil {
+		return nil
+	}
+	b := newBuilder(math.Abs(distance), opt)
+	ret := overlay.UnaryUnion(geom.NewCollectionGeometryData(b.buffer(g, distance)...))
+	ret.EPSG = g.EPSG
+	return ret
+}
+
+func (b *builder) buffer(g *geom.GeometryData, distance float64) []*geom.GeometryData {
+	var parts []*geom.GeometryData
+	switch g.Type {
+	case geom.GeometryPoint:
+		if distance > 0 {
+			parts = append(parts, b.pointBuffer(g.Point)...)
+		}
+	case geom.GeometryMultiPoint:
+		if distance > 0 {
+			for _, p := range g.MultiPoint {
+				parts = append(parts, b.pointBuffer(p)...)
+			}
+		}
+	case geom.GeometryLineString:
+		if distance > 0 {
+			parts = append(parts, b.lineBuffer(g.LineString)...)
+		}
+	case geom.GeometryMultiLineString:
+		if distance > 0 {
+			for _, l := range g.MultiLineString {
+				parts = append(parts, b.lineBuffer(l)...)
+			}
+		}
+	case geom.GeometryPolygon:
+		parts = append(parts, b.polygonBuffer(g.Polygon, distance)...)
+	case geom.GeometryMultiPolygon:
+		for _, p := range g.MultiPolygon {
+			parts = append(parts, b.polygonBuffer(p, distance)...)
+		}
+	case geom.GeometryCollection:
+		for _, c := range g.Geometries {
+			if c != nil {
+				parts = append(parts, b.buffer(c, distance)...)
+			}
+		}
+	}
+	return parts
+}
+
+func (b *builder) pointBuffer(p []float64) []*geom.GeometryData {
+	if len(p) < 2 {
+		return nil
+	}
+	var ring [][]float64
+	switch b.opt.CapStyle {
+	case CapFlat:
+		return nil
+	case CapSquare:
+		for _, c := range [4][2]float64{{1, -1}, {1, 1}, {-1, 1}, {-1, -1}} {
+			ring = append(ring, []float64{p[0] + c[0]*b.d, p[1] + c[1]*b.d})
+		}
+	default:
+		ring = append(ring, []float64{p[0] + b.d, p[1]})
+		ring = b.arc(ring, p, 0, 2*math.Pi)
+	}
+	ring = append(ring, ring[0])
+	return []*geom.GeometryData{geom.NewPolygonGeometryData([][][]float64{ring})}
+}
+
+func (b *builder) lineBuffer(l [][]float64) []*geom.GeometryData {
+	path := cleanPath(l)
+	switch len(path) {
+	case 0:
+		return nil
+	case 1:
+		return b.pointBuffer(path[0])
+	}
+	return windingArea(b.lineCurve(path))
+}
+
+// polygonBuffer grows the polygon by the buffers of its rings or removes
+// them from it.
+func (b *builder) polygonBuffer(p [][][]float64, distance float64) []*geom.GeometryData {
+	if len(p) == 0 {
+		return nil
+	}
+	polygon := geom.NewPolygonGeometryData(p)
+	if distance == 0 {
+		return []*geom.GeometryData{polygon}
+	}
+	var rings []*geom.GeometryData
+	for _, r := range p {
+		path := cleanPath(r)
+		if len(path) > 1 && !samePoint(path[0], path[len(path)-1]) {
+			path = append(path, path[0])
+		}
+		if len(path) < 4 {
+			if distance > 0 {
+				rings = append(rings, b.lineBuffer(path)...)
+			}
+			continue
+		}
+		rings = append(rings, windingArea(b.ringCurve(path))...)
+	}
+	if distance > 0 {
+		return append(rings, polygon)
+	}
+	return []*geom.GeometryData{overlay.Difference(polygon, overlay.UnaryUnion(geom.NewCollectionGeometryData(rings...)))}
+}
+
+// windingArea returns the polygons where the winding number of the closed
+// curve is positive.
+func windingArea(curve [][]float64) []*geom.GeometryData {
+	g := topology.New(geom.NewPolygonGeometryData([][][]float64{curve}), nil)
+	// 曲线是连通的
+	outer := g.OuterCycle()
+	if outer == nil {
+		return nil
+	}
+	winding := map[*topology.Cycle]int{outer: 0}
+	queue := []*topology.Cycle{outer}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		c.Walk(func(h *topology.HalfEdge) {
+			if t := h.Twin.Cycle; t != nil {
+				if _, ok := winding[t]; !ok {
+					winding[t] = winding[c] - h.Direction(0)
+					queue = append(queue, t)
+				}
+			}
+		})
+	}
+	polygons := g.Polygons(func(c *topology.Cycle) bool { return winding[c] > 0 })
+	if len(polygons) == 0 {
+		return nil
+	}
+	return []*geom.GeometryData{geom.NewMultiPolygonGeometryData(polygons...)}
+}
+
+func cleanPath(path [][]float64) [][]float64 {
+	ret := make([][]float64, 0, len(path))
+	for _, p := range path {
+		if len(p) < 2 {
+			continue
+		}
+		if n := len(ret); n > 0 && samePoint(ret[n-1], p) {
+			continue
+		}
+		ret = append(ret, p)
+	}
+	return ret
+}
+
+func samePoint(a, b []float64) bool {
+	return a[0] == b[0] && a[1] == b[1]
+}
@@ -0,0 +1,146 @@
+package buffer
+
+import (
+	"math"
+	"testing"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/measure"
+	"github.com/flywave/go-geom/relate"
+	"github.com/flywave/go-geom/valid"
+	"github.com/stretchr/testify/assert"
+)
+
+func options(cap CapStyle, join JoinStyle) *BufferOptions {
+	opt := DefaultBufferOptions()
+	opt.CapStyle = cap
+	opt.JoinStyle = join
+	return opt
+}
+
+// 正多边形的面积
+func circleArea(r float64, quadrant int) float64 {
+	n := float64(4 * quadrant)
+	return n / 2 * r * r * math.Sin(2*math.Pi/n)
+}
+
+func TestBufferPoint(t *testing.T) {
+	pt := geom.NewPointGeometryData([]float64{1, 2})
+	pt.EPSG = 3857
+	ret := Buffer(pt, 2, nil)
+	assert.Equal(t, geom.GeometryPolygon, ret.Type)
+	assert.Len(t, ret.Polygon[0], 33)
+	assert.InDelta(t, circleArea(2, 8), measure.AreaData(ret), 1e-9)
+	assert.Equal(t, 3857, ret.EPSG)
+
+	opt := DefaultBufferOptions()
+	opt.QuadrantSegments = 2
+	assert.Len(t, Buffer(pt, 2, opt).Polygon[0], 9)
+
+	assert.Equal(t, 16.0, measure.AreaData(Buffer(pt, 2, options(CapSquare, JoinRound))))
+
+	for _, empty := range []*geom.GeometryData{Buffer(pt, 2, options(CapFlat, JoinRound)), Buffer(pt, -1, nil), Buffer(pt, 0, nil)} {
+		assert.Equal(t, geom.GeometryCollection, empty.Type)
+		assert.Len(t, empty.Geometries, 0)
+	}
+
+	// 相交的圆合并为一个多边形
+	mp := Buffer(geom.NewMultiPointGeometryData([]float64{0, 0}, []float64{1, 0}, []float64{10, 0}), 1, nil)
+	assert.Equal(t, geom.GeometryMultiPolygon, mp.Type)
+	assert.Len(t, mp.MultiPolygon, 2)
+}
+
+func TestBufferLine(t *testing.T) {
+	l := geom.NewLineStringGeometryData([][]float64{{0, 0}, {10, 0}, {10, 10}})
+
+	ret := Buffer(l, 1, options(CapFlat, JoinMitre))
+	assert.True(t, relate.Equals(geom.NewPolygonGeometryData([][][]float64{{{0, -1}, {11, -1}, {11, 10}, {9, 10}, {9, 1}, {0, 1}, {0, -1}}}), ret))
+	assert.Equal(t, 39.5, measure.AreaData(Buffer(l, 1, options(CapFlat, JoinBevel))))
+	assert.Equal(t, 44.0, measure.AreaData(Buffer(l, 1, options(CapSquare, JoinMitre))))
+
+	ret = Buffer(l, 1, nil)
+	assert.True(t, valid.IsValid(ret))
+	// 两端的半圆与外侧转角的四分之一圆
+	assert.InDelta(t, 39+1.25*circleArea(1, 8), measure.AreaData(ret), 1e-9)
+
+	// 超过斜接限制时改为斜切
+	sharp := geom.NewLineStringGeometryData([][]float64{{0, 0}, {10, 0}, {0, 10}})
+	opt := options(CapFlat, JoinMitre)
+	mitre := Buffer(sharp, 1, opt)
+	opt.MitreLimit = 2
+	bevel := Buffer(sharp, 1, opt)
+	assert.True(t, measure.AreaData(mitre) > measure.AreaData(bevel))
+	assert.True(t, relate.Equals(bevel, Buffer(sharp, 1, options(CapFlat, JoinBevel))))
+
+	// 自相交的线
+	loop := Buffer(geom.NewLineStringGeometryData([][]float64{{0, 0}, {10, 0}, {10, 10}, {5, 10}, {5, -5}}), 1, nil)
+	assert.True(t, valid.IsValid(loop))
+	assert.Equal(t, geom.GeometryPolygon, loop.Type)
+	assert.Len(t, loop.Polygon, 2)
+
+	assert.Len(t, Buffer(l, -1, nil).Geometries, 0)
+	assert.InDelta(t, circleArea(1, 8), measure.AreaData(Buffer(geom.NewLineStringGeometryData([][]float64{{1, 1}, {1, 1}}), 1, nil)), 1e-9)
+
+	ml := Buffer(geom.NewMultiLineStringGeometryData([][]float64{{0, 0}, {10, 0}}, [][]float64{{5, -5}, {5, 5}}), 1, options(CapFlat, JoinRound))
+	assert.Equal(t, 36.0, measure.AreaData(ml))
+}
+
+func TestBufferPolygon(t *testing.T) {
+	square := geom.NewPolygonGeometryData([][][]float64{{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}})
+	holed := geom.NewPolygonGeometryData([][][]float64{{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}, {{4, 4}, {4, 6}, {6, 6}, {6, 4}, {4, 4}}})
+	mitre := options(CapRound, JoinMitre)
+
+	assert.True(t, relate.Equals(geom.NewPolygonGeometryData([][][]float64{{{-1, -1}, {11, -1}, {11, 11}, {-1, 11}, {-1, -1}}}), Buffer(holed, 1, mitre)))
+	assert.Equal(t, 48.0, measure.AreaData(Buffer(holed, -1, mitre)))
+	assert.Equal(t, 120.0, measure.AreaData(Buffer(holed, 0.5, mitre)))
+	assert.Equal(t, 96.0, measure.AreaData(Buffer(holed, 0, mitre)))
+	assert.Len(t, Buffer(square, -5, nil).Geometries, 0)
+
+	ret := Buffer(square, 1, nil)
+	assert.True(t, valid.IsValid(ret))
+	assert.InDelta(t, 140+circleArea(1, 8), measure.AreaData(ret), 1e-9)
+
+	ret = Buffer(square, -1, nil)
+	assert.True(t, relate.Equals(geom.NewPolygonGeometryData([][][]float64{{{1, 1}, {9, 1}, {9, 9}, {1, 9}, {1, 1}}}), ret))
+
+	// 负缓冲使多边形断开
+	dumbbell := geom.NewPolygonGeometryData([][][]float64{{{0, 0}, {4, 0}, {4, 1.5}, {6, 1.5}, {6, 0}, {10, 0}, {10, 4}, {6, 4}, {6, 2.5}, {4, 2.5}, {4, 4}, {0, 4}, {0, 0}}})
+	ret = Buffer(dumbbell, -1, mitre)
+	assert.Equal(t, geom.GeometryMultiPolygon, ret.Type)
+	assert.Equal(t, 8.0, measure.AreaData(ret))
+
+	col := Buffer(geom.NewCollectionGeometryData(square, geom.NewPointGeometryData([]float64{20, 20})), 1, mitre)
+	assert.Equal(t, geom.GeometryMultiPolygon, col.Type)
+	assert.InDelta(t, 144+circleArea(1, 8), measure.AreaData(col), 1e-9)
+}
+
+func TestOffsetCurve(t *testing.T) {
+	l := geom.NewLineStringGeometryData([][]float64{{0, 0}, {10, 0}, {10, 10}})
+	l.EPSG = 4326
+
+	left := OffsetCurve(l, 1, nil)
+	assert.Equal(t, [][]float64{{0, 1}, {9, 1}, {9, 10}}, left.LineString)
+	assert.Equal(t, 4326, left.EPSG)
+
+	right := OffsetCurve(l, -1, options(CapRound, JoinMitre))
+	assert.Equal(t, [][]float64{{0, -1}, {11, -1}, {11, 10}}, right.LineString)
+	assert.Len(t, OffsetCurve(l, -1, nil).LineString, 11)
+	assert.Equal(t, [][]float64{{0, -1}, {10, -1}, {11, 0}, {11, 10}}, OffsetCurve(l, -1, options(CapRound, JoinBevel)).LineString)
+
+	// 内侧的短边形成的环被剪掉
+	bend := geom.NewLineStringGeometryData([][]float64{{0, 0}, {10, 0}, {10.2, 0.1}, {10.3, 0.3}, {10.3, 10}})
+	ret := OffsetCurve(bend, 1, nil)
+	assert.Equal(t, []float64{0, 1}, ret.LineString[0])
+	assert.Equal(t, []float64{9.3, 10}, ret.LineString[len(ret.LineString)-1])
+	assert.Len(t, ret.LineString, 3)
+	assert.InDelta(t, 9.3, ret.LineString[1][0], 1e-9)
+	assert.InDelta(t, 1, ret.LineString[1][1], 1e-9)
+
+	ring := geom.NewMultiLineStringGeometryData([][]float64{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}})
+	ret = OffsetCurve(ring, 1, nil)
+	assert.Equal(t, geom.GeometryMultiLineString, ret.Type)
+	assert.Equal(t, [][]float64{{9, 1}, {9, 9}, {1, 9}, {1, 1}, {9, 1}}, ret.MultiLineString[0])
+
+	assert.Equal(t, l.LineString, OffsetCurve(l, 0, nil).LineString)
+	assert.Nil(t, OffsetCurve(geom.NewPointGeometryData([]float64{0, 0}), 1, nil))
+}
@@ -0,0 +1,167 @@
+package buffer
+
+import "math"
+
+// builder traces the raw offset curves of paths at distance d. The curves
+// may loop on themselves at inner bends, the buffer is the area they wind
+// around positively.
+type builder struct {
+	d    float64
+	opt  *BufferOptions
+	step float64
+}
+
+func newBuilder(d float64, opt *BufferOptions) *builder {
+	if opt == nil {
+		opt = DefaultBufferOptions()
+	}
+	quadrant := opt.QuadrantSegments
+	if quadrant < 1 {
+		quadrant = 1
+	}
+	return &builder{d: d, opt: opt, step: math.Pi / 2 / float64(quadrant)}
+}
+
+// lineCurve runs along the right of the path, around its end, back along
+// its left and around its start, keeping the line on its left.
+func (b *builder) lineCurve(path [][]float64) [][]float64 {
+	back := reversed(path)
+	curve := b.offsetRight(path, false)
+	curve = b.cap(curve, path[len(path)-2], path[len(path)-1])
+	curve = append(curve, b.offsetRight(back, false)...)
+	curve = b.cap(curve, back[len(back)-2], back[len(back)-1])
+	return append(curve, curve[0])
+}
+
+// ringCurve joins the offset loops on both sides of a closed ring through
+// its first vertex, the bridge is walked both ways so it does not wind.
+func (b *builder) ringCurve(ring [][]float64) [][]float64 {
+	curve := b.offsetRight(ring, true)
+	curve = append(curve, ring[0])
+	curve = append(curve, b.offsetRight(reversed(ring), true)...)
+	curve = append(curve, ring[0], curve[0])
+	return curve
+}
+
+// offsetRight offsets the path on its right, a closed path gives a closed
+// loop starting at its first vertex.
+func (b *builder) offsetRight(path [][]float64, closed bool) [][]float64 {
+	n := len(path) - 1
+	pts := make([][]float64, 0, 2*n+2)
+	if closed {
+		pts = b.join(pts, path[0], path[n-1], path[1])
+	} else {
+		start, _ := b.offsetSegment(path[0], path[1])
+		pts = append(pts, start)
+	}
+	for i := 1; i < n; i++ {
+		pts = b.join(pts, path[i], path[i-1], path[i+1])
+	}
+	if closed {
+		return append(pts, pts[0])
+	}
+	_, end := b.offsetSegment(path[n-1], path[n])
+	return append(pts, end)
+}
+
+// offsetSegment returns the segment pq moved by d on its right.
+func (b *builder) offsetSegment(p, q []float64) ([]float64, []float64) {
+	nx, ny := rightNormal(p, q)
+	return []float64{p[0] + nx*b.d, p[1] + ny*b.d}, []float64{q[0] + nx*b.d, q[1] + ny*b.d}
+}
+
+// join connects at v the offsets of the segments from prev and to next.
+func (b *builder) join(pts [][]float64, v, prev, next []float64) [][]float64 {
+	_, e := b.offsetSegment(prev, v)
+	s, _ := b.offsetSegment(v, next)
+	ux, uy := unit(prev, v)
+	wx, wy := unit(v, next)
+	cross := ux*wy - uy*wx
+	dot := ux*wx + uy*wy
+	switch {
+	case math.Abs(cross) < 1e-12 && dot > 0:
+		return append(pts, e)
+	case cross < 0 && math.Abs(cross) >= 1e-12:
+		// 内侧转角经过顶点, 多出的小环绕数为正, 不影响结果
+		return append(pts, e, v, s)
+	}
+	switch b.opt.JoinStyle {
+	case JoinMitre:
+		n1x, n1y := rightNormal(prev, v)
+		n2x, n2y := rightNormal(v, next)
+		if c := 1 + n1x*n2x + n1y*n2y; c > 0 && math.Sqrt(2/c) <= b.opt.MitreLimit {
+			return append(pts, []float64{v[0] + (n1x+n2x)*b.d/c, v[1] + (n1y+n2y)*b.d/c})
+		}
+	case JoinRound:
+		pts = append(pts, e)
+		pts = b.arc(pts, v, angle(v, e), sweep(angle(v, e), angle(v, s)))
+		return append(pts, s)
+	}
+	return append(pts, e, s)
+}
+
+// cap goes around the end q of the segment pq, from its right to its left.
+func (b *builder) cap(pts [][]float64, p, q []float64) [][]float64 {
+	switch b.opt.CapStyle {
+	case CapFlat:
+	case CapSquare:
+		ux, uy := unit(p, q)
+		for _, side := range [2]float64{1, -1} {
+			nx, ny := uy*side, -ux*side
+			pts = append(pts, []float64{q[0] + (ux+nx)*b.d, q[1] + (uy+ny)*b.d})
+		}
+	default:
+		nx, ny := rightNormal(p, q)
+		a := math.Atan2(ny, nx)
+		pts = append(pts, []float64{q[0] + nx*b.d, q[1] + ny*b.d})
+		pts = b.arc(pts, q, a, math.Pi)
+		pts = append(pts, []float64{q[0] - nx*b.d, q[1] - ny*b.d})
+	}
+	return pts
+}
+
+// arc appends the points strictly inside the arc of radius d around c
+// starting at angle a0.
+func (b *builder) arc(pts [][]float64, c []float64, a0, sweep float64) [][]float64 {
+	n := int(math.Ceil(math.Abs(sweep)/b.step - 1e-9))
+	for i := 1; i < n; i++ {
+		a := a0 + sweep*float64(i)/float64(n)
+		pts = append(pts, []float64{c[0] + b.d*math.Cos(a), c[1] + b.d*math.Sin(a)})
+	}
+	return pts
+}
+
+func rightNormal(p, q []float64) (float64, float64) {
+	ux, uy := unit(p, q)
+	return uy, -ux
+}
+
+func unit(p, q []float64) (float64, float64) {
+	dx, dy := q[0]-p[0], q[1]-p[1]
+	l := math.Hypot(dx, dy)
+	return dx / l, dy / l
+}
+
+func angle(c, p []float64) float64 {
+	return math.Atan2(p[1]-c[1], p[0]-c[0])
+}
+
+// sweep returns the counter-clockwise turn from angle a to b.
+func sweep(a, b float64) float64 {
+	s := b - a
+	for s <= 0 {
+		s += 2 * math.Pi
+	}
+	for s > 2*math.Pi {
+		s -= 2 * math.Pi
+	}
+	return s
+}
+
+func reversed(path [][]float64) [][]float64 {
+	ret := make([][]float64, len(path))
+	for i, p := range path {
+		ret[len(path)-1-i] = p
+	}
+	return ret
+}
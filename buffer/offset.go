@@ -0,0 +1,214 @@
+package buffer
+
+import (
+	"math"
+
+	"github.com/flywave/go-geom"
+)
+
+// OffsetCurve moves lines sideways by distance, to their left when it is
+// positive and to their right otherwise, joining the offset segments with
+// the join style of opt. The loops the offset forms at tight inner bends are
+// cut out. Only LineString and MultiLineString are offset, other types give
+// nil.
+func OffsetCurve(g *geom.GeometryData, distance float64, opt *BufferOptions) *geom.GeometryData {
+	if g == nil {
+		return nil
+	}
+	b := newBuilder(math.Abs(distance), opt)
+	offset := func(l [][]float64) [][]float64 {
+		path := cleanPath(l)
+		if len(path) < 2 || distance == 0 {
+			return path
+		}
+		closed := len(path) > 3 && samePoint(path[0], path[len(path)-1])
+		if closed {
+			path = openRing(path)
+		}
+		var pts [][]float64
+		if distance < 0 {
+			pts = b.offsetRight(path, false)
+		} else {
+			pts = reversed(b.offsetRight(reversed(path), false))
+		}
+		pts = trimLoops(pts, path, b.d)
+		if n := len(pts); closed && n > 3 && orient(pts[n-2], pts[0], pts[1]) == 0 {
+			pts = append(pts[1:n-1], pts[1])
+		}
+		return pts
+	}
+	res := *g
+	switch g.Type {
+	case geom.GeometryLineString:
+		res.LineString = offset(g.LineString)
+	case geom.GeometryMultiLineString:
+		res.MultiLineString = make([][][]float64, len(g.MultiLineString))
+		for i, l := range g.MultiLineString {
+			res.MultiLineString[i] = offset(l)
+		}
+	default:
+		return nil
+	}
+	return &res
+}
+
+// openRing starts the ring in the middle of its longest segment, so the
+// corners of the offset are all cut the same way.
+func openRing(ring [][]float64) [][]float64 {
+	longest, max := 0, -1.0
+	for i := 1; i < len(ring); i++ {
+		if l := math.Hypot(ring[i][0]-ring[i-1][0], ring[i][1]-ring[i-1][1]); l > max {
+			longest, max = i-1, l
+		}
+	}
+	a, b := ring[longest], ring[longest+1]
+	mid := []float64{(a[0] + b[0]) / 2, (a[1] + b[1]) / 2}
+	ret := make([][]float64, 0, len(ring)+1)
+	ret = append(ret, mid)
+	ret = append(ret, ring[longest+1:]...)
+	ret = append(ret, ring[1:longest+1]...)
+	return append(ret, mid)
+}
+
+// trimLoops cuts the loops of the raw offset curve made of points closer
+// than d to the line, then drops the points still too close.
+func trimLoops(pts, line [][]float64, d float64) [][]float64 {
+	length := 0.0
+	for i := 1; i < len(line); i++ {
+		length += math.Abs(line[i][0]-line[i-1][0]) + math.Abs(line[i][1]-line[i-1][1])
+	}
+	cell := math.Max(d, length/float64(len(line)-1))
+	lines := newGrid(cell)
+	for i := 1; i < len(line); i++ {
+		lines.insert(i-1, line[i-1], line[i])
+	}
+	near := func(p []float64) bool {
+		found := false
+		lines.query(p[0]-d, p[1]-d, p[0]+d, p[1]+d, func(i int) bool {
+			found = segmentDistance(p, line[i], line[i+1]) < d*(1-1e-9)
+			return !found
+		})
+		return found
+	}
+
+	// 被截断的线段仍留在格网中, 查询时按当前坐标重新判断
+	out := make([][]float64, 0, len(pts))
+	segs := newGrid(cell)
+	for _, q := range pts {
+		if len(out) > 0 && samePoint(out[len(out)-1], q) {
+			continue
+		}
+		for len(out) > 1 {
+			a := out[len(out)-1]
+			cut, at := -1, []float64(nil)
+			segs.query(math.Min(a[0], q[0]), math.Min(a[1], q[1]), math.Max(a[0], q[0]), math.Max(a[1], q[1]), func(k int) bool {
+				if k >= len(out)-2 || k <= cut {
+					return true
+				}
+				if x := crossing(out[k], out[k+1], a, q); x != nil {
+					cut, at = k, x
+				}
+				return true
+			})
+			if cut < 0 {
+				break
+			}
+			loop := true
+			for _, p := range out[cut+1:] {
+				if !near(p) {
+					loop = false
+					break
+				}
+			}
+			if !loop {
+				break
+			}
+			out = append(out[:cut+1], at)
+			segs.insert(cut, out[cut], at)
+		}
+		if n := len(out); n > 0 {
+			segs.insert(n-1, out[n-1], q)
+		}
+		out = append(out, q)
+	}
+
+	ret := make([][]float64, 0, len(out))
+	for i, p := range out {
+		if i == 0 || i == len(out)-1 || !near(p) {
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
+
+// grid indexes segments by the cells their boxes cover.
+type grid struct {
+	size  float64
+	cells map[[2]int][]int
+}
+
+func newGrid(size float64) *grid {
+	return &grid{size: size, cells: make(map[[2]int][]int)}
+}
+
+func (g *grid) insert(id int, p, q []float64) {
+	x0, y0 := g.cell(math.Min(p[0], q[0]), math.Min(p[1], q[1]))
+	x1, y1 := g.cell(math.Max(p[0], q[0]), math.Max(p[1], q[1]))
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			g.cells[[2]int{x, y}] = append(g.cells[[2]int{x, y}], id)
+		}
+	}
+}
+
+func (g *grid) query(minX, minY, maxX, maxY float64, fn func(id int) bool) {
+	x0, y0 := g.cell(minX, minY)
+	x1, y1 := g.cell(maxX, maxY)
+	seen := make(map[int]bool)
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			for _, id := range g.cells[[2]int{x, y}] {
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+				if !fn(id) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (g *grid) cell(x, y float64) (int, int) {
+	return int(math.Floor(x / g.size)), int(math.Floor(y / g.size))
+}
+
+// crossing returns the point where the segments ab and cd intersect.
+func crossing(a, b, c, d []float64) []float64 {
+	rx, ry := b[0]-a[0], b[1]-a[1]
+	sx, sy := d[0]-c[0], d[1]-c[1]
+	den := rx*sy - ry*sx
+	if den == 0 {
+		return nil
+	}
+	t := ((c[0]-a[0])*sy - (c[1]-a[1])*sx) / den
+	u := ((c[0]-a[0])*ry - (c[1]-a[1])*rx) / den
+	if t < 0 || t > 1 || u < 0 || u > 1 {
+		return nil
+	}
+	return []float64{a[0] + t*rx, a[1] + t*ry}
+}
+
+func orient(a, b, c []float64) float64 {
+	return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+}
+
+func segmentDistance(p, a, b []float64) float64 {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = math.Max(0, math.Min(1, ((p[0]-a[0])*dx+(p[1]-a[1])*dy)/l))
+	}
+	return math.Hypot(p[0]-a[0]-t*dx, p[1]-a[1]-t*dy)
+}
@@ -13,14 +13,15 @@ const (
 )
 
 type Feature struct {
-	ID           interface{}            `json:"id,omitempty"`
-	Type         string                 `json:"type"`
-	BoundingBox  *BoundingBox           `json:"bbox,omitempty"`
-	Geometry     Geometry               `json:"-"`
-	Properties   map[string]interface{} `json:"properties"`
-	CRS          map[string]interface{} `json:"crs,omitempty"`
-	GeometryData GeometryData           `json:"geometry"`
-	ExtData      map[string]interface{} `json:"ext-data,omitempty"`
+	ID             interface{}            `json:"id,omitempty"`
+	Type           string                 `json:"type"`
+	BoundingBox    *BoundingBox           `json:"bbox,omitempty"`
+	Geometry       Geometry               `json:"-"`
+	Properties     map[string]interface{} `json:"properties"`
+	CRS            map[string]interface{} `json:"crs,omitempty"`
+	GeometryData   GeometryData           `json:"geometry"`
+	ExtData        map[string]interface{} `json:"ext-data,omitempty"`
+	ForeignMembers map[string]interface{} `json:"-"`
 }
 
 func NewFeature(geometry Geometry) *Feature {
@@ -99,7 +100,46 @@ func (f Feature) MarshalJSON() ([]byte, error) {
 		fea.CRS = f.CRS
 	}
 
-	return json.Marshal(fea)
+	b, err := json.Marshal(fea)
+	if err != nil {
+		return nil, err
+	}
+	return appendForeignMembers(b, f.ForeignMembers, featureMembers)
+}
+
+func (f *Feature) UnmarshalJSON(data []byte) error {
+	return f.decode(&scanner{data: data})
+}
+
+// decode reads f from s, the geometry being read in place.
+func (f *Feature) decode(s *scanner) error {
+	if s.literal("null") {
+		return nil
+	}
+	return s.members(func(key []byte) error {
+		if string(key) == "geometry" {
+			return s.geometry(&f.GeometryData)
+		}
+		raw, err := s.value()
+		if err != nil {
+			return err
+		}
+		switch string(key) {
+		case "id":
+			return json.Unmarshal(raw, &f.ID)
+		case "type":
+			return json.Unmarshal(raw, &f.Type)
+		case "bbox":
+			return json.Unmarshal(raw, &f.BoundingBox)
+		case "properties":
+			return json.Unmarshal(raw, &f.Properties)
+		case "crs":
+			return json.Unmarshal(raw, &f.CRS)
+		case "ext-data":
+			return json.Unmarshal(raw, &f.ExtData)
+		}
+		return decodeForeignMember(&f.ForeignMembers, string(key), raw)
+	})
 }
 
 func (f *Feature) SetProperty(key string, value interface{}) {
@@ -234,7 +274,7 @@ func BoundingBoxFromPoints(pts [][]float64) *BoundingBox {
 	west, south, buttom, east, north, top := math.MaxFloat64, math.MaxFloat64, math.MaxFloat64, -math.MaxFloat64, -math.MaxFloat64, -math.MaxFloat64
 
 	for _, pt := range pts {
-		if pt == nil {
+		if len(pt) < 2 {
 			continue
 		}
 		x, y, z := pt[0], pt[1], 0.0
@@ -410,8 +450,32 @@ func BoundingBoxFromGeometry(g Geometry) *BoundingBox {
 }
 
 func BoundingBoxFromGeometryData(g *GeometryData) *BoundingBox {
+	if g == nil {
+		return nil
+	}
+	if g.Type == GeometryCollection {
+		var bboxs []*BoundingBox
+		for _, c := range g.Geometries {
+			if b := BoundingBoxFromGeometryData(c); b != nil {
+				bboxs = append(bboxs, b)
+			}
+		}
+		return ExpandBoundingBoxs(bboxs)
+	}
+	// M 不参与范围的计算
+	if g.Layout == XYM {
+		g = ProcessGeometryData(g, func(p []float64) []float64 {
+			if len(p) > 2 {
+				return p[:2]
+			}
+			return p
+		})
+	}
 	switch g.Type {
 	case "Point":
+		if len(g.Point) < 2 {
+			return nil
+		}
 		return BoundingBoxFromPointGeometry(g.Point)
 	case "MultiPoint":
 		return BoundingBoxFromMultiPointGeometry(g.MultiPoint)
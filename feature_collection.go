@@ -5,11 +5,12 @@ import (
 )
 
 type FeatureCollection struct {
-	Type        string                 `json:"type"`
-	BoundingBox *BoundingBox           `json:"bbox,omitempty"`
-	Features    []*Feature             `json:"features"`
-	CRS         map[string]interface{} `json:"crs,omitempty"`
-	Properties  map[string]interface{} `json:"properties,omitempty"`
+	Type           string                 `json:"type"`
+	BoundingBox    *BoundingBox           `json:"bbox,omitempty"`
+	Features       []*Feature             `json:"features"`
+	CRS            map[string]interface{} `json:"crs,omitempty"`
+	Properties     map[string]interface{} `json:"properties,omitempty"`
+	ForeignMembers map[string]interface{} `json:"-"`
 }
 
 func NewFeatureCollection() *FeatureCollection {
@@ -49,5 +50,67 @@ func (fc FeatureCollection) MarshalJSON() ([]byte, error) {
 		fcol.Properties = fc.Properties
 	}
 
-	return json.Marshal(fcol)
+	b, err := json.Marshal(fcol)
+	if err != nil {
+		return nil, err
+	}
+	return appendForeignMembers(b, fc.ForeignMembers, collectionMembers)
+}
+
+func (fc *FeatureCollection) UnmarshalJSON(data []byte) error {
+	s := &scanner{data: data}
+	if s.literal("null") {
+		return nil
+	}
+	return s.members(func(key []byte) error {
+		if string(key) == "features" {
+			return fc.decodeFeatures(s)
+		}
+		raw, err := s.value()
+		if err != nil {
+			return err
+		}
+		switch string(key) {
+		case "type":
+			return json.Unmarshal(raw, &fc.Type)
+		case "bbox":
+			return json.Unmarshal(raw, &fc.BoundingBox)
+		case "crs":
+			return json.Unmarshal(raw, &fc.CRS)
+		case "properties":
+			return json.Unmarshal(raw, &fc.Properties)
+		}
+		return decodeForeignMember(&fc.ForeignMembers, string(key), raw)
+	})
+}
+
+// decodeFeatures reads the features of fc from s.
+func (fc *FeatureCollection) decodeFeatures(s *scanner) error {
+	if s.literal("null") {
+		fc.Features = nil
+		return nil
+	}
+	if !s.consume('[') {
+		return s.syntaxError()
+	}
+	fc.Features = make([]*Feature, 0)
+	if s.consume(']') {
+		return nil
+	}
+	for {
+		var f *Feature
+		if !s.literal("null") {
+			f = &Feature{}
+			if err := f.decode(s); err != nil {
+				return err
+			}
+		}
+		fc.Features = append(fc.Features, f)
+		if s.consume(']') {
+			return nil
+		}
+		if !s.consume(',') {
+			return s.syntaxError()
+		}
+	}
 }
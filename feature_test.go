@@ -337,6 +337,39 @@ func TestProcessGeometryData(t *testing.T) {
 	assert.Equal(t, [][]float64{{2.0, 3.0}, {4.0, 5.0}}, processedMultiPointData.MultiPoint)
 }
 
+// 测试BoundingBoxFromGeometryData函数
+func TestBoundingBoxFromGeometryData(t *testing.T) {
+	assert.Nil(t, BoundingBoxFromGeometryData(nil))
+	assert.Nil(t, BoundingBoxFromGeometryData(&GeometryData{Type: GeometryPoint}))
+
+	// 集合的范围是成员范围的并集, nil 成员被跳过
+	g := NewCollectionGeometryData(
+		NewPointGeometryData([]float64{1, 2}),
+		nil,
+		NewLineStringGeometryData([][]float64{{-1, 0}, {3, 5}}),
+	)
+	assert.Equal(t, &BoundingBox{{-1, 0, 0}, {3, 5, 0}}, BoundingBoxFromGeometryData(g))
+	assert.Nil(t, BoundingBoxFromGeometryData(NewCollectionGeometryData()))
+}
+
+// 测试要素集合的解码
+func TestFeatureCollectionUnmarshalJSON(t *testing.T) {
+	fc := &FeatureCollection{}
+	data := `{"features":[{"geometry":{"coordinates":[[1,2],[3,4]],"type":"LineString"},"type":"Feature","properties":{"a":1}},null],"type":"FeatureCollection"}`
+	assert.NoError(t, json.Unmarshal([]byte(data), fc))
+	assert.Equal(t, "FeatureCollection", fc.Type)
+	assert.Len(t, fc.Features, 2)
+	assert.Equal(t, [][]float64{{1, 2}, {3, 4}}, fc.Features[0].GeometryData.LineString)
+	assert.Equal(t, float64(1), fc.Features[0].Properties["a"])
+	assert.Nil(t, fc.Features[1])
+
+	fc = &FeatureCollection{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"type":"FeatureCollection","features":[]}`), fc))
+	assert.NotNil(t, fc.Features)
+	assert.Empty(t, fc.Features)
+	assert.Error(t, json.Unmarshal([]byte(`{"type":"FeatureCollection","features":[1]}`), fc))
+}
+
 // 测试IsFeatureEqual函数
 func TestIsFeatureEqual(t *testing.T) {
 	// 创建两个相同的feature
@@ -511,3 +544,58 @@ func TestFeatureCollectionMarshalJSON(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, "feature2", feature2Props["name"])
 }
+
+// 测试外部成员的往返
+func TestForeignMembers(t *testing.T) {
+	data := `{
+		"type": "FeatureCollection",
+		"title": "places",
+		"links": [{"href": "http://example.com", "rel": "self"}],
+		"features": [{
+			"type": "Feature",
+			"id": "a",
+			"when": {"start": "2020-01-01"},
+			"geometry": {"type": "Point", "coordinates": [1, 2], "title": "center", "crs": {"type": "name"}},
+			"properties": {"name": "a"}
+		}]
+	}`
+	fc := &FeatureCollection{}
+	assert.NoError(t, json.Unmarshal([]byte(data), fc))
+	assert.Equal(t, "places", fc.ForeignMembers["title"])
+	assert.Len(t, fc.ForeignMembers["links"], 1)
+	assert.Len(t, fc.ForeignMembers, 2)
+	f := fc.Features[0]
+	assert.Equal(t, "a", f.ID)
+	assert.Equal(t, map[string]interface{}{"when": map[string]interface{}{"start": "2020-01-01"}}, f.ForeignMembers)
+	assert.Equal(t, []float64{1, 2}, f.GeometryData.Point)
+	assert.Equal(t, "center", f.GeometryData.ForeignMembers["title"])
+	assert.Equal(t, "name", f.GeometryData.ForeignMembers["crs"].(map[string]interface{})["type"])
+
+	b, err := json.Marshal(fc)
+	assert.NoError(t, err)
+	assert.JSONEq(t, data, string(b))
+
+	// 外部成员不覆盖标准成员
+	g := NewPointGeometryData([]float64{1, 2})
+	g.ForeignMembers = map[string]interface{}{"type": "Polygon", "coordinates": 1, "b": 2, "a": "x"}
+	b, err = json.Marshal(g)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"Point","coordinates":[1,2],"a":"x","b":2}`, string(b))
+
+	feature := NewFeatureFromGeometryData(g)
+	feature.ForeignMembers = map[string]interface{}{"properties": 1, "title": "t"}
+	b, err = json.Marshal(feature)
+	assert.NoError(t, err)
+	ret := &Feature{}
+	assert.NoError(t, json.Unmarshal(b, ret))
+	assert.Equal(t, map[string]interface{}{"title": "t"}, ret.ForeignMembers)
+	assert.Equal(t, map[string]interface{}{"a": "x", "b": float64(2)}, ret.GeometryData.ForeignMembers)
+	assert.Nil(t, ret.Properties)
+
+	// 没有外部成员时为 nil
+	ret = &Feature{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"type":"Feature","geometry":null,"properties":null}`), ret))
+	assert.Nil(t, ret.ForeignMembers)
+	assert.Error(t, json.Unmarshal([]byte(`{"type":"Feature","geometry":{"type":"Point"}}`), ret))
+	assert.Error(t, json.Unmarshal([]byte(`{"type":"FeatureCollection","features":{}}`), fc))
+}
@@ -0,0 +1,198 @@
+package flatgeobuf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/general"
+	"github.com/flywave/go-geom/rtree"
+)
+
+// decodeHeaderFrom reads the magic bytes and the header at the start of r,
+// returning the header with the size of both.
+func decodeHeaderFrom(r io.Reader) (*Header, int, error) {
+	start := make([]byte, len(magic)+4)
+	if _, err := io.ReadFull(r, start); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, 0, errMagic
+		}
+		return nil, 0, err
+	}
+	if err := checkMagic(start); err != nil {
+		return nil, 0, err
+	}
+	size := binary.LittleEndian.Uint32(start[len(magic):])
+	data := make([]byte, len(start)+int(size))
+	copy(data, start)
+	if _, err := io.ReadFull(r, data[len(start):]); err != nil {
+		return nil, 0, errors.New("truncated flatgeobuf header")
+	}
+	return readHeader(data)
+}
+
+// readFeature reads the next size prefixed feature of r, io.EOF at the end
+// of the features.
+func readFeature(r io.Reader, h *Header) (*geom.Feature, error) {
+	var prefix [4]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, errors.New("truncated flatgeobuf feature")
+		}
+		return nil, err
+	}
+	buf := make([]byte, binary.LittleEndian.Uint32(prefix[:]))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, errors.New("truncated flatgeobuf feature")
+	}
+	return decodeFeature(buf, h)
+}
+
+// Decoder reads the features of a FlatGeobuf stream one by one, skipping
+// its index.
+type Decoder struct {
+	r       *bufio.Reader
+	header  *Header
+	skipped bool
+}
+
+// NewDecoder reads the header of r.
+func NewDecoder(r io.Reader) (*Decoder, error) {
+	br := bufio.NewReader(r)
+	h, _, err := decodeHeaderFrom(br)
+	if err != nil {
+		return nil, err
+	}
+	return &Decoder{r: br, header: h}, nil
+}
+
+func (d *Decoder) Header() *Header {
+	return d.header
+}
+
+// Decode returns the next feature, io.EOF when there are no more.
+func (d *Decoder) Decode() (*geom.Feature, error) {
+	if !d.skipped {
+		size := int64(indexSize(int(d.header.FeaturesCount), d.header.IndexNodeSize))
+		if n, err := io.CopyN(ioutil.Discard, d.r, size); err != nil {
+			if n < size && err == io.EOF {
+				return nil, errors.New("truncated flatgeobuf index")
+			}
+			return nil, err
+		}
+		d.skipped = true
+	}
+	return readFeature(d.r, d.header)
+}
+
+// Decode reads all the features of r.
+func Decode(r io.Reader) (*geom.FeatureCollection, error) {
+	d, err := NewDecoder(r)
+	if err != nil {
+		return nil, err
+	}
+	fc := geom.NewFeatureCollection()
+	for {
+		f, err := d.Decode()
+		if err == io.EOF {
+			return fc, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		fc.AddFeature(f)
+	}
+}
+
+func Unmarshal(data []byte) (*geom.FeatureCollection, error) {
+	return Decode(bytes.NewReader(data))
+}
+
+// Reader reads the features of a FlatGeobuf file at random, using its
+// index to read only the features of an extent.
+type Reader struct {
+	r        io.ReaderAt
+	header   *Header
+	index    int64
+	features int64
+}
+
+// NewReader reads the header of r.
+func NewReader(r io.ReaderAt) (*Reader, error) {
+	h, n, err := decodeHeaderFrom(io.NewSectionReader(r, 0, math.MaxInt64))
+	if err != nil {
+		return nil, err
+	}
+	size := indexSize(int(h.FeaturesCount), h.IndexNodeSize)
+	return &Reader{r: r, header: h, index: int64(n), features: int64(n + size)}, nil
+}
+
+func (r *Reader) Header() *Header {
+	return r.header
+}
+
+// scan returns the features of the file accepted by keep.
+func (r *Reader) scan(keep func(f *geom.Feature) bool) ([]*geom.Feature, error) {
+	sr := bufio.NewReader(io.NewSectionReader(r.r, r.features, math.MaxInt64-r.features))
+	var ret []*geom.Feature
+	for {
+		f, err := readFeature(sr, r.header)
+		if err == io.EOF {
+			return ret, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if keep(f) {
+			ret = append(ret, f)
+		}
+	}
+}
+
+// Search returns the features whose bounding boxes intersect extent, in
+// the order of the file. Without index all the features are read.
+func (r *Reader) Search(extent general.Extent) ([]*geom.Feature, error) {
+	h := r.header
+	if h.IndexNodeSize < 2 || h.FeaturesCount == 0 {
+		return r.scan(func(f *geom.Feature) bool {
+			e, ok := rtree.FeatureExtent(f)
+			return ok && intersects(e, extent)
+		})
+	}
+	size := r.features - r.index
+	offsets, err := searchIndex(io.NewSectionReader(r.r, r.index, size), int(h.FeaturesCount), h.IndexNodeSize, extent)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*geom.Feature, 0, len(offsets))
+	for _, o := range offsets {
+		start := r.features + int64(o)
+		f, err := readFeature(io.NewSectionReader(r.r, start, math.MaxInt64-start), h)
+		if err != nil {
+			if err == io.EOF {
+				err = errors.New("flatgeobuf index offset out of range")
+			}
+			return nil, err
+		}
+		ret = append(ret, f)
+	}
+	return ret, nil
+}
+
+// FeatureCollection reads all the features of the file.
+func (r *Reader) FeatureCollection() (*geom.FeatureCollection, error) {
+	features, err := r.scan(func(*geom.Feature) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	fc := geom.NewFeatureCollection()
+	for _, f := range features {
+		fc.AddFeature(f)
+	}
+	return fc, nil
+}
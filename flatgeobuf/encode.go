@@ -0,0 +1,223 @@
+package flatgeobuf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/general"
+	"github.com/flywave/go-geom/rtree"
+)
+
+// EncodeOptions controls the writing of FlatGeobuf files.
+type EncodeOptions struct {
+	Name string
+	// IndexNodeSize is the number of children of the nodes of the packed
+	// Hilbert R-tree, 0 writing no index. The Encoder never writes one.
+	IndexNodeSize int
+	// EPSG is the reference system of the file, 0 using the one of the
+	// geometries.
+	EPSG int
+	// Columns are the properties written, nil inferring them from the
+	// features by InferColumns.
+	Columns []Column
+	// GeometryType and Layout are the type and dimensions of the geometries,
+	// inferred from them when unset.
+	GeometryType geom.GeometryType
+	Layout       geom.Layout
+}
+
+func DefaultEncodeOptions() *EncodeOptions {
+	return &EncodeOptions{IndexNodeSize: 16}
+}
+
+func columnIndex(columns []Column) map[string]int {
+	index := make(map[string]int, len(columns))
+	for i, c := range columns {
+		index[c.Name] = i
+	}
+	return index
+}
+
+// header returns the header of features, with the settings of opt.
+func header(features []*geom.Feature, opt *EncodeOptions) *Header {
+	h := &Header{
+		Name:          opt.Name,
+		GeometryType:  opt.GeometryType,
+		Layout:        opt.Layout,
+		Columns:       opt.Columns,
+		FeaturesCount: uint64(len(features)),
+		IndexNodeSize: opt.IndexNodeSize,
+		EPSG:          opt.EPSG,
+	}
+	if h.Columns == nil {
+		h.Columns = InferColumns(features)
+	}
+	var typ geom.GeometryType
+	var z, m, mixed bool
+	for _, f := range features {
+		g := &f.GeometryData
+		if g.Type == "" {
+			continue
+		}
+		if typ == "" {
+			typ = g.Type
+		} else if typ != g.Type {
+			mixed = true
+		}
+		l := geom.LayoutFromGeometryData(g)
+		z, m = z || l.HasZ(), m || l.HasM()
+		if h.EPSG == 0 {
+			h.EPSG = g.EPSG
+		}
+	}
+	if h.GeometryType == "" && !mixed {
+		h.GeometryType = typ
+	}
+	if h.Layout == geom.NoLayout {
+		switch {
+		case z && m:
+			h.Layout = geom.XYZM
+		case m:
+			h.Layout = geom.XYM
+		case z:
+			h.Layout = geom.XYZ
+		default:
+			h.Layout = geom.XY
+		}
+	}
+	return h
+}
+
+// Encode writes the features of fc as a FlatGeobuf file. With an index the
+// features are written in the order of the Hilbert curve.
+func Encode(w io.Writer, fc *geom.FeatureCollection, opt *EncodeOptions) error {
+	if opt == nil {
+		opt = DefaultEncodeOptions()
+	}
+	if fc == nil {
+		return errors.New("nil feature collection")
+	}
+	features := fc.Features
+	h := header(features, opt)
+	if h.IndexNodeSize == 1 || h.IndexNodeSize < 0 || h.IndexNodeSize > 1<<16-1 {
+		return errors.New("invalid flatgeobuf index node size")
+	}
+	extents := make([]general.Extent, len(features))
+	envelope := emptyExtent()
+	for i, f := range features {
+		extents[i] = emptyExtent()
+		if e, ok := rtree.FeatureExtent(f); ok {
+			extents[i] = e
+			expand(&envelope, e)
+		}
+	}
+	if envelope[0] <= envelope[2] {
+		h.Envelope = envelope[:]
+	}
+	indexed := h.IndexNodeSize > 0 && len(features) > 0
+	order := make([]int, len(features))
+	for i := range order {
+		order[i] = i
+	}
+	if indexed {
+		order = hilbertOrder(extents, envelope)
+	}
+
+	index := columnIndex(h.Columns)
+	var data bytes.Buffer
+	items := make([]nodeItem, len(features))
+	for i, j := range order {
+		buf, err := buildFeature(features[j], h, index)
+		if err != nil {
+			return err
+		}
+		items[i] = nodeItem{extent: extents[j], offset: uint64(data.Len())}
+		data.Write(buf)
+	}
+
+	if _, err := w.Write(magic); err != nil {
+		return err
+	}
+	if _, err := w.Write(h.marshal()); err != nil {
+		return err
+	}
+	if indexed {
+		if _, err := w.Write(marshalIndex(buildIndex(items, h.IndexNodeSize))); err != nil {
+			return err
+		}
+	}
+	_, err := data.WriteTo(w)
+	return err
+}
+
+// Marshal returns the features of fc as a FlatGeobuf file.
+func Marshal(fc *geom.FeatureCollection, opt *EncodeOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, fc, opt); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Encoder writes the features one by one, without index nor count. The
+// header is written with the first feature, from which the columns and the
+// layout are inferred unless set by the options.
+type Encoder struct {
+	w      io.Writer
+	opt    EncodeOptions
+	header *Header
+	index  map[string]int
+}
+
+func NewEncoder(w io.Writer, opt *EncodeOptions) *Encoder {
+	if opt == nil {
+		opt = DefaultEncodeOptions()
+	}
+	return &Encoder{w: w, opt: *opt}
+}
+
+func (e *Encoder) writeHeader(features []*geom.Feature) error {
+	opt := e.opt
+	opt.IndexNodeSize = 0
+	h := header(features, &opt)
+	// 类型只由选项指定, 否则逐个写出
+	h.GeometryType = e.opt.GeometryType
+	h.FeaturesCount = 0
+	e.header, e.index = h, columnIndex(h.Columns)
+	if _, err := e.w.Write(magic); err != nil {
+		return err
+	}
+	_, err := e.w.Write(h.marshal())
+	return err
+}
+
+// Encode writes f.
+func (e *Encoder) Encode(f *geom.Feature) error {
+	if f == nil {
+		return errors.New("nil feature")
+	}
+	if e.header == nil {
+		if err := e.writeHeader([]*geom.Feature{f}); err != nil {
+			return err
+		}
+	}
+	if t := e.header.GeometryType; t != "" && f.GeometryData.Type != "" && f.GeometryData.Type != t {
+		return errors.New("flatgeobuf geometry type differs from the header")
+	}
+	buf, err := buildFeature(f, e.header, e.index)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(buf)
+	return err
+}
+
+// Close writes the header of an empty file when no feature was written.
+func (e *Encoder) Close() error {
+	if e.header == nil {
+		return e.writeHeader(nil)
+	}
+	return nil
+}
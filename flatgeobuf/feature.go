@@ -0,0 +1,387 @@
+package flatgeobuf
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+
+	"github.com/flywave/go-geom"
+)
+
+// columnType returns the type of the column of v, ColumnJSON for the values
+// without column type.
+func columnType(v interface{}) ColumnType {
+	switch v := v.(type) {
+	case bool:
+		return ColumnBool
+	case int8:
+		return ColumnByte
+	case uint8:
+		return ColumnUByte
+	case int16:
+		return ColumnShort
+	case uint16:
+		return ColumnUShort
+	case int32:
+		return ColumnInt
+	case uint32:
+		return ColumnUInt
+	case int, int64:
+		return ColumnLong
+	case uint, uint64:
+		return ColumnULong
+	case float32:
+		return ColumnFloat
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return ColumnLong
+		}
+		return ColumnDouble
+	case string:
+		return ColumnString
+	case time.Time:
+		return ColumnDateTime
+	case []byte:
+		return ColumnBinary
+	}
+	return ColumnJSON
+}
+
+func integer(t ColumnType) bool {
+	return t >= ColumnByte && t <= ColumnULong && t != ColumnBool
+}
+
+func numeric(t ColumnType) bool {
+	return integer(t) || t == ColumnFloat || t == ColumnDouble
+}
+
+// valueSizes are the sizes of the fixed size values, the others being
+// prefixed with their size.
+var valueSizes = map[ColumnType]int{
+	ColumnByte: 1, ColumnUByte: 1, ColumnBool: 1, ColumnShort: 2, ColumnUShort: 2,
+	ColumnInt: 4, ColumnUInt: 4, ColumnLong: 8, ColumnULong: 8, ColumnFloat: 4, ColumnDouble: 8,
+}
+
+// merge returns the column type holding the values of a and b.
+func merge(a, b ColumnType) ColumnType {
+	switch {
+	case a == b:
+		return a
+	case integer(a) && integer(b):
+		return ColumnLong
+	case numeric(a) && numeric(b):
+		return ColumnDouble
+	}
+	return ColumnJSON
+}
+
+// InferColumns returns the columns of the properties of features, sorted
+// by name. The numbers of JSON without fraction are integers, the values
+// of different kinds are kept as JSON and the columns of nil values only
+// are strings.
+func InferColumns(features []*geom.Feature) []Column {
+	types := make(map[string]ColumnType)
+	for _, f := range features {
+		for k, v := range f.Properties {
+			if v == nil {
+				continue
+			}
+			t := columnType(v)
+			if prev, ok := types[k]; ok {
+				t = merge(prev, t)
+			}
+			types[k] = t
+		}
+	}
+	for _, f := range features {
+		for k := range f.Properties {
+			if _, ok := types[k]; !ok {
+				types[k] = ColumnString
+			}
+		}
+	}
+	names := make([]string, 0, len(types))
+	for k := range types {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	columns := make([]Column, len(names))
+	for i, k := range names {
+		columns[i] = Column{Name: k, Type: types[k]}
+	}
+	return columns
+}
+
+func toInt(v interface{}) (int64, bool) {
+	switch v := v.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), true
+	case float32:
+		return int64(v), true
+	case float64:
+		return int64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case uint64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	}
+	n, ok := toInt(v)
+	return float64(n), ok
+}
+
+// appendValue writes v as a value of a column of type t.
+func appendValue(buf []byte, t ColumnType, v interface{}) ([]byte, error) {
+	le := binary.LittleEndian
+	if numeric(t) {
+		n, ok := toInt(v)
+		f, _ := toFloat(v)
+		if !ok {
+			return nil, fmt.Errorf("%T value in a numeric column", v)
+		}
+		switch t {
+		case ColumnByte, ColumnUByte:
+			return append(buf, byte(n)), nil
+		case ColumnShort, ColumnUShort:
+			return le.AppendUint16(buf, uint16(n)), nil
+		case ColumnInt, ColumnUInt:
+			return le.AppendUint32(buf, uint32(n)), nil
+		case ColumnLong:
+			return le.AppendUint64(buf, uint64(n)), nil
+		case ColumnULong:
+			if u, ok := v.(uint64); ok {
+				return le.AppendUint64(buf, u), nil
+			}
+			return le.AppendUint64(buf, uint64(n)), nil
+		case ColumnFloat:
+			return le.AppendUint32(buf, math.Float32bits(float32(f))), nil
+		}
+		return le.AppendUint64(buf, math.Float64bits(f)), nil
+	}
+	var b []byte
+	switch t {
+	case ColumnBool:
+		x, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%T value in a bool column", v)
+		}
+		if x {
+			return append(buf, 1), nil
+		}
+		return append(buf, 0), nil
+	case ColumnString:
+		s, ok := v.(string)
+		if !ok {
+			s = fmt.Sprint(v)
+		}
+		b = []byte(s)
+	case ColumnDateTime:
+		switch x := v.(type) {
+		case time.Time:
+			b = []byte(x.Format(time.RFC3339Nano))
+		case string:
+			b = []byte(x)
+		default:
+			return nil, fmt.Errorf("%T value in a datetime column", v)
+		}
+	case ColumnBinary:
+		switch x := v.(type) {
+		case []byte:
+			b = x
+		case string:
+			b = []byte(x)
+		default:
+			return nil, fmt.Errorf("%T value in a binary column", v)
+		}
+	default:
+		var err error
+		if b, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	buf = le.AppendUint32(buf, uint32(len(b)))
+	return append(buf, b...), nil
+}
+
+// encodeProperties writes the properties found in the columns, each as
+// its column index and value. The nil values are left out.
+func encodeProperties(props map[string]interface{}, columns []Column, index map[string]int) ([]byte, error) {
+	keys := make([]string, 0, len(props))
+	for k, v := range props {
+		if v == nil {
+			continue
+		}
+		if _, ok := index[k]; !ok {
+			return nil, fmt.Errorf("property %s not in the flatgeobuf columns", k)
+		}
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return index[keys[i]] < index[keys[j]] })
+	var buf []byte
+	for _, k := range keys {
+		i := index[k]
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(i))
+		var err error
+		if buf, err = appendValue(buf, columns[i].Type, props[k]); err != nil {
+			return nil, fmt.Errorf("property %s: %v", k, err)
+		}
+	}
+	return buf, nil
+}
+
+var errTruncated = errors.New("truncated flatgeobuf properties")
+
+// decodeProperties reads the properties of a feature: the signed integers
+// are int64, the unsigned ones uint64, the JSON values are decoded and the
+// date times are time.Time when they parse.
+func decodeProperties(buf []byte, columns []Column) (map[string]interface{}, error) {
+	le := binary.LittleEndian
+	props := make(map[string]interface{})
+	for len(buf) > 0 {
+		if len(buf) < 2 {
+			return nil, errTruncated
+		}
+		i := int(le.Uint16(buf))
+		buf = buf[2:]
+		if i >= len(columns) {
+			return nil, fmt.Errorf("flatgeobuf column %d out of range", i)
+		}
+		c := columns[i]
+		size, ok := valueSizes[c.Type]
+		if !ok {
+			if len(buf) < 4 {
+				return nil, errTruncated
+			}
+			size = int(le.Uint32(buf))
+			buf = buf[4:]
+		}
+		if size > len(buf) {
+			return nil, errTruncated
+		}
+		b := buf[:size]
+		buf = buf[size:]
+		var v interface{}
+		switch c.Type {
+		case ColumnByte:
+			v = int64(int8(b[0]))
+		case ColumnUByte:
+			v = uint64(b[0])
+		case ColumnBool:
+			v = b[0] != 0
+		case ColumnShort:
+			v = int64(int16(le.Uint16(b)))
+		case ColumnUShort:
+			v = uint64(le.Uint16(b))
+		case ColumnInt:
+			v = int64(int32(le.Uint32(b)))
+		case ColumnUInt:
+			v = uint64(le.Uint32(b))
+		case ColumnLong:
+			v = int64(le.Uint64(b))
+		case ColumnULong:
+			v = le.Uint64(b)
+		case ColumnFloat:
+			v = float64(math.Float32frombits(le.Uint32(b)))
+		case ColumnDouble:
+			v = math.Float64frombits(le.Uint64(b))
+		case ColumnJSON:
+			if err := json.Unmarshal(b, &v); err != nil {
+				v = string(b)
+			}
+		case ColumnDateTime:
+			if t, err := time.Parse(time.RFC3339Nano, string(b)); err == nil {
+				v = t
+			} else {
+				v = string(b)
+			}
+		case ColumnBinary:
+			v = append([]byte(nil), b...)
+		default:
+			v = string(b)
+		}
+		props[c.Name] = v
+	}
+	return props, nil
+}
+
+// buildFeature returns f as the size prefixed feature table of a file of
+// header h, index holding the positions of its columns.
+func buildFeature(f *geom.Feature, h *Header, index map[string]int) ([]byte, error) {
+	props, err := encodeProperties(f.Properties, h.Columns, index)
+	if err != nil {
+		return nil, err
+	}
+	b := flatbuffers.NewBuilder(256)
+	var g, p flatbuffers.UOffsetT
+	if f.GeometryData.Type != "" {
+		g = buildGeometry(b, &f.GeometryData, h.Layout, h.GeometryType == "")
+	}
+	if len(props) > 0 {
+		p = b.CreateByteVector(props)
+	}
+	b.StartObject(3)
+	b.PrependUOffsetTSlot(0, g, 0)
+	b.PrependUOffsetTSlot(1, p, 0)
+	b.FinishSizePrefixed(b.EndObject())
+	return b.FinishedBytes(), nil
+}
+
+// decodeFeature reads the feature table buf of a file of header h.
+func decodeFeature(buf []byte, h *Header) (f *geom.Feature, err error) {
+	defer guard("feature", &err)
+	t := root(buf)
+	if gt := t.child(0); gt != nil {
+		g, err := decodeGeometry(gt, h.GeometryType)
+		if err != nil {
+			return nil, err
+		}
+		g.EPSG = h.EPSG
+		f = geom.NewFeatureFromGeometryData(g)
+	} else {
+		f = &geom.Feature{Type: "Feature", Properties: make(map[string]interface{}), ExtData: make(map[string]interface{})}
+	}
+	if b := t.bytes(1); len(b) > 0 {
+		if f.Properties, err = decodeProperties(b, h.Columns); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
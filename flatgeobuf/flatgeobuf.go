@@ -0,0 +1,328 @@
+package flatgeobuf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+
+	"github.com/flywave/go-geom"
+)
+
+// magic starts the FlatGeobuf files of version 3.
+var magic = []byte{'f', 'g', 'b', 3, 'f', 'g', 'b', 1}
+
+// ColumnType is the type of the values of a column.
+type ColumnType uint8
+
+const (
+	ColumnByte ColumnType = iota
+	ColumnUByte
+	ColumnBool
+	ColumnShort
+	ColumnUShort
+	ColumnInt
+	ColumnUInt
+	ColumnLong
+	ColumnULong
+	ColumnFloat
+	ColumnDouble
+	ColumnString
+	ColumnJSON
+	ColumnDateTime
+	ColumnBinary
+)
+
+// Column is a property of the features.
+type Column struct {
+	Name        string
+	Type        ColumnType
+	Title       string
+	Description string
+}
+
+// Header describes the features of a file.
+type Header struct {
+	Name string
+	// Envelope is the extent of the features, minx, miny, maxx and maxy.
+	Envelope []float64
+	// GeometryType is the type of all the geometries, "" when they differ.
+	GeometryType geom.GeometryType
+	// Layout tells whether the positions have Z and M.
+	Layout  geom.Layout
+	Columns []Column
+	// FeaturesCount is the number of features, 0 when it is unknown.
+	FeaturesCount uint64
+	// IndexNodeSize is the number of children of the nodes of the spatial
+	// index, 0 when the file has none.
+	IndexNodeSize int
+	EPSG          int
+	Title         string
+	Description   string
+	Metadata      string
+}
+
+var geometryTypes = []geom.GeometryType{
+	"",
+	geom.GeometryPoint,
+	geom.GeometryLineString,
+	geom.GeometryPolygon,
+	geom.GeometryMultiPoint,
+	geom.GeometryMultiLineString,
+	geom.GeometryMultiPolygon,
+	geom.GeometryCollection,
+}
+
+func geometryType(t geom.GeometryType) byte {
+	for i, gt := range geometryTypes {
+		if gt == t {
+			return byte(i)
+		}
+	}
+	return 0
+}
+
+// table reads the fields of a flatbuffers table by their index in the
+// schema.
+type table struct {
+	flatbuffers.Table
+}
+
+func root(buf []byte) *table {
+	return &table{flatbuffers.Table{Bytes: buf, Pos: flatbuffers.GetUOffsetT(buf)}}
+}
+
+func (t *table) offset(slot int) flatbuffers.UOffsetT {
+	return flatbuffers.UOffsetT(t.Offset(flatbuffers.VOffsetT(4 + 2*slot)))
+}
+
+func (t *table) slot(slot int) flatbuffers.VOffsetT {
+	return flatbuffers.VOffsetT(4 + 2*slot)
+}
+
+func (t *table) string(slot int) string {
+	if o := t.offset(slot); o != 0 {
+		return t.String(o + t.Pos)
+	}
+	return ""
+}
+
+func (t *table) bytes(slot int) []byte {
+	if o := t.offset(slot); o != 0 {
+		return t.ByteVector(o + t.Pos)
+	}
+	return nil
+}
+
+func (t *table) float64s(slot int) []float64 {
+	o := t.offset(slot)
+	if o == 0 {
+		return nil
+	}
+	a, n := t.Vector(o), t.VectorLen(o)
+	ret := make([]float64, n)
+	for i := range ret {
+		ret[i] = t.GetFloat64(a + flatbuffers.UOffsetT(i*8))
+	}
+	return ret
+}
+
+func (t *table) uint32s(slot int) []uint32 {
+	o := t.offset(slot)
+	if o == 0 {
+		return nil
+	}
+	a, n := t.Vector(o), t.VectorLen(o)
+	ret := make([]uint32, n)
+	for i := range ret {
+		ret[i] = t.GetUint32(a + flatbuffers.UOffsetT(i*4))
+	}
+	return ret
+}
+
+func (t *table) child(slot int) *table {
+	if o := t.offset(slot); o != 0 {
+		return &table{flatbuffers.Table{Bytes: t.Bytes, Pos: t.Indirect(o + t.Pos)}}
+	}
+	return nil
+}
+
+func (t *table) children(slot int) []*table {
+	o := t.offset(slot)
+	if o == 0 {
+		return nil
+	}
+	a, n := t.Vector(o), t.VectorLen(o)
+	ret := make([]*table, n)
+	for i := range ret {
+		ret[i] = &table{flatbuffers.Table{Bytes: t.Bytes, Pos: t.Indirect(a + flatbuffers.UOffsetT(i*4))}}
+	}
+	return ret
+}
+
+// guard turns the panics of reading a malformed buffer into an error.
+func guard(what string, err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("invalid flatgeobuf %s: %v", what, r)
+	}
+}
+
+func float64Vector(b *flatbuffers.Builder, v []float64) flatbuffers.UOffsetT {
+	b.StartVector(8, len(v), 8)
+	for i := len(v) - 1; i >= 0; i-- {
+		b.PrependFloat64(v[i])
+	}
+	return b.EndVector(len(v))
+}
+
+func uint32Vector(b *flatbuffers.Builder, v []uint32) flatbuffers.UOffsetT {
+	b.StartVector(4, len(v), 4)
+	for i := len(v) - 1; i >= 0; i-- {
+		b.PrependUint32(v[i])
+	}
+	return b.EndVector(len(v))
+}
+
+func stringOffset(b *flatbuffers.Builder, s string) flatbuffers.UOffsetT {
+	if s == "" {
+		return 0
+	}
+	return b.CreateString(s)
+}
+
+func (c *Column) build(b *flatbuffers.Builder) flatbuffers.UOffsetT {
+	name := b.CreateString(c.Name)
+	title := stringOffset(b, c.Title)
+	description := stringOffset(b, c.Description)
+	b.StartObject(11)
+	b.PrependUOffsetTSlot(0, name, 0)
+	b.PrependByteSlot(1, byte(c.Type), 0)
+	b.PrependUOffsetTSlot(2, title, 0)
+	b.PrependUOffsetTSlot(3, description, 0)
+	return b.EndObject()
+}
+
+// build writes h as the header table of a file.
+func (h *Header) build(b *flatbuffers.Builder) flatbuffers.UOffsetT {
+	name := stringOffset(b, h.Name)
+	var envelope, columns, crs flatbuffers.UOffsetT
+	if len(h.Envelope) == 4 {
+		envelope = float64Vector(b, h.Envelope)
+	}
+	if len(h.Columns) > 0 {
+		offsets := make([]flatbuffers.UOffsetT, len(h.Columns))
+		for i := range h.Columns {
+			offsets[i] = h.Columns[i].build(b)
+		}
+		columns = b.CreateVectorOfTables(offsets)
+	}
+	if h.EPSG != 0 {
+		org := b.CreateString("EPSG")
+		b.StartObject(6)
+		b.PrependUOffsetTSlot(0, org, 0)
+		b.PrependInt32Slot(1, int32(h.EPSG), 0)
+		crs = b.EndObject()
+	}
+	title := stringOffset(b, h.Title)
+	description := stringOffset(b, h.Description)
+	metadata := stringOffset(b, h.Metadata)
+	b.StartObject(14)
+	b.PrependUOffsetTSlot(0, name, 0)
+	b.PrependUOffsetTSlot(1, envelope, 0)
+	b.PrependByteSlot(2, geometryType(h.GeometryType), 0)
+	b.PrependBoolSlot(3, h.Layout.HasZ(), false)
+	b.PrependBoolSlot(4, h.Layout.HasM(), false)
+	b.PrependUOffsetTSlot(7, columns, 0)
+	b.PrependUint64Slot(8, h.FeaturesCount, 0)
+	// 索引节点大小的缺省值为 16, 0 表示没有索引
+	b.PrependUint16Slot(9, uint16(h.IndexNodeSize), 16)
+	b.PrependUOffsetTSlot(10, crs, 0)
+	b.PrependUOffsetTSlot(11, title, 0)
+	b.PrependUOffsetTSlot(12, description, 0)
+	b.PrependUOffsetTSlot(13, metadata, 0)
+	return b.EndObject()
+}
+
+// marshal returns h as the size prefixed table following the magic bytes.
+func (h *Header) marshal() []byte {
+	b := flatbuffers.NewBuilder(1024)
+	b.FinishSizePrefixed(h.build(b))
+	return b.FinishedBytes()
+}
+
+func decodeHeader(buf []byte) (h *Header, err error) {
+	defer guard("header", &err)
+	t := root(buf)
+	h = &Header{
+		Name:          t.string(0),
+		Envelope:      t.float64s(1),
+		Title:         t.string(11),
+		Description:   t.string(12),
+		Metadata:      t.string(13),
+		FeaturesCount: t.GetUint64Slot(t.slot(8), 0),
+		IndexNodeSize: int(t.GetUint16Slot(t.slot(9), 16)),
+	}
+	if typ := int(t.GetByteSlot(t.slot(2), 0)); typ < len(geometryTypes) {
+		h.GeometryType = geometryTypes[typ]
+	} else {
+		return nil, fmt.Errorf("unsupported flatgeobuf geometry type %d", typ)
+	}
+	z, m := t.GetBoolSlot(t.slot(3), false), t.GetBoolSlot(t.slot(4), false)
+	switch {
+	case z && m:
+		h.Layout = geom.XYZM
+	case z:
+		h.Layout = geom.XYZ
+	case m:
+		h.Layout = geom.XYM
+	default:
+		h.Layout = geom.XY
+	}
+	for _, c := range t.children(7) {
+		h.Columns = append(h.Columns, Column{
+			Name:        c.string(0),
+			Type:        ColumnType(c.GetByteSlot(c.slot(1), 0)),
+			Title:       c.string(2),
+			Description: c.string(3),
+		})
+	}
+	if crs := t.child(10); crs != nil {
+		org := crs.string(0)
+		code := int(crs.GetInt32Slot(crs.slot(1), 0))
+		if s := crs.string(5); code == 0 && s != "" {
+			code, _ = strconv.Atoi(s)
+		}
+		if org == "" || strings.EqualFold(org, "EPSG") {
+			h.EPSG = code
+		}
+	}
+	return h, nil
+}
+
+var errMagic = errors.New("not a flatgeobuf file")
+
+// checkMagic checks the magic bytes at the start of data, ignoring the
+// patch version.
+func checkMagic(data []byte) error {
+	if len(data) < len(magic) || !bytes.Equal(data[:4], magic[:4]) || !bytes.Equal(data[4:7], magic[4:7]) {
+		return errMagic
+	}
+	return nil
+}
+
+// readHeader checks the magic bytes at the start of data and returns the
+// header following them, with its size.
+func readHeader(data []byte) (*Header, int, error) {
+	if err := checkMagic(data); err != nil || len(data) < len(magic)+4 {
+		return nil, 0, errMagic
+	}
+	size := int(flatbuffers.GetUint32(data[len(magic):]))
+	if len(data) < len(magic)+4+size {
+		return nil, 0, errors.New("truncated flatgeobuf header")
+	}
+	h, err := decodeHeader(data[len(magic)+4 : len(magic)+4+size])
+	return h, len(magic) + 4 + size, err
+}
@@ -0,0 +1,192 @@
+package flatgeobuf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/general"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalUnmarshal(t *testing.T) {
+	fc := geom.NewFeatureCollection()
+	f := geom.NewMultiPolygonFeature(
+		[][][]float64{{{0, 0}, {10, 0}, {10, 10}, {0, 0}}, {{2, 1}, {8, 1}, {8, 7}, {2, 1}}},
+		[][][]float64{{{20, 20}, {30, 20}, {30, 30}, {20, 20}}},
+	)
+	f.GeometryData.EPSG = 4490
+	f.Properties["name"] = "北京市"
+	f.Properties["code"] = float64(110000)
+	f.Properties["area"] = 12.5
+	f.Properties["capital"] = true
+	f.Properties["tags"] = []interface{}{"a", "b"}
+	f.Properties["founded"] = time.Date(1949, 10, 1, 0, 0, 0, 0, time.UTC)
+	fc.AddFeature(f)
+	g := geom.NewPolygonFeature([][][]float64{{{0, 0}, {0, -5}, {-5, -5}, {0, 0}}})
+	g.Properties["name"] = "天津市"
+	g.Properties["code"] = 120000
+	g.Properties["area"] = 3
+	g.Properties["capital"] = nil
+	fc.AddFeature(g)
+
+	data, err := Marshal(fc, &EncodeOptions{Name: "city"})
+	assert.Nil(t, err)
+	ret, err := Unmarshal(data)
+	assert.Nil(t, err)
+	assert.Len(t, ret.Features, 2)
+
+	d, err := NewDecoder(bytes.NewReader(data))
+	assert.Nil(t, err)
+	h := d.Header()
+	assert.Equal(t, "city", h.Name)
+	assert.Equal(t, 4490, h.EPSG)
+	assert.Equal(t, geom.GeometryType(""), h.GeometryType)
+	assert.Equal(t, geom.XY, h.Layout)
+	assert.Equal(t, uint64(2), h.FeaturesCount)
+	assert.Equal(t, []float64{-5, -5, 30, 30}, h.Envelope)
+	assert.Equal(t, []Column{
+		{Name: "area", Type: ColumnDouble},
+		{Name: "capital", Type: ColumnBool},
+		{Name: "code", Type: ColumnLong},
+		{Name: "founded", Type: ColumnDateTime},
+		{Name: "name", Type: ColumnString},
+		{Name: "tags", Type: ColumnJSON},
+	}, h.Columns)
+
+	// 测试几何与属性
+	for _, r := range ret.Features {
+		assert.Equal(t, 4490, r.GeometryData.EPSG)
+		switch r.Properties["name"] {
+		case "北京市":
+			assert.Equal(t, f.GeometryData.MultiPolygon, r.GeometryData.MultiPolygon)
+			assert.Equal(t, int64(110000), r.Properties["code"])
+			assert.Equal(t, 12.5, r.Properties["area"])
+			assert.Equal(t, true, r.Properties["capital"])
+			assert.Equal(t, []interface{}{"a", "b"}, r.Properties["tags"])
+			assert.Equal(t, time.Date(1949, 10, 1, 0, 0, 0, 0, time.UTC), r.Properties["founded"])
+		case "天津市":
+			assert.Equal(t, geom.GeometryPolygon, r.GeometryData.Type)
+			assert.Equal(t, g.GeometryData.Polygon, r.GeometryData.Polygon)
+			assert.Equal(t, 3.0, r.Properties["area"])
+			_, ok := r.Properties["capital"]
+			assert.False(t, ok)
+		default:
+			t.Errorf("unexpected feature %v", r.Properties)
+		}
+	}
+
+	_, err = Unmarshal(data[:20])
+	assert.NotNil(t, err)
+	_, err = Unmarshal([]byte("not flatgeobuf"))
+	assert.NotNil(t, err)
+	_, err = Marshal(fc, &EncodeOptions{Columns: []Column{{Name: "name", Type: ColumnString}}})
+	assert.NotNil(t, err)
+}
+
+func TestLayouts(t *testing.T) {
+	line := geom.NewLineStringGeometryData([][]float64{{0, 0, 1, 10}, {1, 1, 2, 20}})
+	line.Layout = geom.XYZM
+	point := geom.NewPointGeometryData([]float64{5, 6, 7})
+	point.Layout = geom.XYM
+	fc := geom.NewFeatureCollection()
+	fc.AddFeature(geom.NewFeatureFromGeometryData(line))
+	fc.AddFeature(geom.NewCollectionFeature(point, geom.NewLineStringGeometryData([][]float64{{0, 0, 3}, {1, 1, 4}})))
+	fc.AddFeature(&geom.Feature{Type: "Feature", Properties: map[string]interface{}{"id": 1}})
+
+	data, err := Marshal(fc, &EncodeOptions{IndexNodeSize: 0})
+	assert.Nil(t, err)
+	ret, err := Unmarshal(data)
+	assert.Nil(t, err)
+	assert.Len(t, ret.Features, 3)
+
+	assert.Equal(t, geom.XYZM, ret.Features[0].GeometryData.Layout)
+	assert.Equal(t, line.LineString, ret.Features[0].GeometryData.LineString)
+	// 缺少的 Z 与 M 读回为 0
+	c := ret.Features[1].GeometryData
+	assert.Equal(t, geom.GeometryCollection, c.Type)
+	assert.Len(t, c.Geometries, 2)
+	assert.Equal(t, []float64{5, 6, 0, 7}, c.Geometries[0].Point)
+	assert.Equal(t, [][]float64{{0, 0, 3, 0}, {1, 1, 4, 0}}, c.Geometries[1].LineString)
+	assert.Equal(t, geom.GeometryType(""), ret.Features[2].GeometryData.Type)
+	assert.Equal(t, int64(1), ret.Features[2].Properties["id"])
+}
+
+func TestSearch(t *testing.T) {
+	fc := geom.NewFeatureCollection()
+	for i := 0; i < 100; i++ {
+		for j := 0; j < 10; j++ {
+			f := geom.NewPointFeature([]float64{float64(i), float64(j)})
+			f.Properties["id"] = fmt.Sprintf("%d-%d", i, j)
+			fc.AddFeature(f)
+		}
+	}
+	data, err := Marshal(fc, &EncodeOptions{IndexNodeSize: 4, EPSG: 4326})
+	assert.Nil(t, err)
+	r, err := NewReader(bytes.NewReader(data))
+	assert.Nil(t, err)
+	assert.Equal(t, 4, r.Header().IndexNodeSize)
+	assert.Equal(t, 4326, r.Header().EPSG)
+
+	ret, err := r.Search(general.Extent{10.5, 2.5, 12.5, 4.5})
+	assert.Nil(t, err)
+	var ids []string
+	for _, f := range ret {
+		ids = append(ids, f.Properties["id"].(string))
+	}
+	assert.ElementsMatch(t, []string{"11-3", "11-4", "12-3", "12-4"}, ids)
+
+	ret, err = r.Search(general.Extent{200, 200, 300, 300})
+	assert.Nil(t, err)
+	assert.Len(t, ret, 0)
+
+	all, err := r.FeatureCollection()
+	assert.Nil(t, err)
+	assert.Len(t, all.Features, 1000)
+
+	// 测试没有索引时逐个读取
+	data, err = Marshal(fc, &EncodeOptions{})
+	assert.Nil(t, err)
+	r, err = NewReader(bytes.NewReader(data))
+	assert.Nil(t, err)
+	ret, err = r.Search(general.Extent{10.5, 2.5, 12.5, 4.5})
+	assert.Nil(t, err)
+	assert.Len(t, ret, 4)
+}
+
+func TestEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf, nil)
+	for i := 0; i < 3; i++ {
+		f := geom.NewPointFeature([]float64{float64(i), float64(i), float64(i * 10)})
+		f.Properties["id"] = i
+		assert.Nil(t, e.Encode(f))
+	}
+	f := geom.NewPointFeature([]float64{0, 0})
+	f.Properties["other"] = "x"
+	assert.NotNil(t, e.Encode(f))
+	assert.Nil(t, e.Close())
+
+	d, err := NewDecoder(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, geom.XYZ, d.Header().Layout)
+	assert.Equal(t, uint64(0), d.Header().FeaturesCount)
+	for i := 0; i < 3; i++ {
+		f, err := d.Decode()
+		assert.Nil(t, err)
+		assert.Equal(t, []float64{float64(i), float64(i), float64(i * 10)}, f.GeometryData.Point)
+		assert.Equal(t, int64(i), f.Properties["id"])
+	}
+	_, err = d.Decode()
+	assert.Equal(t, io.EOF, err)
+
+	// 测试空文件
+	buf.Reset()
+	assert.Nil(t, NewEncoder(&buf, nil).Close())
+	ret, err := Decode(&buf)
+	assert.Nil(t, err)
+	assert.Len(t, ret.Features, 0)
+}
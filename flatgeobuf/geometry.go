@@ -0,0 +1,185 @@
+package flatgeobuf
+
+import (
+	"fmt"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+
+	"github.com/flywave/go-geom"
+)
+
+// paths returns the parts of the positions of g, nil for the types made of
+// geometries.
+func paths(g *geom.GeometryData) [][][]float64 {
+	switch g.Type {
+	case geom.GeometryPoint:
+		if len(g.Point) < 2 {
+			return nil
+		}
+		return [][][]float64{{g.Point}}
+	case geom.GeometryMultiPoint:
+		return [][][]float64{g.MultiPoint}
+	case geom.GeometryLineString:
+		return [][][]float64{g.LineString}
+	case geom.GeometryMultiLineString:
+		return g.MultiLineString
+	case geom.GeometryPolygon:
+		return g.Polygon
+	}
+	return nil
+}
+
+// buildGeometry writes g, whose positions are read in its own layout and
+// written in the layout of the file. The type is written for the members
+// of collections and the features of files of mixed types.
+func buildGeometry(b *flatbuffers.Builder, g *geom.GeometryData, layout geom.Layout, typed bool) flatbuffers.UOffsetT {
+	src := geom.LayoutFromGeometryData(g)
+	var parts []flatbuffers.UOffsetT
+	switch g.Type {
+	case geom.GeometryMultiPolygon:
+		for _, p := range g.MultiPolygon {
+			polygon := geom.NewPolygonGeometryData(p)
+			polygon.Layout = src
+			parts = append(parts, buildGeometry(b, polygon, layout, false))
+		}
+	case geom.GeometryCollection:
+		for _, c := range g.Geometries {
+			if c != nil {
+				parts = append(parts, buildGeometry(b, c, layout, true))
+			}
+		}
+	}
+	var xy, z, m []float64
+	var ends []uint32
+	ps := paths(g)
+	for _, path := range ps {
+		for _, p := range path {
+			xy = append(xy, p[0], p[1])
+			// 缺少的 Z 与 M 写为 0
+			if layout.HasZ() {
+				v := 0.0
+				if i := src.ZIndex(); i >= 0 && i < len(p) {
+					v = p[i]
+				}
+				z = append(z, v)
+			}
+			if layout.HasM() {
+				v := 0.0
+				if i := src.MIndex(); i >= 0 && i < len(p) {
+					v = p[i]
+				}
+				m = append(m, v)
+			}
+		}
+		ends = append(ends, uint32(len(xy)/2))
+	}
+	var partsOffset, xyOffset, zOffset, mOffset, endsOffset flatbuffers.UOffsetT
+	if len(parts) > 0 {
+		partsOffset = b.CreateVectorOfTables(parts)
+	}
+	if len(ends) > 1 {
+		endsOffset = uint32Vector(b, ends)
+	}
+	if len(xy) > 0 {
+		xyOffset = float64Vector(b, xy)
+	}
+	if len(z) > 0 {
+		zOffset = float64Vector(b, z)
+	}
+	if len(m) > 0 {
+		mOffset = float64Vector(b, m)
+	}
+	b.StartObject(8)
+	b.PrependUOffsetTSlot(0, endsOffset, 0)
+	b.PrependUOffsetTSlot(1, xyOffset, 0)
+	b.PrependUOffsetTSlot(2, zOffset, 0)
+	b.PrependUOffsetTSlot(3, mOffset, 0)
+	if typed {
+		b.PrependByteSlot(6, geometryType(g.Type), 0)
+	}
+	b.PrependUOffsetTSlot(7, partsOffset, 0)
+	return b.EndObject()
+}
+
+// decodeGeometry reads a geometry of type typ, unless it has its own.
+func decodeGeometry(t *table, typ geom.GeometryType) (*geom.GeometryData, error) {
+	if n := int(t.GetByteSlot(t.slot(6), 0)); n >= len(geometryTypes) {
+		return nil, fmt.Errorf("unsupported flatgeobuf geometry type %d", n)
+	} else if n != 0 {
+		typ = geometryTypes[n]
+	}
+	ends, xy, z, m := t.uint32s(0), t.float64s(1), t.float64s(2), t.float64s(3)
+	n := len(xy) / 2
+	hasZ, hasM := n > 0 && len(z) == n, n > 0 && len(m) == n
+	ps := make([][]float64, n)
+	for i := range ps {
+		p := []float64{xy[2*i], xy[2*i+1]}
+		if hasZ {
+			p = append(p, z[i])
+		}
+		if hasM {
+			p = append(p, m[i])
+		}
+		ps[i] = p
+	}
+	if len(ends) == 0 && n > 0 {
+		ends = []uint32{uint32(n)}
+	}
+	var parts [][][]float64
+	start := 0
+	for _, end := range ends {
+		if int(end) < start || int(end) > n {
+			return nil, fmt.Errorf("invalid flatgeobuf geometry end %d", end)
+		}
+		parts = append(parts, ps[start:end])
+		start = int(end)
+	}
+
+	var g *geom.GeometryData
+	switch typ {
+	case geom.GeometryPoint:
+		g = geom.NewPointGeometryData(nil)
+		if n > 0 {
+			g.Point = ps[0]
+		}
+	case geom.GeometryMultiPoint:
+		g = geom.NewMultiPointGeometryData(ps...)
+	case geom.GeometryLineString:
+		g = geom.NewLineStringGeometryData(ps)
+	case geom.GeometryMultiLineString:
+		g = geom.NewMultiLineStringGeometryData(parts...)
+	case geom.GeometryPolygon:
+		g = geom.NewPolygonGeometryData(parts)
+	case geom.GeometryMultiPolygon, geom.GeometryCollection:
+		member := geom.GeometryPolygon
+		if typ == geom.GeometryCollection {
+			member = ""
+		}
+		var children []*geom.GeometryData
+		for _, part := range t.children(7) {
+			c, err := decodeGeometry(part, member)
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, c)
+		}
+		if typ == geom.GeometryCollection {
+			return geom.NewCollectionGeometryData(children...), nil
+		}
+		g = geom.NewMultiPolygonGeometryData()
+		for _, c := range children {
+			g.MultiPolygon = append(g.MultiPolygon, c.Polygon)
+			g.Layout = c.Layout
+		}
+		return g, nil
+	default:
+		return nil, fmt.Errorf("unknown flatgeobuf geometry type %q", typ)
+	}
+	switch {
+	case hasZ && hasM:
+		g.Layout = geom.XYZM
+	case hasM:
+		g.Layout = geom.XYM
+	}
+	return g, nil
+}
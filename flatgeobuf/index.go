@@ -0,0 +1,220 @@
+package flatgeobuf
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/flywave/go-geom/general"
+)
+
+const (
+	nodeItemSize = 40
+	hilbertMax   = 1<<16 - 1
+)
+
+// nodeItem is a node of the packed R-tree: the extent of its children and
+// the index of the first one, or for the leaves the extent and the byte
+// offset of a feature.
+type nodeItem struct {
+	extent general.Extent
+	offset uint64
+}
+
+func emptyExtent() general.Extent {
+	return general.Extent{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+}
+
+func expand(e *general.Extent, o general.Extent) {
+	e[0], e[1] = math.Min(e[0], o[0]), math.Min(e[1], o[1])
+	e[2], e[3] = math.Max(e[2], o[2]), math.Max(e[3], o[3])
+}
+
+func intersects(a, b general.Extent) bool {
+	return a[0] <= b[2] && a[1] <= b[3] && a[2] >= b[0] && a[3] >= b[1]
+}
+
+// levelBounds returns the node ranges of the levels of the tree of
+// numItems leaves, from the leaves to the root, which are stored first.
+func levelBounds(numItems, nodeSize int) [][2]int {
+	n := numItems
+	numNodes := n
+	counts := []int{n}
+	for {
+		n = (n + nodeSize - 1) / nodeSize
+		numNodes += n
+		counts = append(counts, n)
+		if n == 1 {
+			break
+		}
+	}
+	bounds := make([][2]int, len(counts))
+	end := numNodes
+	for i, c := range counts {
+		bounds[i] = [2]int{end - c, end}
+		end -= c
+	}
+	return bounds
+}
+
+// indexSize returns the size in bytes of the tree of numItems leaves.
+func indexSize(numItems, nodeSize int) int {
+	if numItems == 0 || nodeSize < 2 {
+		return 0
+	}
+	b := levelBounds(numItems, nodeSize)
+	return b[0][1] * nodeItemSize
+}
+
+// hilbert returns the distance of x, y along the Hilbert curve of order
+// 16.
+func hilbert(x, y uint32) uint32 {
+	a := x ^ y
+	b := 0xffff ^ a
+	c := 0xffff ^ (x | y)
+	d := x & (y ^ 0xffff)
+
+	A := a | (b >> 1)
+	B := (a >> 1) ^ a
+	C := ((c >> 1) ^ (b & (d >> 1))) ^ c
+	D := ((a & (c >> 1)) ^ (d >> 1)) ^ d
+
+	a, b, c, d = A, B, C, D
+	A = (a & (a >> 2)) ^ (b & (b >> 2))
+	B = (a & (b >> 2)) ^ (b & ((a ^ b) >> 2))
+	C ^= (a & (c >> 2)) ^ (b & (d >> 2))
+	D ^= (b & (c >> 2)) ^ ((a ^ b) & (d >> 2))
+
+	a, b, c, d = A, B, C, D
+	A = (a & (a >> 4)) ^ (b & (b >> 4))
+	B = (a & (b >> 4)) ^ (b & ((a ^ b) >> 4))
+	C ^= (a & (c >> 4)) ^ (b & (d >> 4))
+	D ^= (b & (c >> 4)) ^ ((a ^ b) & (d >> 4))
+
+	a, b, c, d = A, B, C, D
+	C ^= (a & (c >> 8)) ^ (b & (d >> 8))
+	D ^= (b & (c >> 8)) ^ ((a ^ b) & (d >> 8))
+
+	a = C ^ (C >> 1)
+	b = D ^ (D >> 1)
+
+	i0 := x ^ y
+	i1 := b | (0xffff ^ (i0 | a))
+
+	i0 = (i0 | (i0 << 8)) & 0x00ff00ff
+	i0 = (i0 | (i0 << 4)) & 0x0f0f0f0f
+	i0 = (i0 | (i0 << 2)) & 0x33333333
+	i0 = (i0 | (i0 << 1)) & 0x55555555
+
+	i1 = (i1 | (i1 << 8)) & 0x00ff00ff
+	i1 = (i1 | (i1 << 4)) & 0x0f0f0f0f
+	i1 = (i1 | (i1 << 2)) & 0x33333333
+	i1 = (i1 | (i1 << 1)) & 0x55555555
+
+	return (i1 << 1) | i0
+}
+
+// hilbertOrder returns the order of the extents along the Hilbert curve
+// of the centers within bounds, the highest values first as written by the
+// reference implementation.
+func hilbertOrder(extents []general.Extent, bounds general.Extent) []int {
+	scale := func(v, min, max float64) uint32 {
+		if max <= min {
+			return 0
+		}
+		return uint32(math.Floor(hilbertMax * (v - min) / (max - min)))
+	}
+	values := make([]uint32, len(extents))
+	order := make([]int, len(extents))
+	for i, e := range extents {
+		order[i] = i
+		if e[0] > e[2] {
+			continue
+		}
+		x := scale((e[0]+e[2])/2, bounds[0], bounds[2])
+		y := scale((e[1]+e[3])/2, bounds[1], bounds[3])
+		values[i] = hilbert(x, y)
+	}
+	sort.SliceStable(order, func(i, j int) bool { return values[order[i]] > values[order[j]] })
+	return order
+}
+
+// buildIndex returns the nodes of the tree whose leaves are items, the
+// root first.
+func buildIndex(items []nodeItem, nodeSize int) []nodeItem {
+	bounds := levelBounds(len(items), nodeSize)
+	nodes := make([]nodeItem, bounds[0][1])
+	copy(nodes[bounds[0][0]:], items)
+	for i := 0; i+1 < len(bounds); i++ {
+		pos, end, parent := bounds[i][0], bounds[i][1], bounds[i+1][0]
+		for pos < end {
+			n := nodeItem{extent: emptyExtent(), offset: uint64(pos)}
+			for j := 0; j < nodeSize && pos < end; j++ {
+				expand(&n.extent, nodes[pos].extent)
+				pos++
+			}
+			nodes[parent] = n
+			parent++
+		}
+	}
+	return nodes
+}
+
+func marshalIndex(nodes []nodeItem) []byte {
+	buf := make([]byte, 0, len(nodes)*nodeItemSize)
+	for _, n := range nodes {
+		for _, v := range n.extent {
+			buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+		}
+		buf = binary.LittleEndian.AppendUint64(buf, n.offset)
+	}
+	return buf
+}
+
+func readNode(b []byte) nodeItem {
+	var n nodeItem
+	for i := range n.extent {
+		n.extent[i] = math.Float64frombits(binary.LittleEndian.Uint64(b[8*i:]))
+	}
+	n.offset = binary.LittleEndian.Uint64(b[32:])
+	return n
+}
+
+// searchIndex returns the byte offsets of the features whose extents
+// intersect extent, reading the nodes of the tree at the start of r.
+func searchIndex(r io.ReaderAt, numItems, nodeSize int, extent general.Extent) ([]uint64, error) {
+	bounds := levelBounds(numItems, nodeSize)
+	leaves := bounds[0][0]
+	type entry struct {
+		node, level int
+	}
+	queue := []entry{{0, len(bounds) - 1}}
+	var ret []uint64
+	buf := make([]byte, nodeSize*nodeItemSize)
+	for len(queue) > 0 {
+		e := queue[0]
+		queue = queue[1:]
+		end := e.node + nodeSize
+		if end > bounds[e.level][1] {
+			end = bounds[e.level][1]
+		}
+		b := buf[:(end-e.node)*nodeItemSize]
+		if _, err := r.ReadAt(b, int64(e.node)*nodeItemSize); err != nil {
+			return nil, err
+		}
+		for i := e.node; i < end; i++ {
+			n := readNode(b[(i-e.node)*nodeItemSize:])
+			if !intersects(n.extent, extent) {
+				continue
+			}
+			if i >= leaves {
+				ret = append(ret, n.offset)
+			} else {
+				queue = append(queue, entry{int(n.offset), e.level - 1})
+			}
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i] < ret[j] })
+	return ret, nil
+}
@@ -0,0 +1,102 @@
+package geodesic
+
+import (
+	"math"
+)
+
+// accumulator keeps a sum as an unevaluated pair to avoid losing precision
+// when many edge contributions of mixed sign are added.
+type accumulator [2]float64
+
+func (a *accumulator) add(y float64) {
+	z, u := sumx(y, a[1])
+	a[0], a[1] = sumx(z, a[0])
+	if a[0] == 0 {
+		a[0] = u
+	} else {
+		a[1] += u
+	}
+}
+
+func (a *accumulator) rem(y float64) {
+	a[0] = math.Remainder(a[0], y)
+	a.add(0)
+}
+
+func (a *accumulator) neg() {
+	a[0], a[1] = -a[0], -a[1]
+}
+
+// transit counts the crossings of the prime meridian when going from lon1
+// to lon2, which tells if a ring encircles a pole.
+func transit(lon1, lon2 float64) int {
+	lon12, _ := angDiff(lon1, lon2)
+	lon1 = angNormalize(lon1)
+	lon2 = angNormalize(lon2)
+	switch {
+	case lon12 > 0 && ((lon1 < 0 && lon2 >= 0) || (lon1 > 0 && lon2 == 0)):
+		return 1
+	case lon12 < 0 && lon1 >= 0 && lon2 < 0:
+		return -1
+	}
+	return 0
+}
+
+// edgeFunc returns the length of the edge between two points and the area
+// between the edge and the equator, clockwise being positive.
+type edgeFunc func(lat1, lon1, lat2, lon2 float64) (s12, S12 float64)
+
+// ringArea returns the signed area of a ring of [lon, lat] positions,
+// positive for counter-clockwise rings, and its perimeter. area0 is the
+// total area of the surface.
+func ringArea(ring [][]float64, area0 float64, edge edgeFunc) (float64, float64) {
+	n := len(ring)
+	if n > 1 && ring[0][0] == ring[n-1][0] && ring[0][1] == ring[n-1][1] {
+		n--
+	}
+	if n < 2 {
+		return 0, 0
+	}
+	var (
+		area, perimeter accumulator
+		crossings       int
+	)
+	for i := 0; i < n; i++ {
+		p1, p2 := ring[i], ring[(i+1)%n]
+		lon1, lon2 := angNormalize(p1[0]), angNormalize(p2[0])
+		s12, S12 := edge(p1[1], lon1, p2[1], lon2)
+		perimeter.add(s12)
+		area.add(S12)
+		crossings += transit(lon1, lon2)
+	}
+	if n < 3 {
+		return 0, perimeter[0]
+	}
+
+	area.rem(area0)
+	if crossings&1 != 0 {
+		if area[0] < 0 {
+			area.add(area0 / 2)
+		} else {
+			area.add(-area0 / 2)
+		}
+	}
+	area.neg()
+	if area[0] > area0/2 {
+		area.add(-area0)
+	} else if area[0] <= -area0/2 {
+		area.add(area0)
+	}
+	return 0 + area[0], perimeter[0]
+}
+
+// RingArea returns the signed area in square meters enclosed by a ring of
+// [lon, lat] positions with geodesic edges, positive when the ring is
+// counter-clockwise.
+func (g *Geodesic) RingArea(ring [][]float64) float64 {
+	area, _ := ringArea(ring, g.EllipsoidArea(), func(lat1, lon1, lat2, lon2 float64) (float64, float64) {
+		r := g.inverse(lat1, lon1, lat2, lon2, true)
+		return r.s12, r.S12
+	})
+	return area
+}
@@ -0,0 +1,1061 @@
+package geodesic
+
+import (
+	"math"
+)
+
+// The solution of the direct and inverse problems and the polygon area
+// computation follow C. F. F. Karney, "Algorithms for geodesics",
+// J. Geodesy 87, 43-55 (2013) and its reference implementation in
+// GeographicLib (MIT licensed). The series are carried to sixth order in
+// the flattening which keeps the errors at the level of round-off for the
+// terrestrial ellipsoids.
+
+const (
+	nA1   = 6
+	nC1   = 6
+	nC1p  = 6
+	nA2   = 6
+	nC2   = 6
+	nA3   = 6
+	nA3x  = nA3
+	nC3   = 6
+	nC3x  = (nC3 * (nC3 - 1)) / 2
+	nC4   = 6
+	nC4x  = (nC4 * (nC4 + 1)) / 2
+	nC    = 7
+	qd    = 90.0
+	hd    = 180.0
+	td    = 360.0
+	digit = 53
+
+	maxit1 = 20
+	maxit2 = maxit1 + digit + 10
+)
+
+var (
+	tiny    = math.Sqrt(0x1p-1022)
+	tol0    = math.Nextafter(1, 2) - 1
+	tol1    = 200 * tol0
+	tol2    = math.Sqrt(tol0)
+	tolb    = tol0 * tol2
+	xthresh = 1000 * tol2
+	degree  = math.Pi / hd
+)
+
+type Geodesic struct {
+	a, f, f1, e2, ep2, n, b, c2, etol2 float64
+	a3x                                [nA3x]float64
+	c3x                                [nC3x]float64
+	c4x                                [nC4x]float64
+}
+
+// WGS84 is the ellipsoid used by GPS and by EPSG:4326.
+var WGS84 = NewGeodesic(6378137, 1/298.257223563)
+
+// CGCS2000 is the ellipsoid of the China Geodetic Coordinate System 2000
+// (EPSG:4490).
+var CGCS2000 = NewGeodesic(6378137, 1/298.257222101)
+
+// NewGeodesic returns the geodesic calculator for the ellipsoid with
+// equatorial radius a (in meters) and flattening f.
+func NewGeodesic(a, f float64) *Geodesic {
+	g := &Geodesic{a: a, f: f}
+	g.f1 = 1 - f
+	g.e2 = f * (2 - f)
+	g.ep2 = g.e2 / sq(g.f1)
+	g.n = f / (2 - f)
+	g.b = a * g.f1
+	var e float64
+	switch {
+	case g.e2 == 0:
+		e = 1
+	case g.e2 > 0:
+		e = math.Atanh(math.Sqrt(g.e2)) / math.Sqrt(math.Abs(g.e2))
+	default:
+		e = math.Atan(math.Sqrt(-g.e2)) / math.Sqrt(math.Abs(g.e2))
+	}
+	g.c2 = (sq(a) + sq(g.b)*e) / 2
+	g.etol2 = 0.1 * tol2 / math.Sqrt(math.Max(0.001, math.Abs(f))*math.Min(1, 1-f/2)/2)
+	g.a3coeff()
+	g.c3coeff()
+	g.c4coeff()
+	return g
+}
+
+func (g *Geodesic) EquatorialRadius() float64 { return g.a }
+
+func (g *Geodesic) Flattening() float64 { return g.f }
+
+// EllipsoidArea returns the total surface area of the ellipsoid.
+func (g *Geodesic) EllipsoidArea() float64 { return 4 * math.Pi * g.c2 }
+
+// Inverse solves the inverse geodesic problem: the distance s12 in meters
+// between two points given in degrees and the forward azimuths at both
+// ends, in degrees clockwise from north.
+func (g *Geodesic) Inverse(lat1, lon1, lat2, lon2 float64) (s12, azi1, azi2 float64) {
+	r := g.inverse(lat1, lon1, lat2, lon2, false)
+	return r.s12, atan2dx(r.salp1, r.calp1), atan2dx(r.salp2, r.calp2)
+}
+
+// Direct solves the direct geodesic problem: the position reached after
+// travelling s12 meters from (lat1, lon1) with initial azimuth azi1 and the
+// forward azimuth there.
+func (g *Geodesic) Direct(lat1, lon1, azi1, s12 float64) (lat2, lon2, azi2 float64) {
+	l := g.line(lat1, lon1, azi1)
+	return l.position(s12)
+}
+
+type inverseResult struct {
+	s12, a12, S12              float64
+	salp1, calp1, salp2, calp2 float64
+}
+
+func (g *Geodesic) inverse(lat1, lon1, lat2, lon2 float64, area bool) inverseResult {
+	var (
+		s12x, m12x, a12, sig12     float64
+		salp1, calp1, salp2, calp2 float64
+		omg12                      float64
+		somg12, comg12             = 2.0, 0.0
+		ca                         [nC]float64
+	)
+
+	lon12, lon12s := angDiff(lon1, lon2)
+	lonsign := 1.0
+	if math.Signbit(lon12) {
+		lonsign = -1
+	}
+	lon12 *= lonsign
+	lon12s *= lonsign
+	lam12 := lon12 * degree
+	slam12, clam12 := sincosde(lon12, lon12s)
+	lon12s = (hd - lon12) - lon12s
+
+	lat1 = angRound(latFix(lat1))
+	lat2 = angRound(latFix(lat2))
+	swapp := 1.0
+	if math.Abs(lat1) < math.Abs(lat2) || math.IsNaN(lat2) {
+		swapp = -1
+		lonsign *= -1
+		lat1, lat2 = lat2, lat1
+	}
+	latsign := -1.0
+	if math.Signbit(lat1) {
+		latsign = 1
+	}
+	lat1 *= latsign
+	lat2 *= latsign
+
+	sbet1, cbet1 := sincosdx(lat1)
+	sbet1 *= g.f1
+	sbet1, cbet1 = norm2(sbet1, cbet1)
+	cbet1 = math.Max(tiny, cbet1)
+
+	sbet2, cbet2 := sincosdx(lat2)
+	sbet2 *= g.f1
+	sbet2, cbet2 = norm2(sbet2, cbet2)
+	cbet2 = math.Max(tiny, cbet2)
+
+	if cbet1 < -sbet1 {
+		if cbet2 == cbet1 {
+			sbet2 = math.Copysign(sbet1, sbet2)
+		}
+	} else if math.Abs(sbet2) == -sbet1 {
+		cbet2 = cbet1
+	}
+
+	dn1 := math.Sqrt(1 + g.ep2*sq(sbet1))
+	dn2 := math.Sqrt(1 + g.ep2*sq(sbet2))
+
+	meridian := lat1 == -qd || slam12 == 0
+
+	if meridian {
+		calp1, salp1 = clam12, slam12
+		calp2, salp2 = 1, 0
+
+		ssig1, csig1 := sbet1, calp1*cbet1
+		ssig2, csig2 := sbet2, calp2*cbet2
+
+		sig12 = math.Atan2(math.Max(0, csig1*ssig2-ssig1*csig2)+0, csig1*csig2+ssig1*ssig2)
+		lr := g.lengths(g.n, sig12, ssig1, csig1, dn1, ssig2, csig2, dn2, cbet1, cbet2, ca[:])
+		s12x, m12x = lr.s12b, lr.m12b
+		if sig12 < 1 || m12x >= 0 {
+			if sig12 < 3*tiny || (sig12 < tol0 && (s12x < 0 || m12x < 0)) {
+				sig12, m12x, s12x = 0, 0, 0
+			}
+			m12x *= g.b
+			s12x *= g.b
+			a12 = sig12 / degree
+		} else {
+			meridian = false
+		}
+	}
+
+	if !meridian && sbet1 == 0 && (g.f <= 0 || lon12s >= g.f*hd) {
+		// the geodesic runs along the equator
+		calp1, calp2 = 0, 0
+		salp1, salp2 = 1, 1
+		s12x = g.a * lam12
+		sig12 = lam12 / g.f1
+		omg12 = sig12
+		m12x = g.b * math.Sin(sig12)
+		a12 = lon12 / g.f1
+	} else if !meridian {
+		var dnm float64
+		sig12, salp1, calp1, salp2, calp2, dnm = g.inverseStart(sbet1, cbet1, dn1, sbet2, cbet2, dn2, lam12, slam12, clam12, ca[:])
+
+		if sig12 >= 0 {
+			s12x = sig12 * g.b * dnm
+			m12x = sq(dnm) * g.b * math.Sin(sig12/dnm)
+			a12 = sig12 / degree
+			omg12 = lam12 / (g.f1 * dnm)
+		} else {
+			var (
+				lr     lambdaResult
+				numit  int
+				salp1a = tiny
+				calp1a = 1.0
+				salp1b = tiny
+				calp1b = -1.0
+				tripn  bool
+				tripb  bool
+			)
+			for ; ; numit++ {
+				lr = g.lambda12(sbet1, cbet1, dn1, sbet2, cbet2, dn2, salp1, calp1, slam12, clam12, numit < maxit1, ca[:])
+				v := lr.lam12
+				tol := 1.0
+				if tripn {
+					tol = 8
+				}
+				if tripb || !(math.Abs(v) >= tol*tol0) || numit == maxit2 {
+					break
+				}
+				if v > 0 && (numit > maxit1 || calp1/salp1 > calp1b/salp1b) {
+					salp1b, calp1b = salp1, calp1
+				} else if v < 0 && (numit > maxit1 || calp1/salp1 < calp1a/salp1a) {
+					salp1a, calp1a = salp1, calp1
+				}
+				if numit < maxit1 && lr.dlam12 > 0 {
+					dalp1 := -v / lr.dlam12
+					if math.Abs(dalp1) < math.Pi {
+						sdalp1, cdalp1 := math.Sincos(dalp1)
+						nsalp1 := salp1*cdalp1 + calp1*sdalp1
+						if nsalp1 > 0 {
+							calp1 = calp1*cdalp1 - salp1*sdalp1
+							salp1 = nsalp1
+							salp1, calp1 = norm2(salp1, calp1)
+							tripn = math.Abs(v) <= 16*tol0
+							continue
+						}
+					}
+				}
+				salp1 = (salp1a + salp1b) / 2
+				calp1 = (calp1a + calp1b) / 2
+				salp1, calp1 = norm2(salp1, calp1)
+				tripn = false
+				tripb = math.Abs(salp1a-salp1)+(calp1a-calp1) < tolb ||
+					math.Abs(salp1-salp1b)+(calp1-calp1b) < tolb
+			}
+			salp2, calp2, sig12 = lr.salp2, lr.calp2, lr.sig12
+			lens := g.lengths(lr.eps, sig12, lr.ssig1, lr.csig1, dn1, lr.ssig2, lr.csig2, dn2, cbet1, cbet2, ca[:])
+			s12x, m12x = lens.s12b, lens.m12b
+			m12x *= g.b
+			s12x *= g.b
+			a12 = sig12 / degree
+			if area {
+				sdomg12, cdomg12 := math.Sincos(lr.domg12)
+				somg12 = slam12*cdomg12 - clam12*sdomg12
+				comg12 = clam12*cdomg12 + slam12*sdomg12
+			}
+		}
+	}
+
+	res := inverseResult{s12: 0 + s12x, a12: a12}
+
+	if area {
+		salp0 := salp1 * cbet1
+		calp0 := math.Hypot(calp1, salp1*sbet1)
+		var S12 float64
+		if calp0 != 0 && salp0 != 0 {
+			ssig1, csig1 := norm2(sbet1, calp1*cbet1)
+			ssig2, csig2 := norm2(sbet2, calp2*cbet2)
+			k2 := sq(calp0) * g.ep2
+			eps := k2 / (2*(1+math.Sqrt(1+k2)) + k2)
+			A4 := sq(g.a) * calp0 * salp0 * g.e2
+			g.c4f(eps, ca[:])
+			B41 := sinCosSeries(false, ssig1, csig1, ca[:], nC4)
+			B42 := sinCosSeries(false, ssig2, csig2, ca[:], nC4)
+			S12 = A4 * (B42 - B41)
+		}
+
+		if !meridian && somg12 == 2 {
+			somg12, comg12 = math.Sincos(omg12)
+		}
+
+		var alp12 float64
+		if !meridian && comg12 > -0.7071 && sbet2-sbet1 < 1.75 {
+			domg12 := 1 + comg12
+			dbet1 := 1 + cbet1
+			dbet2 := 1 + cbet2
+			alp12 = 2 * math.Atan2(somg12*(sbet1*dbet2+sbet2*dbet1), domg12*(sbet1*sbet2+dbet1*dbet2))
+		} else {
+			salp12 := salp2*calp1 - calp2*salp1
+			calp12 := calp2*calp1 + salp2*salp1
+			if salp12 == 0 && calp12 < 0 {
+				salp12 = tiny * calp1
+				calp12 = -1
+			}
+			alp12 = math.Atan2(salp12, calp12)
+		}
+		S12 += g.c2 * alp12
+		S12 *= swapp * lonsign * latsign
+		res.S12 = S12 + 0
+	}
+
+	if swapp < 0 {
+		salp1, salp2 = salp2, salp1
+		calp1, calp2 = calp2, calp1
+	}
+
+	res.salp1 = salp1 * swapp * lonsign
+	res.calp1 = calp1 * swapp * latsign
+	res.salp2 = salp2 * swapp * lonsign
+	res.calp2 = calp2 * swapp * latsign
+	return res
+}
+
+type lengthsResult struct {
+	s12b, m12b, m0 float64
+}
+
+func (g *Geodesic) lengths(eps, sig12, ssig1, csig1, dn1, ssig2, csig2, dn2, cbet1, cbet2 float64, ca []float64) lengthsResult {
+	var cb [nC]float64
+	A1 := a1m1f(eps)
+	c1f(eps, ca)
+	A2 := a2m1f(eps)
+	c2f(eps, cb[:])
+	m0 := A1 - A2
+	A1 = 1 + A1
+	A2 = 1 + A2
+
+	B1 := sinCosSeries(true, ssig2, csig2, ca, nC1) - sinCosSeries(true, ssig1, csig1, ca, nC1)
+	B2 := sinCosSeries(true, ssig2, csig2, cb[:], nC2) - sinCosSeries(true, ssig1, csig1, cb[:], nC2)
+	J12 := m0*sig12 + (A1*B1 - A2*B2)
+
+	return lengthsResult{
+		s12b: A1 * (sig12 + B1),
+		m12b: dn2*(csig1*ssig2) - dn1*(ssig1*csig2) - csig1*csig2*J12,
+		m0:   m0,
+	}
+}
+
+// astroid solves k^4+2*k^3-(x^2+y^2-1)*k^2-2*y^2*k-y^2 = 0 for the
+// positive root k.
+func astroid(x, y float64) float64 {
+	p := sq(x)
+	q := sq(y)
+	r := (p + q - 1) / 6
+	if q == 0 && r <= 0 {
+		return 0
+	}
+	S := p * q / 4
+	r2 := sq(r)
+	r3 := r * r2
+	disc := S * (S + 2*r3)
+	u := r
+	if disc >= 0 {
+		T3 := S + r3
+		if T3 < 0 {
+			T3 -= math.Sqrt(disc)
+		} else {
+			T3 += math.Sqrt(disc)
+		}
+		T := math.Cbrt(T3)
+		if T != 0 {
+			u += T + r2/T
+		} else {
+			u += T
+		}
+	} else {
+		ang := math.Atan2(math.Sqrt(-disc), -(S + r3))
+		u += 2 * r * math.Cos(ang/3)
+	}
+	v := math.Sqrt(sq(u) + q)
+	var uv float64
+	if u < 0 {
+		uv = q / (v - u)
+	} else {
+		uv = u + v
+	}
+	w := (uv - q) / (2 * v)
+	return uv / (math.Sqrt(uv+sq(w)) + w)
+}
+
+func (g *Geodesic) inverseStart(sbet1, cbet1, dn1, sbet2, cbet2, dn2, lam12, slam12, clam12 float64, ca []float64) (sig12, salp1, calp1, salp2, calp2, dnm float64) {
+	sig12 = -1
+	sbet12 := sbet2*cbet1 - cbet2*sbet1
+	cbet12 := cbet2*cbet1 + sbet2*sbet1
+	sbet12a := sbet2*cbet1 + cbet2*sbet1
+	shortline := cbet12 >= 0 && sbet12 < 0.5 && cbet2*lam12 < 0.5
+	var somg12, comg12 float64
+	if shortline {
+		sbetm2 := sq(sbet1 + sbet2)
+		sbetm2 /= sbetm2 + sq(cbet1+cbet2)
+		dnm = math.Sqrt(1 + g.ep2*sbetm2)
+		omg12 := lam12 / (g.f1 * dnm)
+		somg12, comg12 = math.Sincos(omg12)
+	} else {
+		somg12, comg12 = slam12, clam12
+	}
+
+	salp1 = cbet2 * somg12
+	if comg12 >= 0 {
+		calp1 = sbet12 + cbet2*sbet1*sq(somg12)/(1+comg12)
+	} else {
+		calp1 = sbet12a - cbet2*sbet1*sq(somg12)/(1-comg12)
+	}
+
+	ssig12 := math.Hypot(salp1, calp1)
+	csig12 := sbet1*sbet2 + cbet1*cbet2*comg12
+
+	if shortline && ssig12 < g.etol2 {
+		salp2 = cbet1 * somg12
+		if comg12 >= 0 {
+			calp2 = sbet12 - cbet1*sbet2*(sq(somg12)/(1+comg12))
+		} else {
+			calp2 = sbet12 - cbet1*sbet2*(1-comg12)
+		}
+		salp2, calp2 = norm2(salp2, calp2)
+		sig12 = math.Atan2(ssig12, csig12)
+	} else if math.Abs(g.n) > 0.1 || csig12 >= 0 || ssig12 >= 6*math.Abs(g.n)*math.Pi*sq(cbet1) {
+		// the zeroth order spherical approximation is good enough
+	} else {
+		var x, y, lamscale, betscale float64
+		lam12x := math.Atan2(-slam12, -clam12)
+		if g.f >= 0 {
+			k2 := sq(sbet1) * g.ep2
+			eps := k2 / (2*(1+math.Sqrt(1+k2)) + k2)
+			lamscale = g.f * cbet1 * g.a3f(eps) * math.Pi
+			betscale = lamscale * cbet1
+			x = lam12x / lamscale
+			y = sbet12a / betscale
+		} else {
+			cbet12a := cbet2*cbet1 - sbet2*sbet1
+			bet12a := math.Atan2(sbet12a, cbet12a)
+			lr := g.lengths(g.n, math.Pi+bet12a, sbet1, -cbet1, dn1, sbet2, cbet2, dn2, cbet1, cbet2, ca)
+			x = -1 + lr.m12b/(cbet1*cbet2*lr.m0*math.Pi)
+			if x < -0.01 {
+				betscale = sbet12a / x
+			} else {
+				betscale = -g.f * sq(cbet1) * math.Pi
+			}
+			lamscale = betscale / cbet1
+			y = lam12x / lamscale
+		}
+
+		if y > -tol1 && x > -1-xthresh {
+			if g.f >= 0 {
+				salp1 = math.Min(1, -x)
+				calp1 = -math.Sqrt(1 - sq(salp1))
+			} else {
+				lim := -1.0
+				if x > -tol1 {
+					lim = 0
+				}
+				calp1 = math.Max(lim, x)
+				salp1 = math.Sqrt(1 - sq(calp1))
+			}
+		} else {
+			k := astroid(x, y)
+			var omg12a float64
+			if g.f >= 0 {
+				omg12a = lamscale * (-x * k / (1 + k))
+			} else {
+				omg12a = lamscale * (-y * (1 + k) / k)
+			}
+			somg12, comg12 = math.Sincos(omg12a)
+			comg12 = -comg12
+			salp1 = cbet2 * somg12
+			calp1 = sbet12a - cbet2*sbet1*sq(somg12)/(1-comg12)
+		}
+	}
+	if !(salp1 <= 0) {
+		salp1, calp1 = norm2(salp1, calp1)
+	} else {
+		salp1, calp1 = 1, 0
+	}
+	return
+}
+
+type lambdaResult struct {
+	lam12, salp2, calp2, sig12, ssig1, csig1, ssig2, csig2, eps, domg12, dlam12 float64
+}
+
+func (g *Geodesic) lambda12(sbet1, cbet1, dn1, sbet2, cbet2, dn2, salp1, calp1, slam120, clam120 float64, diffp bool, ca []float64) lambdaResult {
+	var r lambdaResult
+	if sbet1 == 0 && calp1 == 0 {
+		calp1 = -tiny
+	}
+
+	salp0 := salp1 * cbet1
+	calp0 := math.Hypot(calp1, salp1*sbet1)
+
+	somg1 := salp0 * sbet1
+	comg1 := calp1 * cbet1
+	ssig1, csig1 := norm2(sbet1, comg1)
+
+	var salp2, calp2 float64
+	if cbet2 != cbet1 {
+		salp2 = salp0 / cbet2
+	} else {
+		salp2 = salp1
+	}
+	if cbet2 != cbet1 || math.Abs(sbet2) != -sbet1 {
+		var t float64
+		if cbet1 < -sbet1 {
+			t = (cbet2 - cbet1) * (cbet1 + cbet2)
+		} else {
+			t = (sbet1 - sbet2) * (sbet1 + sbet2)
+		}
+		calp2 = math.Sqrt(sq(calp1*cbet1)+t) / cbet2
+	} else {
+		calp2 = math.Abs(calp1)
+	}
+	somg2 := salp0 * sbet2
+	comg2 := calp2 * cbet2
+	ssig2, csig2 := norm2(sbet2, comg2)
+
+	sig12 := math.Atan2(math.Max(0, csig1*ssig2-ssig1*csig2)+0, csig1*csig2+ssig1*ssig2)
+
+	somg12 := math.Max(0, comg1*somg2-somg1*comg2) + 0
+	comg12 := comg1*comg2 + somg1*somg2
+	eta := math.Atan2(somg12*clam120-comg12*slam120, comg12*clam120+somg12*slam120)
+	k2 := sq(calp0) * g.ep2
+	eps := k2 / (2*(1+math.Sqrt(1+k2)) + k2)
+	g.c3f(eps, ca)
+	B312 := sinCosSeries(true, ssig2, csig2, ca, nC3-1) - sinCosSeries(true, ssig1, csig1, ca, nC3-1)
+	domg12 := -g.f * g.a3f(eps) * salp0 * (sig12 + B312)
+
+	if diffp {
+		if calp2 == 0 {
+			r.dlam12 = -2 * g.f1 * dn1 / sbet1
+		} else {
+			lr := g.lengths(eps, sig12, ssig1, csig1, dn1, ssig2, csig2, dn2, cbet1, cbet2, ca)
+			r.dlam12 = lr.m12b * g.f1 / (calp2 * cbet2)
+		}
+	}
+
+	r.lam12 = eta + domg12
+	r.salp2, r.calp2 = salp2, calp2
+	r.sig12 = sig12
+	r.ssig1, r.csig1 = ssig1, csig1
+	r.ssig2, r.csig2 = ssig2, csig2
+	r.eps = eps
+	r.domg12 = domg12
+	return r
+}
+
+type geodesicLine struct {
+	lat1, lon1, azi1                float64
+	a, f, b, c2, f1                 float64
+	salp0, calp0, k2                float64
+	salp1, calp1, ssig1, csig1, dn1 float64
+	stau1, ctau1, somg1, comg1      float64
+	A1m1, A3c, B11, B31             float64
+	c1a, c1pa, c3a                  [nC]float64
+}
+
+func (g *Geodesic) line(lat1, lon1, azi1 float64) *geodesicLine {
+	azi1 = angNormalize(azi1)
+	salp1, calp1 := sincosdx(angRound(azi1))
+
+	l := &geodesicLine{
+		a: g.a, f: g.f, b: g.b, c2: g.c2, f1: g.f1,
+		lat1: latFix(lat1), lon1: lon1, azi1: azi1,
+		salp1: salp1, calp1: calp1,
+	}
+
+	sbet1, cbet1 := sincosdx(angRound(l.lat1))
+	sbet1 *= l.f1
+	sbet1, cbet1 = norm2(sbet1, cbet1)
+	cbet1 = math.Max(tiny, cbet1)
+	l.dn1 = math.Sqrt(1 + g.ep2*sq(sbet1))
+
+	l.salp0 = l.salp1 * cbet1
+	l.calp0 = math.Hypot(l.calp1, l.salp1*sbet1)
+	l.ssig1 = sbet1
+	l.somg1 = l.salp0 * sbet1
+	if sbet1 != 0 || l.calp1 != 0 {
+		l.csig1 = cbet1 * l.calp1
+	} else {
+		l.csig1 = 1
+	}
+	l.comg1 = l.csig1
+	l.ssig1, l.csig1 = norm2(l.ssig1, l.csig1)
+
+	l.k2 = sq(l.calp0) * g.ep2
+	eps := l.k2 / (2*(1+math.Sqrt(1+l.k2)) + l.k2)
+
+	l.A1m1 = a1m1f(eps)
+	c1f(eps, l.c1a[:])
+	l.B11 = sinCosSeries(true, l.ssig1, l.csig1, l.c1a[:], nC1)
+	s, c := math.Sincos(l.B11)
+	l.stau1 = l.ssig1*c + l.csig1*s
+	l.ctau1 = l.csig1*c - l.ssig1*s
+
+	c1pf(eps, l.c1pa[:])
+
+	g.c3f(eps, l.c3a[:])
+	l.A3c = -l.f * l.salp0 * g.a3f(eps)
+	l.B31 = sinCosSeries(true, l.ssig1, l.csig1, l.c3a[:], nC3-1)
+	return l
+}
+
+func (l *geodesicLine) position(s12 float64) (lat2, lon2, azi2 float64) {
+	tau12 := s12 / (l.b * (1 + l.A1m1))
+	s, c := math.Sincos(tau12)
+	B12 := -sinCosSeries(true, l.stau1*c+l.ctau1*s, l.ctau1*c-l.stau1*s, l.c1pa[:], nC1p)
+	sig12 := tau12 - (B12 - l.B11)
+	ssig12, csig12 := math.Sincos(sig12)
+	if math.Abs(l.f) > 0.01 {
+		ssig2 := l.ssig1*csig12 + l.csig1*ssig12
+		csig2 := l.csig1*csig12 - l.ssig1*ssig12
+		B12 = sinCosSeries(true, ssig2, csig2, l.c1a[:], nC1)
+		serr := (1+l.A1m1)*(sig12+(B12-l.B11)) - s12/l.b
+		sig12 = sig12 - serr/math.Sqrt(1+l.k2*sq(ssig2))
+		ssig12, csig12 = math.Sincos(sig12)
+	}
+
+	ssig2 := l.ssig1*csig12 + l.csig1*ssig12
+	csig2 := l.csig1*csig12 - l.ssig1*ssig12
+	sbet2 := l.calp0 * ssig2
+	cbet2 := math.Hypot(l.salp0, l.calp0*csig2)
+	if cbet2 == 0 {
+		cbet2 = tiny
+		csig2 = tiny
+	}
+	salp2 := l.salp0
+	calp2 := l.calp0 * csig2
+
+	E := math.Copysign(1, l.salp0)
+	somg2 := l.salp0 * ssig2
+	comg2 := csig2
+	omg12 := E * (sig12 -
+		(math.Atan2(ssig2, csig2) - math.Atan2(l.ssig1, l.csig1)) +
+		(math.Atan2(E*somg2, comg2) - math.Atan2(E*l.somg1, l.comg1)))
+	lam12 := omg12 + l.A3c*(sig12+(sinCosSeries(true, ssig2, csig2, l.c3a[:], nC3-1)-l.B31))
+	lon12 := lam12 / degree
+	lon2 = angNormalize(angNormalize(l.lon1) + angNormalize(lon12))
+	lat2 = atan2dx(sbet2, l.f1*cbet2)
+	azi2 = atan2dx(salp2, calp2)
+	return
+}
+
+func (g *Geodesic) a3coeff() {
+	coeff := [...]float64{
+		// A3, coeff of eps^5, polynomial in n of order 0
+		-3, 128,
+		// A3, coeff of eps^4, polynomial in n of order 1
+		-2, -3, 64,
+		// A3, coeff of eps^3, polynomial in n of order 2
+		-1, -3, -1, 16,
+		// A3, coeff of eps^2, polynomial in n of order 2
+		3, -1, -2, 8,
+		// A3, coeff of eps^1, polynomial in n of order 1
+		1, -1, 2,
+		// A3, coeff of eps^0, polynomial in n of order 0
+		1, 1,
+	}
+	o, k := 0, 0
+	for j := nA3 - 1; j >= 0; j-- {
+		m := min(nA3-j-1, j)
+		g.a3x[k] = polyval(m, coeff[o:], g.n) / coeff[o+m+1]
+		k++
+		o += m + 2
+	}
+}
+
+func (g *Geodesic) c3coeff() {
+	coeff := [...]float64{
+		// C3[1], coeff of eps^5, polynomial in n of order 0
+		3, 128,
+		// C3[1], coeff of eps^4, polynomial in n of order 1
+		2, 5, 128,
+		// C3[1], coeff of eps^3, polynomial in n of order 2
+		-1, 3, 3, 64,
+		// C3[1], coeff of eps^2, polynomial in n of order 2
+		-1, 0, 1, 8,
+		// C3[1], coeff of eps^1, polynomial in n of order 1
+		-1, 1, 4,
+		// C3[2], coeff of eps^5, polynomial in n of order 0
+		5, 256,
+		// C3[2], coeff of eps^4, polynomial in n of order 1
+		1, 3, 128,
+		// C3[2], coeff of eps^3, polynomial in n of order 2
+		-3, -2, 3, 64,
+		// C3[2], coeff of eps^2, polynomial in n of order 2
+		1, -3, 2, 32,
+		// C3[3], coeff of eps^5, polynomial in n of order 0
+		7, 512,
+		// C3[3], coeff of eps^4, polynomial in n of order 1
+		-10, 9, 384,
+		// C3[3], coeff of eps^3, polynomial in n of order 2
+		5, -9, 5, 192,
+		// C3[4], coeff of eps^5, polynomial in n of order 0
+		7, 512,
+		// C3[4], coeff of eps^4, polynomial in n of order 1
+		-14, 7, 512,
+		// C3[5], coeff of eps^5, polynomial in n of order 0
+		21, 2560,
+	}
+	o, k := 0, 0
+	for l := 1; l < nC3; l++ {
+		for j := nC3 - 1; j >= l; j-- {
+			m := min(nC3-j-1, j)
+			g.c3x[k] = polyval(m, coeff[o:], g.n) / coeff[o+m+1]
+			k++
+			o += m + 2
+		}
+	}
+}
+
+func (g *Geodesic) c4coeff() {
+	coeff := [...]float64{
+		// C4[0], coeff of eps^5, polynomial in n of order 0
+		97, 15015,
+		// C4[0], coeff of eps^4, polynomial in n of order 1
+		1088, 156, 45045,
+		// C4[0], coeff of eps^3, polynomial in n of order 2
+		-224, -4784, 1573, 45045,
+		// C4[0], coeff of eps^2, polynomial in n of order 3
+		-10656, 14144, -4576, -858, 45045,
+		// C4[0], coeff of eps^1, polynomial in n of order 4
+		64, 624, -4576, 6864, -3003, 15015,
+		// C4[0], coeff of eps^0, polynomial in n of order 5
+		100, 208, 572, 3432, -12012, 30030, 45045,
+		// C4[1], coeff of eps^5, polynomial in n of order 0
+		1, 9009,
+		// C4[1], coeff of eps^4, polynomial in n of order 1
+		-2944, 468, 135135,
+		// C4[1], coeff of eps^3, polynomial in n of order 2
+		5792, 1040, -1287, 135135,
+		// C4[1], coeff of eps^2, polynomial in n of order 3
+		5952, -11648, 9152, -2574, 135135,
+		// C4[1], coeff of eps^1, polynomial in n of order 4
+		-64, -624, 4576, -6864, 3003, 135135,
+		// C4[2], coeff of eps^5, polynomial in n of order 0
+		8, 10725,
+		// C4[2], coeff of eps^4, polynomial in n of order 1
+		1856, -936, 225225,
+		// C4[2], coeff of eps^3, polynomial in n of order 2
+		-8448, 4992, -1144, 225225,
+		// C4[2], coeff of eps^2, polynomial in n of order 3
+		-1440, 4160, -4576, 1716, 225225,
+		// C4[3], coeff of eps^5, polynomial in n of order 0
+		-136, 63063,
+		// C4[3], coeff of eps^4, polynomial in n of order 1
+		1024, -208, 105105,
+		// C4[3], coeff of eps^3, polynomial in n of order 2
+		3584, -3328, 1144, 315315,
+		// C4[4], coeff of eps^5, polynomial in n of order 0
+		-128, 135135,
+		// C4[4], coeff of eps^4, polynomial in n of order 1
+		-2560, 832, 405405,
+		// C4[5], coeff of eps^5, polynomial in n of order 0
+		128, 99099,
+	}
+	o, k := 0, 0
+	for l := 0; l < nC4; l++ {
+		for j := nC4 - 1; j >= l; j-- {
+			m := nC4 - j - 1
+			g.c4x[k] = polyval(m, coeff[o:], g.n) / coeff[o+m+1]
+			k++
+			o += m + 2
+		}
+	}
+}
+
+func (g *Geodesic) a3f(eps float64) float64 {
+	return polyval(nA3-1, g.a3x[:], eps)
+}
+
+func (g *Geodesic) c3f(eps float64, c []float64) {
+	mult := 1.0
+	o := 0
+	for l := 1; l < nC3; l++ {
+		m := nC3 - l - 1
+		mult *= eps
+		c[l] = mult * polyval(m, g.c3x[o:], eps)
+		o += m + 1
+	}
+}
+
+func (g *Geodesic) c4f(eps float64, c []float64) {
+	mult := 1.0
+	o := 0
+	for l := 0; l < nC4; l++ {
+		m := nC4 - l - 1
+		c[l] = mult * polyval(m, g.c4x[o:], eps)
+		o += m + 1
+		mult *= eps
+	}
+}
+
+func a1m1f(eps float64) float64 {
+	coeff := [...]float64{
+		// (1-eps)*A1-1, polynomial in eps2 of order 3
+		1, 4, 64, 0, 256,
+	}
+	m := nA1 / 2
+	t := polyval(m, coeff[:], sq(eps)) / coeff[m+1]
+	return (t + eps) / (1 - eps)
+}
+
+func c1f(eps float64, c []float64) {
+	coeff := [...]float64{
+		// C1[1]/eps^1, polynomial in eps2 of order 2
+		-1, 6, -16, 32,
+		// C1[2]/eps^2, polynomial in eps2 of order 2
+		-9, 64, -128, 2048,
+		// C1[3]/eps^3, polynomial in eps2 of order 1
+		9, -16, 768,
+		// C1[4]/eps^4, polynomial in eps2 of order 1
+		3, -5, 512,
+		// C1[5]/eps^5, polynomial in eps2 of order 0
+		-7, 1280,
+		// C1[6]/eps^6, polynomial in eps2 of order 0
+		-7, 2048,
+	}
+	eps2 := sq(eps)
+	d := eps
+	o := 0
+	for l := 1; l <= nC1; l++ {
+		m := (nC1 - l) / 2
+		c[l] = d * polyval(m, coeff[o:], eps2) / coeff[o+m+1]
+		o += m + 2
+		d *= eps
+	}
+}
+
+func c1pf(eps float64, c []float64) {
+	coeff := [...]float64{
+		// C1p[1]/eps^1, polynomial in eps2 of order 2
+		205, -432, 768, 1536,
+		// C1p[2]/eps^2, polynomial in eps2 of order 2
+		4005, -4736, 3840, 12288,
+		// C1p[3]/eps^3, polynomial in eps2 of order 1
+		-225, 116, 384,
+		// C1p[4]/eps^4, polynomial in eps2 of order 1
+		-7173, 2695, 7680,
+		// C1p[5]/eps^5, polynomial in eps2 of order 0
+		3467, 7680,
+		// C1p[6]/eps^6, polynomial in eps2 of order 0
+		38081, 61440,
+	}
+	eps2 := sq(eps)
+	d := eps
+	o := 0
+	for l := 1; l <= nC1p; l++ {
+		m := (nC1p - l) / 2
+		c[l] = d * polyval(m, coeff[o:], eps2) / coeff[o+m+1]
+		o += m + 2
+		d *= eps
+	}
+}
+
+func a2m1f(eps float64) float64 {
+	coeff := [...]float64{
+		// (eps+1)*A2-1, polynomial in eps2 of order 3
+		-11, -28, -192, 0, 256,
+	}
+	m := nA2 / 2
+	t := polyval(m, coeff[:], sq(eps)) / coeff[m+1]
+	return (t - eps) / (1 + eps)
+}
+
+func c2f(eps float64, c []float64) {
+	coeff := [...]float64{
+		// C2[1]/eps^1, polynomial in eps2 of order 2
+		1, 2, 16, 32,
+		// C2[2]/eps^2, polynomial in eps2 of order 2
+		35, 64, 384, 2048,
+		// C2[3]/eps^3, polynomial in eps2 of order 1
+		15, 80, 768,
+		// C2[4]/eps^4, polynomial in eps2 of order 1
+		7, 35, 512,
+		// C2[5]/eps^5, polynomial in eps2 of order 0
+		63, 1280,
+		// C2[6]/eps^6, polynomial in eps2 of order 0
+		77, 2048,
+	}
+	eps2 := sq(eps)
+	d := eps
+	o := 0
+	for l := 1; l <= nC2; l++ {
+		m := (nC2 - l) / 2
+		c[l] = d * polyval(m, coeff[o:], eps2) / coeff[o+m+1]
+		o += m + 2
+		d *= eps
+	}
+}
+
+// sinCosSeries evaluates sum(c[i] * sin(2*i*x), i, 1, n) when sinp is set
+// and sum(c[i] * cos((2*i+1)*x), i, 0, n-1) otherwise, using Clenshaw
+// summation.
+func sinCosSeries(sinp bool, sinx, cosx float64, c []float64, n int) float64 {
+	k := n
+	if sinp {
+		k++
+	}
+	ar := 2 * (cosx - sinx) * (cosx + sinx)
+	var y0, y1 float64
+	if n&1 != 0 {
+		k--
+		y0 = c[k]
+	}
+	for n /= 2; n > 0; n-- {
+		k--
+		y1 = ar*y0 - y1 + c[k]
+		k--
+		y0 = ar*y1 - y0 + c[k]
+	}
+	if sinp {
+		return 2 * sinx * cosx * y0
+	}
+	return cosx * (y0 - y1)
+}
+
+func polyval(n int, p []float64, x float64) float64 {
+	if n < 0 {
+		return 0
+	}
+	y := p[0]
+	for i := 1; i <= n; i++ {
+		y = y*x + p[i]
+	}
+	return y
+}
+
+func sq(x float64) float64 { return x * x }
+
+// sumx is the error free transformation of u+v, returning the rounded sum
+// and the round-off error.
+func sumx(u, v float64) (s, t float64) {
+	s = u + v
+	up := s - v
+	vpp := s - up
+	up -= u
+	vpp -= v
+	if s != 0 {
+		t = 0 - (up + vpp)
+	} else {
+		t = s
+	}
+	return
+}
+
+func norm2(sinx, cosx float64) (float64, float64) {
+	r := math.Hypot(sinx, cosx)
+	return sinx / r, cosx / r
+}
+
+func angNormalize(x float64) float64 {
+	y := math.Remainder(x, td)
+	if math.Abs(y) == hd {
+		return math.Copysign(hd, x)
+	}
+	return y
+}
+
+func latFix(x float64) float64 {
+	if math.Abs(x) > qd {
+		return math.NaN()
+	}
+	return x
+}
+
+// angDiff returns y - x reduced to [-180, 180] together with the round-off
+// error of the difference.
+func angDiff(x, y float64) (d, e float64) {
+	d, t := sumx(math.Remainder(-x, td), math.Remainder(y, td))
+	d, t = sumx(math.Remainder(d, td), t)
+	if d == 0 || math.Abs(d) == hd {
+		if t == 0 {
+			d = math.Copysign(d, y-x)
+		} else {
+			d = math.Copysign(d, -t)
+		}
+	}
+	return d, t
+}
+
+// angRound coarsens tiny angles so that they are represented exactly,
+// which avoids producing denormalized numbers further on.
+func angRound(x float64) float64 {
+	const z = 1.0 / 16
+	y := math.Abs(x)
+	w := z - y
+	if w > 0 {
+		y = z - w
+	}
+	return math.Copysign(y, x)
+}
+
+func remquo90(x float64) (float64, int) {
+	r := math.Remainder(x, qd)
+	q := int(math.Mod(math.Round((x-r)/qd), 4))
+	return r, q
+}
+
+func sincosq(r float64, q int, x float64) (sinx, cosx float64) {
+	s, c := math.Sincos(r * degree)
+	switch uint(q) & 3 {
+	case 0:
+		sinx, cosx = s, c
+	case 1:
+		sinx, cosx = c, -s
+	case 2:
+		sinx, cosx = -s, -c
+	default:
+		sinx, cosx = -c, s
+	}
+	cosx += 0
+	if sinx == 0 {
+		sinx = math.Copysign(sinx, x)
+	}
+	return
+}
+
+// sincosdx computes the sine and cosine of x in degrees with exact results
+// at multiples of 90.
+func sincosdx(x float64) (float64, float64) {
+	r, q := remquo90(x)
+	return sincosq(r, q, x)
+}
+
+func sincosde(x, t float64) (float64, float64) {
+	r, q := remquo90(x)
+	return sincosq(angRound(r+t), q, x)
+}
+
+func atan2dx(y, x float64) float64 {
+	q := 0
+	if math.Abs(y) > math.Abs(x) {
+		x, y = y, x
+		q = 2
+	}
+	if math.Signbit(x) {
+		x = -x
+		q++
+	}
+	ang := math.Atan2(y, x) / degree
+	switch q {
+	case 1:
+		ang = math.Copysign(hd, y) - ang
+	case 2:
+		ang = qd - ang
+	case 3:
+		ang = -qd + ang
+	}
+	return ang
+}
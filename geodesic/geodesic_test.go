@@ -0,0 +1,132 @@
+package geodesic
+
+import (
+	"math"
+	"testing"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/general"
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试反算问题, 参考值来自GeographicLib
+func TestInverse(t *testing.T) {
+	s12, azi1, azi2 := WGS84.Inverse(40.6, -73.8, 51.6, -0.5)
+	assert.InDelta(t, 5551759.400319, s12, 1e-6)
+	assert.InDelta(t, 51.198882845579824, azi1, 1e-12)
+	assert.InDelta(t, 107.821776735514, azi2, 1e-11)
+
+	// 近似对跖点
+	s12, _, _ = WGS84.Inverse(0, 0, 0.5, 179.5)
+	assert.InDelta(t, 19936288.579, s12, 1e-3)
+
+	// 赤道和子午线
+	s12, azi1, _ = WGS84.Inverse(0, 0, 0, 90)
+	assert.InDelta(t, 6378137*math.Pi/2, s12, 1e-6)
+	assert.Equal(t, 90.0, azi1)
+	s12, azi1, _ = WGS84.Inverse(0, 10, 90, 10)
+	assert.InDelta(t, 10001965.729, s12, 1e-3)
+	assert.Equal(t, 0.0, azi1)
+
+	s12, _, _ = WGS84.Inverse(10, 10, 10, 10)
+	assert.Equal(t, 0.0, s12)
+}
+
+// 测试正算问题, 应与反算互逆
+func TestDirect(t *testing.T) {
+	lat2, lon2, azi2 := WGS84.Direct(40.6, -73.8, 51.198882845579824, 5551759.400319)
+	assert.InDelta(t, 51.6, lat2, 1e-10)
+	assert.InDelta(t, -0.5, lon2, 1e-10)
+	assert.InDelta(t, 107.821776735514, azi2, 1e-11)
+
+	cases := [][4]float64{
+		{-30, 150, 20, -170},
+		{89, 0, -89, 90},
+		{0, 0, 0.5, 179.5},
+		{45, 45, 45.0001, 45.0001},
+	}
+	for _, c := range cases {
+		s12, azi1, azi2 := WGS84.Inverse(c[0], c[1], c[2], c[3])
+		lat2, lon2, a2 := WGS84.Direct(c[0], c[1], azi1, s12)
+		assert.InDelta(t, c[2], lat2, 1e-9)
+		assert.InDelta(t, c[3], lon2, 1e-9)
+		assert.InDelta(t, azi2, a2, 1e-7)
+	}
+}
+
+// 测试多边形面积
+func TestRingArea(t *testing.T) {
+	total := WGS84.EllipsoidArea()
+	assert.InDelta(t, 510065621724088.5, total, 0.1)
+
+	octant := [][]float64{{0, 0}, {90, 0}, {0, 90}, {0, 0}}
+	assert.InDelta(t, total/8, WGS84.RingArea(octant), 0.1)
+	reversed := [][]float64{{0, 0}, {0, 90}, {90, 0}}
+	assert.InDelta(t, -total/8, WGS84.RingArea(reversed), 0.1)
+
+	antarctica := [][]float64{
+		{-58, -63.1}, {-74, -72.9}, {-102, -71.9}, {-102, -74.9}, {-131, -74.3},
+		{-163, -77.5}, {163, -77.4}, {172, -71.7}, {140, -65.9}, {113, -65.7},
+		{88, -66.6}, {59, -66.9}, {25, -69.8}, {-4, -70.0}, {-14, -71.0},
+		{-33, -77.3}, {-46, -77.9}, {-61, -74.7},
+	}
+	assert.InDelta(t, 13662703680020.1, math.Abs(WGS84.RingArea(antarctica)), 1)
+	assert.InDelta(t, 16831067.893, Karney.Perimeter(geom.NewPolygonGeometryData([][][]float64{antarctica})), 1e-3)
+
+	// 扁率为0时椭球与球面一致
+	sphere := NewSphere(MeanEarthRadius)
+	ellipsoid := NewGeodesic(MeanEarthRadius, 0)
+	ring := [][]float64{{116.3, 39.9}, {121.5, 31.2}, {113.3, 23.1}, {104.1, 30.7}}
+	assert.InDelta(t, ellipsoid.RingArea(ring), sphere.RingArea(ring), 1)
+	assert.InDelta(t, ellipsoid.RingArea(antarctica), sphere.RingArea(antarctica), 10)
+}
+
+func TestSphere(t *testing.T) {
+	s := NewSphere(MeanEarthRadius)
+	s12, azi1, azi2 := s.Inverse(0, 0, 0, 90)
+	assert.InDelta(t, MeanEarthRadius*math.Pi/2, s12, 1e-6)
+	assert.Equal(t, 90.0, azi1)
+	assert.Equal(t, 90.0, azi2)
+
+	s12, azi1, azi2 = s.Inverse(40.6, -73.8, 51.6, -0.5)
+	lat2, lon2, a2 := s.Direct(40.6, -73.8, azi1, s12)
+	assert.InDelta(t, 51.6, lat2, 1e-9)
+	assert.InDelta(t, -0.5, lon2, 1e-9)
+	assert.InDelta(t, azi2, a2, 1e-9)
+
+	octant := [][]float64{{0, 0}, {90, 0}, {0, 90}}
+	assert.InDelta(t, math.Pi/2*MeanEarthRadius*MeanEarthRadius, s.RingArea(octant), 1)
+}
+
+func TestMeasurer(t *testing.T) {
+	p1, p2 := []float64{-73.8, 40.6}, []float64{-0.5, 51.6}
+	assert.InDelta(t, 5551759.400319, Distance(p1, p2), 1e-6)
+	assert.InDelta(t, 51.198882845579824, InitialBearing(p1, p2), 1e-12)
+	assert.InDelta(t, 107.821776735514, FinalBearing(p1, p2), 1e-11)
+	assert.InDelta(t, Distance(p1, p2), Haversine.Distance(p1, p2), 0.005*Distance(p1, p2))
+
+	dst := Destination([]float64{-73.8, 40.6, 12}, 51.198882845579824, 5551759.400319)
+	assert.Len(t, dst, 3)
+	assert.InDelta(t, -0.5, dst[0], 1e-10)
+	assert.InDelta(t, 51.6, dst[1], 1e-10)
+	assert.Equal(t, 12.0, dst[2])
+
+	line := general.NewLineString([][]float64{{-73.8, 40.6}, {-0.5, 51.6}, {-0.5, 51.6}})
+	assert.InDelta(t, 5551759.400319, Length(line), 1e-6)
+	assert.Equal(t, 0.0, Area(line))
+
+	// 带洞的多边形
+	shell := [][]float64{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}
+	hole := [][]float64{{0.25, 0.25}, {0.75, 0.25}, {0.75, 0.75}, {0.25, 0.75}, {0.25, 0.25}}
+	full := Area(general.NewPolygon([][][]float64{shell}))
+	assert.InDelta(t, 1.2308e10, full, 1e7)
+	holed := Area(geom.NewPolygonGeometryData([][][]float64{shell, hole}))
+	assert.InDelta(t, full*0.75, holed, 1e6)
+	assert.InDelta(t, full, Area(general.NewPolygon([][][]float64{{{0, 0}, {0, 1}, {1, 1}, {1, 0}, {0, 0}}})), 1e-3)
+
+	mp := general.NewMultiPolygon([][][][]float64{{shell}, {shell}})
+	assert.InDelta(t, 2*full, Area(mp), 1e-3)
+	assert.InDelta(t, 2*Perimeter(general.NewPolygon([][][]float64{shell})), Perimeter(mp), 1e-6)
+
+	assert.InDelta(t, full, Haversine.Area(general.NewPolygon([][][]float64{shell})), full*0.01)
+}
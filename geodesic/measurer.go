@@ -0,0 +1,191 @@
+package geodesic
+
+import (
+	"math"
+
+	"github.com/flywave/go-geom"
+)
+
+// Calculator solves the geodesic problems on a reference surface. Angles
+// are in degrees and distances in meters.
+type Calculator interface {
+	Inverse(lat1, lon1, lat2, lon2 float64) (s12, azi1, azi2 float64)
+	Direct(lat1, lon1, azi1, s12 float64) (lat2, lon2, azi2 float64)
+	RingArea(ring [][]float64) float64
+}
+
+// Measurer measures geometries whose positions are [lon, lat] in degrees,
+// like the EPSG:4326 GeoJSON produced by this module.
+type Measurer struct {
+	Calculator
+}
+
+var (
+	// Karney measures on the WGS84 ellipsoid with geodesic edges.
+	Karney = Measurer{WGS84}
+	// Haversine measures on a sphere of the mean earth radius.
+	Haversine = Measurer{NewSphere(MeanEarthRadius)}
+)
+
+// Distance returns the length in meters of the shortest path between two
+// [lon, lat] positions.
+func (m Measurer) Distance(p1, p2 []float64) float64 {
+	s12, _, _ := m.Inverse(p1[1], p1[0], p2[1], p2[0])
+	return s12
+}
+
+// InitialBearing returns the azimuth in degrees clockwise from north, in
+// the range (-180, 180], at p1 of the shortest path from p1 to p2.
+func (m Measurer) InitialBearing(p1, p2 []float64) float64 {
+	_, azi1, _ := m.Inverse(p1[1], p1[0], p2[1], p2[0])
+	return azi1
+}
+
+// FinalBearing returns the azimuth at p2 of the shortest path from p1 to
+// p2.
+func (m Measurer) FinalBearing(p1, p2 []float64) float64 {
+	_, _, azi2 := m.Inverse(p1[1], p1[0], p2[1], p2[0])
+	return azi2
+}
+
+// Destination returns the position reached after travelling distance
+// meters from p with the initial bearing. Ordinates past lon and lat are
+// copied from p.
+func (m Measurer) Destination(p []float64, bearing, distance float64) []float64 {
+	lat2, lon2, _ := m.Direct(p[1], p[0], bearing, distance)
+	ret := make([]float64, len(p))
+	copy(ret, p)
+	ret[0], ret[1] = lon2, lat2
+	return ret
+}
+
+// Length returns the length in meters of the linear components of g.
+func (m Measurer) Length(g geom.Geometry) float64 {
+	return m.lengthData(geom.NewGeometryData(g))
+}
+
+func (m Measurer) lengthData(g *geom.GeometryData) float64 {
+	if g == nil {
+		return 0
+	}
+	switch g.Type {
+	case geom.GeometryLineString:
+		return m.pathLength(g.LineString)
+	case geom.GeometryMultiLineString:
+		var length float64
+		for _, l := range g.MultiLineString {
+			length += m.pathLength(l)
+		}
+		return length
+	case geom.GeometryCollection:
+		var length float64
+		for _, c := range g.Geometries {
+			length += m.lengthData(c)
+		}
+		return length
+	}
+	return 0
+}
+
+// Perimeter returns the length in meters of all polygon rings in g.
+func (m Measurer) Perimeter(g geom.Geometry) float64 {
+	return m.perimeterData(geom.NewGeometryData(g))
+}
+
+func (m Measurer) perimeterData(g *geom.GeometryData) float64 {
+	if g == nil {
+		return 0
+	}
+	switch g.Type {
+	case geom.GeometryPolygon:
+		return m.polygonPerimeter(g.Polygon)
+	case geom.GeometryMultiPolygon:
+		var length float64
+		for _, p := range g.MultiPolygon {
+			length += m.polygonPerimeter(p)
+		}
+		return length
+	case geom.GeometryCollection:
+		var length float64
+		for _, c := range g.Geometries {
+			length += m.perimeterData(c)
+		}
+		return length
+	}
+	return 0
+}
+
+func (m Measurer) polygonPerimeter(polygon [][][]float64) float64 {
+	var length float64
+	for _, ring := range polygon {
+		length += m.pathLength(ring)
+		if n := len(ring); n > 2 && (ring[0][0] != ring[n-1][0] || ring[0][1] != ring[n-1][1]) {
+			length += m.Distance(ring[n-1], ring[0])
+		}
+	}
+	return length
+}
+
+func (m Measurer) pathLength(path [][]float64) float64 {
+	var length float64
+	for i := 1; i < len(path); i++ {
+		length += m.Distance(path[i-1], path[i])
+	}
+	return length
+}
+
+// Area returns the area in square meters of the polygons in g. Holes are
+// subtracted whatever the winding of the rings.
+func (m Measurer) Area(g geom.Geometry) float64 {
+	return m.areaData(geom.NewGeometryData(g))
+}
+
+func (m Measurer) areaData(g *geom.GeometryData) float64 {
+	if g == nil {
+		return 0
+	}
+	switch g.Type {
+	case geom.GeometryPolygon:
+		return m.polygonArea(g.Polygon)
+	case geom.GeometryMultiPolygon:
+		var area float64
+		for _, p := range g.MultiPolygon {
+			area += m.polygonArea(p)
+		}
+		return area
+	case geom.GeometryCollection:
+		var area float64
+		for _, c := range g.Geometries {
+			area += m.areaData(c)
+		}
+		return area
+	}
+	return 0
+}
+
+func (m Measurer) polygonArea(polygon [][][]float64) float64 {
+	if len(polygon) == 0 {
+		return 0
+	}
+	area := math.Abs(m.RingArea(polygon[0]))
+	for _, hole := range polygon[1:] {
+		area -= math.Abs(m.RingArea(hole))
+	}
+	return area
+}
+
+func Distance(p1, p2 []float64) float64 { return Karney.Distance(p1, p2) }
+
+func InitialBearing(p1, p2 []float64) float64 { return Karney.InitialBearing(p1, p2) }
+
+func FinalBearing(p1, p2 []float64) float64 { return Karney.FinalBearing(p1, p2) }
+
+func Destination(p []float64, bearing, distance float64) []float64 {
+	return Karney.Destination(p, bearing, distance)
+}
+
+func Length(g geom.Geometry) float64 { return Karney.Length(g) }
+
+func Perimeter(g geom.Geometry) float64 { return Karney.Perimeter(g) }
+
+func Area(g geom.Geometry) float64 { return Karney.Area(g) }
@@ -0,0 +1,71 @@
+package geodesic
+
+import (
+	"math"
+)
+
+// MeanEarthRadius is the IUGG mean radius of the earth in meters.
+const MeanEarthRadius = 6371008.8
+
+// Sphere computes great circle distances with the haversine formula. It is
+// faster than Geodesic but the errors reach 0.5% on the earth.
+type Sphere struct {
+	Radius float64
+}
+
+func NewSphere(radius float64) *Sphere {
+	return &Sphere{Radius: radius}
+}
+
+func (s *Sphere) Inverse(lat1, lon1, lat2, lon2 float64) (s12, azi1, azi2 float64) {
+	dlon, _ := angDiff(lon1, lon2)
+	sphi1, cphi1 := sincosdx(lat1)
+	sphi2, cphi2 := sincosdx(lat2)
+	slam, clam := sincosdx(dlon)
+
+	h := sq(math.Sin((lat2-lat1)*degree/2)) + cphi1*cphi2*sq(math.Sin(dlon*degree/2))
+	s12 = 2 * s.Radius * math.Asin(math.Min(1, math.Sqrt(h)))
+	azi1 = atan2dx(slam*cphi2, cphi1*sphi2-sphi1*cphi2*clam)
+	azi2 = atan2dx(slam*cphi1, -cphi2*sphi1+sphi2*cphi1*clam)
+	return
+}
+
+func (s *Sphere) Direct(lat1, lon1, azi1, s12 float64) (lat2, lon2, azi2 float64) {
+	delta := s12 / s.Radius
+	sphi1, cphi1 := sincosdx(lat1)
+	salp, calp := sincosdx(azi1)
+	sdelta, cdelta := math.Sincos(delta)
+
+	sphi2 := sphi1*cdelta + cphi1*sdelta*calp
+	lat2 = math.Asin(math.Max(-1, math.Min(1, sphi2))) / degree
+	lon2 = angNormalize(lon1 + atan2dx(salp*sdelta*cphi1, cdelta-sphi1*sphi2))
+	azi2 = atan2dx(salp*cphi1, cdelta*cphi1*calp-sdelta*sphi1)
+	return
+}
+
+// RingArea returns the signed area in square meters enclosed by a ring of
+// [lon, lat] positions with great circle edges, positive when the ring is
+// counter-clockwise.
+func (s *Sphere) RingArea(ring [][]float64) float64 {
+	r2 := sq(s.Radius)
+	area, _ := ringArea(ring, 4*math.Pi*r2, func(lat1, lon1, lat2, lon2 float64) (float64, float64) {
+		s12, azi1, azi2 := s.Inverse(lat1, lon1, lat2, lon2)
+		sbet1, cbet1 := sincosdx(lat1)
+		sbet2, cbet2 := sincosdx(lat2)
+		lon12, _ := angDiff(lon1, lon2)
+		somg12, comg12 := sincosdx(lon12)
+
+		var alp12 float64
+		if comg12 > -0.7071 && math.Abs(sbet2-sbet1) < 1.75 {
+			domg12 := 1 + comg12
+			dbet1 := 1 + cbet1
+			dbet2 := 1 + cbet2
+			alp12 = 2 * math.Atan2(somg12*(sbet1*dbet2+sbet2*dbet1), domg12*(sbet1*sbet2+dbet1*dbet2))
+		} else {
+			d, _ := angDiff(azi1, azi2)
+			alp12 = d * degree
+		}
+		return s12, r2 * alp12
+	})
+	return area
+}
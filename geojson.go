@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 )
 
 type GeometryType string
@@ -29,10 +30,24 @@ type GeometryData struct {
 	MultiPolygon    [][][][]float64
 	Geometries      []*GeometryData
 	EPSG            int `json:"epsg,omitempty"`
+	// Layout of the positions, the decoders set it when they carry a measure.
+	Layout Layout `json:"layout,omitempty"`
+	// ForeignMembers are the members outside of the specification.
+	ForeignMembers map[string]interface{} `json:"-"`
 }
 
 func NewGeometryData(geometry Geometry) *GeometryData {
+	ret := newGeometryData(geometry)
+	if l, ok := geometry.(Layouter); ok && ret != nil && ret.Layout == NoLayout {
+		ret.Layout = l.Layout()
+	}
+	return ret
+}
+
+func newGeometryData(geometry Geometry) *GeometryData {
 	switch geo := geometry.(type) {
+	case *GeometryData:
+		return geo
 	case Point3:
 		var ret GeometryData
 		ret.Type = GeometryPoint
@@ -106,6 +121,72 @@ func NewGeometryData(geometry Geometry) *GeometryData {
 	}
 }
 
+// MemberLayout returns the layout of g, a member of a collection of layout
+// parent. The members without a layout have the one of their collection.
+func MemberLayout(g *GeometryData, parent Layout) Layout {
+	if g.Layout == NoLayout && parent != NoLayout {
+		return parent
+	}
+	return LayoutFromGeometryData(g)
+}
+
+// LayoutFromGeometryData returns the layout of g, inferred from the length
+// of its positions when not set.
+func LayoutFromGeometryData(g *GeometryData) Layout {
+	if g.Layout != NoLayout {
+		return g.Layout
+	}
+	if g.Type == GeometryCollection {
+		var z, m bool
+		for _, c := range g.Geometries {
+			if c != nil {
+				l := LayoutFromGeometryData(c)
+				z, m = z || l.HasZ(), m || l.HasM()
+			}
+		}
+		switch {
+		case z && m:
+			return XYZM
+		case m:
+			return XYM
+		case z:
+			return XYZ
+		}
+		return XY
+	}
+	stride := 0
+	path := func(path [][]float64) {
+		for _, p := range path {
+			if len(p) > stride {
+				stride = len(p)
+			}
+		}
+	}
+	switch g.Type {
+	case GeometryPoint:
+		stride = len(g.Point)
+	case GeometryMultiPoint:
+		path(g.MultiPoint)
+	case GeometryLineString:
+		path(g.LineString)
+	case GeometryMultiLineString:
+		for _, l := range g.MultiLineString {
+			path(l)
+		}
+	case GeometryPolygon:
+		for _, r := range g.Polygon {
+			path(r)
+		}
+	case GeometryMultiPolygon:
+		for _, p := range g.MultiPolygon {
+			for _, r := range p {
+				path(r)
+			}
+		}
+	}
+	return LayoutFromStride(stride)
+}
+
 func NewPointGeometryData(coordinate []float64) *GeometryData {
 	return &GeometryData{
 		Type:  GeometryPoint,
@@ -163,6 +244,7 @@ func (g GeometryData) MarshalJSON() ([]byte, error) {
 		Geometries  interface{}            `json:"geometries,omitempty"`
 		CRS         map[string]interface{} `json:"crs,omitempty"`
 		EPSG        int                    `json:"epsg,omitempty"`
+		Layout      Layout                 `json:"layout,omitempty"`
 	}
 
 	geo := &geometry{
@@ -170,6 +252,11 @@ func (g GeometryData) MarshalJSON() ([]byte, error) {
 		EPSG: g.EPSG,
 	}
 
+	// M 作为位置的额外坐标写出, 只有带 M 时才需要注明
+	if g.Layout.HasM() {
+		geo.Layout = g.Layout
+	}
+
 	if g.BoundingBox != nil && len(g.BoundingBox) != 0 {
 		geo.BoundingBox = g.BoundingBox
 	}
@@ -191,27 +278,33 @@ func (g GeometryData) MarshalJSON() ([]byte, error) {
 		geo.Geometries = g.Geometries
 	}
 
-	return json.Marshal(geo)
+	b, err := json.Marshal(geo)
+	if err != nil {
+		return nil, err
+	}
+	return appendForeignMembers(b, g.ForeignMembers, geometryMembers)
 }
 
 func UnmarshalGeometry(data []byte) (*GeometryData, error) {
 	g := &GeometryData{}
-	err := json.Unmarshal(data, g)
-	if err != nil {
+	// 直接扫描, 不经过 encoding/json 的预先校验
+	if err := g.UnmarshalJSON(data); err != nil {
 		return nil, err
 	}
-
 	return g, nil
 }
 
+// UnmarshalJSON reads the positions straight from data into one flat
+// slice, the feature geometries being null leaving g unchanged.
 func (g *GeometryData) UnmarshalJSON(data []byte) error {
-	var object map[string]interface{}
-	err := json.Unmarshal(data, &object)
-	if err != nil {
+	s := &scanner{data: data}
+	if err := s.geometry(g); err != nil {
 		return err
 	}
-
-	return DecodeGeometry(g, object)
+	if s.space(); s.pos != len(data) {
+		return s.syntaxError()
+	}
+	return nil
 }
 
 func (g *GeometryData) GetType() string {
@@ -247,82 +340,8 @@ func DecodeGeometry(g *GeometryData, object map[string]interface{}) error {
 
 	// 处理BoundingBox
 	if bbox, ok := object["bbox"]; ok {
-		// 检查是否是二维数组形式 [[minx, miny, minz], [maxx, maxy, maxz]]
-		if bboxArray, ok := bbox.([]interface{}); ok && len(bboxArray) == 2 {
-			minArray, ok1 := bboxArray[0].([]interface{})
-			maxArray, ok2 := bboxArray[1].([]interface{})
-			if ok1 && ok2 && len(minArray) >= 2 && len(maxArray) >= 2 {
-				var min [3]float64
-				var max [3]float64
-
-				// 解析min数组
-				if minX, ok := minArray[0].(float64); ok {
-					min[0] = minX
-				}
-				if minY, ok := minArray[1].(float64); ok {
-					min[1] = minY
-				}
-				if len(minArray) >= 3 {
-					if minZ, ok := minArray[2].(float64); ok {
-						min[2] = minZ
-					}
-				}
-
-				// 解析max数组
-				if maxX, ok := maxArray[0].(float64); ok {
-					max[0] = maxX
-				}
-				if maxY, ok := maxArray[1].(float64); ok {
-					max[1] = maxY
-				}
-				if len(maxArray) >= 3 {
-					if maxZ, ok := maxArray[2].(float64); ok {
-						max[2] = maxZ
-					}
-				}
-
-				g.BoundingBox = &BoundingBox{min, max}
-			}
-		} else if bboxArray, ok := bbox.([]interface{}); ok && len(bboxArray) >= 4 {
-			var min [3]float64
-			var max [3]float64
-
-			// 二维情况
-			if len(bboxArray) == 4 {
-				if minX, ok := bboxArray[0].(float64); ok {
-					min[0] = minX
-				}
-				if minY, ok := bboxArray[1].(float64); ok {
-					min[1] = minY
-				}
-				if maxX, ok := bboxArray[2].(float64); ok {
-					max[0] = maxX
-				}
-				if maxY, ok := bboxArray[3].(float64); ok {
-					max[1] = maxY
-				}
-			} else if len(bboxArray) == 6 {
-				if minX, ok := bboxArray[0].(float64); ok {
-					min[0] = minX
-				}
-				if minY, ok := bboxArray[1].(float64); ok {
-					min[1] = minY
-				}
-				if minZ, ok := bboxArray[2].(float64); ok {
-					min[2] = minZ
-				}
-				if maxX, ok := bboxArray[3].(float64); ok {
-					max[0] = maxX
-				}
-				if maxY, ok := bboxArray[4].(float64); ok {
-					max[1] = maxY
-				}
-				if maxZ, ok := bboxArray[5].(float64); ok {
-					max[2] = maxZ
-				}
-			}
-
-			g.BoundingBox = &BoundingBox{min, max}
+		if b := decodeBoundingBoxMember(bbox); b != nil {
+			g.BoundingBox = b
 		}
 	}
 
@@ -336,6 +355,12 @@ func DecodeGeometry(g *GeometryData, object map[string]interface{}) error {
 		}
 	}
 
+	if s, ok := object["layout"].(string); ok {
+		if err := g.Layout.UnmarshalText([]byte(s)); err != nil {
+			return err
+		}
+	}
+
 	var err error
 	switch g.Type {
 	case GeometryPoint:
@@ -353,8 +378,166 @@ func DecodeGeometry(g *GeometryData, object map[string]interface{}) error {
 	case GeometryCollection:
 		g.Geometries, err = decodeGeometries(object["geometries"])
 	}
+	if err != nil {
+		return err
+	}
+
+	for k, v := range object {
+		if !geometryMembers[k] {
+			if g.ForeignMembers == nil {
+				g.ForeignMembers = make(map[string]interface{})
+			}
+			g.ForeignMembers[k] = v
+		}
+	}
+	return nil
+}
+
+// The members of the GeoJSON objects, the others being foreign members.
+var (
+	geometryMembers   = memberSet("type", "bbox", "coordinates", "geometries", "epsg", "layout")
+	featureMembers    = memberSet("id", "type", "bbox", "geometry", "properties", "crs", "ext-data")
+	collectionMembers = memberSet("type", "bbox", "features", "crs", "properties")
+)
+
+func memberSet(keys ...string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+	return set
+}
+
+// decodeForeignMember adds the member key, of text raw, to members. The
+// foreign members are the members of a GeoJSON object outside of the
+// specification, the decoders keep them in the ForeignMembers of the
+// geometries, features and collections so that they are written back.
+func decodeForeignMember(members *map[string]interface{}, key string, raw []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return err
+	}
+	if *members == nil {
+		*members = make(map[string]interface{})
+	}
+	(*members)[key] = v
+	return nil
+}
+
+// appendForeignMembers adds the foreign members to the object written in b,
+// in the order of their keys. The members named as known members are
+// skipped, a foreign member cannot replace the type or coordinates.
+func appendForeignMembers(b []byte, members map[string]interface{}, known map[string]bool) ([]byte, error) {
+	if len(members) == 0 {
+		return b, nil
+	}
+	keys := make([]string, 0, len(members))
+	for k := range members {
+		if !known[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	b = b[:len(b)-1]
+	for _, k := range keys {
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(members[k])
+		if err != nil {
+			return nil, err
+		}
+		if len(b) > 1 {
+			b = append(b, ',')
+		}
+		b = append(append(append(b, key...), ':'), v...)
+	}
+	return append(b, '}'), nil
+}
+
+// decodeBoundingBoxMember reads the bbox member of a geometry, either
+// [[minx, miny, minz], [maxx, maxy, maxz]] or the flat arrays of 4 and 6
+// values. It returns nil for the other values.
+func decodeBoundingBoxMember(bbox interface{}) *BoundingBox {
+	// 检查是否是二维数组形式 [[minx, miny, minz], [maxx, maxy, maxz]]
+	if bboxArray, ok := bbox.([]interface{}); ok && len(bboxArray) == 2 {
+		minArray, ok1 := bboxArray[0].([]interface{})
+		maxArray, ok2 := bboxArray[1].([]interface{})
+		if ok1 && ok2 && len(minArray) >= 2 && len(maxArray) >= 2 {
+			var min [3]float64
+			var max [3]float64
+
+			// 解析min数组
+			if minX, ok := minArray[0].(float64); ok {
+				min[0] = minX
+			}
+			if minY, ok := minArray[1].(float64); ok {
+				min[1] = minY
+			}
+			if len(minArray) >= 3 {
+				if minZ, ok := minArray[2].(float64); ok {
+					min[2] = minZ
+				}
+			}
 
-	return err
+			// 解析max数组
+			if maxX, ok := maxArray[0].(float64); ok {
+				max[0] = maxX
+			}
+			if maxY, ok := maxArray[1].(float64); ok {
+				max[1] = maxY
+			}
+			if len(maxArray) >= 3 {
+				if maxZ, ok := maxArray[2].(float64); ok {
+					max[2] = maxZ
+				}
+			}
+
+			return &BoundingBox{min, max}
+		}
+	} else if bboxArray, ok := bbox.([]interface{}); ok && len(bboxArray) >= 4 {
+		var min [3]float64
+		var max [3]float64
+
+		// 二维情况
+		if len(bboxArray) == 4 {
+			if minX, ok := bboxArray[0].(float64); ok {
+				min[0] = minX
+			}
+			if minY, ok := bboxArray[1].(float64); ok {
+				min[1] = minY
+			}
+			if maxX, ok := bboxArray[2].(float64); ok {
+				max[0] = maxX
+			}
+			if maxY, ok := bboxArray[3].(float64); ok {
+				max[1] = maxY
+			}
+		} else if len(bboxArray) == 6 {
+			if minX, ok := bboxArray[0].(float64); ok {
+				min[0] = minX
+			}
+			if minY, ok := bboxArray[1].(float64); ok {
+				min[1] = minY
+			}
+			if minZ, ok := bboxArray[2].(float64); ok {
+				min[2] = minZ
+			}
+			if maxX, ok := bboxArray[3].(float64); ok {
+				max[0] = maxX
+			}
+			if maxY, ok := bboxArray[4].(float64); ok {
+				max[1] = maxY
+			}
+			if maxZ, ok := bboxArray[5].(float64); ok {
+				max[2] = maxZ
+			}
+		}
+
+		return &BoundingBox{min, max}
+	}
+	return nil
 }
 
 func decodePosition(data interface{}) ([]float64, error) {
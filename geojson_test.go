@@ -2,6 +2,7 @@ package geom
 
 import (
 	"encoding/json"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -18,6 +19,9 @@ func TestNewGeometryData(t *testing.T) {
 	multiPointData := NewMultiPointGeometryData([]float64{1.0, 2.0}, []float64{3.0, 4.0})
 	assert.Equal(t, GeometryMultiPoint, multiPointData.Type)
 	assert.Equal(t, [][]float64{{1.0, 2.0}, {3.0, 4.0}}, multiPointData.MultiPoint)
+
+	// 测试GeometryData本身
+	assert.Same(t, pointData, NewGeometryData(pointData))
 }
 
 // 测试GeometryData的MarshalJSON和UnmarshalJSON方法
@@ -321,3 +325,111 @@ func TestGeometryDataErrorHandling(t *testing.T) {
 	_, err = UnmarshalGeometry([]byte(`invalid json`))
 	assert.Error(t, err)
 }
+
+// 测试M坐标的GeoJSON往返
+func TestGeometryDataLayout(t *testing.T) {
+	g := &GeometryData{Type: GeometryLineString, LineString: [][]float64{{1, 2, 10}, {3, 4, 20}}, Layout: XYM}
+	data, err := json.Marshal(g)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"LineString","coordinates":[[1,2,10],[3,4,20]],"layout":"XYM"}`, string(data))
+
+	ret, err := UnmarshalGeometry(data)
+	assert.NoError(t, err)
+	assert.Equal(t, g, ret)
+
+	// 没有M时不写布局
+	g.Layout = XYZ
+	data, _ = json.Marshal(g)
+	assert.NotContains(t, string(data), "layout")
+
+	_, err = UnmarshalGeometry([]byte(`{"type":"Point","coordinates":[1,2,3],"layout":"MXY"}`))
+	assert.Error(t, err)
+
+	// M不参与范围的计算
+	f := NewFeatureFromGeometryData(&GeometryData{Type: GeometryLineString, LineString: [][]float64{{1, 2, 10}, {3, 4, 20}}, Layout: XYM})
+	assert.Equal(t, &BoundingBox{{1, 2, 0}, {3, 4, 0}}, f.BoundingBox)
+	assert.Equal(t, [][]float64{{1, 2, 10}, {3, 4, 20}}, f.GeometryData.LineString)
+}
+
+// 测试直接从文本读取坐标
+func TestGeometryDataUnmarshalScanner(t *testing.T) {
+	for _, s := range []string{
+		`{"type":"Point","coordinates":[1.5,-2e3,3]}`,
+		`{"type":"MultiPoint","coordinates":[[1,2],[3,4]],"bbox":[1,2,3,4]}`,
+		`{ "coordinates" : [ [ [1, 2], [3, 4] ], [] ], "type" : "MultiLineString", "epsg": 4326 }`,
+		`{"type":"Polygon","coordinates":[[[0,0],[4,0],[4,3],[0,0]],[[1,1],[2,1],[1,2],[1,1]]],"extra":{"a":[1,{"b":null}]}}`,
+		`{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[0,1],[0,0]]],[[[5,5,1],[6,5,1],[5,6,1],[5,5,1]]]]}`,
+		`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2]},{"type":"GeometryCollection","geometries":[{"type":"LineString","coordinates":[[1,2],[3,4]]}]}]}`,
+		`{"type":"LineString","coordinates":[[1,2,10],[3,4,20]],"layout":"XYM"}`,
+		`{"type":"Unknown"}`,
+		`{"type":"Point","coordinates":[1,2]}`,
+	} {
+		var object map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(s), &object))
+		want := &GeometryData{}
+		assert.NoError(t, DecodeGeometry(want, object), s)
+		g, err := UnmarshalGeometry([]byte(s))
+		assert.NoError(t, err, s)
+		assert.Equal(t, want, g, s)
+	}
+
+	// 坐标共用一个数组, 追加不覆盖下一个坐标
+	g, _ := UnmarshalGeometry([]byte(`{"type":"LineString","coordinates":[[1,2],[3,4]]}`))
+	assert.Equal(t, 2, cap(g.LineString[0]))
+	assert.Len(t, append(g.LineString[0], 9), 3)
+	assert.Equal(t, []float64{3, 4}, g.LineString[1])
+
+	for _, s := range []string{
+		`{"type":"Point"}`,
+		`{"type":1,"coordinates":[1,2]}`,
+		`{"coordinates":[1,2]}`,
+		`{"type":"Point","coordinates":[1,"2"]}`,
+		`{"type":"Point","coordinates":[1,2}`,
+		`{"type":"Polygon","coordinates":[[1,2]]}`,
+		`{"type":"LineString","coordinates":[[1,2],[3,4]]} x`,
+		`{"type":"GeometryCollection","geometries":[1]}`,
+		`{"type":"Point","coordinates":[1,2],}`,
+		`{"type":"Point","coordinates":[1,2],"extra":1-2}`,
+		`{"type":"Point","coordinates":[1,2],"bbox":[1,2,3,4e]}`,
+		`{"type":"Point","coordinates":[1,2]`,
+		`[]`,
+	} {
+		var g GeometryData
+		assert.Error(t, json.Unmarshal([]byte(s), &g), s)
+		assert.Error(t, g.UnmarshalJSON([]byte(s)), s)
+	}
+}
+
+func benchmarkPolygon() []byte {
+	rings := make([][][]float64, 10)
+	for r := range rings {
+		ring := make([][]float64, 10000)
+		for i := range ring {
+			a := 2 * math.Pi * float64(i) / float64(len(ring)-1)
+			ring[i] = []float64{120.123456789 + float64(r+1)*math.Cos(a), 30.987654321 + float64(r+1)*math.Sin(a)}
+		}
+		rings[r] = ring
+	}
+	data, _ := json.Marshal(NewPolygonGeometryData(rings))
+	return data
+}
+
+func BenchmarkUnmarshalPolygon(b *testing.B) {
+	data := benchmarkPolygon()
+	b.Run("scanner", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			UnmarshalGeometry(data)
+		}
+	})
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			var object map[string]interface{}
+			json.Unmarshal(data, &object)
+			DecodeGeometry(&GeometryData{}, object)
+		}
+	})
+}
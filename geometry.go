@@ -33,10 +33,102 @@ func (b *BoundingBox) UnmarshalJSON(data []byte) error {
 	return err
 }
 
+// Layout is the order of the ordinates in the positions of a geometry.
+type Layout int
+
+const (
+	// NoLayout leaves the layout to the length of the positions: XY for 2
+	// ordinates, XYZ for 3 and XYZM for 4.
+	NoLayout Layout = iota
+	XY
+	XYZ
+	XYM
+	XYZM
+)
+
+var layoutNames = [...]string{"", "XY", "XYZ", "XYM", "XYZM"}
+
+// LayoutFromStride returns the layout implied by n ordinates.
+func LayoutFromStride(n int) Layout {
+	switch {
+	case n >= 4:
+		return XYZM
+	case n == 3:
+		return XYZ
+	}
+	return XY
+}
+
+// Stride returns the number of ordinates of the positions, 0 for NoLayout.
+func (l Layout) Stride() int {
+	switch l {
+	case XY:
+		return 2
+	case XYZ, XYM:
+		return 3
+	case XYZM:
+		return 4
+	}
+	return 0
+}
+
+func (l Layout) HasZ() bool { return l == XYZ || l == XYZM }
+
+func (l Layout) HasM() bool { return l == XYM || l == XYZM }
+
+// ZIndex returns the index of Z in the positions, -1 when there is none.
+func (l Layout) ZIndex() int {
+	if l.HasZ() {
+		return 2
+	}
+	return -1
+}
+
+// MIndex returns the index of M in the positions, -1 when there is none.
+func (l Layout) MIndex() int {
+	switch l {
+	case XYM:
+		return 2
+	case XYZM:
+		return 3
+	}
+	return -1
+}
+
+func (l Layout) String() string {
+	if l < 0 || int(l) >= len(layoutNames) {
+		return fmt.Sprintf("Layout(%d)", int(l))
+	}
+	return layoutNames[l]
+}
+
+func (l Layout) MarshalText() ([]byte, error) {
+	if l < 0 || int(l) >= len(layoutNames) {
+		return nil, fmt.Errorf("invalid layout %d", int(l))
+	}
+	return []byte(layoutNames[l]), nil
+}
+
+func (l *Layout) UnmarshalText(text []byte) error {
+	for i, n := range layoutNames {
+		if n == string(text) {
+			*l = Layout(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid layout %q", text)
+}
+
 type Geometry interface {
 	GetType() string
 }
 
+// Layouter is implemented by the geometries telling the layout of their
+// positions, those which do not are taken as XY or XYZ.
+type Layouter interface {
+	Layout() Layout
+}
+
 type Point interface {
 	Geometry
 	X() float64
@@ -49,6 +141,11 @@ type Point3 interface {
 	Z() float64
 }
 
+type PointM interface {
+	Point
+	M() float64
+}
+
 type MultiPoint interface {
 	Geometry
 	Points() []Point
@@ -121,6 +218,11 @@ func (c Collection) GetType() string {
 
 func GeometryAsString(g Geometry) string {
 	switch geo := g.(type) {
+	case PointM:
+		if p3, ok := g.(Point3); ok {
+			return fmt.Sprintf("PointZM( %v %v %v %v )", geo.X(), geo.Y(), p3.Z(), geo.M())
+		}
+		return fmt.Sprintf("PointM( %v %v %v )", geo.X(), geo.Y(), geo.M())
 	case LineString:
 		rstring := "["
 		for _, p := range geo.Subpoints() {
@@ -141,6 +243,9 @@ func GeometryAsMap(g Geometry) map[string]interface{} {
 	js := make(map[string]interface{})
 	var vals []map[string]interface{}
 	switch geo := g.(type) {
+	case PointM:
+		js["type"] = g.GetType()
+		js["value"] = geo.Data()
 	case Point3:
 		js["type"] = g.GetType()
 		js["value"] = []float64{geo.X(), geo.Y(), geo.Z()}
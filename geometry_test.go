@@ -217,3 +217,46 @@ func (m *MockLineString) Subpoints() []Point {
 func (m *MockLineString) Data() [][]float64 {
 	return m.Points
 }
+
+// MockPointM 模拟带M坐标的Point
+type MockPointM struct {
+	MockPoint
+}
+
+// M 返回点的M坐标
+func (m MockPointM) M() float64 {
+	return m.Point[2]
+}
+
+// Layout 返回坐标布局
+func (m MockPointM) Layout() Layout {
+	return XYM
+}
+
+// 测试坐标布局
+func TestLayout(t *testing.T) {
+	assert.Equal(t, 3, XYM.Stride())
+	assert.Equal(t, 0, NoLayout.Stride())
+	assert.Equal(t, -1, XYZ.MIndex())
+	assert.Equal(t, 2, XYM.MIndex())
+	assert.Equal(t, 3, XYZM.MIndex())
+	assert.Equal(t, -1, XYM.ZIndex())
+	assert.Equal(t, XYZM, LayoutFromStride(4))
+	assert.Equal(t, XY, LayoutFromStride(0))
+	assert.Equal(t, "XYZM", XYZM.String())
+
+	var l Layout
+	assert.NoError(t, l.UnmarshalText([]byte("XYM")))
+	assert.Equal(t, XYM, l)
+	assert.Error(t, l.UnmarshalText([]byte("XYMZ")))
+
+	p := MockPointM{MockPoint{Point: []float64{1, 2, 3}}}
+	g := NewGeometryData(p)
+	assert.Equal(t, XYM, g.Layout)
+	assert.Equal(t, "PointM( 1 2 3 )", GeometryAsString(p))
+	assert.Equal(t, []float64{1, 2, 3}, GeometryAsMap(p)["value"])
+
+	assert.Equal(t, XYZ, LayoutFromGeometryData(NewLineStringGeometryData([][]float64{{1, 2}, {1, 2, 3}})))
+	col := NewCollectionGeometryData(g, NewPointGeometryData([]float64{1, 2, 3}))
+	assert.Equal(t, XYZM, LayoutFromGeometryData(col))
+}
@@ -0,0 +1,577 @@
+package gml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/proj"
+)
+
+// AxisOrder is the order of the first two ordinates of the positions.
+type AxisOrder int
+
+const (
+	// AxisOrderAuto reads latitude first the geographic systems named by
+	// the EPSG urn and http uri srsNames, such as urn:ogc:def:crs:EPSG::4326,
+	// and the others x y.
+	AxisOrderAuto AxisOrder = iota
+	// AxisOrderXY reads every position x y.
+	AxisOrderXY
+	// AxisOrderYX reads every position y x.
+	AxisOrderYX
+)
+
+// DecodeOptions controls the reading of GML.
+type DecodeOptions struct {
+	// AxisOrder overrides the order given by the srsNames.
+	AxisOrder AxisOrder
+}
+
+func DefaultDecodeOptions() *DecodeOptions {
+	return &DecodeOptions{}
+}
+
+// node is an element of a GML document, compared on local names so that
+// GML 2, 3.1 and 3.2 namespaces are read alike.
+type node struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Content string     `xml:",chardata"`
+	Nodes   []node     `xml:",any"`
+}
+
+func (n *node) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (n *node) child(name string) *node {
+	for i := range n.Nodes {
+		if n.Nodes[i].XMLName.Local == name {
+			return &n.Nodes[i]
+		}
+	}
+	return nil
+}
+
+func (n *node) children(names ...string) []*node {
+	var ret []*node
+	for i := range n.Nodes {
+		for _, name := range names {
+			if n.Nodes[i].XMLName.Local == name {
+				ret = append(ret, &n.Nodes[i])
+				break
+			}
+		}
+	}
+	return ret
+}
+
+// context holds the attributes inherited from the enclosing geometries.
+type context struct {
+	dim      int
+	measured bool
+	swap     bool
+	auto     bool
+}
+
+// latitudeFirstPrefixes are the srsNames of the EPSG axis order, unlike
+// EPSG:4326 and the epsg.xml form read longitude first by usage.
+var latitudeFirstPrefixes = []string{
+	"urn:ogc:def:crs:EPSG:",
+	"urn:x-ogc:def:crs:EPSG:",
+	"http://www.opengis.net/def/crs/EPSG/0/",
+}
+
+// latitudeFirst tells whether srs is a geographic system in the EPSG axis
+// order, the geographic systems being those known to proj as such.
+func latitudeFirst(srs string) bool {
+	for _, prefix := range latitudeFirstPrefixes {
+		if strings.HasPrefix(srs, prefix) {
+			epsg := geom.UrnToSrid(srs)
+			if epsg <= 0 {
+				return false
+			}
+			p, err := proj.Lookup(epsg)
+			if err != nil {
+				return false
+			}
+			_, ok := p.(proj.LonLat)
+			return ok
+		}
+	}
+	return false
+}
+
+func (c context) with(n *node) context {
+	if srs := n.attr("srsName"); srs != "" && c.auto {
+		c.swap = latitudeFirst(srs)
+	}
+	if d, err := strconv.Atoi(n.attr("srsDimension")); err == nil && d > 0 {
+		c.dim = d
+	}
+	if labels := strings.Fields(n.attr("axisLabels")); len(labels) > 0 {
+		c.measured = strings.EqualFold(labels[len(labels)-1], "m")
+	}
+	return c
+}
+
+func (c context) layout(stride int) geom.Layout {
+	if !c.measured || stride < 3 {
+		return geom.NoLayout
+	}
+	if stride > 3 {
+		return geom.XYZM
+	}
+	return geom.XYM
+}
+
+func parseFloats(s string) ([]float64, error) {
+	fields := strings.Fields(s)
+	ret := make([]float64, len(fields))
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gml coordinate %q", f)
+		}
+		ret[i] = v
+	}
+	return ret, nil
+}
+
+func (c context) swapped(p []float64) []float64 {
+	if c.swap && len(p) > 1 {
+		p[0], p[1] = p[1], p[0]
+	}
+	return p
+}
+
+// split cuts flat into positions of dim ordinates.
+func (c context) split(flat []float64, dim int) ([][]float64, error) {
+	if dim <= 0 {
+		dim = 2
+	}
+	if len(flat)%dim != 0 {
+		return nil, fmt.Errorf("%d gml ordinates are not a multiple of dimension %d", len(flat), dim)
+	}
+	ret := make([][]float64, 0, len(flat)/dim)
+	for i := 0; i < len(flat); i += dim {
+		ret = append(ret, c.swapped(flat[i:i+dim:i+dim]))
+	}
+	return ret, nil
+}
+
+// coordinates reads the GML 2 coordinates element with its cs, ts and
+// decimal separators.
+func (c context) coordinates(n *node) ([][]float64, error) {
+	cs, ts, dec := n.attr("cs"), n.attr("ts"), n.attr("decimal")
+	if cs == "" {
+		cs = ","
+	}
+	if ts == "" {
+		ts = " "
+	}
+	var ret [][]float64
+	tuples := strings.Fields(n.Content)
+	if strings.TrimSpace(ts) != "" {
+		tuples = strings.Split(strings.TrimSpace(n.Content), ts)
+	}
+	for _, t := range tuples {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		var p []float64
+		for _, f := range strings.Split(t, cs) {
+			f = strings.TrimSpace(f)
+			if dec != "" && dec != "." {
+				f = strings.Replace(f, dec, ".", 1)
+			}
+			v, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid gml coordinate %q", f)
+			}
+			p = append(p, v)
+		}
+		ret = append(ret, c.swapped(p))
+	}
+	return ret, nil
+}
+
+// coord reads the GML 2 coord element with X, Y and Z children.
+func (c context) coord(n *node) ([]float64, error) {
+	var p []float64
+	for _, name := range []string{"X", "Y", "Z"} {
+		e := n.child(name)
+		if e == nil {
+			break
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(e.Content), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gml coordinate %q", e.Content)
+		}
+		p = append(p, v)
+	}
+	if len(p) < 2 {
+		return nil, errors.New("gml coord without X and Y")
+	}
+	return c.swapped(p), nil
+}
+
+// positions reads the positions of n from its posList, coordinates, pos,
+// coord or pointProperty children.
+func (c context) positions(n *node) ([][]float64, error) {
+	if e := n.child("posList"); e != nil {
+		flat, err := parseFloats(e.Content)
+		if err != nil {
+			return nil, err
+		}
+		return c.split(flat, c.with(e).dim)
+	}
+	if e := n.child("coordinates"); e != nil {
+		return c.coordinates(e)
+	}
+	var ret [][]float64
+	for i := range n.Nodes {
+		e := &n.Nodes[i]
+		switch e.XMLName.Local {
+		case "pos":
+			p, err := parseFloats(e.Content)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, c.swapped(p))
+		case "coord":
+			p, err := c.coord(e)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, p)
+		case "pointProperty", "pointRep":
+			if pt := e.child("Point"); pt != nil {
+				pts, err := c.with(pt).positions(pt)
+				if err != nil {
+					return nil, err
+				}
+				ret = append(ret, pts...)
+			}
+		}
+	}
+	return ret, nil
+}
+
+func stride(path [][]float64) int {
+	ret := 0
+	for _, p := range path {
+		if len(p) > ret {
+			ret = len(p)
+		}
+	}
+	return ret
+}
+
+func (c context) point(n *node) ([]float64, error) {
+	pts, err := c.positions(n)
+	if err != nil {
+		return nil, err
+	}
+	if len(pts) == 0 {
+		return nil, nil
+	}
+	if len(pts) != 1 {
+		return nil, fmt.Errorf("gml point with %d positions", len(pts))
+	}
+	return pts[0], nil
+}
+
+// curve reads a LineString, LinearRing or the LineStringSegments of a Curve
+// or Ring.
+func (c context) curve(n *node) ([][]float64, error) {
+	c = c.with(n)
+	switch n.XMLName.Local {
+	case "LineString", "LinearRing", "LineStringSegment":
+		return c.positions(n)
+	case "Curve", "Ring", "OrientableCurve", "CompositeCurve":
+		var ret [][]float64
+		for _, s := range n.children("segments", "curveMember", "baseCurve") {
+			for i := range s.Nodes {
+				line, err := c.curve(&s.Nodes[i])
+				if err != nil {
+					return nil, err
+				}
+				// 相邻的段共用端点
+				if len(ret) > 0 && len(line) > 0 && equal(ret[len(ret)-1], line[0]) {
+					line = line[1:]
+				}
+				ret = append(ret, line...)
+			}
+		}
+		return ret, nil
+	}
+	return nil, fmt.Errorf("unsupported gml curve %s", n.XMLName.Local)
+}
+
+func equal(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (c context) ring(n *node) ([][]float64, error) {
+	if len(n.Nodes) == 0 {
+		return nil, fmt.Errorf("empty gml %s", n.XMLName.Local)
+	}
+	return c.curve(&n.Nodes[0])
+}
+
+// polygons reads the patches of a Surface, or the single polygon of the
+// other surfaces.
+func (c context) polygons(n *node) ([][][][]float64, error) {
+	if n.XMLName.Local != "Surface" {
+		p, err := c.polygon(n)
+		if err != nil {
+			return nil, err
+		}
+		return [][][][]float64{p}, nil
+	}
+	c = c.with(n)
+	var ret [][][][]float64
+	for _, s := range n.children("patches", "polygonPatches") {
+		for i := range s.Nodes {
+			p, err := c.polygon(&s.Nodes[i])
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, p)
+		}
+	}
+	return ret, nil
+}
+
+// polygon reads a Polygon, a PolygonPatch or an Envelope.
+func (c context) polygon(n *node) ([][][]float64, error) {
+	c = c.with(n)
+	switch n.XMLName.Local {
+	case "Envelope", "Box":
+		min, max, err := c.envelope(n)
+		if err != nil {
+			return nil, err
+		}
+		return [][][]float64{{{min[0], min[1]}, {max[0], min[1]}, {max[0], max[1]}, {min[0], max[1]}, {min[0], min[1]}}}, nil
+	case "Polygon", "PolygonPatch", "Rectangle":
+	default:
+		return nil, fmt.Errorf("unsupported gml surface %s", n.XMLName.Local)
+	}
+	var ret [][][]float64
+	for _, b := range n.children("exterior", "outerBoundaryIs", "interior", "innerBoundaryIs") {
+		r, err := c.ring(b)
+		if err != nil {
+			return nil, err
+		}
+		if name := b.XMLName.Local; name == "exterior" || name == "outerBoundaryIs" {
+			ret = append([][][]float64{r}, ret...)
+		} else {
+			ret = append(ret, r)
+		}
+	}
+	return ret, nil
+}
+
+// envelope reads the corners of an Envelope or a GML 2 Box.
+func (c context) envelope(n *node) ([]float64, []float64, error) {
+	c = c.with(n)
+	var pts [][]float64
+	lower, upper := n.child("lowerCorner"), n.child("upperCorner")
+	if lower != nil && upper != nil {
+		for _, e := range []*node{lower, upper} {
+			p, err := parseFloats(e.Content)
+			if err != nil {
+				return nil, nil, err
+			}
+			pts = append(pts, c.swapped(p))
+		}
+	} else {
+		var err error
+		if pts, err = c.positions(n); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(pts) != 2 || len(pts[0]) < 2 || len(pts[1]) < 2 {
+		return nil, nil, errors.New("gml envelope needs two corners")
+	}
+	return pts[0], pts[1], nil
+}
+
+// members returns the geometries of the member and members properties of a
+// multi geometry.
+func members(n *node, names ...string) []*node {
+	var ret []*node
+	for _, m := range n.children(names...) {
+		for i := range m.Nodes {
+			ret = append(ret, &m.Nodes[i])
+		}
+	}
+	return ret
+}
+
+func polygonsStride(ps [][][][]float64) int {
+	ret := 0
+	for _, p := range ps {
+		for _, r := range p {
+			if s := stride(r); s > ret {
+				ret = s
+			}
+		}
+	}
+	return ret
+}
+
+func (c context) geometry(n *node) (*geom.GeometryData, error) {
+	c = c.with(n)
+	g := &geom.GeometryData{}
+	var err error
+	dims := 0
+	switch n.XMLName.Local {
+	case "Point":
+		g.Type = geom.GeometryPoint
+		g.Point, err = c.point(n)
+		dims = len(g.Point)
+	case "LineString", "LinearRing", "Curve", "Ring", "CompositeCurve", "OrientableCurve":
+		g.Type = geom.GeometryLineString
+		g.LineString, err = c.curve(n)
+		dims = stride(g.LineString)
+	case "Polygon", "Surface", "Envelope", "Box", "Rectangle", "PolygonPatch":
+		var ps [][][][]float64
+		if ps, err = c.polygons(n); err != nil {
+			return nil, err
+		}
+		// 多个面片的曲面作为多多边形
+		if len(ps) == 1 {
+			g.Type, g.Polygon = geom.GeometryPolygon, ps[0]
+		} else {
+			g.Type, g.MultiPolygon = geom.GeometryMultiPolygon, ps
+		}
+		dims = polygonsStride(ps)
+	case "MultiPoint":
+		g.Type = geom.GeometryMultiPoint
+		for _, m := range members(n, "pointMember", "pointMembers") {
+			p, err := c.with(m).point(m)
+			if err != nil {
+				return nil, err
+			}
+			if p != nil {
+				g.MultiPoint = append(g.MultiPoint, p)
+			}
+		}
+		dims = stride(g.MultiPoint)
+	case "MultiLineString", "MultiCurve":
+		g.Type = geom.GeometryMultiLineString
+		for _, m := range members(n, "lineStringMember", "curveMember", "curveMembers") {
+			l, err := c.curve(m)
+			if err != nil {
+				return nil, err
+			}
+			g.MultiLineString = append(g.MultiLineString, l)
+			if s := stride(l); s > dims {
+				dims = s
+			}
+		}
+	case "MultiPolygon", "MultiSurface", "CompositeSurface":
+		g.Type = geom.GeometryMultiPolygon
+		for _, m := range members(n, "polygonMember", "surfaceMember", "surfaceMembers") {
+			ps, err := c.polygons(m)
+			if err != nil {
+				return nil, err
+			}
+			g.MultiPolygon = append(g.MultiPolygon, ps...)
+		}
+		dims = polygonsStride(g.MultiPolygon)
+	case "MultiGeometry", "GeometryCollection":
+		g.Type = geom.GeometryCollection
+		for _, m := range members(n, "geometryMember", "geometryMembers") {
+			child, err := c.geometry(m)
+			if err != nil {
+				return nil, err
+			}
+			g.Geometries = append(g.Geometries, child)
+		}
+		dims = c.dim
+	default:
+		return nil, fmt.Errorf("unsupported gml geometry %s", n.XMLName.Local)
+	}
+	if err != nil {
+		return nil, err
+	}
+	g.Layout = c.layout(dims)
+	if srs := n.attr("srsName"); srs != "" {
+		if epsg := geom.UrnToSrid(srs); epsg > 0 {
+			g.EPSG = epsg
+		}
+	}
+	return g, nil
+}
+
+func parse(r io.Reader) (*node, error) {
+	var n node
+	if err := xml.NewDecoder(r).Decode(&n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func newContext(opt *DecodeOptions) context {
+	if opt == nil {
+		opt = DefaultDecodeOptions()
+	}
+	return context{swap: opt.AxisOrder == AxisOrderYX, auto: opt.AxisOrder == AxisOrderAuto}
+}
+
+// Decode reads a GML geometry, its srsName giving the EPSG.
+func Decode(r io.Reader, opt *DecodeOptions) (*geom.GeometryData, error) {
+	n, err := parse(r)
+	if err != nil {
+		return nil, err
+	}
+	return newContext(opt).geometry(n)
+}
+
+// Unmarshal decodes the GML geometry of data.
+func Unmarshal(data []byte, opt *DecodeOptions) (*geom.GeometryData, error) {
+	return Decode(bytes.NewReader(data), opt)
+}
+
+// DecodeEnvelope reads a GML Envelope or Box as a bounding box.
+func DecodeEnvelope(r io.Reader, opt *DecodeOptions) (*geom.BoundingBox, error) {
+	n, err := parse(r)
+	if err != nil {
+		return nil, err
+	}
+	return newContext(opt).boundingBox(n)
+}
+
+func (c context) boundingBox(n *node) (*geom.BoundingBox, error) {
+	min, max, err := c.envelope(n)
+	if err != nil {
+		return nil, err
+	}
+	var b geom.BoundingBox
+	copy(b[0][:], min)
+	copy(b[1][:], max)
+	return &b, nil
+}
@@ -0,0 +1,156 @@
+package gml
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/flywave/go-geom"
+)
+
+var geometryNames = map[string]bool{
+	"Point": true, "LineString": true, "LinearRing": true, "Curve": true, "Ring": true,
+	"CompositeCurve": true, "OrientableCurve": true, "Polygon": true, "Surface": true,
+	"Envelope": true, "Box": true, "Rectangle": true, "PolygonPatch": true,
+	"MultiPoint": true, "MultiLineString": true, "MultiCurve": true, "MultiPolygon": true,
+	"MultiSurface": true, "CompositeSurface": true, "MultiGeometry": true, "GeometryCollection": true,
+}
+
+// geometryOf returns the geometry held by the property n.
+func geometryOf(n *node) *node {
+	if len(n.Nodes) == 1 && geometryNames[n.Nodes[0].XMLName.Local] {
+		return &n.Nodes[0]
+	}
+	return nil
+}
+
+// value reads a nested property as a string, or a map of its children.
+func value(n *node) interface{} {
+	if len(n.Nodes) == 0 {
+		if n.attr("nil") == "true" {
+			return nil
+		}
+		return strings.TrimSpace(n.Content)
+	}
+	ret := make(map[string]interface{})
+	for i := range n.Nodes {
+		name, v := n.Nodes[i].XMLName.Local, value(&n.Nodes[i])
+		// 重复的元素作为列表
+		if o, ok := ret[name]; !ok {
+			ret[name] = v
+		} else if l, ok := o.([]interface{}); ok {
+			ret[name] = append(l, v)
+		} else {
+			ret[name] = []interface{}{o, v}
+		}
+	}
+	return ret
+}
+
+// feature reads a feature element. Its first geometry property is the
+// geometry of the feature, the others are kept as *geom.GeometryData
+// properties.
+func (c context) feature(n *node) (*geom.Feature, error) {
+	f := &geom.Feature{
+		Type:       "Feature",
+		Properties: make(map[string]interface{}),
+		ExtData:    make(map[string]interface{}),
+	}
+	if id := n.attr("id"); id != "" {
+		f.ID = id
+	} else if id := n.attr("fid"); id != "" {
+		f.ID = id
+	}
+	found := false
+	for i := range n.Nodes {
+		e := &n.Nodes[i]
+		name := e.XMLName.Local
+		if name == "boundedBy" {
+			bbox, err := c.boundedBy(e)
+			if err != nil {
+				return nil, err
+			}
+			f.BoundingBox = bbox
+			continue
+		}
+		if gn := geometryOf(e); gn != nil {
+			g, err := c.geometry(gn)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				f.GeometryData, found = *g, true
+				continue
+			}
+			f.Properties[name] = g
+			continue
+		}
+		f.Properties[name] = value(e)
+	}
+	if found && f.BoundingBox == nil {
+		f.BoundingBox = geom.BoundingBoxFromGeometryData(&f.GeometryData)
+	}
+	return f, nil
+}
+
+// boundedBy reads the Envelope or Box of a boundedBy property, nil for a
+// Null one.
+func (c context) boundedBy(n *node) (*geom.BoundingBox, error) {
+	for _, name := range []string{"Envelope", "Box"} {
+		if b := n.child(name); b != nil {
+			return c.boundingBox(b)
+		}
+	}
+	return nil, nil
+}
+
+// features returns the features of the featureMember, featureMembers and
+// WFS 2.0 member elements of a collection.
+func features(n *node) []*node {
+	return members(n, "featureMember", "featureMembers", "member")
+}
+
+// DecodeFeatureCollection reads a GML or WFS feature collection. The simple
+// properties of the features are kept as strings, nested ones as maps.
+func DecodeFeatureCollection(r io.Reader, opt *DecodeOptions) (*geom.FeatureCollection, error) {
+	n, err := parse(r)
+	if err != nil {
+		return nil, err
+	}
+	c := newContext(opt)
+	fc := geom.NewFeatureCollection()
+	if b := n.child("boundedBy"); b != nil {
+		if fc.BoundingBox, err = c.boundedBy(b); err != nil {
+			return nil, err
+		}
+	}
+	for _, m := range features(n) {
+		f, err := c.feature(m)
+		if err != nil {
+			return nil, err
+		}
+		fc.AddFeature(f)
+	}
+	return fc, nil
+}
+
+// DecodeFeature reads a single GML feature, or the first feature of a
+// collection.
+func DecodeFeature(r io.Reader, opt *DecodeOptions) (*geom.Feature, error) {
+	n, err := parse(r)
+	if err != nil {
+		return nil, err
+	}
+	if fs := features(n); len(fs) > 0 {
+		return newContext(opt).feature(fs[0])
+	} else if len(n.children("featureMember", "featureMembers", "member")) > 0 {
+		return nil, errors.New("empty gml feature member")
+	}
+	return newContext(opt).feature(n)
+}
+
+// UnmarshalFeatureCollection decodes the GML feature collection of data.
+func UnmarshalFeatureCollection(data []byte, opt *DecodeOptions) (*geom.FeatureCollection, error) {
+	return DecodeFeatureCollection(bytes.NewReader(data), opt)
+}
@@ -20,11 +20,11 @@ type Position []float64
 
 func (p Position) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 	buf := bytes.Buffer{}
-	for i := 0; i < len(p)-1; i++ {
-		buf.WriteString(fmt.Sprintf("%f ", p[i]))
-	}
-	if len(p) > 0 {
-		buf.WriteString(fmt.Sprintf("%f", p[len(p)-1]))
+	for i := range p {
+		if i > 0 {
+			buf.WriteString(" ")
+		}
+		buf.WriteString(strconv.FormatFloat(p[i], 'f', -1, 64))
 	}
 	return e.EncodeElement(buf.String(), start)
 }
@@ -67,12 +67,14 @@ func getPositionFromString(position string) []float64 {
 }
 
 type Point struct {
-	XMLName     xml.Name  `xml:"gml:Point"`
-	ID          string    `xml:"gml:id,attr,omitempty"`
-	GML         string    `xml:"xmlns:gml,attr,omitempty"`
-	SrsName     string    `xml:"srsName,attr,omitempty"`
-	Pos         *Position `xml:"gml:pos,omitempty"`
-	Coordinates *Position `xml:"gml:coordinates,omitempty"`
+	XMLName      xml.Name  `xml:"gml:Point"`
+	ID           string    `xml:"gml:id,attr,omitempty"`
+	GML          string    `xml:"xmlns:gml,attr,omitempty"`
+	SrsName      string    `xml:"srsName,attr,omitempty"`
+	SrsDimension int       `xml:"srsDimension,attr,omitempty"`
+	AxisLabels   string    `xml:"axisLabels,attr,omitempty"`
+	Pos          *Position `xml:"gml:pos,omitempty"`
+	Coordinates  *Position `xml:"gml:coordinates,omitempty"`
 }
 
 func (g Point) Marshal() (string, error) {
@@ -88,13 +90,15 @@ func (g Point) Marshal() (string, error) {
 }
 
 type LineString struct {
-	XMLName     xml.Name   `xml:"gml:LineString"`
-	ID          string     `xml:"gml:id,attr,omitempty"`
-	GML         string     `xml:"xmlns:gml,attr,omitempty"`
-	SrsName     string     `xml:"srsName,attr,omitempty"`
-	Pos         []Position `xml:"gml:pos,omitempty"`
-	Coordinates *Position  `xml:"gml:coordinates,omitempty"`
-	PosList     *Position  `xml:"gml:posList,omitempty"`
+	XMLName      xml.Name   `xml:"gml:LineString"`
+	ID           string     `xml:"gml:id,attr,omitempty"`
+	GML          string     `xml:"xmlns:gml,attr,omitempty"`
+	SrsName      string     `xml:"srsName,attr,omitempty"`
+	SrsDimension int        `xml:"srsDimension,attr,omitempty"`
+	AxisLabels   string     `xml:"axisLabels,attr,omitempty"`
+	Pos          []Position `xml:"gml:pos,omitempty"`
+	Coordinates  *Position  `xml:"gml:coordinates,omitempty"`
+	PosList      *Position  `xml:"gml:posList,omitempty"`
 }
 
 func (g LineString) Marshal() (string, error) {
@@ -110,11 +114,13 @@ func (g LineString) Marshal() (string, error) {
 }
 
 type MultiPoint struct {
-	XMLName xml.Name       `xml:"gml:MultiPoint"`
-	ID      string         `xml:"gml:id,attr,omitempty"`
-	GML     string         `xml:"xmlns:gml,attr,omitempty"`
-	SrsName string         `xml:"srsName,attr,omitempty"`
-	Members []PointMembers `xml:"gml:pointMembers,omitempty"`
+	XMLName      xml.Name       `xml:"gml:MultiPoint"`
+	ID           string         `xml:"gml:id,attr,omitempty"`
+	GML          string         `xml:"xmlns:gml,attr,omitempty"`
+	SrsName      string         `xml:"srsName,attr,omitempty"`
+	SrsDimension int            `xml:"srsDimension,attr,omitempty"`
+	AxisLabels   string         `xml:"axisLabels,attr,omitempty"`
+	Members      []PointMembers `xml:"gml:pointMembers,omitempty"`
 }
 
 func (g MultiPoint) Marshal() (string, error) {
@@ -134,17 +140,20 @@ type PointMembers struct {
 }
 
 type LinearRing struct {
+	XMLName     xml.Name   `xml:"gml:LinearRing"`
 	Pos         []Position `xml:"gml:pos,omitempty"`
 	Coordinates *Position  `xml:"gml:coordinates,omitempty"`
 	PosList     *Position  `xml:"gml:posList,omitempty"`
 }
 
 type MultiCurve struct {
-	XMLName xml.Name      `xml:"gml:MultiCurve"`
-	ID      string        `xml:"gml:id,attr,omitempty"`
-	GML     string        `xml:"xmlns:gml,attr,omitempty"`
-	SrsName string        `xml:"srsName,attr,omitempty"`
-	Members []CurveMember `xml:"gml:curveMember,omitempty"`
+	XMLName      xml.Name      `xml:"gml:MultiCurve"`
+	ID           string        `xml:"gml:id,attr,omitempty"`
+	GML          string        `xml:"xmlns:gml,attr,omitempty"`
+	SrsName      string        `xml:"srsName,attr,omitempty"`
+	SrsDimension int           `xml:"srsDimension,attr,omitempty"`
+	AxisLabels   string        `xml:"axisLabels,attr,omitempty"`
+	Members      []CurveMember `xml:"gml:curveMember,omitempty"`
 }
 
 func (g MultiCurve) Marshal() (string, error) {
@@ -164,12 +173,14 @@ type CurveMember struct {
 }
 
 type Polygon struct {
-	XMLName  xml.Name  `xml:"gml:Polygon"`
-	ID       string    `xml:"gml:id,attr,omitempty"`
-	GML      string    `xml:"xmlns:gml,attr,omitempty"`
-	SrsName  string    `xml:"srsName,attr,omitempty"`
-	Exterior *Exterior `xml:"gml:exterior,omitempty"`
-	Interior *Interior `xml:"gml:interior,omitempty"`
+	XMLName      xml.Name   `xml:"gml:Polygon"`
+	ID           string     `xml:"gml:id,attr,omitempty"`
+	GML          string     `xml:"xmlns:gml,attr,omitempty"`
+	SrsName      string     `xml:"srsName,attr,omitempty"`
+	SrsDimension int        `xml:"srsDimension,attr,omitempty"`
+	AxisLabels   string     `xml:"axisLabels,attr,omitempty"`
+	Exterior     *Exterior  `xml:"gml:exterior,omitempty"`
+	Interior     []Interior `xml:"gml:interior,omitempty"`
 }
 
 func (g Polygon) Marshal() (string, error) {
@@ -189,15 +200,17 @@ type Exterior struct {
 }
 
 type Interior struct {
-	Lines []LinearRing
+	Line LinearRing
 }
 
 type MultiSurface struct {
-	XMLName xml.Name       `xml:"gml:MultiSurface"`
-	ID      string         `xml:"gml:id,attr,omitempty"`
-	GML     string         `xml:"xmlns:gml,attr,omitempty"`
-	SrsName string         `xml:"srsName,attr,omitempty"`
-	Members SurfaceMembers `xml:"gml:surfaceMembers,attr,omitempty"`
+	XMLName      xml.Name       `xml:"gml:MultiSurface"`
+	ID           string         `xml:"gml:id,attr,omitempty"`
+	GML          string         `xml:"xmlns:gml,attr,omitempty"`
+	SrsName      string         `xml:"srsName,attr,omitempty"`
+	SrsDimension int            `xml:"srsDimension,attr,omitempty"`
+	AxisLabels   string         `xml:"axisLabels,attr,omitempty"`
+	Members      SurfaceMembers `xml:"gml:surfaceMembers,omitempty"`
 }
 
 func (g MultiSurface) Marshal() (string, error) {
@@ -217,11 +230,25 @@ type SurfaceMembers struct {
 }
 
 type MultiGeometry struct {
-	XMLName xml.Name          `xml:"gml:MultiGeometry"`
-	ID      string            `xml:"gml:id,attr,omitempty"`
-	GML     string            `xml:"xmlns:gml,attr,omitempty"`
-	SrsName string            `xml:"srsName,attr,omitempty"`
-	Members []GeometryMembers `xml:"gml:geometryMembers,omitempty"`
+	XMLName      xml.Name          `xml:"gml:MultiGeometry"`
+	ID           string            `xml:"gml:id,attr,omitempty"`
+	GML          string            `xml:"xmlns:gml,attr,omitempty"`
+	SrsName      string            `xml:"srsName,attr,omitempty"`
+	SrsDimension int               `xml:"srsDimension,attr,omitempty"`
+	AxisLabels   string            `xml:"axisLabels,attr,omitempty"`
+	Members      []GeometryMembers `xml:"gml:geometryMembers,omitempty"`
+}
+
+func (g MultiGeometry) Marshal() (string, error) {
+	si, err := xml.MarshalIndent(g, "", "")
+
+	if err != nil {
+		return "", err
+	}
+
+	xmls := unescapeXML(string(si))
+
+	return xmls, nil
 }
 
 type GeometryMembers []interface{}
@@ -249,6 +276,12 @@ func (g GeometryMembers) MarshalXML(e *xml.Encoder, start xml.StartElement) erro
 		case *MultiSurface, MultiSurface:
 			si, _ := xml.Marshal(t)
 			buf.Write(si)
+		case *MultiCurve, MultiCurve:
+			si, _ := xml.Marshal(t)
+			buf.Write(si)
+		case *MultiGeometry, MultiGeometry:
+			si, _ := xml.Marshal(t)
+			buf.Write(si)
 		}
 	}
 	return e.EncodeElement(buf.String(), start)
@@ -267,7 +300,12 @@ func (g GeometryMembers) Marshal() (string, error) {
 }
 
 func Encode(g geom.Geometry) (interface{}, error) {
+	if l, ok := g.(geom.Layouter); ok && l.Layout().HasM() {
+		return EncodeGeometryData(geom.NewGeometryData(g))
+	}
 	switch g := g.(type) {
+	case *geom.GeometryData:
+		return EncodeGeometryData(g)
 	case geom.Point3:
 		return EncodePoint3(g)
 	case geom.Point:
@@ -299,23 +337,14 @@ func Encode(g geom.Geometry) (interface{}, error) {
 	}
 }
 
+// FlatCoords returns the first dim ordinates of each point, missing ones
+// as 0.
 func FlatCoords(pts [][]float64, dim int) Position {
-	if dim == 2 {
-		ret := make(Position, len(pts)*2)
-		for i := range pts {
-			ret[i*2] = pts[i][0]
-			ret[i*2+1] = pts[i][1]
-		}
-		return ret
-	} else if dim == 3 {
-		ret := make(Position, len(pts)*2)
-		for i := range pts {
-			ret[i*3] = pts[i][0]
-			ret[i*3+1] = pts[i][1]
-		}
-		return ret
+	ret := make(Position, len(pts)*dim)
+	for i := range pts {
+		copy(ret[i*dim:(i+1)*dim], pts[i])
 	}
-	return nil
+	return ret
 }
 
 func EncodeLineString(ls geom.LineString) (*LineString, error) {
@@ -374,12 +403,9 @@ func EncodeMultiPolygon(mp geom.MultiPolygon) (*MultiSurface, error) {
 		flatCoords := FlatCoords(p.Sublines()[0].Data(), 2)
 		ppp.Exterior.Line = LinearRing{Coordinates: &flatCoords}
 
-		if len(p.Sublines()) > 1 {
-			ppp.Interior = &Interior{Lines: make([]LinearRing, len(p.Sublines())-1)}
-			for i, ls := range p.Sublines()[1:] {
-				flatCoords := FlatCoords(ls.Data(), 2)
-				ppp.Interior.Lines[i] = LinearRing{Coordinates: &flatCoords}
-			}
+		for _, ls := range p.Sublines()[1:] {
+			flatCoords := FlatCoords(ls.Data(), 2)
+			ppp.Interior = append(ppp.Interior, Interior{Line: LinearRing{Coordinates: &flatCoords}})
 		}
 		pp.Members.Polygons[i] = *ppp
 	}
@@ -394,12 +420,9 @@ func EncodeMultiPolygon3(mp geom.MultiPolygon3) (*MultiSurface, error) {
 		flatCoords := FlatCoords(p.Sublines()[0].Data(), 3)
 		ppp.Exterior.Line = LinearRing{Coordinates: &flatCoords}
 
-		if len(p.Sublines()) > 1 {
-			ppp.Interior = &Interior{Lines: make([]LinearRing, len(p.Sublines())-1)}
-			for i, ls := range p.Sublines()[1:] {
-				flatCoords := FlatCoords(ls.Data(), 3)
-				ppp.Interior.Lines[i] = LinearRing{Coordinates: &flatCoords}
-			}
+		for _, ls := range p.Sublines()[1:] {
+			flatCoords := FlatCoords(ls.Data(), 3)
+			ppp.Interior = append(ppp.Interior, Interior{Line: LinearRing{Coordinates: &flatCoords}})
 		}
 		pp.Members.Polygons[i] = *ppp
 	}
@@ -424,12 +447,9 @@ func EncodePolygon(p geom.Polygon) (*Polygon, error) {
 	flatCoords := FlatCoords(p.Sublines()[0].Data(), 2)
 	pp.Exterior.Line = LinearRing{Coordinates: &flatCoords}
 
-	if len(p.Sublines()) > 1 {
-		pp.Interior = &Interior{Lines: make([]LinearRing, len(p.Sublines())-1)}
-		for i, ls := range p.Sublines()[1:] {
-			flatCoords := FlatCoords(ls.Data(), 2)
-			pp.Interior.Lines[i] = LinearRing{Coordinates: &flatCoords}
-		}
+	for _, ls := range p.Sublines()[1:] {
+		flatCoords := FlatCoords(ls.Data(), 2)
+		pp.Interior = append(pp.Interior, Interior{Line: LinearRing{Coordinates: &flatCoords}})
 	}
 
 	return pp, nil
@@ -441,12 +461,9 @@ func EncodePolygon3(p geom.Polygon3) (*Polygon, error) {
 	flatCoords := FlatCoords(p.Sublines()[0].Data(), 3)
 	pp.Exterior.Line = LinearRing{Coordinates: &flatCoords}
 
-	if len(p.Sublines()) > 1 {
-		pp.Interior = &Interior{Lines: make([]LinearRing, len(p.Sublines())-1)}
-		for i, ls := range p.Sublines()[1:] {
-			flatCoords := FlatCoords(ls.Data(), 3)
-			pp.Interior.Lines[i] = LinearRing{Coordinates: &flatCoords}
-		}
+	for _, ls := range p.Sublines()[1:] {
+		flatCoords := FlatCoords(ls.Data(), 3)
+		pp.Interior = append(pp.Interior, Interior{Line: LinearRing{Coordinates: &flatCoords}})
 	}
 
 	return pp, nil
@@ -463,3 +480,88 @@ func EncodeGeometryCollection(geoms geom.Collection) (*MultiGeometry, error) {
 	}
 	return pp, nil
 }
+
+// axisLabels names the ordinates of the layouts with a measure, which have
+// no axis in the reference system.
+func axisLabels(layout geom.Layout) string {
+	switch layout {
+	case geom.XYM:
+		return "x y m"
+	case geom.XYZM:
+		return "x y z m"
+	}
+	return ""
+}
+
+func encodeRings(rings [][][]float64, dim int) Polygon {
+	var pp Polygon
+	for i, r := range rings {
+		flatCoords := FlatCoords(r, dim)
+		if i == 0 {
+			pp.Exterior = &Exterior{Line: LinearRing{PosList: &flatCoords}}
+		} else {
+			pp.Interior = append(pp.Interior, Interior{Line: LinearRing{PosList: &flatCoords}})
+		}
+	}
+	return pp
+}
+
+// EncodeGeometryData encodes g with the ordinates of its layout, counted in
+// srsDimension and, for measures, named in axisLabels.
+func EncodeGeometryData(g *geom.GeometryData) (interface{}, error) {
+	return encodeGeometryData(g, geom.NoLayout)
+}
+
+// encodeGeometryData encodes g, a member of a collection of layout parent.
+func encodeGeometryData(g *geom.GeometryData, parent geom.Layout) (interface{}, error) {
+	layout := geom.MemberLayout(g, parent)
+	dim, labels := layout.Stride(), axisLabels(layout)
+	switch g.Type {
+	case geom.GeometryPoint:
+		pos := FlatCoords([][]float64{g.Point}, dim)
+		return &Point{SrsDimension: dim, AxisLabels: labels, Pos: &pos}, nil
+	case geom.GeometryLineString:
+		flatCoords := FlatCoords(g.LineString, dim)
+		return &LineString{SrsDimension: dim, AxisLabels: labels, PosList: &flatCoords}, nil
+	case geom.GeometryPolygon:
+		pp := encodeRings(g.Polygon, dim)
+		pp.SrsDimension, pp.AxisLabels = dim, labels
+		return &pp, nil
+	case geom.GeometryMultiPoint:
+		pp := &MultiPoint{SrsDimension: dim, AxisLabels: labels, Members: make([]PointMembers, len(g.MultiPoint))}
+		for i, p := range g.MultiPoint {
+			pos := FlatCoords([][]float64{p}, dim)
+			pp.Members[i].Points = []Point{{Pos: &pos}}
+		}
+		return pp, nil
+	case geom.GeometryMultiLineString:
+		pp := &MultiCurve{SrsDimension: dim, AxisLabels: labels, Members: make([]CurveMember, len(g.MultiLineString))}
+		for i, l := range g.MultiLineString {
+			flatCoords := FlatCoords(l, dim)
+			pp.Members[i].Lines = []LineString{{PosList: &flatCoords}}
+		}
+		return pp, nil
+	case geom.GeometryMultiPolygon:
+		pp := &MultiSurface{SrsDimension: dim, AxisLabels: labels}
+		for _, p := range g.MultiPolygon {
+			pp.Members.Polygons = append(pp.Members.Polygons, encodeRings(p, dim))
+		}
+		return pp, nil
+	case geom.GeometryCollection:
+		pp := &MultiGeometry{Members: []GeometryMembers{make(GeometryMembers, len(g.Geometries))}}
+		if g.Layout.HasM() {
+			pp.SrsDimension, pp.AxisLabels = dim, labels
+		}
+		if g.Layout != geom.NoLayout {
+			parent = g.Layout
+		}
+		for i, c := range g.Geometries {
+			var err error
+			if pp.Members[0][i], err = encodeGeometryData(c, parent); err != nil {
+				return nil, err
+			}
+		}
+		return pp, nil
+	}
+	return nil, fmt.Errorf("unsupport geometry type %q", g.Type)
+}
@@ -1,10 +1,16 @@
 package gml
 
 import (
+	"bytes"
 	"encoding/xml"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/general"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestPoint(t *testing.T) {
@@ -48,3 +54,206 @@ func TestGeom(t *testing.T) {
 	ioutil.WriteFile("./test.xml", []byte(xmls), os.ModePerm)
 
 }
+
+// 测试多边形的内环与坐标的写出
+func TestEncodePolygon(t *testing.T) {
+	p, err := EncodePolygon3(general.NewPolygon3([][][]float64{
+		{{0, 0, 1}, {4, 0, 1}, {4, 4, 1}, {0, 0, 1}},
+		{{1, 1, 1}, {2, 1, 1}, {2, 2, 1}, {1, 1, 1}},
+		{{3, 1, 1}, {3.5, 1, 1}, {3.5, 1.5, 1}, {3, 1, 1}},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ := p.Marshal()
+	want := `<gml:Polygon><gml:exterior><gml:LinearRing><gml:coordinates>0 0 1 4 0 1 4 4 1 0 0 1</gml:coordinates></gml:LinearRing></gml:exterior>` +
+		`<gml:interior><gml:LinearRing><gml:coordinates>1 1 1 2 1 1 2 2 1 1 1 1</gml:coordinates></gml:LinearRing></gml:interior>` +
+		`<gml:interior><gml:LinearRing><gml:coordinates>3 1 1 3.5 1 1 3.5 1.5 1 3 1 1</gml:coordinates></gml:LinearRing></gml:interior></gml:Polygon>`
+	if s != want {
+		t.Fatalf("got %s", s)
+	}
+
+	mp, _ := EncodeMultiPolygon(general.NewMultiPolygon([][][][]float64{{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}}))
+	s, _ = mp.Marshal()
+	if !strings.Contains(s, `<gml:surfaceMembers><gml:Polygon>`) {
+		t.Fatalf("got %s", s)
+	}
+}
+
+// 测试按布局编码
+func TestEncodeLayout(t *testing.T) {
+	g := &geom.GeometryData{Type: geom.GeometryLineString, LineString: [][]float64{{1, 2, 10}, {3, 4.5, 20}}, Layout: geom.XYM}
+	e, err := Encode(g)
+	assert.NoError(t, err)
+	s, _ := e.(*LineString).Marshal()
+	assert.Equal(t, `<gml:LineString srsDimension="3" axisLabels="x y m"><gml:posList>1 2 10 3 4.5 20</gml:posList></gml:LineString>`, s)
+
+	e, _ = EncodeGeometryData(geom.NewPolygonGeometryData([][][]float64{
+		{{0, 0, 1}, {4, 0, 1}, {4, 4, 1}, {0, 0, 1}},
+		{{1, 1}, {2, 1}, {2, 2}, {1, 1}},
+	}))
+	s, _ = e.(*Polygon).Marshal()
+	assert.Equal(t, `<gml:Polygon srsDimension="3"><gml:exterior><gml:LinearRing><gml:posList>0 0 1 4 0 1 4 4 1 0 0 1</gml:posList></gml:LinearRing></gml:exterior>`+
+		`<gml:interior><gml:LinearRing><gml:posList>1 1 0 2 1 0 2 2 0 1 1 0</gml:posList></gml:LinearRing></gml:interior></gml:Polygon>`, s)
+
+	e, _ = EncodeGeometryData(geom.NewMultiPolygonGeometryData([][][]float64{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}))
+	s, err = e.(*MultiSurface).Marshal()
+	assert.NoError(t, err)
+	assert.Contains(t, s, `<gml:surfaceMembers><gml:Polygon>`)
+
+	e, _ = EncodeGeometryData(&geom.GeometryData{Type: geom.GeometryPoint, Point: []float64{1, 2, 3, 4}})
+	assert.Equal(t, Position{1, 2, 3, 4}, *e.(*Point).Pos)
+	assert.Equal(t, "x y z m", e.(*Point).AxisLabels)
+
+	// 集合中没有布局的成员使用集合的布局
+	c := geom.NewCollectionGeometryData(geom.NewPointGeometryData([]float64{1, 2, 3}))
+	c.Layout = geom.XYM
+	e, err = EncodeGeometryData(c)
+	assert.NoError(t, err)
+	assert.Equal(t, "x y m", e.(*MultiGeometry).Members[0][0].(*Point).AxisLabels)
+}
+
+// 测试 GML 2 解码
+func TestDecodeGML2(t *testing.T) {
+	g, err := Unmarshal([]byte(`<gml:Polygon xmlns:gml="http://www.opengis.net/gml" srsName="http://www.opengis.net/gml/srs/epsg.xml#4326">
+<gml:outerBoundaryIs><gml:LinearRing><gml:coordinates>0,0 4,0 4,4 0,0</gml:coordinates></gml:LinearRing></gml:outerBoundaryIs>
+<gml:innerBoundaryIs><gml:LinearRing><gml:coordinates decimal="," cs=";" ts=" ">1,5;1 2;1 2;2 1,5;1</gml:coordinates></gml:LinearRing></gml:innerBoundaryIs>
+</gml:Polygon>`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, geom.GeometryPolygon, g.Type)
+	assert.Equal(t, 4326, g.EPSG)
+	assert.Equal(t, [][][]float64{{{0, 0}, {4, 0}, {4, 4}, {0, 0}}, {{1.5, 1}, {2, 1}, {2, 2}, {1.5, 1}}}, g.Polygon)
+
+	g, err = Unmarshal([]byte(`<gml:Point><gml:coord><gml:X>1</gml:X><gml:Y>2</gml:Y></gml:coord></gml:Point>`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 2}, g.Point)
+
+	b, err := DecodeEnvelope(bytes.NewReader([]byte(`<gml:Box><gml:coordinates>1,2 3,4</gml:coordinates></gml:Box>`)), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, geom.BoundingBox{{1, 2, 0}, {3, 4, 0}}, *b)
+}
+
+// 测试 GML 3 解码
+func TestDecodeGML3(t *testing.T) {
+	g, err := Unmarshal([]byte(`<gml:LineString xmlns:gml="http://www.opengis.net/gml/3.2" srsName="urn:ogc:def:crs:EPSG::3857" srsDimension="3">
+<gml:posList>1 2 3 4 5 6</gml:posList></gml:LineString>`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3857, g.EPSG)
+	assert.Equal(t, geom.NoLayout, g.Layout)
+	assert.Equal(t, [][]float64{{1, 2, 3}, {4, 5, 6}}, g.LineString)
+
+	g, err = Unmarshal([]byte(`<Curve><segments>
+<LineStringSegment><pos>0 0</pos><pos>1 0</pos></LineStringSegment>
+<LineStringSegment><posList>1 0 1 1</posList></LineStringSegment>
+</segments></Curve>`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]float64{{0, 0}, {1, 0}, {1, 1}}, g.LineString)
+
+	g, err = Unmarshal([]byte(`<MultiCurve><curveMember><LineString><posList>0 0 1 1</posList></LineString></curveMember>
+<curveMembers><LineString><posList>2 2 3 3</posList></LineString><LineString><posList>4 4 5 5</posList></LineString></curveMembers></MultiCurve>`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, geom.GeometryMultiLineString, g.Type)
+	assert.Len(t, g.MultiLineString, 3)
+
+	g, err = Unmarshal([]byte(`<MultiSurface><surfaceMember><Polygon><exterior><LinearRing><posList>0 0 1 0 1 1 0 0</posList></LinearRing></exterior></Polygon></surfaceMember>
+<surfaceMember><Surface><patches><PolygonPatch><exterior><LinearRing><posList>5 5 6 5 6 6 5 5</posList></LinearRing></exterior></PolygonPatch></patches></Surface></surfaceMember></MultiSurface>`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, geom.GeometryMultiPolygon, g.Type)
+	assert.Equal(t, [][][]float64{{{5, 5}, {6, 5}, {6, 6}, {5, 5}}}, g.MultiPolygon[1])
+
+	g, err = Unmarshal([]byte(`<MultiGeometry><geometryMember><Point><pos>1 2</pos></Point></geometryMember>
+<geometryMember><MultiPoint><pointMember><Point><pos>3 4</pos></Point></pointMember></MultiPoint></geometryMember>
+<geometryMember><Envelope><lowerCorner>0 0</lowerCorner><upperCorner>2 1</upperCorner></Envelope></geometryMember></MultiGeometry>`), nil)
+	assert.NoError(t, err)
+	assert.Len(t, g.Geometries, 3)
+	assert.Equal(t, [][]float64{{3, 4}}, g.Geometries[1].MultiPoint)
+	assert.Equal(t, [][][]float64{{{0, 0}, {2, 0}, {2, 1}, {0, 1}, {0, 0}}}, g.Geometries[2].Polygon)
+
+	// 纬度在前的坐标系
+	for _, srs := range []string{"urn:ogc:def:crs:EPSG::4326", "http://www.opengis.net/def/crs/EPSG/0/4490"} {
+		g, err = Unmarshal([]byte(`<Point srsName="`+srs+`"><pos>30 120</pos></Point>`), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{120, 30}, g.Point, srs)
+	}
+	g, err = Unmarshal([]byte(`<MultiGeometry srsName="urn:ogc:def:crs:EPSG::4326"><geometryMember><LineString><posList>30 120 31 121</posList></LineString></geometryMember></MultiGeometry>`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]float64{{120, 30}, {121, 31}}, g.Geometries[0].LineString)
+	// 经度在前的坐标系与投影坐标系不交换
+	for _, srs := range []string{"EPSG:4326", "urn:ogc:def:crs:OGC:1.3:CRS84", "http://www.opengis.net/gml/srs/epsg.xml#4326", "urn:ogc:def:crs:EPSG::3857"} {
+		g, err = Unmarshal([]byte(`<Point srsName="`+srs+`"><pos>30 120</pos></Point>`), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{30, 120}, g.Point, srs)
+	}
+	// 选项优先
+	g, err = Unmarshal([]byte(`<Point srsName="urn:ogc:def:crs:EPSG::4326"><pos>120 30</pos></Point>`), &DecodeOptions{AxisOrder: AxisOrderXY})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{120, 30}, g.Point)
+	g, err = Unmarshal([]byte(`<Point srsName="EPSG:4326"><pos>30 120</pos></Point>`), &DecodeOptions{AxisOrder: AxisOrderYX})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{120, 30}, g.Point)
+
+	_, err = Unmarshal([]byte(`<LineString srsDimension="3"><posList>1 2 3 4</posList></LineString>`), nil)
+	assert.Error(t, err)
+	_, err = Unmarshal([]byte(`<Solid/>`), nil)
+	assert.Error(t, err)
+}
+
+// 测试带 M 坐标的编解码往返
+func TestDecodeRoundTrip(t *testing.T) {
+	for _, g := range []*geom.GeometryData{
+		{Type: geom.GeometryLineString, LineString: [][]float64{{1, 2, 10}, {3, 4.5, 20}}, Layout: geom.XYM},
+		{Type: geom.GeometryPoint, Point: []float64{1, 2, 3, 4}, Layout: geom.XYZM},
+		geom.NewMultiPolygonGeometryData([][][]float64{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}, [][][]float64{{{5, 5}, {6, 5}, {6, 6}, {5, 5}}}),
+		geom.NewCollectionGeometryData(
+			&geom.GeometryData{Type: geom.GeometryMultiPoint, MultiPoint: [][]float64{{1, 2, 3}}, Layout: geom.XYM},
+			geom.NewMultiLineStringGeometryData([][]float64{{0, 0}, {1, 1}})),
+	} {
+		e, err := EncodeGeometryData(g)
+		assert.NoError(t, err)
+		s, err := e.(interface{ Marshal() (string, error) }).Marshal()
+		assert.NoError(t, err)
+		d, err := Unmarshal([]byte(s), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, g, d)
+	}
+}
+
+// 测试要素集合解码
+func TestDecodeFeatureCollection(t *testing.T) {
+	data := `<wfs:FeatureCollection xmlns:wfs="http://www.opengis.net/wfs/2.0" xmlns:gml="http://www.opengis.net/gml/3.2" xmlns:app="http://example.com/app" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+<wfs:boundedBy><gml:Envelope srsName="EPSG:4326"><gml:lowerCorner>0 0</gml:lowerCorner><gml:upperCorner>10 10</gml:upperCorner></gml:Envelope></wfs:boundedBy>
+<wfs:member><app:road gml:id="road.1">
+<app:name>Main</app:name>
+<app:lanes>2</app:lanes>
+<app:note xsi:nil="true"/>
+<app:geom><gml:LineString srsName="EPSG:4326"><gml:posList>0 0 10 10</gml:posList></gml:LineString></app:geom>
+<app:start><gml:Point><gml:pos>0 0</gml:pos></gml:Point></app:start>
+<app:owner><app:tag>a</app:tag><app:tag>b</app:tag></app:owner>
+</app:road></wfs:member>
+<wfs:member><app:road gml:id="road.2"><gml:boundedBy><gml:Null>unknown</gml:Null></gml:boundedBy><app:name>Empty</app:name></app:road></wfs:member>
+</wfs:FeatureCollection>`
+	fc, err := UnmarshalFeatureCollection([]byte(data), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, geom.BoundingBox{{0, 0, 0}, {10, 10, 0}}, *fc.BoundingBox)
+	assert.Len(t, fc.Features, 2)
+
+	f := fc.Features[0]
+	assert.Equal(t, "road.1", f.ID)
+	assert.Equal(t, geom.GeometryLineString, f.GeometryData.Type)
+	assert.Equal(t, 4326, f.GeometryData.EPSG)
+	assert.Equal(t, "Main", f.Properties["name"])
+	assert.Equal(t, "2", f.Properties["lanes"])
+	assert.Nil(t, f.Properties["note"])
+	assert.Equal(t, []float64{0, 0}, f.Properties["start"].(*geom.GeometryData).Point)
+	assert.Equal(t, map[string]interface{}{"tag": []interface{}{"a", "b"}}, f.Properties["owner"])
+	assert.NotNil(t, f.BoundingBox)
+
+	assert.Nil(t, fc.Features[1].BoundingBox)
+	assert.Equal(t, geom.GeometryType(""), fc.Features[1].GeometryData.Type)
+
+	// GML 2 featureMember
+	f, err = DecodeFeature(bytes.NewReader([]byte(`<wfs:FeatureCollection><gml:featureMember><app:poi fid="poi.7"><app:the_geom><gml:Point><gml:coordinates>1,2</gml:coordinates></gml:Point></app:the_geom></app:poi></gml:featureMember></wfs:FeatureCollection>`)), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "poi.7", f.ID)
+	assert.Equal(t, []float64{1, 2}, f.GeometryData.Point)
+}
@@ -8,8 +8,10 @@ require (
 	github.com/devork/geom v0.0.5
 	github.com/flywave/go3d v0.0.0-20250314015505-bf0fda02e242
 	github.com/fogleman/gg v1.3.0
+	github.com/google/flatbuffers v25.2.10+incompatible
 	github.com/stretchr/testify v1.10.0
 	github.com/twpayne/go-kml/v3 v3.3.0
+	golang.org/x/text v0.26.0
 )
 
 require (
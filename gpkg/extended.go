@@ -0,0 +1,350 @@
+package gpkg
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/flywave/go-geom"
+)
+
+// The WKB codes of the geometry types of the GeoPackage extensions, after
+// the seven simple feature types.
+const (
+	wkbCircularString    = 8
+	wkbCompoundCurve     = 9
+	wkbCurvePolygon      = 10
+	wkbMultiCurve        = 11
+	wkbMultiSurface      = 12
+	wkbCurve             = 13
+	wkbSurface           = 14
+	wkbPolyhedralSurface = 15
+	wkbTIN               = 16
+	wkbTriangle          = 17
+)
+
+// arcSegments is the number of segments of a full circle when the arcs
+// are linearized.
+const arcSegments = 72
+
+var errTruncated = errors.New("truncated geopackage wkb")
+
+// reader reads the WKB of the extended geometry types, which the wkb
+// package does not support.
+type reader struct {
+	data []byte
+	pos  int
+}
+
+func (r *reader) next(n int) ([]byte, error) {
+	if n < 0 || len(r.data)-r.pos < n {
+		return nil, errTruncated
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *reader) uint32(order binary.ByteOrder) (int, error) {
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return int(order.Uint32(b)), nil
+}
+
+func (r *reader) positions(order binary.ByteOrder, stride int) ([][]float64, error) {
+	n, err := r.uint32(order)
+	if err != nil {
+		return nil, err
+	}
+	return r.coordinates(n, order, stride)
+}
+
+func (r *reader) coordinates(n int, order binary.ByteOrder, stride int) ([][]float64, error) {
+	b, err := r.next(n * stride * 8)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([][]float64, n)
+	for i := range ret {
+		p := make([]float64, stride)
+		for j := range p {
+			p[j] = math.Float64frombits(order.Uint64(b[8*(i*stride+j):]))
+		}
+		ret[i] = p
+	}
+	return ret, nil
+}
+
+// unmarshalExtended reads a geometry of WKB data whose curves and surfaces
+// are linearized: circular strings and compound curves as line strings,
+// curve polygons and triangles as polygons, multi curves as multi line
+// strings and the surfaces as multi polygons.
+func unmarshalExtended(data []byte) (*geom.GeometryData, error) {
+	r := &reader{data: data}
+	g, _, err := r.geometry()
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// geometry returns the next geometry with its WKB type code.
+func (r *reader) geometry() (*geom.GeometryData, int, error) {
+	b, err := r.next(5)
+	if err != nil {
+		return nil, 0, err
+	}
+	var order binary.ByteOrder
+	switch b[0] {
+	case 0:
+		order = binary.BigEndian
+	case 1:
+		order = binary.LittleEndian
+	default:
+		return nil, 0, fmt.Errorf("invalid wkb byte order %d", b[0])
+	}
+	code := order.Uint32(b[1:])
+	// 同时支持 ISO 与 EWKB 的维度标记
+	z, m := code&0x80000000 != 0, code&0x40000000 != 0
+	if code&0x20000000 != 0 {
+		if _, err := r.next(4); err != nil {
+			return nil, 0, err
+		}
+	}
+	base := code & 0x0fffffff
+	switch base / 1000 {
+	case 1:
+		z = true
+	case 2:
+		m = true
+	case 3:
+		z, m = true, true
+	}
+	layout := geom.XY
+	switch {
+	case z && m:
+		layout = geom.XYZM
+	case z:
+		layout = geom.XYZ
+	case m:
+		layout = geom.XYM
+	}
+	typ := int(base % 1000)
+	g, err := r.body(typ, order, layout.Stride())
+	if err != nil {
+		return nil, 0, err
+	}
+	if m {
+		g.Layout = layout
+	}
+	return g, typ, nil
+}
+
+func (r *reader) body(typ int, order binary.ByteOrder, stride int) (*geom.GeometryData, error) {
+	switch typ {
+	case 1:
+		pts, err := r.coordinates(1, order, stride)
+		if err != nil {
+			return nil, err
+		}
+		g := geom.NewPointGeometryData(nil)
+		if !math.IsNaN(pts[0][0]) {
+			g.Point = pts[0]
+		}
+		return g, nil
+	case 2:
+		line, err := r.positions(order, stride)
+		if err != nil {
+			return nil, err
+		}
+		return geom.NewLineStringGeometryData(line), nil
+	case 3, wkbTriangle:
+		n, err := r.uint32(order)
+		if err != nil {
+			return nil, err
+		}
+		var rings [][][]float64
+		for i := 0; i < n; i++ {
+			ring, err := r.positions(order, stride)
+			if err != nil {
+				return nil, err
+			}
+			rings = append(rings, ring)
+		}
+		return geom.NewPolygonGeometryData(rings), nil
+	case wkbCircularString:
+		pts, err := r.positions(order, stride)
+		if err != nil {
+			return nil, err
+		}
+		line, err := linearize(pts)
+		if err != nil {
+			return nil, err
+		}
+		return geom.NewLineStringGeometryData(line), nil
+	case wkbCurve, wkbSurface:
+		return nil, fmt.Errorf("unsupported abstract wkb geometry type %d", typ)
+	}
+	if typ < 4 || typ > wkbTriangle {
+		return nil, fmt.Errorf("unsupported wkb geometry type %d", typ)
+	}
+
+	n, err := r.uint32(order)
+	if err != nil {
+		return nil, err
+	}
+	var members []*geom.GeometryData
+	for i := 0; i < n; i++ {
+		c, code, err := r.geometry()
+		if err != nil {
+			return nil, err
+		}
+		if !member(typ, code) {
+			return nil, fmt.Errorf("unexpected wkb geometry type %d in %d", code, typ)
+		}
+		members = append(members, c)
+	}
+	switch typ {
+	case 4:
+		g := geom.NewMultiPointGeometryData()
+		for _, c := range members {
+			if c.Point != nil {
+				g.MultiPoint = append(g.MultiPoint, c.Point)
+			}
+		}
+		return g, nil
+	case 5, wkbMultiCurve:
+		g := geom.NewMultiLineStringGeometryData()
+		for _, c := range members {
+			g.MultiLineString = append(g.MultiLineString, c.LineString)
+		}
+		return g, nil
+	case 6, wkbMultiSurface, wkbPolyhedralSurface, wkbTIN:
+		g := geom.NewMultiPolygonGeometryData()
+		for _, c := range members {
+			g.MultiPolygon = append(g.MultiPolygon, c.Polygon)
+		}
+		return g, nil
+	case wkbCompoundCurve:
+		var line [][]float64
+		for _, c := range members {
+			// 相接的端点只保留一个
+			if len(line) > 0 && len(c.LineString) > 0 {
+				line = append(line, c.LineString[1:]...)
+			} else {
+				line = append(line, c.LineString...)
+			}
+		}
+		return geom.NewLineStringGeometryData(line), nil
+	case wkbCurvePolygon:
+		var rings [][][]float64
+		for _, c := range members {
+			rings = append(rings, c.LineString)
+		}
+		return geom.NewPolygonGeometryData(rings), nil
+	}
+	return geom.NewCollectionGeometryData(members...), nil
+}
+
+// member tells whether a geometry of WKB type code may be a member of one
+// of type typ.
+func member(typ, code int) bool {
+	curve := code == 2 || code == wkbCircularString || code == wkbCompoundCurve
+	switch typ {
+	case 4:
+		return code == 1
+	case 5:
+		return code == 2
+	case 6:
+		return code == 3
+	case wkbCompoundCurve:
+		return code == 2 || code == wkbCircularString
+	case wkbCurvePolygon, wkbMultiCurve:
+		return curve
+	case wkbMultiSurface:
+		return code == 3 || code == wkbCurvePolygon
+	case wkbPolyhedralSurface:
+		return code == 3
+	case wkbTIN:
+		return code == wkbTriangle
+	}
+	return true
+}
+
+// linearize returns the line string of the arcs of a circular string, each
+// through three of its positions. The ordinates other than X and Y are
+// interpolated along each half of the arcs.
+func linearize(pts [][]float64) ([][]float64, error) {
+	if len(pts) == 0 {
+		return nil, nil
+	}
+	if len(pts) < 3 || len(pts)%2 == 0 {
+		return nil, fmt.Errorf("invalid wkb circular string of %d positions", len(pts))
+	}
+	line := [][]float64{pts[0]}
+	for i := 0; i+2 < len(pts); i += 2 {
+		line = append(line, arc(pts[i], pts[i+1], pts[i+2])...)
+	}
+	return line, nil
+}
+
+// arc returns the positions after p0 of the arc from p0 through p1 to p2.
+func arc(p0, p1, p2 []float64) [][]float64 {
+	var cx, cy, sweep, mid float64
+	a0 := 0.0
+	if p0[0] == p2[0] && p0[1] == p2[1] {
+		// 首尾相同时为整圆, p1 为直径的另一端
+		cx, cy = (p0[0]+p1[0])/2, (p0[1]+p1[1])/2
+		a0 = math.Atan2(p0[1]-cy, p0[0]-cx)
+		sweep, mid = 2*math.Pi, math.Pi
+	} else {
+		ax, ay, bx, by, qx, qy := p0[0], p0[1], p1[0], p1[1], p2[0], p2[1]
+		d := 2 * (ax*(by-qy) + bx*(qy-ay) + qx*(ay-by))
+		if math.Abs(d) < 1e-12*math.Max(1, math.Abs(ax*by)) {
+			return [][]float64{p1, p2}
+		}
+		sa, sb, sq := ax*ax+ay*ay, bx*bx+by*by, qx*qx+qy*qy
+		cx = (sa*(by-qy) + sb*(qy-ay) + sq*(ay-by)) / d
+		cy = (sa*(qx-bx) + sb*(ax-qx) + sq*(bx-ax)) / d
+		a0 = math.Atan2(ay-cy, ax-cx)
+		a1, a2 := math.Atan2(by-cy, bx-cx), math.Atan2(qy-cy, qx-cx)
+		// 按 p0, p1, p2 的转向确定方向
+		ccw := (bx-ax)*(qy-by)-(by-ay)*(qx-bx) > 0
+		norm := func(a float64) float64 {
+			if !ccw {
+				a = -a
+			}
+			a = math.Mod(a, 2*math.Pi)
+			if a < 0 {
+				a += 2 * math.Pi
+			}
+			return a
+		}
+		sweep, mid = norm(a2-a0), norm(a1-a0)
+		if !ccw {
+			sweep, mid = -sweep, -mid
+		}
+	}
+	r := math.Hypot(p0[0]-cx, p0[1]-cy)
+	n := int(math.Ceil(math.Abs(sweep) / (2 * math.Pi / arcSegments)))
+	if n < 2 {
+		n = 2
+	}
+	ret := make([][]float64, 0, n)
+	for i := 1; i < n; i++ {
+		a := sweep * float64(i) / float64(n)
+		p := []float64{cx + r*math.Cos(a0+a), cy + r*math.Sin(a0+a)}
+		for j := 2; j < len(p0); j++ {
+			if math.Abs(a) <= math.Abs(mid) {
+				p = append(p, p0[j]+(p1[j]-p0[j])*a/mid)
+			} else {
+				p = append(p, p1[j]+(p2[j]-p1[j])*(a-mid)/(sweep-mid))
+			}
+		}
+		ret = append(ret, p)
+	}
+	return append(ret, p2)
+}
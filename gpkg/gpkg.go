@@ -0,0 +1,269 @@
+package gpkg
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/wkb"
+)
+
+// EnvelopeType is the envelope contents indicator of the header, telling
+// which ranges the envelope holds.
+type EnvelopeType int
+
+const (
+	NoEnvelope EnvelopeType = iota
+	EnvelopeXY
+	EnvelopeXYZ
+	EnvelopeXYM
+	EnvelopeXYZM
+	// EnvelopeAuto writes the ranges of the dimensions of the geometry,
+	// and no envelope for points and empty geometries.
+	EnvelopeAuto EnvelopeType = -1
+)
+
+// Size returns the number of values of the envelope.
+func (t EnvelopeType) Size() int {
+	switch t {
+	case EnvelopeXY:
+		return 4
+	case EnvelopeXYZ, EnvelopeXYM:
+		return 6
+	case EnvelopeXYZM:
+		return 8
+	}
+	return 0
+}
+
+const (
+	flagLittleEndian = 1 << 0
+	flagEmpty        = 1 << 4
+	flagExtended     = 1 << 5
+)
+
+// Header is the GeoPackage binary header preceding the WKB of a geometry.
+type Header struct {
+	Version byte
+	// ByteOrder of the SRS id and the envelope.
+	ByteOrder binary.ByteOrder
+	// Extended tells an ExtendedGeoPackageBinary, whose body is not WKB.
+	Extended bool
+	Empty    bool
+	SRSID    int32
+	// Envelope holds minx, maxx, miny, maxy then minz, maxz and minm, maxm
+	// as told by EnvelopeType.
+	EnvelopeType EnvelopeType
+	Envelope     []float64
+}
+
+// Size returns the size in bytes of h.
+func (h *Header) Size() int {
+	return 8 + 8*h.EnvelopeType.Size()
+}
+
+// Append writes h to buf.
+func (h *Header) Append(buf []byte) []byte {
+	order := h.ByteOrder
+	if order == nil {
+		order = binary.LittleEndian
+	}
+	flags := byte(h.EnvelopeType) << 1
+	if order == binary.LittleEndian {
+		flags |= flagLittleEndian
+	}
+	if h.Empty {
+		flags |= flagEmpty
+	}
+	if h.Extended {
+		flags |= flagExtended
+	}
+	var b [8]byte
+	order.PutUint32(b[:], uint32(h.SRSID))
+	buf = append(buf, 'G', 'P', h.Version, flags, b[0], b[1], b[2], b[3])
+	for i := 0; i < h.EnvelopeType.Size(); i++ {
+		v := math.NaN()
+		if i < len(h.Envelope) {
+			v = h.Envelope[i]
+		}
+		order.PutUint64(b[:], math.Float64bits(v))
+		buf = append(buf, b[:]...)
+	}
+	return buf
+}
+
+// UnmarshalHeader reads the header at the start of data and returns it
+// with the body following it.
+func UnmarshalHeader(data []byte) (*Header, []byte, error) {
+	if len(data) < 8 || data[0] != 'G' || data[1] != 'P' {
+		return nil, nil, errors.New("not a geopackage binary geometry")
+	}
+	flags := data[3]
+	if flags>>6 != 0 {
+		return nil, nil, fmt.Errorf("invalid geopackage binary flags %#x", flags)
+	}
+	h := &Header{
+		Version:      data[2],
+		ByteOrder:    binary.BigEndian,
+		Extended:     flags&flagExtended != 0,
+		Empty:        flags&flagEmpty != 0,
+		EnvelopeType: EnvelopeType(flags >> 1 & 7),
+	}
+	if h.EnvelopeType > EnvelopeXYZM {
+		return nil, nil, fmt.Errorf("invalid geopackage envelope contents indicator %d", h.EnvelopeType)
+	}
+	if flags&flagLittleEndian != 0 {
+		h.ByteOrder = binary.LittleEndian
+	}
+	if len(data) < h.Size() {
+		return nil, nil, errors.New("truncated geopackage binary header")
+	}
+	h.SRSID = int32(h.ByteOrder.Uint32(data[4:]))
+	for i := 0; i < h.EnvelopeType.Size(); i++ {
+		h.Envelope = append(h.Envelope, math.Float64frombits(h.ByteOrder.Uint64(data[8+8*i:])))
+	}
+	return h, data[h.Size():], nil
+}
+
+// Unmarshal decodes a GeoPackage binary geometry. The SRS id goes in the
+// EPSG field when positive. The curves and surfaces of the extended
+// geometry types are read as their linear counterparts.
+func Unmarshal(data []byte) (*geom.GeometryData, error) {
+	h, body, err := UnmarshalHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if h.Extended {
+		if len(body) >= 4 {
+			return nil, fmt.Errorf("unsupported geopackage extended binary %q", body[:4])
+		}
+		return nil, errors.New("unsupported geopackage extended binary")
+	}
+	g, err := wkb.Unmarshal(body)
+	var unsupported *wkb.UnsupportedTypeError
+	if errors.As(err, &unsupported) {
+		g, err = unmarshalExtended(body)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if h.SRSID > 0 {
+		g.EPSG = int(h.SRSID)
+	}
+	return g, nil
+}
+
+// EncodeOptions controls the output of Marshal.
+type EncodeOptions struct {
+	// ByteOrder of the header and of the WKB.
+	ByteOrder binary.ByteOrder
+	Envelope  EnvelopeType
+	// SRSID is the SRS id written, 0 writing the EPSG of the geometry.
+	SRSID int
+}
+
+func DefaultEncodeOptions() *EncodeOptions {
+	return &EncodeOptions{ByteOrder: binary.LittleEndian, Envelope: EnvelopeAuto}
+}
+
+// Marshal returns g as a GeoPackage binary geometry, the header followed
+// by ISO WKB.
+func Marshal(g *geom.GeometryData, opt *EncodeOptions) ([]byte, error) {
+	if opt == nil {
+		opt = DefaultEncodeOptions()
+	}
+	if g == nil {
+		return nil, errors.New("nil geometry")
+	}
+	order := opt.ByteOrder
+	if order == nil {
+		order = binary.LittleEndian
+	}
+	layout := geom.LayoutFromGeometryData(g)
+	h := &Header{ByteOrder: order, SRSID: int32(opt.SRSID), EnvelopeType: opt.Envelope}
+	if h.SRSID == 0 {
+		h.SRSID = int32(g.EPSG)
+	}
+	box := bounds(g, false, geom.NoLayout)
+	h.Empty = box == nil
+	if h.EnvelopeType == EnvelopeAuto {
+		switch {
+		case h.Empty || g.Type == geom.GeometryPoint:
+			h.EnvelopeType = NoEnvelope
+		case layout == geom.XYZM:
+			h.EnvelopeType = EnvelopeXYZM
+		case layout == geom.XYZ:
+			h.EnvelopeType = EnvelopeXYZ
+		case layout == geom.XYM:
+			h.EnvelopeType = EnvelopeXYM
+		default:
+			h.EnvelopeType = EnvelopeXY
+		}
+	}
+	if h.EnvelopeType < NoEnvelope || h.EnvelopeType > EnvelopeXYZM {
+		return nil, fmt.Errorf("invalid geopackage envelope type %d", h.EnvelopeType)
+	}
+	// 空几何的范围写为 NaN
+	if !h.Empty && h.EnvelopeType != NoEnvelope {
+		h.Envelope = []float64{box[0][0], box[1][0], box[0][1], box[1][1]}
+		if h.EnvelopeType == EnvelopeXYZ || h.EnvelopeType == EnvelopeXYZM {
+			h.Envelope = append(h.Envelope, box[0][2], box[1][2])
+		}
+		if h.EnvelopeType == EnvelopeXYM || h.EnvelopeType == EnvelopeXYZM {
+			m := bounds(g, true, geom.NoLayout)
+			h.Envelope = append(h.Envelope, m[0][0], m[1][0])
+		}
+	}
+	body, err := wkb.Marshal(g, &wkb.EncodeOptions{ByteOrder: order, Flavor: wkb.ISO})
+	if err != nil {
+		return nil, err
+	}
+	return append(h.Append(make([]byte, 0, h.Size()+len(body))), body...), nil
+}
+
+// bounds returns the bounding box of the positions of g, nil when g is
+// empty. With measure the box has the range of M as X, the missing
+// measures counting as 0 like they are written. g is a member of a
+// collection of layout parent.
+func bounds(g *geom.GeometryData, measure bool, parent geom.Layout) *geom.BoundingBox {
+	switch g.Type {
+	case geom.GeometryCollection:
+		if g.Layout != geom.NoLayout {
+			parent = g.Layout
+		}
+		var boxes []*geom.BoundingBox
+		for _, c := range g.Geometries {
+			if c == nil {
+				continue
+			}
+			if b := bounds(c, measure, parent); b != nil {
+				boxes = append(boxes, b)
+			}
+		}
+		return geom.ExpandBoundingBoxs(boxes)
+	case geom.GeometryPoint:
+		if len(g.Point) < 2 || math.IsNaN(g.Point[0]) {
+			return nil
+		}
+	}
+	c := *g
+	c.Layout = geom.MemberLayout(g, parent)
+	g = &c
+	if measure {
+		i := c.Layout.MIndex()
+		c.Layout = geom.XY
+		g = geom.ProcessGeometryData(&c, func(p []float64) []float64 {
+			if i >= 0 && i < len(p) {
+				return []float64{p[i], p[i]}
+			}
+			return []float64{0, 0}
+		})
+	}
+	box := geom.BoundingBoxFromGeometryData(g)
+	if box == nil || box[0][0] > box[1][0] {
+		return nil
+	}
+	return box
+}
@@ -0,0 +1,243 @@
+package gpkg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"testing"
+
+	"github.com/flywave/go-geom"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalUnmarshal(t *testing.T) {
+	polygon := geom.NewPolygonGeometryData([][][]float64{{{0, 0}, {4, 0}, {4, 3}, {0, 0}}})
+	polygon.EPSG = 4326
+	b, err := Marshal(polygon, nil)
+	assert.NoError(t, err)
+	h, body, err := UnmarshalHeader(b)
+	assert.NoError(t, err)
+	assert.Equal(t, binary.LittleEndian, h.ByteOrder)
+	assert.Equal(t, int32(4326), h.SRSID)
+	assert.Equal(t, EnvelopeXY, h.EnvelopeType)
+	assert.Equal(t, []float64{0, 4, 0, 3}, h.Envelope)
+	assert.False(t, h.Empty)
+	assert.Equal(t, 40, len(b)-len(body))
+	// 几何为 ISO WKB
+	assert.Equal(t, []byte{1, 3, 0, 0, 0}, body[:5])
+	ret, err := Unmarshal(b)
+	assert.NoError(t, err)
+	assert.Equal(t, polygon, ret)
+
+	// 测试各种范围
+	line := geom.NewLineStringGeometryData([][]float64{{0, 0, 5}, {2, 1, -1}})
+	point := geom.NewPointGeometryData([]float64{1, 2, 3})
+	point.Layout = geom.XYM
+	measured := geom.NewLineStringGeometryData([][]float64{{0, 0, 7}, {2, 1, 9}})
+	measured.Layout = geom.XYM
+	collection := geom.NewCollectionGeometryData(point, geom.NewLineStringGeometryData([][]float64{{5, 5, 1, 4}, {6, 6, 2, 8}}))
+	collection.Layout = geom.XYZM
+	for _, c := range []struct {
+		g        *geom.GeometryData
+		opt      *EncodeOptions
+		typ      EnvelopeType
+		envelope []float64
+	}{
+		{line, nil, EnvelopeXYZ, []float64{0, 2, 0, 1, -1, 5}},
+		{measured, nil, EnvelopeXYM, []float64{0, 2, 0, 1, 7, 9}},
+		{collection, nil, EnvelopeXYZM, []float64{1, 6, 2, 6, 0, 2, 3, 8}},
+		{point, nil, NoEnvelope, nil},
+		{point, &EncodeOptions{ByteOrder: binary.BigEndian, Envelope: EnvelopeXY}, EnvelopeXY, []float64{1, 1, 2, 2}},
+		{line, &EncodeOptions{Envelope: EnvelopeXYZM, SRSID: 4490}, EnvelopeXYZM, []float64{0, 2, 0, 1, -1, 5, 0, 0}},
+	} {
+		b, err := Marshal(c.g, c.opt)
+		assert.NoError(t, err)
+		h, _, err := UnmarshalHeader(b)
+		assert.NoError(t, err)
+		assert.Equal(t, c.typ, h.EnvelopeType)
+		assert.Equal(t, c.envelope, h.Envelope)
+		ret, err := Unmarshal(b)
+		assert.NoError(t, err)
+		assert.Equal(t, c.g.Type, ret.Type)
+		if c.opt != nil && c.opt.SRSID != 0 {
+			assert.Equal(t, c.opt.SRSID, ret.EPSG)
+		}
+	}
+
+	// 集合中没有布局的成员使用集合的 M
+	xym := geom.NewCollectionGeometryData(geom.NewPointGeometryData([]float64{1, 2, 3}))
+	xym.Layout = geom.XYM
+	b, err = Marshal(xym, nil)
+	assert.NoError(t, err)
+	h, _, err = UnmarshalHeader(b)
+	assert.NoError(t, err)
+	assert.Equal(t, EnvelopeXYM, h.EnvelopeType)
+	assert.Equal(t, []float64{1, 1, 2, 2, 3, 3}, h.Envelope)
+	ret, err = Unmarshal(b)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 2, 3}, ret.Geometries[0].Point)
+
+	b, _ = Marshal(point, &EncodeOptions{ByteOrder: binary.BigEndian})
+	assert.Equal(t, byte(0), b[3]&1)
+	ret, err = Unmarshal(b)
+	assert.NoError(t, err)
+	assert.Equal(t, point, ret)
+
+	// 测试 GDAL 写出的点
+	b, _ = hex.DecodeString("47500001e6100000" + "0101000000000000000000f03f0000000000000040")
+	ret, err = Unmarshal(b)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 2}, ret.Point)
+	assert.Equal(t, 4326, ret.EPSG)
+}
+
+func TestEmptyAndErrors(t *testing.T) {
+	for _, g := range []*geom.GeometryData{
+		geom.NewPointGeometryData(nil),
+		geom.NewPolygonGeometryData(nil),
+		geom.NewCollectionGeometryData(geom.NewMultiPointGeometryData()),
+	} {
+		b, err := Marshal(g, nil)
+		assert.NoError(t, err)
+		h, _, _ := UnmarshalHeader(b)
+		assert.True(t, h.Empty)
+		assert.Equal(t, NoEnvelope, h.EnvelopeType)
+		ret, err := Unmarshal(b)
+		assert.NoError(t, err)
+		assert.Equal(t, g.Type, ret.Type)
+	}
+	// 空几何的范围为 NaN
+	b, _ := Marshal(geom.NewPointGeometryData(nil), &EncodeOptions{Envelope: EnvelopeXY})
+	h, _, _ := UnmarshalHeader(b)
+	assert.True(t, math.IsNaN(h.Envelope[0]))
+
+	_, err := Marshal(nil, nil)
+	assert.Error(t, err)
+	_, err = Marshal(geom.NewPointGeometryData([]float64{1, 2}), &EncodeOptions{Envelope: 5})
+	assert.Error(t, err)
+	_, err = Unmarshal([]byte("GP"))
+	assert.Error(t, err)
+	_, err = Unmarshal([]byte{'G', 'P', 0, 0x0b, 0, 0, 0, 0})
+	assert.Error(t, err)
+	_, err = Unmarshal([]byte{'G', 'P', 0, 0x03, 0, 0, 0, 0, 0})
+	assert.Error(t, err)
+	_, _, err = UnmarshalHeader([]byte{'X', 'P', 0, 0x01, 0, 0, 0, 0})
+	assert.Error(t, err)
+
+	// 扩展格式的内容不是 WKB
+	h = &Header{Extended: true}
+	_, err = Unmarshal(append(h.Append(nil), 'A', 'B', 'C', 'D'))
+	assert.Error(t, err)
+	h, _, err = UnmarshalHeader(h.Append(nil))
+	assert.NoError(t, err)
+	assert.True(t, h.Extended)
+}
+
+type wkbWriter struct {
+	bytes.Buffer
+}
+
+func (w *wkbWriter) header(code uint32) *wkbWriter {
+	w.WriteByte(1)
+	binary.Write(w, binary.LittleEndian, code)
+	return w
+}
+
+func (w *wkbWriter) count(n int) *wkbWriter {
+	binary.Write(w, binary.LittleEndian, uint32(n))
+	return w
+}
+
+func (w *wkbWriter) positions(pts ...[]float64) *wkbWriter {
+	w.count(len(pts))
+	for _, p := range pts {
+		binary.Write(w, binary.LittleEndian, p)
+	}
+	return w
+}
+
+func gpkg(w *wkbWriter) []byte {
+	h := &Header{SRSID: 4326}
+	return append(h.Append(nil), w.Bytes()...)
+}
+
+func TestExtendedTypes(t *testing.T) {
+	onCircle := func(line [][]float64, cx, cy, r float64) {
+		for _, p := range line {
+			assert.InDelta(t, r, math.Hypot(p[0]-cx, p[1]-cy), 1e-9)
+		}
+	}
+
+	// 圆弧
+	w := (&wkbWriter{}).header(wkbCircularString).positions([]float64{0, 0}, []float64{1, 1}, []float64{2, 0})
+	g, err := Unmarshal(gpkg(w))
+	assert.NoError(t, err)
+	assert.Equal(t, geom.GeometryLineString, g.Type)
+	assert.Equal(t, 4326, g.EPSG)
+	assert.Len(t, g.LineString, arcSegments/2+1)
+	assert.Equal(t, []float64{2, 0}, g.LineString[len(g.LineString)-1])
+	onCircle(g.LineString, 1, 0, 1)
+	for _, p := range g.LineString {
+		assert.GreaterOrEqual(t, p[1], -1e-9)
+	}
+
+	// 顺时针带 Z 的复合曲线
+	w = (&wkbWriter{}).header(1000 + wkbCompoundCurve).count(2)
+	w.header(1000+wkbCircularString).positions([]float64{0, 0, 0}, []float64{1, -1, 5}, []float64{2, 0, 10})
+	w.header(1002).positions([]float64{2, 0, 10}, []float64{5, 0, 10})
+	g, err = Unmarshal(gpkg(w))
+	assert.NoError(t, err)
+	n := len(g.LineString)
+	assert.Equal(t, arcSegments/2+2, n)
+	onCircle(g.LineString[:n-1], 1, 0, 1)
+	assert.InDelta(t, 5, g.LineString[n/2-1][2], 1e-9)
+	assert.Equal(t, []float64{5, 0, 10}, g.LineString[n-1])
+
+	// 整圆的曲线多边形
+	w = (&wkbWriter{}).header(wkbCurvePolygon).count(2)
+	w.header(wkbCircularString).positions([]float64{0, 0}, []float64{4, 0}, []float64{0, 0})
+	w.header(2).positions([]float64{1, 0}, []float64{2, 0}, []float64{2, 1}, []float64{1, 0})
+	g, err = Unmarshal(gpkg(w))
+	assert.NoError(t, err)
+	assert.Equal(t, geom.GeometryPolygon, g.Type)
+	assert.Len(t, g.Polygon, 2)
+	assert.Len(t, g.Polygon[0], arcSegments+1)
+	onCircle(g.Polygon[0], 2, 0, 2)
+
+	// TIN 与多曲面
+	w = (&wkbWriter{}).header(wkbTIN).count(2)
+	w.header(wkbTriangle).count(1).positions([]float64{0, 0}, []float64{1, 0}, []float64{0, 1}, []float64{0, 0})
+	w.header(wkbTriangle).count(1).positions([]float64{1, 0}, []float64{1, 1}, []float64{0, 1}, []float64{1, 0})
+	g, err = Unmarshal(gpkg(w))
+	assert.NoError(t, err)
+	assert.Equal(t, geom.GeometryMultiPolygon, g.Type)
+	assert.Len(t, g.MultiPolygon, 2)
+
+	w = (&wkbWriter{}).header(2000 + wkbMultiCurve).count(1)
+	w.header(2000+wkbCircularString).positions([]float64{0, 0, 1}, []float64{1, 1, 2}, []float64{2, 0, 3})
+	g, err = Unmarshal(gpkg(w))
+	assert.NoError(t, err)
+	assert.Equal(t, geom.GeometryMultiLineString, g.Type)
+	assert.Equal(t, geom.XYM, g.Layout)
+	assert.Equal(t, []float64{2, 0, 3}, g.MultiLineString[0][len(g.MultiLineString[0])-1])
+
+	// 集合中的曲线
+	w = (&wkbWriter{}).header(7).count(2)
+	w.header(1).Write(make([]byte, 16))
+	w.header(wkbCircularString).positions([]float64{0, 0}, []float64{1, 1}, []float64{2, 2})
+	g, err = Unmarshal(gpkg(w))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]float64{{0, 0}, {1, 1}, {2, 2}}, g.Geometries[1].LineString)
+
+	for _, w := range []*wkbWriter{
+		(&wkbWriter{}).header(wkbCurve),
+		(&wkbWriter{}).header(wkbCircularString).positions([]float64{0, 0}, []float64{1, 1}),
+		(&wkbWriter{}).header(wkbTIN).count(1).header(3).count(0),
+		(&wkbWriter{}).header(wkbCompoundCurve).count(3),
+		(&wkbWriter{}).header(99),
+	} {
+		_, err = Unmarshal(gpkg(w))
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,198 @@
+package hull
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/measure"
+)
+
+// Circle is a circle in the plane of the geometry.
+type Circle struct {
+	Center []float64
+	Radius float64
+}
+
+// MinimumBoundingCircle returns the smallest circle containing g, nil when
+// g is empty.
+func MinimumBoundingCircle(g geom.Geometry) *Circle {
+	pts := convexHull(points(geom.NewGeometryData(g)))
+	if len(pts) == 0 {
+		return nil
+	}
+	// Welzl 算法的迭代形式, 打乱顺序使期望复杂度为线性
+	r := rand.New(rand.NewSource(1))
+	r.Shuffle(len(pts), func(i, j int) { pts[i], pts[j] = pts[j], pts[i] })
+	c := circle2(pts[0], pts[0])
+	for i := 1; i < len(pts); i++ {
+		if c.contains(pts[i]) {
+			continue
+		}
+		c = circle2(pts[0], pts[i])
+		for j := 1; j < i; j++ {
+			if c.contains(pts[j]) {
+				continue
+			}
+			c = circle2(pts[i], pts[j])
+			for k := 0; k < j; k++ {
+				if !c.contains(pts[k]) {
+					c = circle3(pts[i], pts[j], pts[k])
+				}
+			}
+		}
+	}
+	return c
+}
+
+func circle2(a, b []float64) *Circle {
+	return &Circle{
+		Center: []float64{(a[0] + b[0]) / 2, (a[1] + b[1]) / 2},
+		Radius: math.Hypot(b[0]-a[0], b[1]-a[1]) / 2,
+	}
+}
+
+func circle3(a, b, c []float64) *Circle {
+	if orient(a, b, c) == 0 {
+		// 共线时取最远的两点
+		ret := circle2(a, b)
+		for _, o := range []*Circle{circle2(a, c), circle2(b, c)} {
+			if o.Radius > ret.Radius {
+				ret = o
+			}
+		}
+		return ret
+	}
+	center := circumcenter(a, b, c)
+	return &Circle{Center: center[:], Radius: math.Hypot(a[0]-center[0], a[1]-center[1])}
+}
+
+func (c *Circle) contains(p []float64) bool {
+	return math.Hypot(p[0]-c.Center[0], p[1]-c.Center[1]) <= c.Radius*(1+1e-12)
+}
+
+// MaximumInscribedCircle returns the largest circle inside the polygons of
+// g, nil when g has no area. Its center is the pole of inaccessibility, the
+// point farthest from the boundary, which makes a good label position. The
+// search stops when the radius is known within tolerance, a tolerance of
+// zero or less uses a thousandth of the extent.
+func MaximumInscribedCircle(g geom.Geometry, tolerance float64) *Circle {
+	data := geom.NewGeometryData(g)
+	var polygons [][][][]float64
+	var collect func(g *geom.GeometryData)
+	collect = func(g *geom.GeometryData) {
+		if g == nil {
+			return
+		}
+		switch g.Type {
+		case geom.GeometryPolygon:
+			polygons = append(polygons, g.Polygon)
+		case geom.GeometryMultiPolygon:
+			polygons = append(polygons, g.MultiPolygon...)
+		case geom.GeometryCollection:
+			for _, c := range g.Geometries {
+				collect(c)
+			}
+		}
+	}
+	collect(data)
+	var rings [][][]float64
+	box := [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for _, p := range polygons {
+		for _, r := range p {
+			rings = append(rings, r)
+			for _, pt := range r {
+				box[0], box[1] = math.Min(box[0], pt[0]), math.Min(box[1], pt[1])
+				box[2], box[3] = math.Max(box[2], pt[0]), math.Max(box[3], pt[1])
+			}
+		}
+	}
+	w, h := box[2]-box[0], box[3]-box[1]
+	if len(rings) == 0 || w <= 0 || h <= 0 {
+		return nil
+	}
+	if tolerance <= 0 {
+		tolerance = math.Max(w, h) / 1000
+	}
+
+	// polylabel: 按可能的最大距离优先细分网格
+	distance := func(x, y float64) float64 {
+		inside := false
+		min := math.Inf(1)
+		for _, r := range rings {
+			for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+				a, b := r[i], r[j]
+				if (a[1] > y) != (b[1] > y) && x < (b[0]-a[0])*(y-a[1])/(b[1]-a[1])+a[0] {
+					inside = !inside
+				}
+				min = math.Min(min, segmentDistance(x, y, a, b))
+			}
+		}
+		if inside {
+			return min
+		}
+		return -min
+	}
+	newCell := func(x, y, half float64) cell {
+		d := distance(x, y)
+		return cell{x: x, y: y, half: half, d: d, max: d + half*math.Sqrt2}
+	}
+
+	size := math.Min(w, h)
+	q := &cellQueue{}
+	for x := box[0]; x < box[2]; x += size {
+		for y := box[1]; y < box[3]; y += size {
+			heap.Push(q, newCell(x+size/2, y+size/2, size/2))
+		}
+	}
+	best := newCell(box[0]+w/2, box[1]+h/2, 0)
+	if c := measure.CentroidData(data); c != nil {
+		if centroid := newCell(c[0], c[1], 0); centroid.d > best.d {
+			best = centroid
+		}
+	}
+	for q.Len() > 0 {
+		c := heap.Pop(q).(cell)
+		if c.d > best.d {
+			best = c
+		}
+		if c.max-best.d <= tolerance {
+			continue
+		}
+		half := c.half / 2
+		for _, o := range [4][2]float64{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}} {
+			heap.Push(q, newCell(c.x+o[0]*half, c.y+o[1]*half, half))
+		}
+	}
+	return &Circle{Center: []float64{best.x, best.y}, Radius: math.Max(best.d, 0)}
+}
+
+func segmentDistance(x, y float64, a, b []float64) float64 {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = math.Max(0, math.Min(1, ((x-a[0])*dx+(y-a[1])*dy)/l))
+	}
+	return math.Hypot(x-a[0]-t*dx, y-a[1]-t*dy)
+}
+
+// cell is a square of the polylabel grid, max bounds the distance to the
+// boundary of any point inside it.
+type cell struct {
+	x, y, half float64
+	d, max     float64
+}
+
+type cellQueue []cell
+
+func (q cellQueue) Len() int            { return len(q) }
+func (q cellQueue) Less(i, j int) bool  { return q[i].max > q[j].max }
+func (q cellQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *cellQueue) Push(x interface{}) { *q = append(*q, x.(cell)) }
+func (q *cellQueue) Pop() interface{} {
+	old := *q
+	x := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,190 @@
+package hull
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/overlay"
+)
+
+// ConcaveHull returns a polygon enclosing the points of g, tighter than the
+// convex hull. The Delaunay triangulation of the points is eroded from the
+// outside while its border edges are longer than a length taken at ratio
+// between the shortest and the longest edge: 1 gives the convex hull and 0
+// the tightest hull. The polygon has no hole and contains all the points.
+func ConcaveHull(g geom.Geometry, ratio float64) *geom.GeometryData {
+	data := geom.NewGeometryData(g)
+	pts := distinct(points(data))
+	d := newDelaunay(pts)
+	if len(d.triangles) == 0 {
+		return hullGeometry(convexHull(pts), data)
+	}
+	min, max := math.Inf(1), 0.0
+	for e, o := range d.halfedges {
+		if o < e {
+			l := d.edgeLength(e)
+			min, max = math.Min(min, l), math.Max(max, l)
+		}
+	}
+	return concaveHull(d, min+ratio*(max-min), data)
+}
+
+// ConcaveHullByLength is ConcaveHull eroding the border edges longer than
+// length.
+func ConcaveHullByLength(g geom.Geometry, length float64) *geom.GeometryData {
+	data := geom.NewGeometryData(g)
+	pts := distinct(points(data))
+	d := newDelaunay(pts)
+	if len(d.triangles) == 0 {
+		return hullGeometry(convexHull(pts), data)
+	}
+	return concaveHull(d, length, data)
+}
+
+// AlphaShape returns the union of the Delaunay triangles of the points of g
+// whose circumradius is at most alpha. Unlike ConcaveHull it may have
+// holes, be split in several polygons and leave points out.
+func AlphaShape(g geom.Geometry, alpha float64) *geom.GeometryData {
+	data := geom.NewGeometryData(g)
+	d := newDelaunay(distinct(points(data)))
+	var triangles []*geom.GeometryData
+	for t := 0; t < len(d.triangles); t += 3 {
+		a, b, c := d.pts[d.triangles[t]], d.pts[d.triangles[t+1]], d.pts[d.triangles[t+2]]
+		if math.Sqrt(circumradius(a, b, c)) <= alpha {
+			triangles = append(triangles, geom.NewPolygonGeometryData([][][]float64{{a, b, c, a}}))
+		}
+	}
+	ret := overlay.UnaryUnion(geom.NewCollectionGeometryData(triangles...))
+	if data != nil {
+		ret.EPSG = data.EPSG
+	}
+	return ret
+}
+
+func (d *delaunay) edgeLength(e int) float64 {
+	a, b := d.pts[d.triangles[e]], d.pts[d.triangles[nextEdge(e)]]
+	return math.Hypot(b[0]-a[0], b[1]-a[1])
+}
+
+func nextEdge(e int) int {
+	if e%3 == 2 {
+		return e - 2
+	}
+	return e + 1
+}
+
+func concaveHull(d *delaunay, length float64, g *geom.GeometryData) *geom.GeometryData {
+	n := len(d.triangles) / 3
+	alive := make([]bool, n)
+	for i := range alive {
+		alive[i] = true
+	}
+	border := func(e int) bool {
+		o := d.halfedges[e]
+		return o < 0 || !alive[o/3]
+	}
+	// 每个顶点所在的边界边数
+	degree := make([]int, len(d.pts))
+	longest := func(t int) (float64, int) {
+		l, count := 0.0, 0
+		for e := 3 * t; e < 3*t+3; e++ {
+			if border(e) {
+				l = math.Max(l, d.edgeLength(e))
+				count++
+			}
+		}
+		return l, count
+	}
+
+	q := &triangleQueue{}
+	for e, o := range d.halfedges {
+		if o < 0 {
+			degree[d.triangles[e]]++
+			degree[d.triangles[nextEdge(e)]]++
+			if l, _ := longest(e / 3); l > length {
+				heap.Push(q, queuedTriangle{t: e / 3, length: l})
+			}
+		}
+	}
+	for q.Len() > 0 {
+		item := heap.Pop(q).(queuedTriangle)
+		t := item.t
+		if !alive[t] {
+			continue
+		}
+		l, count := longest(t)
+		// 只移除一条边在边界上的三角形, 否则会丢掉顶点;
+		// 对顶点已在边界上时移除会使多边形断开
+		if l != item.length || l <= length || count != 1 {
+			continue
+		}
+		opposite := -1
+		for e := 3 * t; e < 3*t+3; e++ {
+			if border(e) {
+				opposite = d.triangles[nextEdge(nextEdge(e))]
+			}
+		}
+		if degree[opposite] > 0 {
+			continue
+		}
+		for e := 3 * t; e < 3*t+3; e++ {
+			a, b := d.triangles[e], d.triangles[nextEdge(e)]
+			if border(e) {
+				degree[a]--
+				degree[b]--
+			} else {
+				degree[a]++
+				degree[b]++
+			}
+		}
+		alive[t] = false
+		for e := 3 * t; e < 3*t+3; e++ {
+			if o := d.halfedges[e]; o >= 0 && alive[o/3] {
+				if l, _ := longest(o / 3); l > length {
+					heap.Push(q, queuedTriangle{t: o / 3, length: l})
+				}
+			}
+		}
+	}
+
+	start := -1
+	for e := range d.halfedges {
+		if alive[e/3] && border(e) {
+			start = e
+			break
+		}
+	}
+	var ring [][]float64
+	e := start
+	for {
+		ring = append(ring, d.pts[d.triangles[e]])
+		// 绕终点旋转到下一条边界边
+		c := nextEdge(e)
+		for !border(c) {
+			c = nextEdge(d.halfedges[c])
+		}
+		if e = c; e == start {
+			break
+		}
+	}
+	return hullGeometry(ring, g)
+}
+
+type queuedTriangle struct {
+	t      int
+	length float64
+}
+
+type triangleQueue []queuedTriangle
+
+func (q triangleQueue) Len() int            { return len(q) }
+func (q triangleQueue) Less(i, j int) bool  { return q[i].length > q[j].length }
+func (q triangleQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *triangleQueue) Push(x interface{}) { *q = append(*q, x.(queuedTriangle)) }
+func (q *triangleQueue) Pop() interface{} {
+	old := *q
+	x := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return x
+}
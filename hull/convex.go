@@ -0,0 +1,115 @@
+// Package hull computes the shapes enclosing a geometry: convex and concave
+// hulls, the minimum rotated rectangle and the minimum bounding circle, and
+// the maximum inscribed circle of polygons.
+//
+// Only the X and Y of the coordinates are used. Hulls of collinear points
+// degrade to a LineString and hulls of a single point to a Point, an empty
+// geometry gives an empty GeometryCollection.
+package hull
+
+import (
+	"sort"
+
+	"github.com/flywave/go-geom"
+)
+
+// ConvexHull returns the smallest convex polygon containing g, its shell is
+// counter-clockwise.
+func ConvexHull(g geom.Geometry) *geom.GeometryData {
+	data := geom.NewGeometryData(g)
+	return hullGeometry(convexHull(points(data)), data)
+}
+
+// convexHull returns the hull vertices counter-clockwise, without collinear
+// points and starting from the leftmost one.
+func convexHull(pts [][]float64) [][]float64 {
+	pts = distinct(pts)
+	if len(pts) < 3 {
+		return pts
+	}
+	hull := make([][]float64, 0, 2*len(pts))
+	for _, p := range pts {
+		for len(hull) >= 2 && orient(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(pts) - 2; i >= 0; i-- {
+		p := pts[i]
+		for len(hull) >= lower && orient(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	return hull[:len(hull)-1]
+}
+
+// hullGeometry turns hull vertices into a Polygon, a LineString for two
+// points or a Point, keeping the EPSG of g.
+func hullGeometry(hull [][]float64, g *geom.GeometryData) *geom.GeometryData {
+	var ret *geom.GeometryData
+	switch len(hull) {
+	case 0:
+		ret = geom.NewCollectionGeometryData()
+		ret.Geometries = []*geom.GeometryData{}
+	case 1:
+		ret = geom.NewPointGeometryData(hull[0])
+	case 2:
+		ret = geom.NewLineStringGeometryData(hull)
+	default:
+		ret = geom.NewPolygonGeometryData([][][]float64{append(hull[:len(hull):len(hull)], hull[0])})
+	}
+	if g != nil {
+		ret.EPSG = g.EPSG
+	}
+	return ret
+}
+
+// points returns the 2D coordinates of g.
+func points(g *geom.GeometryData) [][]float64 {
+	var pts [][]float64
+	var walk func(g *geom.GeometryData)
+	walk = func(g *geom.GeometryData) {
+		if g == nil {
+			return
+		}
+		if g.Type == geom.GeometryCollection {
+			for _, c := range g.Geometries {
+				walk(c)
+			}
+			return
+		}
+		geom.ProcessGeometryData(g, func(p []float64) []float64 {
+			if len(p) >= 2 {
+				pts = append(pts, []float64{p[0], p[1]})
+			}
+			return p
+		})
+	}
+	walk(g)
+	return pts
+}
+
+// distinct sorts the points by x then y and drops the duplicates.
+func distinct(pts [][]float64) [][]float64 {
+	sorted := append([][]float64(nil), pts...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i][0] != sorted[j][0] {
+			return sorted[i][0] < sorted[j][0]
+		}
+		return sorted[i][1] < sorted[j][1]
+	})
+	ret := sorted[:0]
+	for _, p := range sorted {
+		if n := len(ret); n > 0 && ret[n-1][0] == p[0] && ret[n-1][1] == p[1] {
+			continue
+		}
+		ret = append(ret, p)
+	}
+	return ret
+}
+
+func orient(a, b, c []float64) float64 {
+	return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+}
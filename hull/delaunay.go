@@ -0,0 +1,275 @@
+package hull
+
+import (
+	"math"
+	"sort"
+)
+
+// delaunay is a Delaunay triangulation built by sweeping the points in
+// order of distance from a seed triangle. Triangle t has the vertices
+// triangles[3t:3t+3] counter-clockwise, half-edge e runs from triangles[e]
+// to the next vertex of its triangle and halfedges[e] is the opposite
+// half-edge, -1 on the convex hull.
+type delaunay struct {
+	pts       [][]float64
+	triangles []int
+	halfedges []int
+
+	hullNext []int
+	hullPrev []int
+	hullEdge map[[2]int]int
+	hash     []int
+	center   [2]float64
+}
+
+// newDelaunay triangulates distinct points, collinear points give no
+// triangle.
+func newDelaunay(pts [][]float64) *delaunay {
+	d := &delaunay{pts: pts, hullEdge: make(map[[2]int]int)}
+	n := len(pts)
+	if n < 3 {
+		return d
+	}
+	i0, i1, i2, ok := d.seed()
+	if !ok {
+		return d
+	}
+	if orient(pts[i0], pts[i1], pts[i2]) < 0 {
+		i1, i2 = i2, i1
+	}
+	d.center = circumcenter(pts[i0], pts[i1], pts[i2])
+
+	ids := make([]int, 0, n)
+	dist := make([]float64, n)
+	for i, p := range pts {
+		dist[i] = sqDist(p, d.center[:])
+		if i != i0 && i != i1 && i != i2 {
+			ids = append(ids, i)
+		}
+	}
+	sort.Slice(ids, func(a, b int) bool { return dist[ids[a]] < dist[ids[b]] })
+
+	d.hullNext = make([]int, n)
+	d.hullPrev = make([]int, n)
+	for i := range d.hullNext {
+		d.hullNext[i], d.hullPrev[i] = -1, -1
+	}
+	d.hash = make([]int, int(math.Ceil(math.Sqrt(float64(n)))))
+	for i := range d.hash {
+		d.hash[i] = -1
+	}
+	for _, v := range [3][2]int{{i0, i1}, {i1, i2}, {i2, i0}} {
+		d.hullNext[v[0]], d.hullPrev[v[1]] = v[1], v[0]
+		d.hash[d.hashKey(pts[v[0]])] = v[0]
+	}
+	d.addTriangle(i0, i1, i2, -1, -1, -1)
+	d.hullEdge[[2]int{i0, i1}] = 0
+	d.hullEdge[[2]int{i1, i2}] = 1
+	d.hullEdge[[2]int{i2, i0}] = 2
+
+	for _, i := range ids {
+		d.add(i)
+	}
+	return d
+}
+
+// seed picks a small triangle near the center of the points.
+func (d *delaunay) seed() (int, int, int, bool) {
+	pts := d.pts
+	box := [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for _, p := range pts {
+		box[0], box[1] = math.Min(box[0], p[0]), math.Min(box[1], p[1])
+		box[2], box[3] = math.Max(box[2], p[0]), math.Max(box[3], p[1])
+	}
+	c := []float64{(box[0] + box[2]) / 2, (box[1] + box[3]) / 2}
+	closest := func(to []float64, skip ...int) int {
+		best, min := -1, math.Inf(1)
+		for i, p := range pts {
+			if i == skip[0] || i == skip[len(skip)-1] {
+				continue
+			}
+			if dd := sqDist(p, to); dd < min {
+				best, min = i, dd
+			}
+		}
+		return best
+	}
+	i0 := closest(c, -1)
+	i1 := closest(pts[i0], i0)
+	i2, min := -1, math.Inf(1)
+	for i, p := range pts {
+		if i == i0 || i == i1 {
+			continue
+		}
+		if r := circumradius(pts[i0], pts[i1], p); r < min {
+			i2, min = i, r
+		}
+	}
+	return i0, i1, i2, i2 >= 0 && !math.IsInf(min, 1)
+}
+
+// add inserts point i, which lies outside of the current hull.
+func (d *delaunay) add(i int) {
+	p := d.pts[i]
+	start := -1
+	key := d.hashKey(p)
+	for j := 0; j < len(d.hash); j++ {
+		start = d.hash[(key+j)%len(d.hash)]
+		if start >= 0 && d.hullNext[start] >= 0 {
+			break
+		}
+	}
+	start = d.hullPrev[start]
+	e := start
+	for orient(d.pts[e], d.pts[d.hullNext[e]], p) >= 0 {
+		e = d.hullNext[e]
+		if e == start {
+			// 重合或位于凸包边上的点
+			return
+		}
+	}
+
+	// 向前添加所有可见边上的三角形
+	n := d.hullNext[e]
+	t := d.addTriangle(e, i, n, -1, -1, d.takeHullEdge(e, n))
+	d.hullEdge[[2]int{e, i}], d.hullEdge[[2]int{i, n}] = t, t+1
+	d.legalize(t + 2)
+	for {
+		nn := d.hullNext[n]
+		if orient(d.pts[n], d.pts[nn], p) >= 0 {
+			break
+		}
+		t = d.addTriangle(n, i, nn, d.takeHullEdge(i, n), -1, d.takeHullEdge(n, nn))
+		d.hullEdge[[2]int{i, nn}] = t + 1
+		d.legalize(t + 2)
+		d.hullNext[n] = -1
+		n = nn
+	}
+	// 向后
+	for {
+		pe := d.hullPrev[e]
+		if orient(d.pts[pe], d.pts[e], p) >= 0 {
+			break
+		}
+		t = d.addTriangle(pe, i, e, -1, d.takeHullEdge(e, i), d.takeHullEdge(pe, e))
+		d.hullEdge[[2]int{pe, i}] = t
+		d.legalize(t + 2)
+		d.hullNext[e] = -1
+		e = pe
+	}
+
+	d.hullNext[e], d.hullPrev[i] = i, e
+	d.hullNext[i], d.hullPrev[n] = n, i
+	d.hash[d.hashKey(p)] = i
+	d.hash[d.hashKey(d.pts[e])] = e
+}
+
+// takeHullEdge removes the hull edge from a to b and returns its half-edge.
+func (d *delaunay) takeHullEdge(a, b int) int {
+	e := d.hullEdge[[2]int{a, b}]
+	delete(d.hullEdge, [2]int{a, b})
+	return e
+}
+
+// addTriangle appends the triangle abc, linking its edges ab, bc and ca to
+// the given opposite half-edges.
+func (d *delaunay) addTriangle(a, b, c, ab, bc, ca int) int {
+	t := len(d.triangles)
+	d.triangles = append(d.triangles, a, b, c)
+	d.halfedges = append(d.halfedges, -1, -1, -1)
+	d.link(t, ab)
+	d.link(t+1, bc)
+	d.link(t+2, ca)
+	return t
+}
+
+func (d *delaunay) link(a, b int) {
+	d.halfedges[a] = b
+	if b >= 0 {
+		d.halfedges[b] = a
+	}
+}
+
+// legalize flips the edges around half-edge a until they all satisfy the
+// Delaunay condition.
+func (d *delaunay) legalize(a int) {
+	stack := []int{a}
+	for len(stack) > 0 {
+		a := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		b := d.halfedges[a]
+		if b < 0 {
+			continue
+		}
+		a0, b0 := a-a%3, b-b%3
+		al, ar := a0+(a+1)%3, a0+(a+2)%3
+		bl, br := b0+(b+2)%3, b0+(b+1)%3
+		p0, pr, pl, p1 := d.triangles[ar], d.triangles[a], d.triangles[al], d.triangles[bl]
+		if !inCircle(d.pts[p0], d.pts[pr], d.pts[pl], d.pts[p1]) {
+			continue
+		}
+		d.triangles[a], d.triangles[b] = p1, p0
+		hbl, har := d.halfedges[bl], d.halfedges[ar]
+		d.link(a, hbl)
+		d.link(b, har)
+		d.link(ar, bl)
+		if hbl < 0 {
+			d.hullEdge[[2]int{p1, pl}] = a
+		}
+		if har < 0 {
+			d.hullEdge[[2]int{p0, pr}] = b
+		}
+		stack = append(stack, br, a)
+	}
+}
+
+func (d *delaunay) hashKey(p []float64) int {
+	dx, dy := p[0]-d.center[0], p[1]-d.center[1]
+	if dx == 0 && dy == 0 {
+		return 0
+	}
+	a := dx / (math.Abs(dx) + math.Abs(dy))
+	if dy > 0 {
+		a = 3 - a
+	} else {
+		a = 1 + a
+	}
+	return int(math.Floor(a/4*float64(len(d.hash)))) % len(d.hash)
+}
+
+// inCircle tells if d lies inside the circle through the counter-clockwise
+// triangle abc.
+func inCircle(a, b, c, d []float64) bool {
+	ax, ay := a[0]-d[0], a[1]-d[1]
+	bx, by := b[0]-d[0], b[1]-d[1]
+	cx, cy := c[0]-d[0], c[1]-d[1]
+	ap := ax*ax + ay*ay
+	bp := bx*bx + by*by
+	cp := cx*cx + cy*cy
+	return ax*(by*cp-bp*cy)-ay*(bx*cp-bp*cx)+ap*(bx*cy-by*cx) > 0
+}
+
+func circumcenter(a, b, c []float64) [2]float64 {
+	bx, by := b[0]-a[0], b[1]-a[1]
+	cx, cy := c[0]-a[0], c[1]-a[1]
+	bl, cl := bx*bx+by*by, cx*cx+cy*cy
+	den := 0.5 / (bx*cy - by*cx)
+	return [2]float64{a[0] + (cy*bl-by*cl)*den, a[1] + (bx*cl-cx*bl)*den}
+}
+
+func circumradius(a, b, c []float64) float64 {
+	bx, by := b[0]-a[0], b[1]-a[1]
+	cx, cy := c[0]-a[0], c[1]-a[1]
+	bl, cl := bx*bx+by*by, cx*cx+cy*cy
+	den := 0.5 / (bx*cy - by*cx)
+	x, y := (cy*bl-by*cl)*den, (bx*cl-cx*bl)*den
+	if math.IsNaN(x) || math.IsInf(x, 0) || math.IsInf(y, 0) {
+		return math.Inf(1)
+	}
+	return x*x + y*y
+}
+
+func sqDist(a, b []float64) float64 {
+	dx, dy := a[0]-b[0], a[1]-b[1]
+	return dx*dx + dy*dy
+}
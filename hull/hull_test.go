@@ -0,0 +1,148 @@
+package hull
+
+import (
+	"math"
+	"testing"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/measure"
+	"github.com/flywave/go-geom/relate"
+	"github.com/flywave/go-geom/valid"
+	"github.com/stretchr/testify/assert"
+)
+
+// C 形的点集
+func cShape() *geom.GeometryData {
+	var pts [][]float64
+	for x := 0.0; x <= 10; x++ {
+		pts = append(pts, []float64{x, 0}, []float64{x, 10})
+	}
+	for y := 1.0; y < 10; y++ {
+		for x := 0.0; x <= 2; x++ {
+			pts = append(pts, []float64{x, y})
+		}
+	}
+	for y := 1.0; y <= 2; y++ {
+		for x := 3.0; x <= 10; x++ {
+			pts = append(pts, []float64{x, y}, []float64{x, 10 - y})
+		}
+	}
+	return geom.NewMultiPointGeometryData(pts...)
+}
+
+func TestConvexHull(t *testing.T) {
+	mp := geom.NewMultiPointGeometryData([]float64{0, 0}, []float64{10, 0}, []float64{5, 5}, []float64{10, 10}, []float64{0, 10}, []float64{5, 0}, []float64{0, 0})
+	mp.EPSG = 4326
+	ret := ConvexHull(mp)
+	assert.Equal(t, geom.GeometryPolygon, ret.Type)
+	assert.Equal(t, [][][]float64{{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}}, ret.Polygon)
+	assert.Equal(t, 4326, ret.EPSG)
+
+	// 共线的点
+	ret = ConvexHull(geom.NewLineStringGeometryData([][]float64{{0, 0}, {1, 1}, {3, 3}, {2, 2}}))
+	assert.Equal(t, geom.GeometryLineString, ret.Type)
+	assert.Equal(t, [][]float64{{0, 0}, {3, 3}}, ret.LineString)
+
+	ret = ConvexHull(geom.NewMultiPointGeometryData([]float64{1, 2, 3}, []float64{1, 2}))
+	assert.Equal(t, geom.GeometryPoint, ret.Type)
+	assert.Equal(t, []float64{1, 2}, ret.Point)
+
+	ret = ConvexHull(geom.NewCollectionGeometryData())
+	assert.Equal(t, geom.GeometryCollection, ret.Type)
+	assert.Len(t, ret.Geometries, 0)
+}
+
+func TestConcaveHull(t *testing.T) {
+	pts := cShape()
+	convex := ConvexHull(pts)
+	assert.True(t, relate.Equals(convex, ConcaveHull(pts, 1)))
+
+	ret := ConcaveHull(pts, 0)
+	assert.Equal(t, geom.GeometryPolygon, ret.Type)
+	assert.True(t, valid.IsValid(ret))
+	assert.True(t, measure.AreaData(ret) < measure.AreaData(convex))
+	// C 形的缺口被挖去
+	assert.InDelta(t, 100-8*6, measure.AreaData(ret), 1e-9)
+	for _, p := range pts.MultiPoint {
+		assert.True(t, relate.Covers(ret, geom.NewPointGeometryData(p)))
+	}
+
+	assert.True(t, relate.Equals(ret, ConcaveHullByLength(pts, 1)))
+	assert.True(t, relate.Equals(convex, ConcaveHullByLength(pts, 100)))
+
+	ret = ConcaveHull(geom.NewMultiPointGeometryData([]float64{0, 0}, []float64{1, 1}), 0)
+	assert.Equal(t, geom.GeometryLineString, ret.Type)
+}
+
+func TestAlphaShape(t *testing.T) {
+	pts := cShape()
+	ret := AlphaShape(pts, 1)
+	assert.True(t, valid.IsValid(ret))
+	// 缺口的两个内角处各留下半个格子
+	assert.InDelta(t, 100-8*6+1, measure.AreaData(ret), 1e-9)
+	assert.True(t, relate.Equals(ConvexHull(pts), AlphaShape(pts, 100)))
+
+	// 相距较远的两组点
+	two := geom.NewMultiPointGeometryData([]float64{0, 0}, []float64{1, 0}, []float64{0, 1}, []float64{10, 0}, []float64{11, 0}, []float64{10, 1})
+	ret = AlphaShape(two, 1)
+	assert.Equal(t, geom.GeometryMultiPolygon, ret.Type)
+	assert.Equal(t, 1.0, measure.AreaData(ret))
+}
+
+func TestMinimumRotatedRectangle(t *testing.T) {
+	diamond := geom.NewPolygonGeometryData([][][]float64{{{0, 0}, {2, 2}, {0, 4}, {-2, 2}, {0, 0}}})
+	ret := MinimumRotatedRectangle(diamond)
+	assert.True(t, relate.Equals(diamond, ret))
+	assert.InDelta(t, 8, measure.AreaData(ret), 1e-9)
+
+	// 倾斜的矩形内部与边上的点
+	var pts [][]float64
+	for i := 0.0; i <= 4; i++ {
+		pts = append(pts, []float64{3 * i, 4 * i}, []float64{3*i - 4, 4*i + 3})
+	}
+	pts = append(pts, []float64{1, 3})
+	ret = MinimumRotatedRectangle(geom.NewMultiPointGeometryData(pts...))
+	assert.Equal(t, geom.GeometryPolygon, ret.Type)
+	assert.InDelta(t, 100, measure.AreaData(ret), 1e-9)
+
+	ret = MinimumRotatedRectangle(geom.NewLineStringGeometryData([][]float64{{0, 0}, {1, 1}, {2, 2}}))
+	assert.Equal(t, geom.GeometryLineString, ret.Type)
+}
+
+func TestMinimumBoundingCircle(t *testing.T) {
+	c := MinimumBoundingCircle(geom.NewMultiPointGeometryData([]float64{0, 0}, []float64{4, 0}, []float64{2, 1}))
+	assert.InDeltaSlice(t, []float64{2, 0}, c.Center, 1e-9)
+	assert.InDelta(t, 2, c.Radius, 1e-9)
+
+	c = MinimumBoundingCircle(geom.NewPolygonGeometryData([][][]float64{{{0, 0}, {4, 0}, {2, 3}, {0, 0}}}))
+	assert.InDeltaSlice(t, []float64{2, 5.0 / 6}, c.Center, 1e-9)
+	assert.InDelta(t, 13.0/6, c.Radius, 1e-9)
+
+	c = MinimumBoundingCircle(cShape())
+	assert.InDeltaSlice(t, []float64{5, 5}, c.Center, 1e-9)
+	assert.InDelta(t, 5*math.Sqrt2, c.Radius, 1e-9)
+
+	c = MinimumBoundingCircle(geom.NewPointGeometryData([]float64{1, 1}))
+	assert.Equal(t, 0.0, c.Radius)
+	assert.Nil(t, MinimumBoundingCircle(geom.NewCollectionGeometryData()))
+}
+
+func TestMaximumInscribedCircle(t *testing.T) {
+	rect := geom.NewPolygonGeometryData([][][]float64{{{0, 0}, {10, 0}, {10, 4}, {0, 4}, {0, 0}}})
+	c := MaximumInscribedCircle(rect, 1e-3)
+	assert.InDelta(t, 2, c.Radius, 1e-3)
+	assert.InDelta(t, 2, c.Center[1], 1e-3)
+
+	// 中心的洞使极点偏离质心, 落在角上
+	holed := geom.NewPolygonGeometryData([][][]float64{{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}, {{2, 2}, {2, 8}, {8, 8}, {8, 2}, {2, 2}}})
+	c = MaximumInscribedCircle(holed, 0)
+	assert.InDelta(t, 2*math.Sqrt2/(1+math.Sqrt2), c.Radius, 1e-2)
+	assert.True(t, relate.Covers(holed, geom.NewPointGeometryData(c.Center)))
+
+	mp := geom.NewMultiPolygonGeometryData(rect.Polygon, [][][]float64{{{20, 0}, {26, 0}, {26, 6}, {20, 6}, {20, 0}}})
+	c = MaximumInscribedCircle(mp, 1e-3)
+	assert.InDeltaSlice(t, []float64{23, 3}, c.Center, 1e-3)
+	assert.InDelta(t, 3, c.Radius, 1e-3)
+
+	assert.Nil(t, MaximumInscribedCircle(geom.NewLineStringGeometryData([][]float64{{0, 0}, {1, 1}}), 0))
+}
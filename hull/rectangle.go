@@ -0,0 +1,57 @@
+package hull
+
+import (
+	"math"
+
+	"github.com/flywave/go-geom"
+)
+
+// MinimumRotatedRectangle returns the rectangle of smallest area containing
+// g, which has a side along an edge of the convex hull. Collinear points
+// give a LineString and a single point a Point.
+func MinimumRotatedRectangle(g geom.Geometry) *geom.GeometryData {
+	data := geom.NewGeometryData(g)
+	hull := convexHull(points(data))
+	n := len(hull)
+	if n < 3 {
+		return hullGeometry(hull, data)
+	}
+
+	// 旋转卡壳: 对每条边, 沿边方向的最远与最近点以及离边最远的点都单调前进
+	dot := func(p []float64, u [2]float64) float64 { return p[0]*u[0] + p[1]*u[1] }
+	var best [][]float64
+	min := math.Inf(1)
+	right, left, top := 0, 0, 0
+	for i := 0; i < n; i++ {
+		a, b := hull[i], hull[(i+1)%n]
+		l := math.Hypot(b[0]-a[0], b[1]-a[1])
+		u := [2]float64{(b[0] - a[0]) / l, (b[1] - a[1]) / l}
+		v := [2]float64{-u[1], u[0]}
+		if i == 0 {
+			right, left, top = 1, 1, 1
+		}
+		for dot(hull[(right+1)%n], u) > dot(hull[right], u) {
+			right = (right + 1) % n
+		}
+		for dot(hull[(top+1)%n], v) > dot(hull[top], v) {
+			top = (top + 1) % n
+		}
+		if i == 0 {
+			left = top
+		}
+		for dot(hull[(left+1)%n], u) < dot(hull[left], u) {
+			left = (left + 1) % n
+		}
+
+		umin, umax := dot(hull[left], u)-dot(a, u), dot(hull[right], u)-dot(a, u)
+		h := dot(hull[top], v) - dot(a, v)
+		if area := (umax - umin) * h; area < min {
+			min = area
+			corner := func(s, t float64) []float64 {
+				return []float64{a[0] + s*u[0] + t*v[0], a[1] + s*u[1] + t*v[1]}
+			}
+			best = [][]float64{corner(umin, 0), corner(umax, 0), corner(umax, h), corner(umin, h)}
+		}
+	}
+	return hullGeometry(best, data)
+}
@@ -0,0 +1,455 @@
+// Package topology builds the planar graph of two geometries noded
+// together. Every vertex, edge and face of the graph is labelled with its
+// location relative to both inputs, which is what relate, overlay and
+// validity checks are computed from.
+package topology
+
+import (
+	"math"
+	"sort"
+
+	"github.com/flywave/go-geom"
+)
+
+type Location int
+
+const (
+	Interior Location = iota
+	Boundary
+	Exterior
+)
+
+// Vertex and Edge keep two locations per input: Loc covers all the parts
+// of the input while Area only considers its polygons.
+type Vertex struct {
+	XY   [2]float64
+	Out  []*HalfEdge
+	Loc  [2]Location
+	Area [2]Location
+
+	point [2]bool
+	ends  [2]int
+}
+
+type Edge struct {
+	Half *HalfEdge
+	Loc  [2]Location
+	Area [2]Location
+	Line [2]bool
+
+	rings    [2][]ringLabel
+	dirs     [2]int
+	backward [2]bool
+}
+
+// ringLabel counts the rings of a polygon along an edge, positive when the
+// interior of the polygon is on the left of the edge.
+type ringLabel struct {
+	poly int
+	wind int
+}
+
+type HalfEdge struct {
+	Origin  *Vertex
+	Twin    *HalfEdge
+	Next    *HalfEdge
+	Edge    *Edge
+	Cycle   *Cycle
+	Forward bool
+}
+
+func (h *HalfEdge) Dest() *Vertex {
+	return h.Twin.Origin
+}
+
+// Cycle is a closed walk of half-edges bounding a face on its left. A face
+// with holes has a cycle for its shell and one for each hole.
+type Cycle struct {
+	Start *HalfEdge
+	Loc   [2]Location
+}
+
+// Graph is the planar subdivision induced by the two inputs.
+type Graph struct {
+	Vertices  []*Vertex
+	Edges     []*Edge
+	Cycles    []*Cycle
+	Tolerance float64
+
+	inputs [2]*input
+}
+
+// New nodes a and b together and labels the resulting graph. b may be nil.
+func New(a, b *geom.GeometryData) *Graph {
+	g := &Graph{inputs: [2]*input{newInput(a), newInput(b)}}
+	g.Tolerance = tolerance(g.inputs[:])
+
+	n := &noder{index: newVertexIndex(g.Tolerance)}
+	for src, in := range g.inputs {
+		for _, p := range in.points {
+			v := n.index.insert([2]float64{p[0], p[1]})
+			n.add(v, v, segLabel{src: src, poly: pointLabel})
+		}
+		for _, l := range in.lines {
+			n.addPath(l, segLabel{src: src, poly: lineLabel})
+		}
+		for k, p := range in.polys {
+			for i, r := range p {
+				ccw := RingSignedArea(r) > 0
+				n.addPath(r, segLabel{src: src, poly: k, left: ccw == (i == 0)})
+			}
+		}
+	}
+	n.node()
+
+	g.build(n)
+	g.label()
+	return g
+}
+
+// Dimension returns the dimension of input i, -1 when it is empty.
+func (g *Graph) Dimension(i int) int {
+	return g.inputs[i].dimension()
+}
+
+func tolerance(inputs []*input) float64 {
+	m := 1.0
+	see := func(p []float64) {
+		m = math.Max(m, math.Max(math.Abs(p[0]), math.Abs(p[1])))
+	}
+	for _, in := range inputs {
+		for _, p := range in.points {
+			see(p)
+		}
+		for _, l := range in.lines {
+			for _, p := range l {
+				see(p)
+			}
+		}
+		for _, b := range in.boxes {
+			see(b[:2])
+			see(b[2:])
+		}
+	}
+	return m * 1e-12
+}
+
+func (g *Graph) build(n *noder) {
+	verts := n.index.verts
+	g.Vertices = make([]*Vertex, len(verts))
+	for i, p := range verts {
+		g.Vertices[i] = &Vertex{XY: p}
+	}
+	for src, in := range g.inputs {
+		for _, l := range in.lines {
+			if len(l) < 2 {
+				continue
+			}
+			for _, p := range [2][]float64{l[0], l[len(l)-1]} {
+				g.Vertices[n.index.insert([2]float64{p[0], p[1]})].ends[src]++
+			}
+		}
+	}
+
+	edges := make(map[[2]int]*Edge)
+	for _, s := range n.segs {
+		if s.label.poly == pointLabel {
+			g.Vertices[s.a].point[s.label.src] = true
+			continue
+		}
+		a, b, forward := s.a, s.b, true
+		if a > b {
+			a, b, forward = b, a, false
+		}
+		e, ok := edges[[2]int{a, b}]
+		if !ok {
+			e = g.newEdge(g.Vertices[a], g.Vertices[b])
+			edges[[2]int{a, b}] = e
+		}
+		if s.label.poly == lineLabel {
+			if !e.Line[s.label.src] {
+				e.Line[s.label.src] = true
+				e.backward[s.label.src] = !forward
+			}
+			continue
+		}
+		wind := 1
+		if s.label.left != forward {
+			wind = -1
+		}
+		e.addRing(s.label.src, s.label.poly, wind)
+		if forward {
+			e.dirs[s.label.src]++
+		} else {
+			e.dirs[s.label.src]--
+		}
+	}
+
+	for _, v := range g.Vertices {
+		sort.Slice(v.Out, func(i, j int) bool { return angle(v.Out[i]) < angle(v.Out[j]) })
+	}
+	for _, v := range g.Vertices {
+		for i, h := range v.Out {
+			// 沿顺时针方向的下一条出边, 使面始终位于半边的左侧
+			h.Twin.Next = v.Out[(i+len(v.Out)-1)%len(v.Out)]
+		}
+	}
+	for _, e := range g.Edges {
+		for _, h := range [2]*HalfEdge{e.Half, e.Half.Twin} {
+			if h.Cycle != nil {
+				continue
+			}
+			c := &Cycle{Start: h}
+			for x := h; x.Cycle == nil; x = x.Next {
+				x.Cycle = c
+			}
+			g.Cycles = append(g.Cycles, c)
+		}
+	}
+}
+
+func (g *Graph) newEdge(a, b *Vertex) *Edge {
+	e := &Edge{}
+	h := &HalfEdge{Origin: a, Edge: e, Forward: true}
+	t := &HalfEdge{Origin: b, Edge: e, Twin: h}
+	h.Twin = t
+	e.Half = h
+	a.Out = append(a.Out, h)
+	b.Out = append(b.Out, t)
+	g.Edges = append(g.Edges, e)
+	return e
+}
+
+func (e *Edge) addRing(src, poly, wind int) {
+	for i := range e.rings[src] {
+		if e.rings[src][i].poly == poly {
+			e.rings[src][i].wind += wind
+			return
+		}
+	}
+	e.rings[src] = append(e.rings[src], ringLabel{poly: poly, wind: wind})
+}
+
+// OnRing tells if the edge lies on a polygon ring of input i.
+func (e *Edge) OnRing(i int) bool {
+	return len(e.rings[i]) > 0
+}
+
+// Winding returns the net count of rings of input i along the half-edge,
+// a ring counting one when it puts its polygon on the left of the
+// half-edge and minus one otherwise. An odd winding means the faces on
+// both sides are separated by the rings.
+func (h *HalfEdge) Winding(i int) int {
+	w := 0
+	for _, r := range h.Edge.rings[i] {
+		w += r.wind
+	}
+	if !h.Forward {
+		w = -w
+	}
+	return w
+}
+
+// Direction returns the net count of rings of input i running along the
+// half-edge, whatever side their polygon is on.
+func (h *HalfEdge) Direction(i int) int {
+	if !h.Forward {
+		return -h.Edge.dirs[i]
+	}
+	return h.Edge.dirs[i]
+}
+
+// LineHalf returns the half-edge running in the direction of the first
+// line of input i along the edge.
+func (e *Edge) LineHalf(i int) *HalfEdge {
+	if e.backward[i] {
+		return e.Half.Twin
+	}
+	return e.Half
+}
+
+func (e *Edge) ringWind(src, poly int) (int, bool) {
+	for _, r := range e.rings[src] {
+		if r.poly == poly {
+			return r.wind, true
+		}
+	}
+	return 0, false
+}
+
+func angle(h *HalfEdge) float64 {
+	a, b := h.Origin.XY, h.Dest().XY
+	return math.Atan2(b[1]-a[1], b[0]-a[0])
+}
+
+func midpoint(h *HalfEdge) [2]float64 {
+	a, b := h.Origin.XY, h.Dest().XY
+	return [2]float64{(a[0] + b[0]) / 2, (a[1] + b[1]) / 2}
+}
+
+// Walk calls fn for every half-edge of the cycle in order.
+func (c *Cycle) Walk(fn func(h *HalfEdge)) {
+	h := c.Start
+	for {
+		fn(h)
+		h = h.Next
+		if h == c.Start {
+			return
+		}
+	}
+}
+
+// SignedArea returns twice the signed area enclosed by the cycle, positive
+// for the shell of a bounded face.
+func (c *Cycle) SignedArea() float64 {
+	o := c.Start.Origin.XY
+	var sum float64
+	c.Walk(func(h *HalfEdge) {
+		a, b := h.Origin.XY, h.Dest().XY
+		sum += (a[0]-o[0])*(b[1]-o[1]) - (b[0]-o[0])*(a[1]-o[1])
+	})
+	return sum
+}
+
+// OuterCycle returns the cycle around the unbounded face of a connected
+// graph, the one of the smallest signed area, or nil without cycles.
+func (g *Graph) OuterCycle() *Cycle {
+	var outer *Cycle
+	min := math.Inf(1)
+	for _, c := range g.Cycles {
+		if a := c.SignedArea(); a < min {
+			outer, min = c, a
+		}
+	}
+	return outer
+}
+
+func (g *Graph) label() {
+	for _, c := range g.Cycles {
+		for i := range g.inputs {
+			c.Loc[i] = g.cycleLocation(c, i)
+		}
+	}
+	for _, e := range g.Edges {
+		for i := range g.inputs {
+			left, right := e.Half.Cycle.Loc[i], e.Half.Twin.Cycle.Loc[i]
+			var loc Location
+			switch {
+			case left != right:
+				loc = Boundary
+			case left == Interior:
+				loc = Interior
+			case e.OnRing(i):
+				loc = Boundary
+			default:
+				loc = Exterior
+			}
+			e.Area[i] = loc
+			if loc == Exterior && e.Line[i] {
+				loc = Interior
+			}
+			e.Loc[i] = loc
+		}
+	}
+	for _, v := range g.Vertices {
+		for i, in := range g.inputs {
+			v.Area[i] = g.vertexArea(v, i, in)
+			v.Loc[i] = vertexLocation(v, i)
+		}
+	}
+}
+
+func (g *Graph) vertexArea(v *Vertex, i int, in *input) Location {
+	area := Exterior
+	if len(v.Out) == 0 {
+		if len(in.polys) > 0 {
+			area = in.locate(v.XY, g.Tolerance)
+		}
+	} else {
+		area = v.Out[0].Cycle.Loc[i]
+		for _, h := range v.Out {
+			if h.Edge.Area[i] == Boundary {
+				return Boundary
+			}
+		}
+	}
+	return area
+}
+
+func vertexLocation(v *Vertex, i int) Location {
+	if v.Area[i] != Exterior {
+		return v.Area[i]
+	}
+	if v.ends[i]%2 == 1 {
+		return Boundary
+	}
+	if v.point[i] || v.ends[i] > 0 {
+		return Interior
+	}
+	for _, h := range v.Out {
+		if h.Edge.Line[i] {
+			return Interior
+		}
+	}
+	return Exterior
+}
+
+// cycleLocation locates the face on the left of c relative to the
+// polygons of input i. Polygons along the cycle are resolved from the ring
+// orientation, the others by a point in polygon test.
+func (g *Graph) cycleLocation(c *Cycle, i int) Location {
+	in := g.inputs[i]
+	if len(in.polys) == 0 {
+		return Exterior
+	}
+	var known map[int]bool
+	interior := false
+	c.Walk(func(h *HalfEdge) {
+		if interior {
+			return
+		}
+		for _, r := range h.Edge.rings[i] {
+			if r.wind == 0 {
+				continue
+			}
+			if (r.wind > 0) == h.Forward {
+				interior = true
+				return
+			}
+			if known == nil {
+				known = make(map[int]bool)
+			}
+			known[r.poly] = true
+		}
+	})
+	if interior {
+		return Interior
+	}
+	pt := midpoint(c.Start)
+	tol := g.Tolerance
+	for k, p := range in.polys {
+		if known[k] {
+			continue
+		}
+		b := in.boxes[k]
+		if pt[0] < b[0]-tol || pt[0] > b[2]+tol || pt[1] < b[1]-tol || pt[1] > b[3]+tol {
+			continue
+		}
+		test := pt
+		if _, ok := c.Start.Edge.ringWind(i, k); ok {
+			found := false
+			c.Walk(func(h *HalfEdge) {
+				if _, ok := h.Edge.ringWind(i, k); !found && !ok {
+					test, found = midpoint(h), true
+				}
+			})
+			if !found {
+				continue
+			}
+		}
+		if LocatePolygon(test, p, tol) == Interior {
+			return Interior
+		}
+	}
+	return Exterior
+}
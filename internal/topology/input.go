@@ -0,0 +1,213 @@
+package topology
+
+import (
+	"math"
+
+	"github.com/flywave/go-geom"
+)
+
+// input is a geometry flattened into its points, lines and polygons. The
+// components of collections are merged so that a collection is handled as
+// the union of its parts.
+type input struct {
+	points [][]float64
+	lines  [][][]float64
+	polys  [][][][]float64
+	boxes  [][4]float64
+}
+
+func newInput(g *geom.GeometryData) *input {
+	in := &input{}
+	if g != nil {
+		in.add(g)
+	}
+	return in
+}
+
+func (in *input) add(g *geom.GeometryData) {
+	switch g.Type {
+	case geom.GeometryPoint:
+		if len(g.Point) >= 2 {
+			in.points = append(in.points, g.Point)
+		}
+	case geom.GeometryMultiPoint:
+		for _, p := range g.MultiPoint {
+			if len(p) >= 2 {
+				in.points = append(in.points, p)
+			}
+		}
+	case geom.GeometryLineString:
+		in.addLine(g.LineString)
+	case geom.GeometryMultiLineString:
+		for _, l := range g.MultiLineString {
+			in.addLine(l)
+		}
+	case geom.GeometryPolygon:
+		in.addPolygon(g.Polygon)
+	case geom.GeometryMultiPolygon:
+		for _, p := range g.MultiPolygon {
+			in.addPolygon(p)
+		}
+	case geom.GeometryCollection:
+		for _, c := range g.Geometries {
+			if c != nil {
+				in.add(c)
+			}
+		}
+	}
+}
+
+func (in *input) addLine(l [][]float64) {
+	l = dedupe(l)
+	switch len(l) {
+	case 0:
+	case 1:
+		in.points = append(in.points, l[0])
+	default:
+		in.lines = append(in.lines, l)
+	}
+}
+
+func (in *input) addPolygon(p [][][]float64) {
+	if len(p) == 0 || len(p[0]) == 0 {
+		return
+	}
+	rings := make([][][]float64, 0, len(p))
+	for _, r := range p {
+		r = closeRing(dedupe(r))
+		if len(r) > 0 {
+			rings = append(rings, r)
+		}
+	}
+	if len(rings) == 0 {
+		return
+	}
+	box := [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for _, pt := range rings[0] {
+		box[0] = math.Min(box[0], pt[0])
+		box[1] = math.Min(box[1], pt[1])
+		box[2] = math.Max(box[2], pt[0])
+		box[3] = math.Max(box[3], pt[1])
+	}
+	in.polys = append(in.polys, rings)
+	in.boxes = append(in.boxes, box)
+}
+
+func (in *input) empty() bool {
+	return len(in.points) == 0 && len(in.lines) == 0 && len(in.polys) == 0
+}
+
+// Dimension returns the topological dimension of the input, -1 when empty.
+func (in *input) dimension() int {
+	switch {
+	case len(in.polys) > 0:
+		return 2
+	case len(in.lines) > 0:
+		return 1
+	case len(in.points) > 0:
+		return 0
+	}
+	return -1
+}
+
+// locate classifies pt against the polygons of the input.
+func (in *input) locate(pt [2]float64, tol float64) Location {
+	loc := Exterior
+	for i, p := range in.polys {
+		b := in.boxes[i]
+		if pt[0] < b[0]-tol || pt[0] > b[2]+tol || pt[1] < b[1]-tol || pt[1] > b[3]+tol {
+			continue
+		}
+		switch LocatePolygon(pt, p, tol) {
+		case Interior:
+			return Interior
+		case Boundary:
+			loc = Boundary
+		}
+	}
+	return loc
+}
+
+// LocatePolygon classifies pt against a polygon given by its rings, the
+// first one being the shell.
+func LocatePolygon(pt [2]float64, polygon [][][]float64, tol float64) Location {
+	if len(polygon) == 0 {
+		return Exterior
+	}
+	loc := LocateRing(pt, polygon[0], tol)
+	if loc != Interior {
+		return loc
+	}
+	for _, hole := range polygon[1:] {
+		switch LocateRing(pt, hole, tol) {
+		case Interior:
+			return Exterior
+		case Boundary:
+			return Boundary
+		}
+	}
+	return Interior
+}
+
+// LocateRing classifies pt against the area enclosed by ring using the
+// crossing number, points within tol of an edge are on the boundary.
+func LocateRing(pt [2]float64, ring [][]float64, tol float64) Location {
+	n := len(ring)
+	if n == 0 {
+		return Exterior
+	}
+	inside := false
+	x, y := pt[0], pt[1]
+	for i := 0; i < n; i++ {
+		a := ring[i]
+		b := ring[(i+1)%n]
+		if segmentDistance(pt, [2]float64{a[0], a[1]}, [2]float64{b[0], b[1]}) <= tol {
+			return Boundary
+		}
+		if (a[1] > y) != (b[1] > y) {
+			xi := a[0] + (y-a[1])*(b[0]-a[0])/(b[1]-a[1])
+			if x < xi {
+				inside = !inside
+			}
+		}
+	}
+	if inside {
+		return Interior
+	}
+	return Exterior
+}
+
+func dedupe(l [][]float64) [][]float64 {
+	ret := make([][]float64, 0, len(l))
+	for _, p := range l {
+		if len(p) < 2 {
+			continue
+		}
+		if n := len(ret); n > 0 && ret[n-1][0] == p[0] && ret[n-1][1] == p[1] {
+			continue
+		}
+		ret = append(ret, p)
+	}
+	return ret
+}
+
+func closeRing(r [][]float64) [][]float64 {
+	if n := len(r); n > 1 && (r[0][0] != r[n-1][0] || r[0][1] != r[n-1][1]) {
+		r = append(r[:n:n], r[0])
+	}
+	return r
+}
+
+// RingSignedArea returns twice the signed area of ring, positive when it
+// is counter-clockwise.
+func RingSignedArea(ring [][]float64) float64 {
+	if len(ring) < 3 {
+		return 0
+	}
+	x0, y0 := ring[0][0], ring[0][1]
+	var sum float64
+	for i := 1; i < len(ring)-1; i++ {
+		sum += (ring[i][0]-x0)*(ring[i+1][1]-y0) - (ring[i+1][0]-x0)*(ring[i][1]-y0)
+	}
+	return sum
+}
@@ -0,0 +1,220 @@
+package topology
+
+import (
+	"math"
+	"sort"
+)
+
+// maxNodingPasses bounds the noding iterations, each pass only has work
+// to do when snapping moved a vertex onto another segment.
+const maxNodingPasses = 8
+
+// vertexIndex snaps coordinates closer than tol to a single vertex so the
+// noded edges share their end points exactly.
+type vertexIndex struct {
+	tol   float64
+	cell  float64
+	grid  map[[2]int64][]int
+	verts [][2]float64
+}
+
+func newVertexIndex(tol float64) *vertexIndex {
+	return &vertexIndex{tol: tol, cell: 2 * tol, grid: make(map[[2]int64][]int)}
+}
+
+func (vi *vertexIndex) key(p [2]float64) [2]int64 {
+	return [2]int64{int64(math.Floor(p[0] / vi.cell)), int64(math.Floor(p[1] / vi.cell))}
+}
+
+func (vi *vertexIndex) insert(p [2]float64) int {
+	k := vi.key(p)
+	for dx := int64(-1); dx <= 1; dx++ {
+		for dy := int64(-1); dy <= 1; dy++ {
+			for _, id := range vi.grid[[2]int64{k[0] + dx, k[1] + dy}] {
+				if math.Hypot(vi.verts[id][0]-p[0], vi.verts[id][1]-p[1]) <= vi.tol {
+					return id
+				}
+			}
+		}
+	}
+	id := len(vi.verts)
+	vi.verts = append(vi.verts, p)
+	vi.grid[k] = append(vi.grid[k], id)
+	return id
+}
+
+// segLabel tells where a segment comes from. poly is -1 for lines and -2
+// for points, which are kept as degenerate segments while noding.
+type segLabel struct {
+	src  int
+	poly int
+	left bool
+}
+
+const (
+	lineLabel  = -1
+	pointLabel = -2
+)
+
+type segment struct {
+	a, b  int
+	label segLabel
+	cuts  []int
+	box   [4]float64
+}
+
+type noder struct {
+	index *vertexIndex
+	segs  []*segment
+}
+
+func (n *noder) add(a, b int, label segLabel) {
+	n.segs = append(n.segs, &segment{a: a, b: b, label: label})
+}
+
+func (n *noder) addPath(path [][]float64, label segLabel) {
+	prev := -1
+	for _, p := range path {
+		v := n.index.insert([2]float64{p[0], p[1]})
+		if prev >= 0 && v != prev {
+			n.add(prev, v, label)
+		}
+		prev = v
+	}
+}
+
+func (n *noder) node() {
+	for pass := 0; pass < maxNodingPasses; pass++ {
+		if !n.intersect() {
+			return
+		}
+		n.split()
+	}
+}
+
+func (n *noder) intersect() bool {
+	verts := n.index.verts
+	tol := n.index.tol
+	for _, s := range n.segs {
+		p, q := verts[s.a], verts[s.b]
+		s.box = [4]float64{math.Min(p[0], q[0]), math.Min(p[1], q[1]), math.Max(p[0], q[0]), math.Max(p[1], q[1])}
+		s.cuts = s.cuts[:0]
+	}
+	sort.Slice(n.segs, func(i, j int) bool { return n.segs[i].box[0] < n.segs[j].box[0] })
+
+	changed := false
+	for i, s := range n.segs {
+		for _, t := range n.segs[i+1:] {
+			if t.box[0] > s.box[2]+tol {
+				break
+			}
+			if t.box[1] > s.box[3]+tol || t.box[3] < s.box[1]-tol {
+				continue
+			}
+			if n.intersectPair(s, t) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func (n *noder) intersectPair(s, t *segment) bool {
+	verts := n.index.verts
+	tol := n.index.tol
+	changed := false
+	cut := func(seg *segment, v int) {
+		if v != seg.a && v != seg.b && seg.a != seg.b {
+			seg.cuts = append(seg.cuts, v)
+			changed = true
+		}
+	}
+	for _, v := range [2]int{t.a, t.b} {
+		if v != s.a && v != s.b && segmentDistance(verts[v], verts[s.a], verts[s.b]) <= tol {
+			cut(s, v)
+		}
+	}
+	for _, v := range [2]int{s.a, s.b} {
+		if v != t.a && v != t.b && segmentDistance(verts[v], verts[t.a], verts[t.b]) <= tol {
+			cut(t, v)
+		}
+	}
+	if s.a == s.b || t.a == t.b {
+		return changed
+	}
+	if pt, ok := crossing(verts[s.a], verts[s.b], verts[t.a], verts[t.b]); ok {
+		v := n.index.insert(pt)
+		cut(s, v)
+		cut(t, v)
+	}
+	return changed
+}
+
+// split replaces the segments with cuts by their pieces.
+func (n *noder) split() {
+	verts := n.index.verts
+	segs := make([]*segment, 0, len(n.segs))
+	for _, s := range n.segs {
+		if len(s.cuts) == 0 || s.a == s.b {
+			segs = append(segs, s)
+			continue
+		}
+		p, q := verts[s.a], verts[s.b]
+		dx, dy := q[0]-p[0], q[1]-p[1]
+		param := func(v int) float64 {
+			return (verts[v][0]-p[0])*dx + (verts[v][1]-p[1])*dy
+		}
+		cuts := s.cuts
+		sort.Slice(cuts, func(i, j int) bool { return param(cuts[i]) < param(cuts[j]) })
+		prev := s.a
+		for _, v := range append(cuts, s.b) {
+			if v == prev {
+				continue
+			}
+			segs = append(segs, &segment{a: prev, b: v, label: s.label})
+			prev = v
+		}
+	}
+	n.segs = segs
+}
+
+// crossing returns the point where two segments properly cross.
+func crossing(p1, p2, q1, q2 [2]float64) ([2]float64, bool) {
+	d1 := orient(p1, p2, q1)
+	d2 := orient(p1, p2, q2)
+	d3 := orient(q1, q2, p1)
+	d4 := orient(q1, q2, p2)
+	if !((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) || !((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return [2]float64{}, false
+	}
+	rx, ry := p2[0]-p1[0], p2[1]-p1[1]
+	sx, sy := q2[0]-q1[0], q2[1]-q1[1]
+	t := ((q1[0]-p1[0])*sy - (q1[1]-p1[1])*sx) / (rx*sy - ry*sx)
+	pt := [2]float64{p1[0] + t*rx, p1[1] + t*ry}
+	// 保证交点落在两条线段的外包框内
+	pt[0] = clamp(pt[0], math.Max(math.Min(p1[0], p2[0]), math.Min(q1[0], q2[0])), math.Min(math.Max(p1[0], p2[0]), math.Max(q1[0], q2[0])))
+	pt[1] = clamp(pt[1], math.Max(math.Min(p1[1], p2[1]), math.Min(q1[1], q2[1])), math.Min(math.Max(p1[1], p2[1]), math.Max(q1[1], q2[1])))
+	return pt, true
+}
+
+func clamp(v, lo, hi float64) float64 {
+	if lo > hi {
+		return v
+	}
+	return math.Max(lo, math.Min(hi, v))
+}
+
+func orient(a, b, c [2]float64) float64 {
+	return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+}
+
+func segmentDistance(p, a, b [2]float64) float64 {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	l2 := dx*dx + dy*dy
+	if l2 == 0 {
+		return math.Hypot(p[0]-a[0], p[1]-a[1])
+	}
+	t := ((p[0]-a[0])*dx + (p[1]-a[1])*dy) / l2
+	t = math.Max(0, math.Min(1, t))
+	return math.Hypot(p[0]-a[0]-t*dx, p[1]-a[1]-t*dy)
+}
@@ -0,0 +1,121 @@
+package topology
+
+import (
+	"math"
+	"sort"
+)
+
+type ring struct {
+	coords [][]float64
+	area   float64
+	box    [4]float64
+}
+
+func newRing(coords [][]float64) *ring {
+	r := &ring{coords: coords, area: RingSignedArea(coords)}
+	r.box = [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for _, p := range coords {
+		r.box[0] = math.Min(r.box[0], p[0])
+		r.box[1] = math.Min(r.box[1], p[1])
+		r.box[2] = math.Max(r.box[2], p[0])
+		r.box[3] = math.Max(r.box[3], p[1])
+	}
+	return r
+}
+
+func (r *ring) covers(o *ring) bool {
+	return r.box[0] <= o.box[0] && r.box[1] <= o.box[1] && r.box[2] >= o.box[2] && r.box[3] >= o.box[3]
+}
+
+// Polygons traces the rings between the faces selected by inArea and the
+// others, then puts each hole in the smallest shell around it. Shells are
+// counter-clockwise and holes clockwise.
+func (g *Graph) Polygons(inArea func(*Cycle) bool) [][][][]float64 {
+	isBoundary := func(h *HalfEdge) bool {
+		return inArea(h.Cycle) && !inArea(h.Twin.Cycle)
+	}
+	visited := make(map[*HalfEdge]bool)
+	var shells, holes []*ring
+	for _, e := range g.Edges {
+		for _, start := range [2]*HalfEdge{e.Half, e.Half.Twin} {
+			if visited[start] || !isBoundary(start) {
+				continue
+			}
+			var coords [][]float64
+			h := start
+			for !visited[h] {
+				visited[h] = true
+				coords = append(coords, []float64{h.Origin.XY[0], h.Origin.XY[1]})
+				// 绕终点顺时针旋转, 找到下一条结果边界
+				x := h.Next
+				for !isBoundary(x) {
+					x = x.Twin.Next
+				}
+				h = x
+			}
+			for _, loop := range splitRing(coords) {
+				r := newRing(loop)
+				switch {
+				case r.area > 0:
+					shells = append(shells, r)
+				case r.area < 0:
+					holes = append(holes, r)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(shells, func(i, j int) bool { return shells[i].area < shells[j].area })
+	polygons := make([][][][]float64, len(shells))
+	for i, s := range shells {
+		polygons[i] = [][][]float64{s.coords}
+	}
+	for _, h := range holes {
+		for i, s := range shells {
+			if s.covers(h) && ringInside(h, s, g.Tolerance) {
+				polygons[i] = append(polygons[i], h.coords)
+				break
+			}
+		}
+	}
+	return polygons
+}
+
+// splitRing cuts a traced ring touching itself at a vertex into simple
+// closed loops, an inverted hole pinched to its shell becomes a hole.
+func splitRing(coords [][]float64) [][][]float64 {
+	var loops [][][]float64
+	stack := make([][]float64, 0, len(coords))
+	at := make(map[[2]float64]int)
+	for _, p := range append(coords, coords[0]) {
+		k := [2]float64{p[0], p[1]}
+		if i, ok := at[k]; ok {
+			loop := append(append([][]float64(nil), stack[i:]...), p)
+			for _, q := range stack[i+1:] {
+				delete(at, [2]float64{q[0], q[1]})
+			}
+			stack = stack[:i+1]
+			loops = append(loops, loop)
+			continue
+		}
+		at[k] = len(stack)
+		stack = append(stack, p)
+	}
+	return loops
+}
+
+// ringInside tells if r lies inside shell, the rings may share vertices.
+func ringInside(r, shell *ring, tol float64) bool {
+	for i := 0; i < len(r.coords)-1; i++ {
+		a, b := r.coords[i], r.coords[i+1]
+		for _, pt := range [2][2]float64{{a[0], a[1]}, {(a[0] + b[0]) / 2, (a[1] + b[1]) / 2}} {
+			switch LocateRing(pt, shell.coords, tol) {
+			case Interior:
+				return true
+			case Exterior:
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,463 @@
+package kml
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/flywave/go-geom"
+)
+
+// DecodeOptions controls the reading of KML documents.
+type DecodeOptions struct {
+	// PathProperty is the property holding the names of the folders of a
+	// placemark, none when empty.
+	PathProperty string
+	// PathSeparator joins the folder names of PathProperty.
+	PathSeparator string
+}
+
+func DefaultDecodeOptions() *DecodeOptions {
+	return &DecodeOptions{PathProperty: "path", PathSeparator: "/"}
+}
+
+// node is an element of a KML document, compared on local names so that the
+// gx extensions are read alike.
+type node struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Content string     `xml:",chardata"`
+	Nodes   []node     `xml:",any"`
+}
+
+func (n *node) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (n *node) child(name string) *node {
+	for i := range n.Nodes {
+		if n.Nodes[i].XMLName.Local == name {
+			return &n.Nodes[i]
+		}
+	}
+	return nil
+}
+
+func (n *node) text(name string) string {
+	if e := n.child(name); e != nil {
+		return strings.TrimSpace(e.Content)
+	}
+	return ""
+}
+
+// schema maps the SimpleField names of a Schema to their types.
+type schema map[string]string
+
+type decoder struct {
+	opt     *DecodeOptions
+	schemas map[string]schema
+	fc      *geom.FeatureCollection
+}
+
+// collectSchemas reads the Schema declarations of the document, by id and by
+// name.
+func (d *decoder) collectSchemas(n *node) {
+	for i := range n.Nodes {
+		e := &n.Nodes[i]
+		if e.XMLName.Local != "Schema" {
+			d.collectSchemas(e)
+			continue
+		}
+		s := make(schema)
+		for j := range e.Nodes {
+			if f := &e.Nodes[j]; f.XMLName.Local == "SimpleField" {
+				s[f.attr("name")] = f.attr("type")
+			}
+		}
+		if id := e.attr("id"); id != "" {
+			d.schemas[id] = s
+		}
+		if name := e.attr("name"); name != "" {
+			d.schemas[name] = s
+		}
+	}
+}
+
+// container walks a Document or Folder, folders adding their names to the
+// path of the placemarks.
+func (d *decoder) container(n *node, folders []string) error {
+	for i := range n.Nodes {
+		e := &n.Nodes[i]
+		switch e.XMLName.Local {
+		case "kml", "Document":
+			if err := d.container(e, folders); err != nil {
+				return err
+			}
+		case "Folder":
+			sub := folders
+			if name := e.text("name"); name != "" {
+				sub = append(folders[:len(folders):len(folders)], name)
+			}
+			if err := d.container(e, sub); err != nil {
+				return err
+			}
+		case "Placemark":
+			f, err := d.placemark(e, folders)
+			if err != nil {
+				return err
+			}
+			d.fc.AddFeature(f)
+		}
+	}
+	return nil
+}
+
+func (d *decoder) placemark(n *node, folders []string) (*geom.Feature, error) {
+	f := &geom.Feature{
+		Type:       "Feature",
+		Properties: make(map[string]interface{}),
+		ExtData:    make(map[string]interface{}),
+	}
+	if id := n.attr("id"); id != "" {
+		f.ID = id
+	}
+	for _, name := range []string{"name", "description"} {
+		if e := n.child(name); e != nil {
+			f.Properties[name] = strings.TrimSpace(e.Content)
+		}
+	}
+	if d.opt.PathProperty != "" && len(folders) > 0 {
+		f.Properties[d.opt.PathProperty] = strings.Join(folders, d.opt.PathSeparator)
+	}
+	if e := n.child("ExtendedData"); e != nil {
+		if err := d.extendedData(e, f.Properties); err != nil {
+			return nil, err
+		}
+	}
+	for i := range n.Nodes {
+		e := &n.Nodes[i]
+		if !geometryNames[e.XMLName.Local] {
+			continue
+		}
+		g, err := geometry(e, f.ExtData)
+		if err != nil {
+			return nil, err
+		}
+		f.GeometryData = *g
+		f.BoundingBox = geom.BoundingBoxFromGeometryData(g)
+		break
+	}
+	return f, nil
+}
+
+// extendedData reads the Data and SchemaData of a placemark, SimpleData
+// being converted to the type of its SimpleField.
+func (d *decoder) extendedData(n *node, props map[string]interface{}) error {
+	for i := range n.Nodes {
+		e := &n.Nodes[i]
+		switch e.XMLName.Local {
+		case "Data":
+			props[e.attr("name")] = e.text("value")
+		case "SchemaData":
+			s := d.schemas[strings.TrimPrefix(e.attr("schemaUrl"), "#")]
+			for j := range e.Nodes {
+				sd := &e.Nodes[j]
+				if sd.XMLName.Local != "SimpleData" {
+					continue
+				}
+				name := sd.attr("name")
+				v, err := simpleValue(strings.TrimSpace(sd.Content), s[name])
+				if err != nil {
+					return fmt.Errorf("kml simple data %s: %v", name, err)
+				}
+				props[name] = v
+			}
+		}
+	}
+	return nil
+}
+
+func simpleValue(s, typ string) (interface{}, error) {
+	if s == "" {
+		return s, nil
+	}
+	switch typ {
+	case "int", "short":
+		return strconv.ParseInt(s, 10, 64)
+	case "uint", "ushort":
+		return strconv.ParseUint(s, 10, 64)
+	case "float", "double":
+		return strconv.ParseFloat(s, 64)
+	case "bool":
+		return strconv.ParseBool(s)
+	}
+	return s, nil
+}
+
+var geometryNames = map[string]bool{
+	"Point": true, "LineString": true, "LinearRing": true, "Polygon": true,
+	"MultiGeometry": true, "Track": true, "MultiTrack": true,
+}
+
+// coordinates reads the lon,lat[,alt] tuples of a coordinates element.
+func coordinates(s string) ([][]float64, error) {
+	var ret [][]float64
+	for _, t := range strings.Fields(s) {
+		var p []float64
+		for _, f := range strings.Split(t, ",") {
+			v, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid kml coordinate %q", f)
+			}
+			p = append(p, v)
+		}
+		if len(p) < 2 {
+			return nil, fmt.Errorf("invalid kml coordinate %q", t)
+		}
+		ret = append(ret, p)
+	}
+	return ret, nil
+}
+
+// altitudeMode keeps the first altitudeMode of the geometries in ext.
+func altitudeMode(n *node, ext map[string]interface{}) {
+	if _, ok := ext["altitudeMode"]; ok {
+		return
+	}
+	if m := n.text("altitudeMode"); m != "" {
+		ext["altitudeMode"] = m
+	}
+}
+
+func ring(n *node) ([][]float64, error) {
+	if r := n.child("LinearRing"); r != nil {
+		return coordinates(r.text("coordinates"))
+	}
+	return nil, fmt.Errorf("kml %s without LinearRing", n.XMLName.Local)
+}
+
+// track reads the gx:coord positions of a gx:Track, its when values going
+// to ext.
+func track(n *node, ext map[string]interface{}) ([][]float64, error) {
+	var ret [][]float64
+	var when []string
+	for i := range n.Nodes {
+		e := &n.Nodes[i]
+		switch e.XMLName.Local {
+		case "coord":
+			p, err := parseFloats(e.Content)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, p)
+		case "when":
+			when = append(when, strings.TrimSpace(e.Content))
+		}
+	}
+	if len(when) > 0 {
+		w, _ := ext["when"].([]string)
+		ext["when"] = append(w, when...)
+	}
+	return ret, nil
+}
+
+func parseFloats(s string) ([]float64, error) {
+	fields := strings.Fields(s)
+	ret := make([]float64, len(fields))
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kml coordinate %q", f)
+		}
+		ret[i] = v
+	}
+	return ret, nil
+}
+
+func geometry(n *node, ext map[string]interface{}) (*geom.GeometryData, error) {
+	altitudeMode(n, ext)
+	switch n.XMLName.Local {
+	case "Point":
+		pts, err := coordinates(n.text("coordinates"))
+		if err != nil {
+			return nil, err
+		}
+		if len(pts) != 1 {
+			return nil, fmt.Errorf("kml point with %d coordinates", len(pts))
+		}
+		return geom.NewPointGeometryData(pts[0]), nil
+	case "LineString", "LinearRing":
+		pts, err := coordinates(n.text("coordinates"))
+		if err != nil {
+			return nil, err
+		}
+		return geom.NewLineStringGeometryData(pts), nil
+	case "Polygon":
+		var rings [][][]float64
+		for i := range n.Nodes {
+			b := &n.Nodes[i]
+			switch b.XMLName.Local {
+			case "outerBoundaryIs":
+				r, err := ring(b)
+				if err != nil {
+					return nil, err
+				}
+				rings = append([][][]float64{r}, rings...)
+			case "innerBoundaryIs":
+				// 一个内边界可以包含多个环
+				for j := range b.Nodes {
+					if b.Nodes[j].XMLName.Local != "LinearRing" {
+						continue
+					}
+					r, err := coordinates(b.Nodes[j].text("coordinates"))
+					if err != nil {
+						return nil, err
+					}
+					rings = append(rings, r)
+				}
+			}
+		}
+		return geom.NewPolygonGeometryData(rings), nil
+	case "Track":
+		pts, err := track(n, ext)
+		if err != nil {
+			return nil, err
+		}
+		return geom.NewLineStringGeometryData(pts), nil
+	case "MultiTrack":
+		var lines [][][]float64
+		for i := range n.Nodes {
+			if t := &n.Nodes[i]; t.XMLName.Local == "Track" {
+				altitudeMode(t, ext)
+				pts, err := track(t, ext)
+				if err != nil {
+					return nil, err
+				}
+				lines = append(lines, pts)
+			}
+		}
+		return geom.NewMultiLineStringGeometryData(lines...), nil
+	case "MultiGeometry":
+		var geoms []*geom.GeometryData
+		for i := range n.Nodes {
+			if e := &n.Nodes[i]; geometryNames[e.XMLName.Local] {
+				g, err := geometry(e, ext)
+				if err != nil {
+					return nil, err
+				}
+				geoms = append(geoms, g)
+			}
+		}
+		return multiGeometry(geoms), nil
+	}
+	return nil, fmt.Errorf("unsupported kml geometry %s", n.XMLName.Local)
+}
+
+// multiGeometry returns the multi geometry of geoms when they all are
+// points, lines or polygons, a collection otherwise.
+func multiGeometry(geoms []*geom.GeometryData) *geom.GeometryData {
+	if len(geoms) == 0 {
+		return geom.NewCollectionGeometryData()
+	}
+	for _, g := range geoms[1:] {
+		if g.Type != geoms[0].Type {
+			return geom.NewCollectionGeometryData(geoms...)
+		}
+	}
+	switch geoms[0].Type {
+	case geom.GeometryPoint:
+		pts := make([][]float64, len(geoms))
+		for i, g := range geoms {
+			pts[i] = g.Point
+		}
+		return geom.NewMultiPointGeometryData(pts...)
+	case geom.GeometryLineString:
+		lines := make([][][]float64, len(geoms))
+		for i, g := range geoms {
+			lines[i] = g.LineString
+		}
+		return geom.NewMultiLineStringGeometryData(lines...)
+	case geom.GeometryPolygon:
+		polys := make([][][][]float64, len(geoms))
+		for i, g := range geoms {
+			polys[i] = g.Polygon
+		}
+		return geom.NewMultiPolygonGeometryData(polys...)
+	}
+	return geom.NewCollectionGeometryData(geoms...)
+}
+
+// Decode reads the placemarks of a KML document as features, the folders
+// being flattened into the path property.
+func Decode(r io.Reader, opt *DecodeOptions) (*geom.FeatureCollection, error) {
+	if opt == nil {
+		opt = DefaultDecodeOptions()
+	}
+	var n node
+	if err := xml.NewDecoder(r).Decode(&n); err != nil {
+		return nil, err
+	}
+	d := &decoder{opt: opt, schemas: make(map[string]schema), fc: geom.NewFeatureCollection()}
+	d.collectSchemas(&n)
+	if err := d.container(&node{Nodes: []node{n}}, nil); err != nil {
+		return nil, err
+	}
+	return d.fc, nil
+}
+
+// DecodeKMZ reads the main KML document of a KMZ archive, doc.kml or else
+// the first .kml file at its root.
+func DecodeKMZ(r io.ReaderAt, size int64, opt *DecodeOptions) (*geom.FeatureCollection, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	var doc *zip.File
+	for _, f := range z.File {
+		if f.Name == "doc.kml" {
+			doc = f
+			break
+		}
+		if doc == nil && path.Dir(f.Name) == "." && strings.EqualFold(path.Ext(f.Name), ".kml") {
+			doc = f
+		}
+	}
+	if doc == nil {
+		return nil, errors.New("kmz without kml document")
+	}
+	rc, err := doc.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return Decode(rc, opt)
+}
+
+// Unmarshal decodes a KML document, or a KMZ archive when data is zipped.
+func Unmarshal(data []byte, opt *DecodeOptions) (*geom.FeatureCollection, error) {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return DecodeKMZ(bytes.NewReader(data), int64(len(data)), opt)
+	}
+	return Decode(bytes.NewReader(data), opt)
+}
+
+// ReadFile decodes the KML or KMZ file at filename.
+func ReadFile(filename string, opt *DecodeOptions) (*geom.FeatureCollection, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data, opt)
+}
@@ -9,6 +9,8 @@ import (
 
 func Encode(g geom.Geometry) (kml.Element, error) {
 	switch g := g.(type) {
+	case *geom.GeometryData:
+		return EncodeGeometryData(g)
 	case geom.Point3:
 		return EncodePoint3(g), nil
 	case geom.Point:
@@ -197,3 +199,87 @@ func EncodeGeometryCollection(geoms geom.Collection) (kml.Element, error) {
 	}
 	return kml.MultiGeometry(geometries...), nil
 }
+
+// coords flattens pts to x,y or x,y,z ordinates, M being dropped.
+func coords(pts [][]float64, layout geom.Layout) *kml.CoordinatesFlatElement {
+	dim := 2
+	if layout.HasZ() {
+		dim = 3
+	}
+	ret := make([]float64, len(pts)*dim)
+	for i, p := range pts {
+		copy(ret[i*dim:i*dim+2], p)
+		if z := layout.ZIndex(); z >= 0 && z < len(p) {
+			ret[i*dim+2] = p[z]
+		}
+	}
+	return kml.CoordinatesFlat(ret, 0, len(ret), dim, dim)
+}
+
+func encodeRings(rings [][][]float64, layout geom.Layout) kml.Element {
+	boundaries := make([]kml.Element, len(rings))
+	for i, r := range rings {
+		c := coords(r, layout)
+		if i == 0 {
+			boundaries[i] = kml.OuterBoundaryIs(kml.LinearRing(c))
+		} else {
+			boundaries[i] = kml.InnerBoundaryIs(kml.LinearRing(c))
+		}
+	}
+	return kml.Polygon(boundaries...)
+}
+
+// EncodeGeometryData encodes g with the altitudes of its layout, the
+// measures having no KML counterpart.
+func EncodeGeometryData(g *geom.GeometryData) (kml.Element, error) {
+	return encodeGeometryData(g, geom.NoLayout)
+}
+
+// encodeGeometryData encodes g, a member of a collection of layout parent.
+func encodeGeometryData(g *geom.GeometryData, parent geom.Layout) (kml.Element, error) {
+	layout := geom.MemberLayout(g, parent)
+	switch g.Type {
+	case geom.GeometryPoint:
+		c := coords([][]float64{g.Point}, layout)
+		return kml.Point(c), nil
+	case geom.GeometryMultiPoint:
+		points := make([]kml.Element, len(g.MultiPoint))
+		for i, p := range g.MultiPoint {
+			c := coords([][]float64{p}, layout)
+			points[i] = kml.Point(c)
+		}
+		return kml.MultiGeometry(points...), nil
+	case geom.GeometryLineString:
+		c := coords(g.LineString, layout)
+		return kml.LineString(c), nil
+	case geom.GeometryMultiLineString:
+		lineStrings := make([]kml.Element, len(g.MultiLineString))
+		for i, ls := range g.MultiLineString {
+			c := coords(ls, layout)
+			lineStrings[i] = kml.LineString(c)
+		}
+		return kml.MultiGeometry(lineStrings...), nil
+	case geom.GeometryPolygon:
+		return encodeRings(g.Polygon, layout), nil
+	case geom.GeometryMultiPolygon:
+		polygons := make([]kml.Element, len(g.MultiPolygon))
+		for i, p := range g.MultiPolygon {
+			polygons[i] = encodeRings(p, layout)
+		}
+		return kml.MultiGeometry(polygons...), nil
+	case geom.GeometryCollection:
+		geometries := make([]kml.Element, len(g.Geometries))
+		if g.Layout != geom.NoLayout {
+			parent = g.Layout
+		}
+		for i, c := range g.Geometries {
+			var err error
+			geometries[i], err = encodeGeometryData(c, parent)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return kml.MultiGeometry(geometries...), nil
+	}
+	return nil, fmt.Errorf("unsupport geom type %s", g.Type)
+}
@@ -1,11 +1,16 @@
 package kml
 
 import (
+	"archive/zip"
+	"bytes"
 	"encoding/xml"
+	"image/color"
 	"strings"
 	"testing"
 
+	"github.com/flywave/go-geom"
 	"github.com/flywave/go-geom/general"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestKmlPoint(t *testing.T) {
@@ -273,3 +278,199 @@ func TestKmlGeometryCollection(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+// 测试集合中没有布局的成员丢弃集合的 M
+func TestKmlCollectionLayout(t *testing.T) {
+	g := geom.NewCollectionGeometryData(geom.NewPointGeometryData([]float64{1, 2, 3}))
+	g.Layout = geom.XYM
+	sb := &strings.Builder{}
+	element, err := EncodeGeometryData(g)
+	assert.NoError(t, err)
+	xml.NewEncoder(sb).Encode(element)
+	assert.Equal(t, "<MultiGeometry><Point><coordinates>1,2</coordinates></Point></MultiGeometry>", sb.String())
+}
+
+const testDocument = `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2" xmlns:gx="http://www.google.com/kml/ext/2.2">
+<Document>
+  <name>doc</name>
+  <Schema name="poi" id="poiSchema">
+    <SimpleField type="int" name="rank"/>
+    <SimpleField type="double" name="height"/>
+    <SimpleField type="string" name="kind"/>
+  </Schema>
+  <Placemark id="p1">
+    <name>Top</name>
+    <description>top level</description>
+    <ExtendedData>
+      <Data name="owner"><value>city</value></Data>
+      <SchemaData schemaUrl="#poiSchema">
+        <SimpleData name="rank">3</SimpleData>
+        <SimpleData name="height">12.5</SimpleData>
+        <SimpleData name="kind">tower</SimpleData>
+      </SchemaData>
+    </ExtendedData>
+    <Point><altitudeMode>absolute</altitudeMode><coordinates>1,2,30</coordinates></Point>
+  </Placemark>
+  <Folder>
+    <name>A</name>
+    <Folder>
+      <name>B</name>
+      <Placemark>
+        <MultiGeometry>
+          <LineString><coordinates>0,0 1,1</coordinates></LineString>
+          <LineString><coordinates>2,2 3,3</coordinates></LineString>
+        </MultiGeometry>
+      </Placemark>
+    </Folder>
+    <Placemark>
+      <Polygon>
+        <gx:altitudeMode>relativeToSeaFloor</gx:altitudeMode>
+        <outerBoundaryIs><LinearRing><coordinates>0,0 4,0 4,4 0,0</coordinates></LinearRing></outerBoundaryIs>
+        <innerBoundaryIs><LinearRing><coordinates>1,1 2,1 2,2 1,1</coordinates></LinearRing></innerBoundaryIs>
+      </Polygon>
+    </Placemark>
+  </Folder>
+  <Placemark>
+    <gx:Track>
+      <when>2020-05-28T02:02:09Z</when>
+      <when>2020-05-28T02:02:35Z</when>
+      <gx:coord>-122.207881 37.371915 156.0</gx:coord>
+      <gx:coord>-122.205712 37.373288 152.0</gx:coord>
+    </gx:Track>
+  </Placemark>
+  <Placemark>
+    <MultiGeometry>
+      <Point><coordinates>0,0</coordinates></Point>
+      <LineString><coordinates>0,0 1,1</coordinates></LineString>
+    </MultiGeometry>
+  </Placemark>
+</Document>
+</kml>`
+
+// 测试读取 KML 文档
+func TestDecode(t *testing.T) {
+	fc, err := Decode(strings.NewReader(testDocument), nil)
+	assert.NoError(t, err)
+	assert.Len(t, fc.Features, 5)
+
+	f := fc.Features[0]
+	assert.Equal(t, "p1", f.ID)
+	assert.Equal(t, "Top", f.Properties["name"])
+	assert.Equal(t, "top level", f.Properties["description"])
+	assert.Equal(t, "city", f.Properties["owner"])
+	assert.Equal(t, int64(3), f.Properties["rank"])
+	assert.Equal(t, 12.5, f.Properties["height"])
+	assert.Equal(t, "tower", f.Properties["kind"])
+	assert.Nil(t, f.Properties["path"])
+	assert.Equal(t, []float64{1, 2, 30}, f.GeometryData.Point)
+	assert.Equal(t, "absolute", f.ExtData["altitudeMode"])
+
+	f = fc.Features[1]
+	assert.Equal(t, "A/B", f.Properties["path"])
+	assert.Equal(t, geom.GeometryMultiLineString, f.GeometryData.Type)
+	assert.Len(t, f.GeometryData.MultiLineString, 2)
+
+	f = fc.Features[2]
+	assert.Equal(t, "A", f.Properties["path"])
+	assert.Equal(t, "relativeToSeaFloor", f.ExtData["altitudeMode"])
+	assert.Equal(t, [][][]float64{{{0, 0}, {4, 0}, {4, 4}, {0, 0}}, {{1, 1}, {2, 1}, {2, 2}, {1, 1}}}, f.GeometryData.Polygon)
+
+	f = fc.Features[3]
+	assert.Equal(t, [][]float64{{-122.207881, 37.371915, 156}, {-122.205712, 37.373288, 152}}, f.GeometryData.LineString)
+	assert.Equal(t, []string{"2020-05-28T02:02:09Z", "2020-05-28T02:02:35Z"}, f.ExtData["when"])
+
+	f = fc.Features[4]
+	assert.Equal(t, geom.GeometryCollection, f.GeometryData.Type)
+	assert.Len(t, f.GeometryData.Geometries, 2)
+
+	// 自定义路径属性
+	fc, err = Decode(strings.NewReader(testDocument), &DecodeOptions{PathProperty: "folder", PathSeparator: " > "})
+	assert.NoError(t, err)
+	assert.Equal(t, "A > B", fc.Features[1].Properties["folder"])
+
+	_, err = Decode(strings.NewReader(`<kml><Placemark><Point><coordinates>a,b</coordinates></Point></Placemark></kml>`), nil)
+	assert.Error(t, err)
+}
+
+// 测试读取 KMZ 文件
+func TestDecodeKMZ(t *testing.T) {
+	var buf bytes.Buffer
+	z := zip.NewWriter(&buf)
+	w, _ := z.Create("files/icon.kml")
+	w.Write([]byte("<kml/>"))
+	w, _ = z.Create("doc.kml")
+	w.Write([]byte(testDocument))
+	z.Close()
+
+	fc, err := Unmarshal(buf.Bytes(), nil)
+	assert.NoError(t, err)
+	assert.Len(t, fc.Features, 5)
+
+	buf.Reset()
+	z = zip.NewWriter(&buf)
+	z.Create("readme.txt")
+	z.Close()
+	_, err = Unmarshal(buf.Bytes(), nil)
+	assert.Error(t, err)
+}
+
+// 测试写出 KML 文档
+func TestWrite(t *testing.T) {
+	fc := geom.NewFeatureCollection()
+	f := geom.NewPointFeature([]float64{1, 2, 30})
+	f.ID = 7
+	f.Properties["name"] = "Tower"
+	f.Properties["kind"] = "tower"
+	// 属性中的 <no value> 原样写出
+	f.Properties["note"] = "<no value>"
+	f.Properties["height"] = 12.5
+	f.ExtData["altitudeMode"] = "absolute"
+	fc.AddFeature(f)
+	f = geom.NewPolygonFeature([][][]float64{{{0, 0}, {4, 0}, {4, 4}, {0, 0}}})
+	f.Properties["kind"] = "park"
+	f.Properties["tags"] = []string{"a", "b"}
+	fc.AddFeature(f)
+	f = geom.NewFeatureFromGeometryData(&geom.GeometryData{Type: geom.GeometryLineString, LineString: [][]float64{{0, 0, 5}, {1, 1, 6}}, Layout: geom.XYM})
+	f.ExtData["altitudeMode"] = "clampToSeaFloor"
+	fc.AddFeature(f)
+
+	opt := &EncodeOptions{
+		Name:                "doc",
+		DescriptionTemplate: "{{.kind}}{{.missing}}{{if .note}} {{.note}}{{end}}",
+		Styles: []StyleRule{
+			{Property: "kind", Value: "park", LineColor: color.RGBA{0, 255, 0, 255}, LineWidth: 2, FillColor: color.RGBA{0, 128, 0, 128}},
+			{Icon: "http://example.com/icon.png", IconScale: 1.5},
+		},
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, Write(&buf, fc, opt))
+	s := buf.String()
+	assert.Contains(t, s, `xmlns:gx="http://www.google.com/kml/ext/2.2"`)
+	assert.Contains(t, s, `<Style id="style0"><LineStyle><color>ff00ff00</color><width>2</width></LineStyle><PolyStyle><color>80008000</color><fill>1</fill></PolyStyle></Style>`)
+	assert.Contains(t, s, `<Placemark id="7"><name>Tower</name><description>tower &lt;no value&gt;</description><styleUrl>#style1</styleUrl>`)
+	assert.Contains(t, s, `<Point><altitudeMode>absolute</altitudeMode><coordinates>1,2,30</coordinates></Point>`)
+	assert.Contains(t, s, `<styleUrl>#style0</styleUrl>`)
+	assert.Contains(t, s, `<Data name="tags"><value>[&#34;a&#34;,&#34;b&#34;]</value></Data>`)
+	assert.Contains(t, s, `<coordinates>0,0 1,1</coordinates>`)
+
+	// 读回
+	back, err := Decode(&buf, nil)
+	assert.NoError(t, err)
+	assert.Len(t, back.Features, 3)
+	assert.Equal(t, "7", back.Features[0].ID)
+	assert.Equal(t, "Tower", back.Features[0].Properties["name"])
+	assert.Equal(t, "12.5", back.Features[0].Properties["height"])
+	assert.Equal(t, "absolute", back.Features[0].ExtData["altitudeMode"])
+	assert.Equal(t, fc.Features[1].GeometryData.Polygon, back.Features[1].GeometryData.Polygon)
+	assert.Equal(t, "clampToSeaFloor", back.Features[2].ExtData["altitudeMode"])
+
+	buf.Reset()
+	assert.NoError(t, WriteKMZ(&buf, fc, nil))
+	back, err = Unmarshal(buf.Bytes(), nil)
+	assert.NoError(t, err)
+	assert.Len(t, back.Features, 3)
+
+	_, err = EncodeFeatureCollection(fc, &EncodeOptions{NameTemplate: "{{"})
+	assert.Error(t, err)
+}
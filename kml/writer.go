@@ -0,0 +1,359 @@
+package kml
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/flywave/go-geom"
+	"github.com/twpayne/go-kml/v3"
+)
+
+// StyleRule styles the placemarks of the features whose Property equals
+// Value, or of every feature when Property is empty.
+type StyleRule struct {
+	Property string
+	Value    interface{}
+
+	LineColor color.Color
+	LineWidth float64
+	// FillColor fills the polygons, which are only outlined when it is nil.
+	FillColor color.Color
+	Icon      string
+	IconScale float64
+}
+
+func (r *StyleRule) match(props map[string]interface{}) bool {
+	if r.Property == "" {
+		return true
+	}
+	v, ok := props[r.Property]
+	return ok && fmt.Sprint(v) == fmt.Sprint(r.Value)
+}
+
+func (r *StyleRule) style(id string) kml.Element {
+	var children []kml.Element
+	if r.Icon != "" {
+		icon := []kml.Element{kml.Icon(kml.Href(r.Icon))}
+		if r.IconScale > 0 {
+			icon = append([]kml.Element{kml.Scale(r.IconScale)}, icon...)
+		}
+		children = append(children, kml.IconStyle(icon...))
+	}
+	if r.LineColor != nil || r.LineWidth > 0 {
+		var line []kml.Element
+		if r.LineColor != nil {
+			line = append(line, kml.Color(r.LineColor))
+		}
+		if r.LineWidth > 0 {
+			line = append(line, kml.Width(r.LineWidth))
+		}
+		children = append(children, kml.LineStyle(line...))
+	}
+	if r.FillColor != nil {
+		children = append(children, kml.PolyStyle(kml.Color(r.FillColor), kml.Fill(true)))
+	} else {
+		children = append(children, kml.PolyStyle(kml.Fill(false)))
+	}
+	return kml.SharedStyle(id, children...)
+}
+
+// EncodeOptions controls the writing of KML documents.
+type EncodeOptions struct {
+	// Name is the name of the document.
+	Name string
+	// NameTemplate and DescriptionTemplate are text/template executed on
+	// the properties of a feature. When empty the name and description
+	// properties are used.
+	NameTemplate        string
+	DescriptionTemplate string
+	// Styles are the style rules, the first matching one styling a feature.
+	Styles []StyleRule
+}
+
+func DefaultEncodeOptions() *EncodeOptions {
+	return &EncodeOptions{}
+}
+
+// placemarkElement is a Placemark with an id, which go-kml does not write.
+type placemarkElement struct {
+	ID       string
+	Children []kml.Element
+}
+
+func (e *placemarkElement) MarshalXML(encoder *xml.Encoder, _ xml.StartElement) error {
+	start := xml.StartElement{Name: xml.Name{Local: "Placemark"}}
+	if e.ID != "" {
+		start.Attr = []xml.Attr{{Name: xml.Name{Local: "id"}, Value: e.ID}}
+	}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, c := range e.Children {
+		if err := encoder.Encode(c); err != nil {
+			return err
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+type encoder struct {
+	opt         *EncodeOptions
+	name        *template.Template
+	description *template.Template
+	gx          bool
+}
+
+func newEncoder(opt *EncodeOptions) (*encoder, error) {
+	if opt == nil {
+		opt = DefaultEncodeOptions()
+	}
+	e := &encoder{opt: opt}
+	var err error
+	if opt.NameTemplate != "" {
+		if e.name, err = template.New("name").Parse(opt.NameTemplate); err != nil {
+			return nil, err
+		}
+	}
+	if opt.DescriptionTemplate != "" {
+		if e.description, err = template.New("description").Parse(opt.DescriptionTemplate); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+func styleID(i int) string {
+	return fmt.Sprintf("style%d", i)
+}
+
+// text executes t on props, or returns the property name when t is nil.
+func text(t *template.Template, props map[string]interface{}, name string) (string, error) {
+	if t == nil {
+		if v, ok := props[name]; ok && v != nil {
+			return fmt.Sprint(v), nil
+		}
+		return "", nil
+	}
+	// 缺失或为 nil 的属性输出为空
+	fields := make(map[string]bool)
+	templateFields(t.Tree.Root, fields)
+	data := make(map[string]interface{}, len(props)+len(fields))
+	for k, v := range props {
+		data[k] = v
+	}
+	for k := range fields {
+		if data[k] == nil {
+			data[k] = ""
+		}
+	}
+	var sb strings.Builder
+	if err := t.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// templateFields adds to fields the properties named by the .name fields
+// of the template node n.
+func templateFields(n parse.Node, fields map[string]bool) {
+	switch n := n.(type) {
+	case *parse.ListNode:
+		if n != nil {
+			for _, c := range n.Nodes {
+				templateFields(c, fields)
+			}
+		}
+	case *parse.ActionNode:
+		templateFields(n.Pipe, fields)
+	case *parse.PipeNode:
+		if n != nil {
+			for _, c := range n.Cmds {
+				templateFields(c, fields)
+			}
+		}
+	case *parse.CommandNode:
+		for _, a := range n.Args {
+			templateFields(a, fields)
+		}
+	case *parse.FieldNode:
+		if len(n.Ident) == 1 {
+			fields[n.Ident[0]] = true
+		}
+	case *parse.IfNode:
+		templateBranchFields(&n.BranchNode, fields)
+	case *parse.RangeNode:
+		templateBranchFields(&n.BranchNode, fields)
+	case *parse.WithNode:
+		templateBranchFields(&n.BranchNode, fields)
+	case *parse.TemplateNode:
+		templateFields(n.Pipe, fields)
+	}
+}
+
+func templateBranchFields(n *parse.BranchNode, fields map[string]bool) {
+	templateFields(n.Pipe, fields)
+	templateFields(n.List, fields)
+	templateFields(n.ElseList, fields)
+}
+
+// dataValue returns v as written by kml.Value, nested values as JSON.
+func dataValue(v interface{}) (interface{}, error) {
+	switch v.(type) {
+	case string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// altitudeMode adds the altitude mode to the geometries of e.
+func (enc *encoder) altitudeMode(e kml.Element, mode string) {
+	var m kml.Element
+	switch kml.AltitudeModeEnum(mode) {
+	case kml.AltitudeModeClampToGround, kml.AltitudeModeRelativeToGround, kml.AltitudeModeAbsolute:
+		m = kml.AltitudeMode(kml.AltitudeModeEnum(mode))
+	default:
+		m = kml.GxAltitudeMode(kml.GxAltitudeModeEnum(mode))
+		enc.gx = true
+	}
+	switch e := e.(type) {
+	case *kml.PointElement:
+		e.Children = append([]kml.Element{m}, e.Children...)
+	case *kml.LineStringElement:
+		e.Children = append([]kml.Element{m}, e.Children...)
+	case *kml.PolygonElement:
+		e.Children = append([]kml.Element{m}, e.Children...)
+	case *kml.MultiGeometryElement:
+		for _, c := range e.Children {
+			enc.altitudeMode(c, mode)
+		}
+	}
+}
+
+func (enc *encoder) placemark(f *geom.Feature) (kml.Element, error) {
+	p := &placemarkElement{}
+	if f.ID != nil {
+		p.ID = fmt.Sprint(f.ID)
+	}
+	name, err := text(enc.name, f.Properties, "name")
+	if err != nil {
+		return nil, err
+	}
+	if name != "" {
+		p.Children = append(p.Children, kml.Name(name))
+	}
+	description, err := text(enc.description, f.Properties, "description")
+	if err != nil {
+		return nil, err
+	}
+	if description != "" {
+		p.Children = append(p.Children, kml.Description(description))
+	}
+	for i := range enc.opt.Styles {
+		if enc.opt.Styles[i].match(f.Properties) {
+			p.Children = append(p.Children, kml.StyleURL("#"+styleID(i)))
+			break
+		}
+	}
+	keys := make([]string, 0, len(f.Properties))
+	for k, v := range f.Properties {
+		if v != nil {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) > 0 {
+		sort.Strings(keys)
+		data := make([]kml.Element, 0, len(keys))
+		for _, k := range keys {
+			v, err := dataValue(f.Properties[k])
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, kml.Data(k, kml.Value(v)))
+		}
+		p.Children = append(p.Children, kml.ExtendedData(data...))
+	}
+	if f.GeometryData.Type != "" {
+		g, err := EncodeGeometryData(&f.GeometryData)
+		if err != nil {
+			return nil, err
+		}
+		if mode, ok := f.ExtData["altitudeMode"].(string); ok && mode != "" {
+			enc.altitudeMode(g, mode)
+		}
+		p.Children = append(p.Children, g)
+	}
+	return p, nil
+}
+
+func (enc *encoder) document(fc *geom.FeatureCollection) (kml.Element, error) {
+	var children []kml.Element
+	if enc.opt.Name != "" {
+		children = append(children, kml.Name(enc.opt.Name))
+	}
+	for i := range enc.opt.Styles {
+		children = append(children, enc.opt.Styles[i].style(styleID(i)))
+	}
+	for _, f := range fc.Features {
+		p, err := enc.placemark(f)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, p)
+	}
+	doc := kml.Document(children...)
+	if enc.gx {
+		return kml.GxKML(doc), nil
+	}
+	return kml.KML(doc), nil
+}
+
+// EncodeFeature returns the Placemark of f, its properties as ExtendedData.
+func EncodeFeature(f *geom.Feature, opt *EncodeOptions) (kml.Element, error) {
+	enc, err := newEncoder(opt)
+	if err != nil {
+		return nil, err
+	}
+	return enc.placemark(f)
+}
+
+// EncodeFeatureCollection returns the kml element of a document holding a
+// Placemark per feature and the shared styles of the style rules.
+func EncodeFeatureCollection(fc *geom.FeatureCollection, opt *EncodeOptions) (kml.Element, error) {
+	enc, err := newEncoder(opt)
+	if err != nil {
+		return nil, err
+	}
+	return enc.document(fc)
+}
+
+// Write writes the KML document of fc to w.
+func Write(w io.Writer, fc *geom.FeatureCollection, opt *EncodeOptions) error {
+	doc, err := EncodeFeatureCollection(fc, opt)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(doc)
+}
+
+// WriteKMZ writes the KML document of fc as the doc.kml of a KMZ archive.
+func WriteKMZ(w io.Writer, fc *geom.FeatureCollection, opt *EncodeOptions) error {
+	doc, err := EncodeFeatureCollection(fc, opt)
+	if err != nil {
+		return err
+	}
+	return kml.WriteKMZ(w, map[string]interface{}{"doc.kml": doc})
+}
@@ -0,0 +1,147 @@
+package measure
+
+import (
+	"github.com/flywave/go-geom"
+)
+
+type centroid struct {
+	areaSum  float64
+	areaX    float64
+	areaY    float64
+	lineSum  float64
+	lineX    float64
+	lineY    float64
+	ptCount  int
+	ptX      float64
+	ptY      float64
+	hasPolys bool
+}
+
+// Centroid returns the centroid of g as an {x, y} pair, or nil when g is
+// empty. As with the OGC definition, only the highest dimension present
+// contributes: areas dominate lines and lines dominate points, so that the
+// centroid of a collection does not drift towards its lower dimensional
+// parts. Degenerate polygons fall back to the centroid of their rings.
+func Centroid(g geom.Geometry) []float64 {
+	return CentroidData(geom.NewGeometryData(g))
+}
+
+func CentroidData(g *geom.GeometryData) []float64 {
+	if g == nil {
+		return nil
+	}
+	c := &centroid{}
+	c.add(g)
+	return c.result()
+}
+
+func (c *centroid) add(g *geom.GeometryData) {
+	switch g.Type {
+	case geom.GeometryPoint:
+		if len(g.Point) >= 2 {
+			c.addPoint(g.Point)
+		}
+	case geom.GeometryMultiPoint:
+		for _, p := range g.MultiPoint {
+			c.addPoint(p)
+		}
+	case geom.GeometryLineString:
+		c.addPath(g.LineString)
+	case geom.GeometryMultiLineString:
+		for _, l := range g.MultiLineString {
+			c.addPath(l)
+		}
+	case geom.GeometryPolygon:
+		c.addPolygon(g.Polygon)
+	case geom.GeometryMultiPolygon:
+		for _, p := range g.MultiPolygon {
+			c.addPolygon(p)
+		}
+	case geom.GeometryCollection:
+		for _, child := range g.Geometries {
+			if child != nil {
+				c.add(child)
+			}
+		}
+	}
+}
+
+func (c *centroid) addPoint(pt []float64) {
+	c.ptCount++
+	c.ptX += pt[0]
+	c.ptY += pt[1]
+}
+
+func (c *centroid) addPath(path [][]float64) {
+	for i := 1; i < len(path); i++ {
+		c.addSegment(path[i-1], path[i])
+	}
+	for _, pt := range path {
+		c.addPoint(pt)
+	}
+}
+
+func (c *centroid) addSegment(a, b []float64) {
+	l := distance(a, b, -1)
+	c.lineSum += l
+	c.lineX += l * (a[0] + b[0]) / 2
+	c.lineY += l * (a[1] + b[1]) / 2
+}
+
+func (c *centroid) addPolygon(polygon [][][]float64) {
+	if len(polygon) == 0 {
+		return
+	}
+	c.hasPolys = true
+	for i, ring := range polygon {
+		c.addRing(ring, i == 0)
+	}
+}
+
+func (c *centroid) addRing(ring [][]float64, shell bool) {
+	if len(ring) == 0 {
+		return
+	}
+	x0, y0 := ring[0][0], ring[0][1]
+	var area, cx, cy float64
+	for i := 1; i < len(ring)-1; i++ {
+		x1, y1 := ring[i][0]-x0, ring[i][1]-y0
+		x2, y2 := ring[i+1][0]-x0, ring[i+1][1]-y0
+		cross := x1*y2 - x2*y1
+		area += cross
+		cx += cross * (x1 + x2)
+		cy += cross * (y1 + y2)
+	}
+	// shells add area and holes remove it, whatever way they are wound
+	sign := 1.0
+	if (area < 0) == shell {
+		sign = -1
+	}
+	area *= sign / 2
+	c.areaSum += area
+	c.areaX += sign*cx/6 + area*x0
+	c.areaY += sign*cy/6 + area*y0
+
+	for i := 1; i < len(ring); i++ {
+		c.addSegment(ring[i-1], ring[i])
+	}
+	if n := len(ring); n > 2 && !samePoint(ring[0], ring[n-1]) {
+		c.addSegment(ring[n-1], ring[0])
+	}
+	for _, pt := range ring {
+		c.addPoint(pt)
+	}
+}
+
+func (c *centroid) result() []float64 {
+	switch {
+	case c.hasPolys && c.areaSum != 0:
+		return []float64{c.areaX / c.areaSum, c.areaY / c.areaSum}
+	case c.lineSum > 0:
+		return []float64{c.lineX / c.lineSum, c.lineY / c.lineSum}
+	case c.ptCount > 0:
+		n := float64(c.ptCount)
+		return []float64{c.ptX / n, c.ptY / n}
+	}
+	return nil
+}
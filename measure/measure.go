@@ -0,0 +1,216 @@
+package measure
+
+import (
+	"math"
+
+	"github.com/flywave/go-geom"
+)
+
+// Area returns the planar area of g. Holes are subtracted from their shell
+// regardless of ring orientation; points and lines have no area.
+func Area(g geom.Geometry) float64 {
+	return AreaData(geom.NewGeometryData(g))
+}
+
+func AreaData(g *geom.GeometryData) float64 {
+	if g == nil {
+		return 0
+	}
+	switch g.Type {
+	case geom.GeometryPolygon:
+		return PolygonArea(g.Polygon)
+	case geom.GeometryMultiPolygon:
+		var area float64
+		for _, p := range g.MultiPolygon {
+			area += PolygonArea(p)
+		}
+		return area
+	case geom.GeometryCollection:
+		var area float64
+		for _, c := range g.Geometries {
+			area += AreaData(c)
+		}
+		return area
+	}
+	return 0
+}
+
+// RingSignedArea returns the shoelace area of ring, positive when the ring
+// is counter-clockwise. The ring may or may not repeat its first point.
+func RingSignedArea(ring [][]float64) float64 {
+	if len(ring) < 3 {
+		return 0
+	}
+	x0, y0 := ring[0][0], ring[0][1]
+	var sum float64
+	for i := 1; i < len(ring)-1; i++ {
+		x1, y1 := ring[i][0]-x0, ring[i][1]-y0
+		x2, y2 := ring[i+1][0]-x0, ring[i+1][1]-y0
+		sum += x1*y2 - x2*y1
+	}
+	return sum / 2
+}
+
+func RingArea(ring [][]float64) float64 {
+	return math.Abs(RingSignedArea(ring))
+}
+
+func PolygonArea(polygon [][][]float64) float64 {
+	if len(polygon) == 0 {
+		return 0
+	}
+	area := RingArea(polygon[0])
+	for _, hole := range polygon[1:] {
+		area -= RingArea(hole)
+	}
+	return area
+}
+
+// Length returns the planar length of the linear components of g. Polygon
+// boundaries are not counted, use Perimeter for those.
+func Length(g geom.Geometry) float64 {
+	return lengthData(geom.NewGeometryData(g), false, geom.NoLayout)
+}
+
+// Length3D is like Length but takes the Z ordinate into account.
+func Length3D(g geom.Geometry) float64 {
+	return lengthData(geom.NewGeometryData(g), true, geom.NoLayout)
+}
+
+func LengthData(g *geom.GeometryData) float64 {
+	return lengthData(g, false, geom.NoLayout)
+}
+
+func Length3DData(g *geom.GeometryData) float64 {
+	return lengthData(g, true, geom.NoLayout)
+}
+
+func lengthData(g *geom.GeometryData, is3d bool, layout geom.Layout) float64 {
+	if g == nil {
+		return 0
+	}
+	z, layout := zIndex(g, is3d, layout)
+	switch g.Type {
+	case geom.GeometryLineString:
+		return pathLength(g.LineString, z)
+	case geom.GeometryMultiLineString:
+		var length float64
+		for _, l := range g.MultiLineString {
+			length += pathLength(l, z)
+		}
+		return length
+	case geom.GeometryCollection:
+		var length float64
+		for _, c := range g.Geometries {
+			length += lengthData(c, is3d, layout)
+		}
+		return length
+	}
+	return 0
+}
+
+// Perimeter returns the planar length of all polygon rings in g, holes
+// included.
+func Perimeter(g geom.Geometry) float64 {
+	return perimeterData(geom.NewGeometryData(g), false, geom.NoLayout)
+}
+
+// Perimeter3D is like Perimeter but takes the Z ordinate into account.
+func Perimeter3D(g geom.Geometry) float64 {
+	return perimeterData(geom.NewGeometryData(g), true, geom.NoLayout)
+}
+
+func PerimeterData(g *geom.GeometryData) float64 {
+	return perimeterData(g, false, geom.NoLayout)
+}
+
+func Perimeter3DData(g *geom.GeometryData) float64 {
+	return perimeterData(g, true, geom.NoLayout)
+}
+
+func perimeterData(g *geom.GeometryData, is3d bool, layout geom.Layout) float64 {
+	if g == nil {
+		return 0
+	}
+	z, layout := zIndex(g, is3d, layout)
+	switch g.Type {
+	case geom.GeometryPolygon:
+		return polygonPerimeter(g.Polygon, z)
+	case geom.GeometryMultiPolygon:
+		var length float64
+		for _, p := range g.MultiPolygon {
+			length += polygonPerimeter(p, z)
+		}
+		return length
+	case geom.GeometryCollection:
+		var length float64
+		for _, c := range g.Geometries {
+			length += perimeterData(c, is3d, layout)
+		}
+		return length
+	}
+	return 0
+}
+
+func polygonPerimeter(polygon [][][]float64, z int) float64 {
+	var length float64
+	for _, ring := range polygon {
+		length += ringLength(ring, z)
+	}
+	return length
+}
+
+// ringLength closes the ring implicitly when its last point does not repeat
+// the first one.
+func ringLength(ring [][]float64, z int) float64 {
+	length := pathLength(ring, z)
+	if n := len(ring); n > 2 && !samePoint(ring[0], ring[n-1]) {
+		length += distance(ring[n-1], ring[0], z)
+	}
+	return length
+}
+
+func pathLength(path [][]float64, z int) float64 {
+	var length float64
+	for i := 1; i < len(path); i++ {
+		length += distance(path[i-1], path[i], z)
+	}
+	return length
+}
+
+// zIndex returns the index of Z in the positions of g, -1 in the plane or
+// without Z, so that the measures of XYM are not taken for elevations. The
+// layout of g defaults to layout, the one of its collection.
+func zIndex(g *geom.GeometryData, is3d bool, layout geom.Layout) (int, geom.Layout) {
+	if g.Layout != geom.NoLayout {
+		layout = g.Layout
+	}
+	switch {
+	case !is3d:
+		return -1, layout
+	case layout == geom.NoLayout:
+		return geom.LayoutFromGeometryData(g).ZIndex(), layout
+	}
+	return layout.ZIndex(), layout
+}
+
+// distance measures in 3D when z, the index of Z, is not negative.
+func distance(a, b []float64, z int) float64 {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	if z < 0 {
+		return math.Hypot(dx, dy)
+	}
+	dz := zOf(b, z) - zOf(a, z)
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
+func zOf(pt []float64, z int) float64 {
+	if len(pt) > z {
+		return pt[z]
+	}
+	return 0
+}
+
+func samePoint(a, b []float64) bool {
+	return a[0] == b[0] && a[1] == b[1]
+}
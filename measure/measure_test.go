@@ -0,0 +1,127 @@
+package measure
+
+import (
+	"math"
+	"testing"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/general"
+	"github.com/stretchr/testify/assert"
+)
+
+var square = [][]float64{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}
+var hole = [][]float64{{2, 2}, {2, 4}, {4, 4}, {4, 2}, {2, 2}}
+
+func TestArea(t *testing.T) {
+	assert.Equal(t, 100.0, Area(general.NewPolygon([][][]float64{square})))
+	assert.Equal(t, 96.0, Area(general.NewPolygon([][][]float64{square, hole})))
+
+	// 环方向不影响结果
+	cw := [][]float64{{0, 0}, {0, 10}, {10, 10}, {10, 0}, {0, 0}}
+	assert.Equal(t, 100.0, Area(general.NewPolygon([][][]float64{cw})))
+	assert.Equal(t, 50.0, RingSignedArea([][]float64{{0, 0}, {10, 0}, {0, 10}}))
+	assert.Equal(t, -50.0, RingSignedArea([][]float64{{0, 0}, {0, 10}, {10, 0}}))
+
+	mp := general.NewMultiPolygon([][][][]float64{{square, hole}, {{{20, 0}, {21, 0}, {21, 1}, {20, 0}}}})
+	assert.Equal(t, 96.5, Area(mp))
+
+	col := general.NewGeometryCollection(mp, general.NewPoint([]float64{1, 1}))
+	assert.Equal(t, 96.5, Area(col))
+
+	assert.Equal(t, 0.0, Area(general.NewLineString(square)))
+	assert.Equal(t, 96.0, AreaData(geom.NewPolygonGeometryData([][][]float64{square, hole})))
+	assert.Equal(t, 0.0, Area(nil))
+}
+
+func TestLength(t *testing.T) {
+	assert.Equal(t, 40.0, Length(general.NewLineString(square)))
+	assert.Equal(t, 0.0, Length(general.NewPolygon([][][]float64{square})))
+
+	ml := general.NewMultiLineString([][][]float64{{{0, 0}, {3, 4}}, {{0, 0}, {0, 2}}})
+	assert.Equal(t, 7.0, Length(ml))
+
+	line3 := [][]float64{{0, 0, 0}, {3, 4, 12}}
+	assert.Equal(t, 5.0, Length(general.NewLineString3(line3)))
+	assert.Equal(t, 13.0, Length3D(general.NewLineString3(line3)))
+	assert.Equal(t, 13.0, Length3DData(geom.NewLineStringGeometryData(line3)))
+
+	gd := geom.NewCollectionGeometryData(geom.NewLineStringGeometryData(line3), geom.NewPointGeometryData([]float64{1, 1}))
+	assert.Equal(t, 5.0, LengthData(gd))
+	assert.Equal(t, 5.0, Length(gd))
+
+	// M 不是高程
+	xym := geom.NewLineStringGeometryData([][]float64{{0, 0, 0}, {3, 4, 100}})
+	xym.Layout = geom.XYM
+	assert.Equal(t, 5.0, Length3DData(xym))
+	xyzm := geom.NewLineStringGeometryData([][]float64{{0, 0, 0, 0}, {3, 4, 12, 100}})
+	xyzm.Layout = geom.XYZM
+	assert.Equal(t, 13.0, Length3DData(xyzm))
+	col := geom.NewCollectionGeometryData(geom.NewLineStringGeometryData([][]float64{{0, 0, 0}, {3, 4, 100}}), xyzm)
+	col.Layout = geom.XYM
+	assert.Equal(t, 18.0, Length3DData(col))
+	ring := geom.NewPolygonGeometryData([][][]float64{{{0, 0, 5}, {3, 4, 50}, {0, 4, 500}, {0, 0, 5}}})
+	ring.Layout = geom.XYM
+	assert.Equal(t, 12.0, Perimeter3DData(ring))
+}
+
+func TestPerimeter(t *testing.T) {
+	assert.Equal(t, 40.0, Perimeter(general.NewPolygon([][][]float64{square})))
+	assert.Equal(t, 48.0, Perimeter(general.NewPolygon([][][]float64{square, hole})))
+	assert.Equal(t, 0.0, Perimeter(general.NewLineString(square)))
+
+	// 未闭合的环
+	open := [][]float64{{0, 0}, {10, 0}, {10, 10}, {0, 10}}
+	assert.Equal(t, 40.0, PerimeterData(geom.NewPolygonGeometryData([][][]float64{open})))
+
+	tilted := [][]float64{{0, 0, 0}, {3, 0, 4}, {3, 4, 4}, {0, 4, 0}, {0, 0, 0}}
+	assert.Equal(t, 14.0, Perimeter(general.NewPolygon3([][][]float64{tilted})))
+	assert.Equal(t, 18.0, Perimeter3D(general.NewPolygon3([][][]float64{tilted})))
+	assert.Equal(t, 18.0, Perimeter3DData(geom.NewPolygonGeometryData([][][]float64{tilted})))
+}
+
+func TestCentroid(t *testing.T) {
+	assert.Equal(t, []float64{5, 5}, Centroid(general.NewPolygon([][][]float64{square})))
+
+	c := Centroid(general.NewPolygon([][][]float64{square, hole}))
+	assert.InDelta(t, (100*5-4*3)/96.0, c[0], 1e-12)
+	assert.InDelta(t, (100*5-4*3)/96.0, c[1], 1e-12)
+
+	// 多个多边形按面积加权
+	mp := general.NewMultiPolygon([][][][]float64{
+		{{{0, 0}, {2, 0}, {2, 2}, {0, 2}, {0, 0}}},
+		{{{10, 0}, {12, 0}, {12, 2}, {10, 2}, {10, 0}}},
+	})
+	assert.Equal(t, []float64{6, 1}, Centroid(mp))
+
+	// 高维几何优先
+	col := general.NewGeometryCollection(
+		general.NewPolygon([][][]float64{square}),
+		general.NewLineString([][]float64{{100, 100}, {200, 100}}),
+		general.NewPoint([]float64{-100, -100}),
+	)
+	assert.Equal(t, []float64{5, 5}, Centroid(col))
+
+	line := general.NewMultiLineString([][][]float64{{{0, 0}, {4, 0}}, {{0, 2}, {0, 4}}})
+	c = Centroid(line)
+	assert.InDelta(t, 8/6.0, c[0], 1e-12)
+	assert.InDelta(t, 6/6.0, c[1], 1e-12)
+
+	assert.Equal(t, []float64{2, 3}, Centroid(general.NewMultiPoint([][]float64{{1, 2}, {3, 4}})))
+
+	// 退化多边形退化为线的质心
+	flat := [][]float64{{0, 0}, {4, 0}, {0, 0}}
+	assert.Equal(t, []float64{2, 0}, CentroidData(geom.NewPolygonGeometryData([][][]float64{flat})))
+
+	assert.Nil(t, Centroid(geom.NewCollectionGeometryData()))
+}
+
+func TestCentroidFarFromOrigin(t *testing.T) {
+	off := 1e7
+	ring := make([][]float64, len(square))
+	for i, p := range square {
+		ring[i] = []float64{p[0] + off, p[1] + off}
+	}
+	c := Centroid(general.NewPolygon([][][]float64{ring}))
+	assert.True(t, math.Abs(c[0]-off-5) < 1e-6)
+	assert.True(t, math.Abs(c[1]-off-5) < 1e-6)
+}
@@ -0,0 +1,170 @@
+package mvt
+
+// box is the clipping rectangle of a tile, its extent grown by the buffer.
+type box struct {
+	min, max float64
+}
+
+func (b box) contains(p []float64) bool {
+	return p[0] >= b.min && p[0] <= b.max && p[1] >= b.min && p[1] <= b.max
+}
+
+func (b box) points(pts [][]float64) [][]float64 {
+	var ret [][]float64
+	for _, p := range pts {
+		if b.contains(p) {
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
+
+// segment clips the segment a b with the Liang-Barsky algorithm.
+func (b box) segment(a, c []float64) ([]float64, []float64, bool) {
+	t0, t1 := 0.0, 1.0
+	dx, dy := c[0]-a[0], c[1]-a[1]
+	for _, e := range [4][2]float64{
+		{-dx, a[0] - b.min},
+		{dx, b.max - a[0]},
+		{-dy, a[1] - b.min},
+		{dy, b.max - a[1]},
+	} {
+		p, q := e[0], e[1]
+		if p == 0 {
+			if q < 0 {
+				return nil, nil, false
+			}
+			continue
+		}
+		r := q / p
+		if p < 0 {
+			if r > t1 {
+				return nil, nil, false
+			}
+			if r > t0 {
+				t0 = r
+			}
+		} else {
+			if r < t0 {
+				return nil, nil, false
+			}
+			if r < t1 {
+				t1 = r
+			}
+		}
+	}
+	return []float64{a[0] + t0*dx, a[1] + t0*dy}, []float64{a[0] + t1*dx, a[1] + t1*dy}, true
+}
+
+// line clips a line string, which is cut in parts where it leaves the box.
+func (b box) line(line [][]float64) [][][]float64 {
+	var ret [][][]float64
+	var cur [][]float64
+	for i := 0; i+1 < len(line); i++ {
+		p, q, ok := b.segment(line[i], line[i+1])
+		if !ok {
+			if len(cur) > 1 {
+				ret = append(ret, cur)
+			}
+			cur = nil
+			continue
+		}
+		if len(cur) == 0 {
+			cur = append(cur, p)
+		}
+		cur = append(cur, q)
+		// 线段在终点之前离开裁剪框
+		if q[0] != line[i+1][0] || q[1] != line[i+1][1] {
+			ret = append(ret, cur)
+			cur = nil
+		}
+	}
+	if len(cur) > 1 {
+		ret = append(ret, cur)
+	}
+	return ret
+}
+
+func (b box) lines(lines [][][]float64) [][][]float64 {
+	var ret [][][]float64
+	for _, l := range lines {
+		ret = append(ret, b.line(l)...)
+	}
+	return ret
+}
+
+// ring clips a polygon ring with the Sutherland-Hodgman algorithm against
+// each edge of the box.
+func (b box) ring(ring [][]float64) [][]float64 {
+	edges := []struct {
+		inside func(p []float64) bool
+		cut    func(p, q []float64) []float64
+	}{
+		{func(p []float64) bool { return p[0] >= b.min }, func(p, q []float64) []float64 { return cutX(p, q, b.min) }},
+		{func(p []float64) bool { return p[0] <= b.max }, func(p, q []float64) []float64 { return cutX(p, q, b.max) }},
+		{func(p []float64) bool { return p[1] >= b.min }, func(p, q []float64) []float64 { return cutY(p, q, b.min) }},
+		{func(p []float64) bool { return p[1] <= b.max }, func(p, q []float64) []float64 { return cutY(p, q, b.max) }},
+	}
+	out := ring
+	if len(out) > 1 && equal(out[0], out[len(out)-1]) {
+		out = out[:len(out)-1]
+	}
+	for _, e := range edges {
+		in := out
+		out = nil
+		for i, q := range in {
+			p := in[(i+len(in)-1)%len(in)]
+			switch {
+			case e.inside(q):
+				if !e.inside(p) {
+					out = append(out, e.cut(p, q))
+				}
+				out = append(out, q)
+			case e.inside(p):
+				out = append(out, e.cut(p, q))
+			}
+		}
+	}
+	if len(out) < 3 {
+		return nil
+	}
+	return append(out, out[0])
+}
+
+func cutX(p, q []float64, x float64) []float64 {
+	return []float64{x, p[1] + (q[1]-p[1])*(x-p[0])/(q[0]-p[0])}
+}
+
+func cutY(p, q []float64, y float64) []float64 {
+	return []float64{p[0] + (q[0]-p[0])*(y-p[1])/(q[1]-p[1]), y}
+}
+
+// polygon clips the rings of a polygon, nil when its exterior is outside.
+func (b box) polygon(polygon [][][]float64) [][][]float64 {
+	var ret [][][]float64
+	for i, r := range polygon {
+		c := b.ring(r)
+		if c == nil {
+			if i == 0 {
+				return nil
+			}
+			continue
+		}
+		ret = append(ret, c)
+	}
+	return ret
+}
+
+func (b box) polygons(polygons [][][][]float64) [][][][]float64 {
+	var ret [][][][]float64
+	for _, p := range polygons {
+		if c := b.polygon(p); c != nil {
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
+
+func equal(a, b []float64) bool {
+	return a[0] == b[0] && a[1] == b[1]
+}
@@ -0,0 +1,336 @@
+package mvt
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/flywave/go-geom"
+)
+
+// DecodeOptions controls the decoding of tiles.
+type DecodeOptions struct {
+	// Tile converts the positions to lon/lat when set, they are kept in tile
+	// coordinates otherwise.
+	Tile *Tile
+}
+
+func DefaultDecodeOptions() *DecodeOptions {
+	return &DecodeOptions{}
+}
+
+func unzigzag(v uint32) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+// paths reads the commands of a geometry, each MoveTo starting a path and
+// ClosePath repeating its first position.
+func paths(cmds []uint32) ([][][]float64, error) {
+	var ret [][][]float64
+	var cur [][]float64
+	var x, y int64
+	for i := 0; i < len(cmds); {
+		id, count := int(cmds[i]&7), int(cmds[i]>>3)
+		i++
+		switch id {
+		case cmdMoveTo, cmdLineTo:
+			if len(cmds)-i < 2*count {
+				return nil, errors.New("truncated mvt geometry")
+			}
+			for k := 0; k < count; k++ {
+				x += unzigzag(cmds[i])
+				y += unzigzag(cmds[i+1])
+				i += 2
+				if id == cmdMoveTo && len(cur) > 0 {
+					ret = append(ret, cur)
+					cur = nil
+				}
+				cur = append(cur, []float64{float64(x), float64(y)})
+			}
+		case cmdClosePath:
+			if len(cur) > 0 {
+				cur = append(cur, []float64{cur[0][0], cur[0][1]})
+			}
+		default:
+			return nil, fmt.Errorf("unknown mvt command %d", id)
+		}
+	}
+	if len(cur) > 0 {
+		ret = append(ret, cur)
+	}
+	return ret, nil
+}
+
+func ringArea(ring [][]float64) float64 {
+	var a float64
+	for i := 0; i+1 < len(ring); i++ {
+		a += ring[i][0]*ring[i+1][1] - ring[i+1][0]*ring[i][1]
+	}
+	return a
+}
+
+func decodeGeometry(typ int, cmds []uint32) (*geom.GeometryData, error) {
+	ps, err := paths(cmds)
+	if err != nil {
+		return nil, err
+	}
+	switch typ {
+	case typePoint:
+		var pts [][]float64
+		for _, p := range ps {
+			pts = append(pts, p...)
+		}
+		if len(pts) == 1 {
+			return geom.NewPointGeometryData(pts[0]), nil
+		}
+		return geom.NewMultiPointGeometryData(pts...), nil
+	case typeLineString:
+		if len(ps) == 1 {
+			return geom.NewLineStringGeometryData(ps[0]), nil
+		}
+		return geom.NewMultiLineStringGeometryData(ps...), nil
+	case typePolygon:
+		// 顺时针的环开始新的多边形, 逆时针的环为其洞
+		var polygons [][][][]float64
+		for _, r := range ps {
+			a := ringArea(r)
+			if a == 0 {
+				continue
+			}
+			if a > 0 || len(polygons) == 0 {
+				polygons = append(polygons, [][][]float64{r})
+			} else {
+				polygons[len(polygons)-1] = append(polygons[len(polygons)-1], r)
+			}
+		}
+		if len(polygons) == 1 {
+			return geom.NewPolygonGeometryData(polygons[0]), nil
+		}
+		return geom.NewMultiPolygonGeometryData(polygons...), nil
+	}
+	return nil, fmt.Errorf("unknown mvt geometry type %d", typ)
+}
+
+func readValue(b []byte) (interface{}, error) {
+	r := pbfReader{buf: b}
+	var v interface{}
+	for r.more() {
+		field, wire, err := r.key()
+		if err != nil {
+			return nil, err
+		}
+		switch field {
+		case 1:
+			s, err := r.bytes()
+			if err != nil {
+				return nil, err
+			}
+			v = string(s)
+		case 2:
+			f, err := r.fixed32()
+			if err != nil {
+				return nil, err
+			}
+			v = float64(math.Float32frombits(f))
+		case 3:
+			f, err := r.fixed64()
+			if err != nil {
+				return nil, err
+			}
+			v = math.Float64frombits(f)
+		case 4, 5, 6, 7:
+			n, err := r.varint()
+			if err != nil {
+				return nil, err
+			}
+			switch field {
+			case 4:
+				v = int64(n)
+			case 5:
+				v = n
+			case 6:
+				v = int64(n>>1) ^ -int64(n&1)
+			case 7:
+				v = n != 0
+			}
+		default:
+			if err := r.skip(wire); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
+
+// decodeFeature reads a feature, nil when its geometry type is unknown.
+func decodeFeature(b []byte, keys []string, values []interface{}) (*geom.Feature, error) {
+	r := pbfReader{buf: b}
+	var (
+		id    interface{}
+		tags  []uint32
+		typ   int
+		cmds  []uint32
+		field int
+		wire  int
+		err   error
+	)
+	for r.more() {
+		if field, wire, err = r.key(); err != nil {
+			return nil, err
+		}
+		switch field {
+		case 1:
+			n, err := r.varint()
+			if err != nil {
+				return nil, err
+			}
+			id = n
+		case 2:
+			tags, err = r.packed(wire, tags)
+		case 3:
+			var n uint64
+			n, err = r.varint()
+			typ = int(n)
+		case 4:
+			cmds, err = r.packed(wire, cmds)
+		default:
+			err = r.skip(wire)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(tags)%2 != 0 {
+		return nil, errors.New("odd number of mvt tags")
+	}
+	// 未知类型的要素可以忽略
+	if typ == typeUnknown {
+		return nil, nil
+	}
+	g, err := decodeGeometry(typ, cmds)
+	if err != nil {
+		return nil, err
+	}
+	f := geom.NewFeatureFromGeometryData(g)
+	f.ID = id
+	for i := 0; i < len(tags); i += 2 {
+		if int(tags[i]) >= len(keys) || int(tags[i+1]) >= len(values) {
+			return nil, errors.New("mvt tag out of range")
+		}
+		f.Properties[keys[tags[i]]] = values[tags[i+1]]
+	}
+	return f, nil
+}
+
+func decodeLayer(b []byte) (*Layer, error) {
+	r := pbfReader{buf: b}
+	l := &Layer{Version: 1, Extent: 4096}
+	var (
+		features [][]byte
+		keys     []string
+		values   []interface{}
+	)
+	// 要素可能在键值表之前, 先收集
+	for r.more() {
+		field, wire, err := r.key()
+		if err != nil {
+			return nil, err
+		}
+		switch field {
+		case 1, 2, 3, 4:
+			var v []byte
+			if v, err = r.bytes(); err != nil {
+				return nil, err
+			}
+			switch field {
+			case 1:
+				l.Name = string(v)
+			case 2:
+				features = append(features, v)
+			case 3:
+				keys = append(keys, string(v))
+			case 4:
+				val, err := readValue(v)
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, val)
+			}
+		case 5, 15:
+			n, err := r.varint()
+			if err != nil {
+				return nil, err
+			}
+			if field == 5 {
+				l.Extent = int(n)
+			} else {
+				l.Version = int(n)
+			}
+		default:
+			if err := r.skip(wire); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if l.Extent <= 0 {
+		return nil, fmt.Errorf("invalid mvt layer %s extent %d", l.Name, l.Extent)
+	}
+	for _, fb := range features {
+		f, err := decodeFeature(fb, keys, values)
+		if err != nil {
+			return nil, fmt.Errorf("mvt layer %s: %v", l.Name, err)
+		}
+		if f != nil {
+			l.Features = append(l.Features, f)
+		}
+	}
+	return l, nil
+}
+
+// unproject converts the features of l from tile coordinates to lon/lat.
+func (l *Layer) unproject(t Tile) {
+	fn := func(p []float64) []float64 {
+		lon, lat := t.Unproject(p[0], p[1], l.Extent)
+		return []float64{lon, lat}
+	}
+	for _, f := range l.Features {
+		g := geom.ProcessGeometryData(&f.GeometryData, fn)
+		g.EPSG = 4326
+		f.GeometryData = *g
+		f.BoundingBox = geom.BoundingBoxFromGeometryData(g)
+	}
+}
+
+// Decode reads the layers of a tile.
+func Decode(data []byte, opt *DecodeOptions) ([]*Layer, error) {
+	if opt == nil {
+		opt = DefaultDecodeOptions()
+	}
+	r := pbfReader{buf: data}
+	var layers []*Layer
+	for r.more() {
+		field, wire, err := r.key()
+		if err != nil {
+			return nil, err
+		}
+		if field != 3 {
+			if err := r.skip(wire); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		b, err := r.bytes()
+		if err != nil {
+			return nil, err
+		}
+		l, err := decodeLayer(b)
+		if err != nil {
+			return nil, err
+		}
+		if opt.Tile != nil {
+			l.unproject(*opt.Tile)
+		}
+		layers = append(layers, l)
+	}
+	return layers, nil
+}
@@ -0,0 +1,387 @@
+package mvt
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/proj"
+)
+
+// EncodeOptions controls the encoding of tiles.
+type EncodeOptions struct {
+	// Extent is the width of the tile in tile coordinates, used for the
+	// layers without their own.
+	Extent int
+	// Buffer is the margin, in tile coordinates, kept around the tile when
+	// clipping.
+	Buffer int
+}
+
+func DefaultEncodeOptions() *EncodeOptions {
+	return &EncodeOptions{Extent: 4096, Buffer: 64}
+}
+
+func zigzag(v int64) uint32 {
+	return uint32((v << 1) ^ (v >> 63))
+}
+
+// cursor writes the commands of a geometry, the positions being relative
+// to the previous one.
+type cursor struct {
+	x, y int64
+	cmds []uint32
+}
+
+func (c *cursor) command(id, count int) {
+	c.cmds = append(c.cmds, uint32(id&7|count<<3))
+}
+
+func (c *cursor) to(p [2]int64) {
+	c.cmds = append(c.cmds, zigzag(p[0]-c.x), zigzag(p[1]-c.y))
+	c.x, c.y = p[0], p[1]
+}
+
+// round snaps path to the integer grid, dropping the repeated positions
+// and, for rings, the closing one.
+func round(path [][]float64, ring bool) [][2]int64 {
+	ret := make([][2]int64, 0, len(path))
+	for _, p := range path {
+		q := [2]int64{int64(math.Round(p[0])), int64(math.Round(p[1]))}
+		if len(ret) > 0 && ret[len(ret)-1] == q {
+			continue
+		}
+		ret = append(ret, q)
+	}
+	if ring && len(ret) > 1 && ret[0] == ret[len(ret)-1] {
+		ret = ret[:len(ret)-1]
+	}
+	return ret
+}
+
+// area returns twice the signed area of ring, positive for the clockwise
+// rings of the y down tile coordinates.
+func area(ring [][2]int64) int64 {
+	var a int64
+	for i := range ring {
+		p, q := ring[i], ring[(i+1)%len(ring)]
+		a += p[0]*q[1] - q[0]*p[1]
+	}
+	return a
+}
+
+func (c *cursor) line(line [][2]int64) {
+	c.command(cmdMoveTo, 1)
+	c.to(line[0])
+	c.command(cmdLineTo, len(line)-1)
+	for _, p := range line[1:] {
+		c.to(p)
+	}
+}
+
+// polygon writes the rings of polygon, the exterior clockwise and the
+// holes counterclockwise.
+func (c *cursor) polygon(polygon [][][]float64) {
+	for i, r := range polygon {
+		ring := round(r, true)
+		a := area(ring)
+		if len(ring) < 3 || a == 0 {
+			if i == 0 {
+				return
+			}
+			continue
+		}
+		if (i == 0) != (a > 0) {
+			// 保留起点反转方向
+			for j, k := 1, len(ring)-1; j < k; j, k = j+1, k-1 {
+				ring[j], ring[k] = ring[k], ring[j]
+			}
+		}
+		c.line(ring)
+		c.command(cmdClosePath, 1)
+	}
+}
+
+// encodeGeometry clips g, in tile coordinates, to b and returns its type
+// and commands.
+func encodeGeometry(g *geom.GeometryData, b box) (int, []uint32) {
+	var c cursor
+	switch g.Type {
+	case geom.GeometryPoint, geom.GeometryMultiPoint:
+		pts := g.MultiPoint
+		if g.Type == geom.GeometryPoint {
+			pts = [][]float64{g.Point}
+		}
+		var ps [][2]int64
+		for _, p := range b.points(pts) {
+			ps = append(ps, round([][]float64{p}, false)...)
+		}
+		if len(ps) == 0 {
+			return typeUnknown, nil
+		}
+		c.command(cmdMoveTo, len(ps))
+		for _, p := range ps {
+			c.to(p)
+		}
+		return typePoint, c.cmds
+	case geom.GeometryLineString, geom.GeometryMultiLineString:
+		lines := g.MultiLineString
+		if g.Type == geom.GeometryLineString {
+			lines = [][][]float64{g.LineString}
+		}
+		for _, l := range b.lines(lines) {
+			if line := round(l, false); len(line) > 1 {
+				c.line(line)
+			}
+		}
+		return typeLineString, c.cmds
+	case geom.GeometryPolygon, geom.GeometryMultiPolygon:
+		polygons := g.MultiPolygon
+		if g.Type == geom.GeometryPolygon {
+			polygons = [][][][]float64{g.Polygon}
+		}
+		for _, p := range b.polygons(polygons) {
+			c.polygon(p)
+		}
+		return typePolygon, c.cmds
+	}
+	return typeUnknown, nil
+}
+
+// flatten returns the members of the collections of g, which tiles do not
+// hold.
+func flatten(g *geom.GeometryData) []*geom.GeometryData {
+	if g.Type != geom.GeometryCollection {
+		return []*geom.GeometryData{g}
+	}
+	var ret []*geom.GeometryData
+	for _, c := range g.Geometries {
+		if c != nil {
+			if c.EPSG == 0 {
+				cc := *c
+				cc.EPSG = g.EPSG
+				c = &cc
+			}
+			ret = append(ret, flatten(c)...)
+		}
+	}
+	return ret
+}
+
+// value returns v as one of the types of the tile values, nested values
+// being written as JSON.
+func value(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case nil:
+		return nil, false
+	case string, bool, float32, float64:
+		return v, true
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return uint64(v), true
+	case uint8:
+		return uint64(v), true
+	case uint16:
+		return uint64(v), true
+	case uint32:
+		return uint64(v), true
+	case uint64:
+		return v, true
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v), true
+	}
+	return string(b), true
+}
+
+// featureID returns the unsigned integer id of a feature, if it has one.
+func featureID(id interface{}) (uint64, bool) {
+	switch v := id.(type) {
+	case string:
+		n, err := strconv.ParseUint(v, 10, 64)
+		return n, err == nil
+	case float64:
+		return uint64(v), v >= 0 && v == math.Trunc(v)
+	case float32:
+		return uint64(v), v >= 0 && v == float32(math.Trunc(float64(v)))
+	case nil:
+		return 0, false
+	}
+	if v, ok := value(id); ok {
+		switch v := v.(type) {
+		case int64:
+			return uint64(v), v >= 0
+		case uint64:
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// layerEncoder holds the key and value tables of a layer.
+type layerEncoder struct {
+	keys     []string
+	keyIndex map[string]uint32
+	values   []interface{}
+	valIndex map[interface{}]uint32
+	features [][]byte
+}
+
+func (e *layerEncoder) tags(props map[string]interface{}) []uint32 {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var tags []uint32
+	for _, k := range keys {
+		v, ok := value(props[k])
+		if !ok {
+			continue
+		}
+		ki, ok := e.keyIndex[k]
+		if !ok {
+			ki = uint32(len(e.keys))
+			e.keyIndex[k] = ki
+			e.keys = append(e.keys, k)
+		}
+		vi, ok := e.valIndex[v]
+		if !ok {
+			vi = uint32(len(e.values))
+			e.valIndex[v] = vi
+			e.values = append(e.values, v)
+		}
+		tags = append(tags, ki, vi)
+	}
+	return tags
+}
+
+func writeValue(v interface{}) []byte {
+	var w pbfWriter
+	switch v := v.(type) {
+	case string:
+		w.string(1, v)
+	case float32:
+		w.float(2, v)
+	case float64:
+		w.double(3, v)
+	case int64:
+		if v < 0 {
+			w.uint(6, uint64((v<<1)^(v>>63)))
+		} else {
+			w.uint(4, uint64(v))
+		}
+	case uint64:
+		w.uint(5, v)
+	case bool:
+		b := uint64(0)
+		if v {
+			b = 1
+		}
+		w.uint(7, b)
+	}
+	return w.buf
+}
+
+func encodeLayer(t Tile, l *Layer, opt *EncodeOptions) ([]byte, error) {
+	extent := l.Extent
+	if extent <= 0 {
+		extent = opt.Extent
+	}
+	b := box{min: -float64(opt.Buffer), max: float64(extent + opt.Buffer)}
+	project := func(p []float64) []float64 {
+		x, y := t.Project(p[0], p[1], extent)
+		return []float64{x, y}
+	}
+	e := &layerEncoder{keyIndex: make(map[string]uint32), valIndex: make(map[interface{}]uint32)}
+	for _, f := range l.Features {
+		if f == nil || f.GeometryData.Type == "" {
+			continue
+		}
+		var tags []uint32
+		// 集合拆出的要素中只有第一个带 id, id 在图层内唯一
+		id, hasID := featureID(f.ID)
+		for _, g := range flatten(&f.GeometryData) {
+			// 非经纬度的几何先转换到 WGS84
+			if g.EPSG != 0 && g.EPSG != 4326 {
+				var err error
+				if g, err = proj.TransformGeometryData(g, 4326); err != nil {
+					return nil, err
+				}
+			}
+			typ, cmds := encodeGeometry(geom.ProcessGeometryData(g, project), b)
+			if len(cmds) == 0 {
+				continue
+			}
+			if tags == nil {
+				tags = e.tags(f.Properties)
+			}
+			var w pbfWriter
+			if hasID {
+				w.uint(1, id)
+				hasID = false
+			}
+			w.packed(2, tags)
+			w.uint(3, uint64(typ))
+			w.packed(4, cmds)
+			e.features = append(e.features, w.buf)
+		}
+	}
+	version := l.Version
+	if version <= 0 {
+		version = 2
+	}
+	var w pbfWriter
+	w.uint(15, uint64(version))
+	w.string(1, l.Name)
+	for _, f := range e.features {
+		w.bytes(2, f)
+	}
+	for _, k := range e.keys {
+		w.string(3, k)
+	}
+	for _, v := range e.values {
+		w.bytes(4, writeValue(v))
+	}
+	w.uint(5, uint64(extent))
+	return w.buf, nil
+}
+
+// Encode returns the tile t of the layers, whose features are projected
+// from lon/lat, or from the system of their EPSG, to tile coordinates and
+// clipped to the tile and its buffer.
+func Encode(t Tile, layers []*Layer, opt *EncodeOptions) ([]byte, error) {
+	if opt == nil {
+		opt = DefaultEncodeOptions()
+	}
+	if opt.Extent <= 0 {
+		return nil, fmt.Errorf("invalid mvt extent %d", opt.Extent)
+	}
+	var w pbfWriter
+	for _, l := range layers {
+		b, err := encodeLayer(t, l, opt)
+		if err != nil {
+			return nil, err
+		}
+		w.bytes(3, b)
+	}
+	return w.buf, nil
+}
+
+// EncodeFeatureCollection returns the tile t holding fc in the layer name.
+func EncodeFeatureCollection(t Tile, name string, fc *geom.FeatureCollection, opt *EncodeOptions) ([]byte, error) {
+	return Encode(t, []*Layer{NewLayer(name, fc)}, opt)
+}
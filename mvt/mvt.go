@@ -0,0 +1,82 @@
+package mvt
+
+import (
+	"math"
+
+	"github.com/flywave/go-geom"
+)
+
+// 要素几何类型
+const (
+	typeUnknown    = 0
+	typePoint      = 1
+	typeLineString = 2
+	typePolygon    = 3
+)
+
+// 几何命令
+const (
+	cmdMoveTo    = 1
+	cmdLineTo    = 2
+	cmdClosePath = 7
+)
+
+const maxLatitude = 85.0511287798066
+
+// Tile is the z/x/y address of a tile of the web mercator grid.
+type Tile struct {
+	Z, X, Y int
+}
+
+func (t Tile) size() float64 {
+	return float64(uint64(1) << uint(t.Z))
+}
+
+// Project returns the position of lon and lat in the coordinates of the
+// tile, extent being its width.
+func (t Tile) Project(lon, lat float64, extent int) (float64, float64) {
+	lat = math.Max(-maxLatitude, math.Min(maxLatitude, lat))
+	n := t.size()
+	sin := math.Sin(lat * math.Pi / 180)
+	x := (lon/360 + 0.5) * n
+	y := (0.5 - 0.25*math.Log((1+sin)/(1-sin))/math.Pi) * n
+	return (x - float64(t.X)) * float64(extent), (y - float64(t.Y)) * float64(extent)
+}
+
+// Unproject returns the lon and lat of the tile coordinates x and y.
+func (t Tile) Unproject(x, y float64, extent int) (float64, float64) {
+	n := t.size()
+	x = float64(t.X) + x/float64(extent)
+	y = float64(t.Y) + y/float64(extent)
+	lon := x/n*360 - 180
+	lat := math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180 / math.Pi
+	return lon, lat
+}
+
+// Bound returns the lon/lat bounding box of the tile.
+func (t Tile) Bound() geom.BoundingBox {
+	minLon, maxLat := t.Unproject(0, 0, 1)
+	maxLon, minLat := t.Unproject(1, 1, 1)
+	return geom.BoundingBox{{minLon, minLat, 0}, {maxLon, maxLat, 0}}
+}
+
+// Layer is a named layer of a tile.
+type Layer struct {
+	Name    string
+	Version int
+	// Extent is the width of the tile in the coordinates of the layer.
+	Extent   int
+	Features []*geom.Feature
+}
+
+// NewLayer returns a layer of the features of fc.
+func NewLayer(name string, fc *geom.FeatureCollection) *Layer {
+	return &Layer{Name: name, Version: 2, Features: fc.Features}
+}
+
+// FeatureCollection returns the features of the layer as a collection.
+func (l *Layer) FeatureCollection() *geom.FeatureCollection {
+	fc := geom.NewFeatureCollection()
+	fc.Features = append(fc.Features, l.Features...)
+	return fc
+}
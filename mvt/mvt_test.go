@@ -0,0 +1,187 @@
+package mvt
+
+import (
+	"math"
+	"testing"
+
+	"github.com/flywave/go-geom"
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试规范中的几何命令
+func TestCommands(t *testing.T) {
+	b := box{min: -64, max: 4160}
+	typ, cmds := encodeGeometry(geom.NewPointGeometryData([]float64{25, 17}), b)
+	assert.Equal(t, typePoint, typ)
+	assert.Equal(t, []uint32{9, 50, 34}, cmds)
+
+	_, cmds = encodeGeometry(geom.NewMultiPointGeometryData([]float64{5, 7}, []float64{3, 2}), b)
+	assert.Equal(t, []uint32{17, 10, 14, 3, 9}, cmds)
+
+	typ, cmds = encodeGeometry(geom.NewLineStringGeometryData([][]float64{{2, 2}, {2, 10}, {10, 10}}), b)
+	assert.Equal(t, typeLineString, typ)
+	assert.Equal(t, []uint32{9, 4, 4, 18, 0, 16, 16, 0}, cmds)
+
+	typ, cmds = encodeGeometry(geom.NewPolygonGeometryData([][][]float64{{{3, 6}, {8, 12}, {20, 34}, {3, 6}}}), b)
+	assert.Equal(t, typePolygon, typ)
+	assert.Equal(t, []uint32{9, 6, 12, 18, 10, 12, 24, 44, 15}, cmds)
+
+	// 逆时针的外环被反转
+	_, cmds = encodeGeometry(geom.NewPolygonGeometryData([][][]float64{{{3, 6}, {20, 34}, {8, 12}, {3, 6}}}), b)
+	assert.Equal(t, []uint32{9, 6, 12, 18, 10, 12, 24, 44, 15}, cmds)
+
+	g, err := decodeGeometry(typePolygon, []uint32{9, 6, 12, 18, 10, 12, 24, 44, 15})
+	assert.NoError(t, err)
+	assert.Equal(t, [][][]float64{{{3, 6}, {8, 12}, {20, 34}, {3, 6}}}, g.Polygon)
+
+	g, err = decodeGeometry(typeLineString, []uint32{9, 4, 4, 18, 0, 16, 16, 0, 9, 17, 17, 10, 4, 8})
+	assert.NoError(t, err)
+	assert.Equal(t, [][][]float64{{{2, 2}, {2, 10}, {10, 10}}, {{1, 1}, {3, 5}}}, g.MultiLineString)
+
+	_, err = decodeGeometry(typeLineString, []uint32{9, 4})
+	assert.Error(t, err)
+	_, err = decodeGeometry(typePoint, []uint32{12})
+	assert.Error(t, err)
+}
+
+// 测试裁剪
+func TestClip(t *testing.T) {
+	b := box{min: 0, max: 10}
+	assert.Equal(t, [][][]float64{{{0, 5}, {5, 5}, {5, 10}}, {{5, 10}, {5, 5}, {10, 5}}}, b.line([][]float64{{-5, 5}, {5, 5}, {5, 15}, {5, 5}, {15, 5}}))
+	assert.Nil(t, b.line([][]float64{{-5, -5}, {-1, 20}}))
+
+	r := b.ring([][]float64{{-5, -5}, {5, -5}, {5, 5}, {-5, 5}, {-5, -5}})
+	assert.Equal(t, 50.0, math.Abs(ringArea(r)))
+	assert.Nil(t, b.polygon([][][]float64{{{20, 20}, {30, 20}, {30, 30}, {20, 20}}}))
+	assert.Equal(t, [][]float64{{1, 1}}, b.points([][]float64{{1, 1}, {11, 1}}))
+}
+
+// 测试瓦片投影
+func TestTile(t *testing.T) {
+	tile := Tile{Z: 1, X: 1, Y: 0}
+	x, y := tile.Project(0, 0, 4096)
+	assert.InDelta(t, 0, x, 1e-9)
+	assert.InDelta(t, 4096, y, 1e-9)
+	lon, lat := tile.Unproject(x, y, 4096)
+	assert.InDelta(t, 0, lon, 1e-9)
+	assert.InDelta(t, 0, lat, 1e-9)
+
+	b := tile.Bound()
+	assert.InDelta(t, 0, b[0][0], 1e-9)
+	assert.InDelta(t, 180, b[1][0], 1e-9)
+	assert.InDelta(t, maxLatitude, b[1][1], 1e-9)
+}
+
+// 测试瓦片编解码往返
+func TestEncodeDecode(t *testing.T) {
+	tile := Tile{Z: 2, X: 2, Y: 1}
+	bound := tile.Bound()
+	cx, cy := (bound[0][0]+bound[1][0])/2, (bound[0][1]+bound[1][1])/2
+
+	roads := geom.NewFeatureCollection()
+	f := geom.NewLineStringFeature([][]float64{{cx - 10, cy}, {cx + 10, cy}, {cx + 200, cy}})
+	f.ID = float64(12)
+	f.Properties["name"] = "main"
+	f.Properties["lanes"] = 2
+	f.Properties["speed"] = -30
+	f.Properties["width"] = 7.5
+	f.Properties["toll"] = false
+	f.Properties["tags"] = []string{"a"}
+	f.Properties["none"] = nil
+	roads.AddFeature(f)
+	f = geom.NewPolygonFeature([][][]float64{{{cx - 5, cy - 5}, {cx + 5, cy - 5}, {cx + 5, cy + 5}, {cx - 5, cy + 5}, {cx - 5, cy - 5}}})
+	f.ID = "x"
+	f.Properties["name"] = "main"
+	roads.AddFeature(f)
+	// 瓦片外的要素被丢弃
+	roads.AddFeature(geom.NewPointFeature([]float64{bound[0][0] - 30, cy}))
+
+	pois := geom.NewFeatureCollection()
+	f = geom.NewFeatureFromGeometryData(geom.NewCollectionGeometryData(
+		geom.NewPointGeometryData([]float64{cx, cy}),
+		geom.NewMultiPointGeometryData([]float64{cx + 1, cy}, []float64{cx + 2, cy})))
+	f.ID = 7
+	f.Properties["kind"] = uint8(3)
+	pois.AddFeature(f)
+
+	data, err := Encode(tile, []*Layer{NewLayer("roads", roads), {Name: "pois", Version: 1, Features: pois.Features}}, nil)
+	assert.NoError(t, err)
+
+	layers, err := Decode(data, nil)
+	assert.NoError(t, err)
+	assert.Len(t, layers, 2)
+	assert.Equal(t, "roads", layers[0].Name)
+	assert.Equal(t, 2, layers[0].Version)
+	assert.Equal(t, 4096, layers[0].Extent)
+	assert.Len(t, layers[0].Features, 2)
+
+	f = layers[0].Features[0]
+	assert.Equal(t, uint64(12), f.ID)
+	assert.Equal(t, map[string]interface{}{"name": "main", "lanes": int64(2), "speed": int64(-30), "width": 7.5, "toll": false, "tags": `["a"]`}, f.Properties)
+	// 线在缓冲区边界被裁剪
+	x, y := tile.Project(cx-10, cy, 4096)
+	assert.Equal(t, [][]float64{{math.Round(x), math.Round(y)}, {4160, math.Round(y)}}, [][]float64{f.GeometryData.LineString[0], f.GeometryData.LineString[len(f.GeometryData.LineString)-1]})
+	assert.Nil(t, layers[0].Features[1].ID)
+	assert.Equal(t, geom.GeometryPolygon, layers[0].Features[1].GeometryData.Type)
+
+	assert.Len(t, layers[1].Features, 2)
+	assert.Equal(t, 1, layers[1].Version)
+	assert.Equal(t, uint64(7), layers[1].Features[0].ID)
+	assert.Nil(t, layers[1].Features[1].ID)
+	assert.Equal(t, uint64(3), layers[1].Features[1].Properties["kind"])
+	assert.Equal(t, geom.GeometryMultiPoint, layers[1].Features[1].GeometryData.Type)
+
+	layers, err = Decode(data, &DecodeOptions{Tile: &tile})
+	assert.NoError(t, err)
+	p := layers[1].Features[0].GeometryData
+	assert.Equal(t, 4326, p.EPSG)
+	assert.InDelta(t, cx, p.Point[0], 0.01)
+	assert.InDelta(t, cy, p.Point[1], 0.01)
+
+	fc := layers[0].FeatureCollection()
+	assert.Len(t, fc.Features, 2)
+
+	_, err = Decode(data[:len(data)-3], nil)
+	assert.Error(t, err)
+	_, err = Encode(tile, nil, &EncodeOptions{})
+	assert.Error(t, err)
+}
+
+// 测试未知类型的要素与无效的范围
+func TestDecodeUnknown(t *testing.T) {
+	tile := func(extent uint64) []byte {
+		var unknown, point, layer, tile pbfWriter
+		unknown.uint(3, typeUnknown)
+		unknown.packed(4, []uint32{9, 2, 2})
+		point.uint(3, typePoint)
+		point.packed(4, []uint32{9, 2, 2})
+		layer.uint(15, 2)
+		layer.string(1, "l")
+		layer.bytes(2, unknown.buf)
+		layer.bytes(2, point.buf)
+		layer.uint(5, extent)
+		tile.bytes(3, layer.buf)
+		return tile.buf
+	}
+	layers, err := Decode(tile(4096), nil)
+	assert.NoError(t, err)
+	assert.Len(t, layers[0].Features, 1)
+	assert.Equal(t, []float64{1, 1}, layers[0].Features[0].GeometryData.Point)
+
+	_, err = Decode(tile(0), nil)
+	assert.Error(t, err)
+}
+
+// 测试投影坐标系的要素
+func TestEncodeProjected(t *testing.T) {
+	fc := geom.NewFeatureCollection()
+	g := geom.NewPointGeometryData([]float64{0, 0})
+	g.EPSG = 3857
+	fc.AddFeature(geom.NewFeatureFromGeometryData(g))
+	data, err := EncodeFeatureCollection(Tile{}, "points", fc, &EncodeOptions{Extent: 256})
+	assert.NoError(t, err)
+	layers, err := Decode(data, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 256, layers[0].Extent)
+	assert.Equal(t, []float64{128, 128}, layers[0].Features[0].GeometryData.Point)
+}
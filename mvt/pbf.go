@@ -0,0 +1,166 @@
+package mvt
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// 协议缓冲区的线路类型
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("truncated mvt message")
+
+// pbfWriter appends the fields of a protocol buffer message.
+type pbfWriter struct {
+	buf []byte
+}
+
+func (w *pbfWriter) varint(v uint64) {
+	w.buf = binary.AppendUvarint(w.buf, v)
+}
+
+func (w *pbfWriter) key(field, wire int) {
+	w.varint(uint64(field)<<3 | uint64(wire))
+}
+
+func (w *pbfWriter) uint(field int, v uint64) {
+	w.key(field, wireVarint)
+	w.varint(v)
+}
+
+func (w *pbfWriter) bytes(field int, b []byte) {
+	w.key(field, wireBytes)
+	w.varint(uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *pbfWriter) string(field int, s string) {
+	w.key(field, wireBytes)
+	w.varint(uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *pbfWriter) float(field int, v float32) {
+	w.key(field, wireFixed32)
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, math.Float32bits(v))
+}
+
+func (w *pbfWriter) double(field int, v float64) {
+	w.key(field, wireFixed64)
+	w.buf = binary.LittleEndian.AppendUint64(w.buf, math.Float64bits(v))
+}
+
+func (w *pbfWriter) packed(field int, vs []uint32) {
+	if len(vs) == 0 {
+		return
+	}
+	var p pbfWriter
+	for _, v := range vs {
+		p.varint(uint64(v))
+	}
+	w.bytes(field, p.buf)
+}
+
+// pbfReader reads the fields of a protocol buffer message.
+type pbfReader struct {
+	buf []byte
+	i   int
+}
+
+func (r *pbfReader) more() bool {
+	return r.i < len(r.buf)
+}
+
+func (r *pbfReader) varint() (uint64, error) {
+	v, n := binary.Uvarint(r.buf[r.i:])
+	if n <= 0 {
+		return 0, errTruncated
+	}
+	r.i += n
+	return v, nil
+}
+
+func (r *pbfReader) key() (field, wire int, err error) {
+	k, err := r.varint()
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(k >> 3), int(k & 7), nil
+}
+
+func (r *pbfReader) bytes() ([]byte, error) {
+	n, err := r.varint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(r.buf)-r.i) {
+		return nil, errTruncated
+	}
+	b := r.buf[r.i : r.i+int(n)]
+	r.i += int(n)
+	return b, nil
+}
+
+func (r *pbfReader) fixed32() (uint32, error) {
+	if len(r.buf)-r.i < 4 {
+		return 0, errTruncated
+	}
+	v := binary.LittleEndian.Uint32(r.buf[r.i:])
+	r.i += 4
+	return v, nil
+}
+
+func (r *pbfReader) fixed64() (uint64, error) {
+	if len(r.buf)-r.i < 8 {
+		return 0, errTruncated
+	}
+	v := binary.LittleEndian.Uint64(r.buf[r.i:])
+	r.i += 8
+	return v, nil
+}
+
+// skip passes over a field of an unknown number.
+func (r *pbfReader) skip(wire int) error {
+	var err error
+	switch wire {
+	case wireVarint:
+		_, err = r.varint()
+	case wireFixed64:
+		_, err = r.fixed64()
+	case wireBytes:
+		_, err = r.bytes()
+	case wireFixed32:
+		_, err = r.fixed32()
+	default:
+		err = fmt.Errorf("unsupported mvt wire type %d", wire)
+	}
+	return err
+}
+
+// packed reads a packed repeated uint32 field, or a single unpacked value.
+func (r *pbfReader) packed(wire int, vs []uint32) ([]uint32, error) {
+	if wire == wireVarint {
+		v, err := r.varint()
+		return append(vs, uint32(v)), err
+	}
+	b, err := r.bytes()
+	if err != nil {
+		return nil, err
+	}
+	p := pbfReader{buf: b}
+	for p.more() {
+		v, err := p.varint()
+		if err != nil {
+			return nil, err
+		}
+		vs = append(vs, uint32(v))
+	}
+	return vs, nil
+}
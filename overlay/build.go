@@ -0,0 +1,66 @@
+package overlay
+
+import (
+	"github.com/flywave/go-geom/internal/topology"
+)
+
+// buildLines merges the result edges into lines, joining them at vertices
+// where exactly two result edges meet.
+func buildLines(edges []*topology.Edge, inLine map[*topology.Edge]bool) [][][]float64 {
+	degree := func(v *topology.Vertex) (int, []*topology.HalfEdge) {
+		var out []*topology.HalfEdge
+		for _, h := range v.Out {
+			if inLine[h.Edge] {
+				out = append(out, h)
+			}
+		}
+		return len(out), out
+	}
+	visited := make(map[*topology.Edge]bool)
+	next := func(v *topology.Vertex) *topology.HalfEdge {
+		n, out := degree(v)
+		if n != 2 {
+			return nil
+		}
+		for _, h := range out {
+			if !visited[h.Edge] {
+				return h
+			}
+		}
+		return nil
+	}
+
+	var lines [][][]float64
+	for _, e := range edges {
+		if visited[e] {
+			continue
+		}
+		h := e.Half
+		if e.Line[0] {
+			h = e.LineHalf(0)
+		} else if e.Line[1] {
+			h = e.LineHalf(1)
+		}
+		visited[e] = true
+		chain := []*topology.HalfEdge{h}
+		for x := next(h.Dest()); x != nil; x = next(x.Dest()) {
+			visited[x.Edge] = true
+			chain = append(chain, x)
+		}
+		var head []*topology.HalfEdge
+		for x := next(h.Origin); x != nil; x = next(x.Dest()) {
+			visited[x.Edge] = true
+			head = append(head, x.Twin)
+		}
+		line := make([][]float64, 0, len(head)+len(chain)+1)
+		for i := len(head) - 1; i >= 0; i-- {
+			line = append(line, []float64{head[i].Origin.XY[0], head[i].Origin.XY[1]})
+		}
+		for _, x := range chain {
+			line = append(line, []float64{x.Origin.XY[0], x.Origin.XY[1]})
+		}
+		last := chain[len(chain)-1].Dest().XY
+		lines = append(lines, append(line, []float64{last[0], last[1]}))
+	}
+	return lines
+}
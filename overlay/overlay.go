@@ -0,0 +1,146 @@
+// Package overlay computes the boolean operations of two geometries:
+// intersection, union, difference and symmetric difference.
+//
+// Both inputs are noded together so touching or overlapping edges are
+// handled exactly. Polygons of the result have counter-clockwise shells and
+// clockwise holes as RFC 7946 recommends. Lines and points only appear in
+// the result where they are not covered by a result polygon, an overlay
+// collapsing to nothing returns an empty GeometryCollection. Coordinates of
+// the result are 2D.
+package overlay
+
+import (
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/internal/topology"
+)
+
+type Op int
+
+const (
+	OpIntersection Op = iota
+	OpUnion
+	OpDifference
+	OpSymDifference
+)
+
+func (op Op) selects(a, b bool) bool {
+	switch op {
+	case OpIntersection:
+		return a && b
+	case OpUnion:
+		return a || b
+	case OpDifference:
+		return a && !b
+	case OpSymDifference:
+		return a != b
+	}
+	return false
+}
+
+func Intersection(a, b *geom.GeometryData) *geom.GeometryData {
+	return Overlay(a, b, OpIntersection)
+}
+
+func Union(a, b *geom.GeometryData) *geom.GeometryData {
+	return Overlay(a, b, OpUnion)
+}
+
+func Difference(a, b *geom.GeometryData) *geom.GeometryData {
+	return Overlay(a, b, OpDifference)
+}
+
+func SymDifference(a, b *geom.GeometryData) *geom.GeometryData {
+	return Overlay(a, b, OpSymDifference)
+}
+
+// UnaryUnion merges the parts of g, dissolving overlapping polygons and
+// the lines and points they cover.
+func UnaryUnion(g *geom.GeometryData) *geom.GeometryData {
+	return Overlay(g, nil, OpUnion)
+}
+
+// Overlay computes op between a and b.
+func Overlay(a, b *geom.GeometryData, op Op) *geom.GeometryData {
+	g := topology.New(a, b)
+
+	inArea := func(c *topology.Cycle) bool {
+		return op.selects(c.Loc[0] == topology.Interior, c.Loc[1] == topology.Interior)
+	}
+	covered := func(e *topology.Edge) bool {
+		return inArea(e.Half.Cycle) || inArea(e.Half.Twin.Cycle)
+	}
+
+	var lineEdges []*topology.Edge
+	inLine := make(map[*topology.Edge]bool)
+	for _, e := range g.Edges {
+		if !covered(e) && op.selects(e.Loc[0] != topology.Exterior, e.Loc[1] != topology.Exterior) {
+			lineEdges = append(lineEdges, e)
+			inLine[e] = true
+		}
+	}
+
+	var points [][]float64
+	for _, v := range g.Vertices {
+		if !op.selects(v.Loc[0] != topology.Exterior, v.Loc[1] != topology.Exterior) {
+			continue
+		}
+		if len(v.Out) == 0 {
+			if op.selects(v.Area[0] == topology.Interior, v.Area[1] == topology.Interior) {
+				continue
+			}
+		} else if vertexCovered(v, covered, inLine) {
+			continue
+		}
+		points = append(points, []float64{v.XY[0], v.XY[1]})
+	}
+
+	ret := collect(g.Polygons(inArea), buildLines(lineEdges, inLine), points)
+	for _, in := range [2]*geom.GeometryData{a, b} {
+		if in != nil && in.EPSG != 0 {
+			ret.EPSG = in.EPSG
+			break
+		}
+	}
+	return ret
+}
+
+func vertexCovered(v *topology.Vertex, covered func(*topology.Edge) bool, inLine map[*topology.Edge]bool) bool {
+	for _, h := range v.Out {
+		if inLine[h.Edge] || covered(h.Edge) {
+			return true
+		}
+	}
+	return false
+}
+
+func collect(polygons [][][][]float64, lines [][][]float64, points [][]float64) *geom.GeometryData {
+	var parts []*geom.GeometryData
+	switch len(polygons) {
+	case 0:
+	case 1:
+		parts = append(parts, geom.NewPolygonGeometryData(polygons[0]))
+	default:
+		parts = append(parts, geom.NewMultiPolygonGeometryData(polygons...))
+	}
+	switch len(lines) {
+	case 0:
+	case 1:
+		parts = append(parts, geom.NewLineStringGeometryData(lines[0]))
+	default:
+		parts = append(parts, geom.NewMultiLineStringGeometryData(lines...))
+	}
+	switch len(points) {
+	case 0:
+	case 1:
+		parts = append(parts, geom.NewPointGeometryData(points[0]))
+	default:
+		parts = append(parts, geom.NewMultiPointGeometryData(points...))
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	if parts == nil {
+		parts = []*geom.GeometryData{}
+	}
+	return geom.NewCollectionGeometryData(parts...)
+}
@@ -0,0 +1,198 @@
+package overlay
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/measure"
+	"github.com/flywave/go-geom/relate"
+	"github.com/stretchr/testify/assert"
+)
+
+func square(x, y, size float64) [][]float64 {
+	return [][]float64{{x, y}, {x + size, y}, {x + size, y + size}, {x, y + size}, {x, y}}
+}
+
+func polygon(rings ...[][]float64) *geom.GeometryData {
+	return geom.NewPolygonGeometryData(rings)
+}
+
+// 测试结果多边形满足RFC 7946的环方向
+func assertWellFormed(t *testing.T, g *geom.GeometryData) {
+	var polygons [][][][]float64
+	switch g.Type {
+	case geom.GeometryPolygon:
+		polygons = [][][][]float64{g.Polygon}
+	case geom.GeometryMultiPolygon:
+		polygons = g.MultiPolygon
+	}
+	for _, p := range polygons {
+		for i, r := range p {
+			assert.Equal(t, r[0], r[len(r)-1])
+			if i == 0 {
+				assert.Greater(t, measure.RingSignedArea(r), 0.0)
+			} else {
+				assert.Less(t, measure.RingSignedArea(r), 0.0)
+			}
+		}
+	}
+}
+
+func TestOverlayPolygons(t *testing.T) {
+	a := polygon(square(0, 0, 2))
+	b := polygon(square(1, 1, 2))
+
+	inter := Intersection(a, b)
+	assert.Equal(t, geom.GeometryPolygon, inter.Type)
+	assert.Equal(t, 1.0, measure.AreaData(inter))
+	assert.True(t, relate.Equals(inter, polygon(square(1, 1, 1))))
+
+	union := Union(a, b)
+	assert.Equal(t, geom.GeometryPolygon, union.Type)
+	assert.Equal(t, 7.0, measure.AreaData(union))
+	assert.Len(t, union.Polygon[0], 9)
+
+	diff := Difference(a, b)
+	assert.Equal(t, 3.0, measure.AreaData(diff))
+	assert.True(t, relate.Covers(a, diff))
+	assert.False(t, relate.Overlaps(diff, b))
+
+	sym := SymDifference(a, b)
+	assert.Equal(t, geom.GeometryMultiPolygon, sym.Type)
+	assert.Len(t, sym.MultiPolygon, 2)
+	assert.Equal(t, 6.0, measure.AreaData(sym))
+
+	for _, g := range []*geom.GeometryData{inter, union, diff, sym} {
+		assertWellFormed(t, g)
+	}
+
+	// 不相交的多边形
+	far := polygon(square(5, 5, 1))
+	assert.Equal(t, geom.GeometryMultiPolygon, Union(a, far).Type)
+	empty := Intersection(a, far)
+	assert.Equal(t, geom.GeometryCollection, empty.Type)
+	assert.Len(t, empty.Geometries, 0)
+	assert.True(t, relate.Equals(a, Difference(a, far)))
+}
+
+// 测试带洞的多边形
+func TestOverlayHoles(t *testing.T) {
+	outer := polygon(square(0, 0, 10))
+	inner := polygon(square(4, 4, 2))
+
+	holed := Difference(outer, inner)
+	assert.Equal(t, geom.GeometryPolygon, holed.Type)
+	assert.Len(t, holed.Polygon, 2)
+	assert.Equal(t, 96.0, measure.AreaData(holed))
+	assertWellFormed(t, holed)
+
+	// 填充洞
+	filled := Union(holed, inner)
+	assert.Len(t, filled.Polygon, 1)
+	assert.True(t, relate.Equals(filled, outer))
+
+	// 洞内的多边形不相交
+	assert.Equal(t, 0, len(Intersection(holed, polygon(square(4.5, 4.5, 1))).Geometries))
+
+	// 洞与外环在一点相接
+	touching := Difference(outer, polygon([][]float64{{0, 0}, {3, 2}, {2, 3}, {0, 0}}))
+	assert.InDelta(t, 97.5, measure.AreaData(touching), 1e-9)
+	assertWellFormed(t, touching)
+
+	// 洞与外环在边上相接时成为外环的一部分
+	notch := Difference(outer, polygon(square(0, 4, 2)))
+	assert.Equal(t, geom.GeometryPolygon, notch.Type)
+	assert.Len(t, notch.Polygon, 1)
+	assert.Equal(t, 96.0, measure.AreaData(notch))
+}
+
+// 测试退化结果
+func TestOverlayDegenerate(t *testing.T) {
+	a := polygon(square(0, 0, 1))
+
+	edge := Intersection(a, polygon(square(1, 0, 1)))
+	assert.Equal(t, geom.GeometryLineString, edge.Type)
+	assert.True(t, relate.Equals(edge, geom.NewLineStringGeometryData([][]float64{{1, 0}, {1, 1}})))
+
+	corner := Intersection(a, polygon(square(1, 1, 1)))
+	assert.Equal(t, geom.GeometryPoint, corner.Type)
+	assert.Equal(t, []float64{1, 1}, corner.Point)
+
+	assert.Equal(t, 0, len(Difference(a, a).Geometries))
+	assert.True(t, relate.Equals(a, Union(a, a)))
+	assert.True(t, relate.Equals(a, Intersection(a, polygon([][]float64{{1, 1}, {0, 1}, {0, 0}, {1, 0}, {1, 1}}))))
+
+	// 相接的多边形合并为一个
+	merged := Union(a, polygon(square(1, 0, 1)))
+	assert.Equal(t, geom.GeometryPolygon, merged.Type)
+	assert.True(t, relate.Equals(merged, polygon([][]float64{{0, 0}, {2, 0}, {2, 1}, {0, 1}, {0, 0}})))
+
+	mp := geom.NewMultiPolygonGeometryData([][][]float64{square(0, 0, 2)}, [][][]float64{square(1, 1, 2)})
+	dissolved := UnaryUnion(mp)
+	assert.Equal(t, geom.GeometryPolygon, dissolved.Type)
+	assert.Equal(t, 7.0, measure.AreaData(dissolved))
+}
+
+func TestOverlayLines(t *testing.T) {
+	a := polygon(square(0, 0, 2))
+	line := geom.NewLineStringGeometryData([][]float64{{-1, 1}, {3, 1}})
+
+	clipped := Intersection(line, a)
+	assert.Equal(t, geom.GeometryLineString, clipped.Type)
+	assert.Equal(t, [][]float64{{0, 1}, {2, 1}}, clipped.LineString)
+
+	outside := Difference(line, a)
+	assert.Equal(t, geom.GeometryMultiLineString, outside.Type)
+	assert.Equal(t, 2.0, measure.LengthData(outside))
+
+	// 线不改变多边形的面积
+	assert.True(t, relate.Equals(a, Difference(a, line)))
+	union := Union(a, line)
+	assert.Equal(t, geom.GeometryCollection, union.Type)
+	assert.Equal(t, 4.0, measure.AreaData(union))
+	assert.Equal(t, 2.0, measure.LengthData(union))
+
+	// 沿边界的线
+	border := Intersection(geom.NewLineStringGeometryData([][]float64{{-1, 0}, {1, 0}}), a)
+	assert.Equal(t, [][]float64{{0, 0}, {1, 0}}, border.LineString)
+
+	// 线的方向保持不变
+	reversed := Intersection(geom.NewLineStringGeometryData([][]float64{{3, 1}, {1, 1}, {1, -1}}), a)
+	assert.Equal(t, [][]float64{{2, 1}, {1, 1}, {1, 0}}, reversed.LineString)
+
+	lines := Union(geom.NewLineStringGeometryData([][]float64{{0, 0}, {2, 2}}), geom.NewLineStringGeometryData([][]float64{{0, 2}, {2, 0}}))
+	assert.Equal(t, geom.GeometryMultiLineString, lines.Type)
+	assert.Len(t, lines.MultiLineString, 4)
+}
+
+func TestOverlayPoints(t *testing.T) {
+	a := polygon(square(0, 0, 2))
+	pts := geom.NewMultiPointGeometryData([]float64{1, 1}, []float64{5, 5}, []float64{0, 1})
+
+	inter := Intersection(pts, a)
+	assert.Equal(t, geom.GeometryMultiPoint, inter.Type)
+	assert.ElementsMatch(t, [][]float64{{1, 1}, {0, 1}}, inter.MultiPoint)
+
+	diff := Difference(pts, a)
+	assert.Equal(t, geom.GeometryPoint, diff.Type)
+	assert.Equal(t, []float64{5, 5}, diff.Point)
+
+	union := Union(a, pts)
+	assert.Equal(t, geom.GeometryCollection, union.Type)
+	assert.Len(t, union.Geometries, 2)
+	assert.Equal(t, []float64{5, 5}, union.Geometries[1].Point)
+}
+
+func TestOverlayGeoJSON(t *testing.T) {
+	a := polygon(square(0, 0, 2))
+	a.EPSG = 4326
+	ret := Intersection(a, polygon(square(1, 1, 2)))
+	assert.Equal(t, 4326, ret.EPSG)
+
+	data, err := json.Marshal(ret)
+	assert.NoError(t, err)
+	back, err := geom.UnmarshalGeometry(data)
+	assert.NoError(t, err)
+	assert.True(t, relate.Equals(ret, back))
+}
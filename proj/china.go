@@ -0,0 +1,80 @@
+package proj
+
+import (
+	"math"
+)
+
+// GCJ02Projection is the obfuscated geographic system mandated for the maps
+// published in China. Positions outside of China are not offset. The
+// inverse is solved iteratively to about a millimeter.
+type GCJ02Projection struct{}
+
+// BD09Projection is the geographic system of Baidu maps, a further offset
+// of GCJ-02.
+type BD09Projection struct{}
+
+const (
+	gcjA  = 6378245.0
+	gcjEE = 0.00669342162296594323
+	bdPi  = math.Pi * 3000 / 180
+)
+
+func outOfChina(lon, lat float64) bool {
+	return lon < 72.004 || lon > 137.8347 || lat < 0.8293 || lat > 55.8271
+}
+
+func gcjOffset(lon, lat float64) (float64, float64) {
+	x, y := lon-105, lat-35
+	dLat := -100 + 2*x + 3*y + 0.2*y*y + 0.1*x*y + 0.2*math.Sqrt(math.Abs(x))
+	dLon := 300 + x + 2*y + 0.1*x*x + 0.1*x*y + 0.1*math.Sqrt(math.Abs(x))
+	common := (20*math.Sin(6*x*math.Pi) + 20*math.Sin(2*x*math.Pi)) * 2 / 3
+	dLat += common + (20*math.Sin(y*math.Pi)+40*math.Sin(y/3*math.Pi))*2/3 +
+		(160*math.Sin(y/12*math.Pi)+320*math.Sin(y*math.Pi/30))*2/3
+	dLon += common + (20*math.Sin(x*math.Pi)+40*math.Sin(x/3*math.Pi))*2/3 +
+		(150*math.Sin(x/12*math.Pi)+300*math.Sin(x/30*math.Pi))*2/3
+
+	s := math.Sin(lat * degree)
+	magic := 1 - gcjEE*s*s
+	sqrtMagic := math.Sqrt(magic)
+	dLat = dLat / ((gcjA * (1 - gcjEE)) / (magic * sqrtMagic) * degree)
+	dLon = dLon / (gcjA / sqrtMagic * math.Cos(lat*degree) * degree)
+	return dLon, dLat
+}
+
+func (GCJ02Projection) Forward(lon, lat float64) (float64, float64) {
+	if outOfChina(lon, lat) {
+		return lon, lat
+	}
+	dLon, dLat := gcjOffset(lon, lat)
+	return lon + dLon, lat + dLat
+}
+
+func (p GCJ02Projection) Inverse(x, y float64) (float64, float64) {
+	if outOfChina(x, y) {
+		return x, y
+	}
+	lon, lat := x, y
+	for i := 0; i < 30; i++ {
+		gx, gy := p.Forward(lon, lat)
+		dx, dy := gx-x, gy-y
+		lon, lat = lon-dx, lat-dy
+		if math.Abs(dx) < 1e-9 && math.Abs(dy) < 1e-9 {
+			break
+		}
+	}
+	return lon, lat
+}
+
+func (BD09Projection) Forward(lon, lat float64) (float64, float64) {
+	x, y := GCJ02Projection{}.Forward(lon, lat)
+	z := math.Hypot(x, y) + 0.00002*math.Sin(y*bdPi)
+	theta := math.Atan2(y, x) + 0.000003*math.Cos(x*bdPi)
+	return z*math.Cos(theta) + 0.0065, z*math.Sin(theta) + 0.006
+}
+
+func (BD09Projection) Inverse(x, y float64) (float64, float64) {
+	x, y = x-0.0065, y-0.006
+	z := math.Hypot(x, y) - 0.00002*math.Sin(y*bdPi)
+	theta := math.Atan2(y, x) - 0.000003*math.Cos(x*bdPi)
+	return GCJ02Projection{}.Inverse(z*math.Cos(theta), z*math.Sin(theta))
+}
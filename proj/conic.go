@@ -0,0 +1,152 @@
+package proj
+
+import (
+	"math"
+)
+
+// LambertConformalConic is the ellipsoidal Lambert Conformal Conic
+// projection with two standard parallels, after J. P. Snyder, "Map
+// Projections: A Working Manual" (1987).
+type LambertConformalConic struct {
+	ellipsoid Ellipsoid
+	lon0      float64
+	x0, y0    float64
+	n, af     float64
+	rho0      float64
+}
+
+// NewLambertConformalConic returns the projection with false origin at
+// lon0, lat0 and standard parallels lat1 and lat2, all in degrees. Equal
+// parallels give the one standard parallel variant.
+func NewLambertConformalConic(ellipsoid Ellipsoid, lon0, lat0, lat1, lat2, falseEasting, falseNorthing float64) *LambertConformalConic {
+	p := &LambertConformalConic{ellipsoid: ellipsoid, lon0: lon0, x0: falseEasting, y0: falseNorthing}
+	m1, m2 := ellipsoid.m(lat1), ellipsoid.m(lat2)
+	t1, t2 := ellipsoid.t(lat1), ellipsoid.t(lat2)
+	if lat1 == lat2 {
+		p.n = math.Sin(lat1 * degree)
+	} else {
+		p.n = (math.Log(m1) - math.Log(m2)) / (math.Log(t1) - math.Log(t2))
+	}
+	p.af = ellipsoid.A * m1 / (p.n * math.Pow(t1, p.n))
+	p.rho0 = p.rho(lat0)
+	return p
+}
+
+// m returns the radius of the parallel at lat over the equatorial radius.
+func (e Ellipsoid) m(lat float64) float64 {
+	s := math.Sin(lat * degree)
+	return math.Cos(lat*degree) / math.Sqrt(1-e.E2()*s*s)
+}
+
+// t returns exp(-psi) with psi the isometric latitude of lat.
+func (e Ellipsoid) t(lat float64) float64 {
+	return math.Exp(-math.Asinh(e.conformal(math.Tan(lat * degree))))
+}
+
+func (p *LambertConformalConic) rho(lat float64) float64 {
+	if lat*p.n >= 90*math.Abs(p.n) {
+		return 0
+	}
+	return p.af * math.Pow(p.ellipsoid.t(lat), p.n)
+}
+
+func (p *LambertConformalConic) Forward(lon, lat float64) (float64, float64) {
+	rho := p.rho(lat)
+	theta := p.n * normalize((lon-p.lon0)*degree)
+	return p.x0 + rho*math.Sin(theta), p.y0 + p.rho0 - rho*math.Cos(theta)
+}
+
+func (p *LambertConformalConic) Inverse(x, y float64) (float64, float64) {
+	dx, dy := x-p.x0, p.rho0-(y-p.y0)
+	if p.n < 0 {
+		dx, dy = -dx, -dy
+	}
+	rho := math.Copysign(math.Hypot(dx, dy), p.n)
+	if rho == 0 {
+		return p.lon0, math.Copysign(90, p.n)
+	}
+	t := math.Pow(rho/p.af, 1/p.n)
+	lon := p.lon0 + math.Atan2(dx, dy)/p.n/degree
+	return lon, latitude(p.ellipsoid.geographic(math.Sinh(-math.Log(t))))
+}
+
+// AlbersEqualArea is the ellipsoidal Albers Equal-Area Conic projection,
+// after Snyder.
+type AlbersEqualArea struct {
+	ellipsoid Ellipsoid
+	lon0      float64
+	x0, y0    float64
+	n, c      float64
+	rho0      float64
+}
+
+// NewAlbersEqualArea returns the projection with false origin at lon0,
+// lat0 and standard parallels lat1 and lat2, all in degrees.
+func NewAlbersEqualArea(ellipsoid Ellipsoid, lon0, lat0, lat1, lat2, falseEasting, falseNorthing float64) *AlbersEqualArea {
+	p := &AlbersEqualArea{ellipsoid: ellipsoid, lon0: lon0, x0: falseEasting, y0: falseNorthing}
+	m1, m2 := ellipsoid.m(lat1), ellipsoid.m(lat2)
+	q1, q2 := ellipsoid.q(lat1), ellipsoid.q(lat2)
+	if lat1 == lat2 {
+		p.n = math.Sin(lat1 * degree)
+	} else {
+		p.n = (m1*m1 - m2*m2) / (q2 - q1)
+	}
+	p.c = m1*m1 + p.n*q1
+	p.rho0 = p.rho(lat0)
+	return p
+}
+
+// q is the authalic function of Snyder.
+func (e Ellipsoid) q(lat float64) float64 {
+	s := math.Sin(lat * degree)
+	e2 := e.E2()
+	if e2 == 0 {
+		return 2 * s
+	}
+	es := math.Sqrt(e2)
+	return (1 - e2) * (s/(1-e2*s*s) + math.Atanh(es*s)/es)
+}
+
+func (p *AlbersEqualArea) rho(lat float64) float64 {
+	return p.ellipsoid.A * math.Sqrt(math.Max(0, p.c-p.n*p.ellipsoid.q(lat))) / p.n
+}
+
+func (p *AlbersEqualArea) Forward(lon, lat float64) (float64, float64) {
+	rho := p.rho(lat)
+	theta := p.n * normalize((lon-p.lon0)*degree)
+	return p.x0 + rho*math.Sin(theta), p.y0 + p.rho0 - rho*math.Cos(theta)
+}
+
+func (p *AlbersEqualArea) Inverse(x, y float64) (float64, float64) {
+	dx, dy := x-p.x0, p.rho0-(y-p.y0)
+	if p.n < 0 {
+		dx, dy = -dx, -dy
+	}
+	rho := math.Hypot(dx, dy)
+	a := p.ellipsoid.A
+	q := (p.c - rho*rho*p.n*p.n/(a*a)) / p.n
+	lon := p.lon0 + math.Atan2(dx, dy)/p.n/degree
+
+	e2 := p.ellipsoid.E2()
+	es := math.Sqrt(e2)
+	// 极点处的 q 值, 超出时取极点
+	if qp := p.ellipsoid.q(90); math.Abs(q) >= qp {
+		return lon, math.Copysign(90, q)
+	}
+	phi := math.Asin(q / 2)
+	for i := 0; i < 15; i++ {
+		s := math.Sin(phi)
+		w := 1 - e2*s*s
+		var d float64
+		if e2 == 0 {
+			d = (q/2 - s) / math.Cos(phi)
+		} else {
+			d = w * w / (2 * math.Cos(phi)) * (q/(1-e2) - s/w - math.Atanh(es*s)/es)
+		}
+		phi += d
+		if math.Abs(d) < 1e-13 {
+			break
+		}
+	}
+	return lon, phi / degree
+}
@@ -0,0 +1,71 @@
+package proj
+
+import (
+	"math"
+)
+
+// Ellipsoid is a reference ellipsoid of equatorial radius A in meters and
+// flattening F.
+type Ellipsoid struct {
+	A, F float64
+}
+
+var (
+	WGS84Ellipsoid    = Ellipsoid{A: 6378137, F: 1 / 298.257223563}
+	GRS80Ellipsoid    = Ellipsoid{A: 6378137, F: 1 / 298.257222101}
+	CGCS2000Ellipsoid = GRS80Ellipsoid
+	Clarke1866        = Ellipsoid{A: 6378206.4, F: 1 / 294.978698214}
+	Airy1830          = Ellipsoid{A: 6377563.396, F: 1 / 299.3249646}
+	Krassovsky1940    = Ellipsoid{A: 6378245, F: 1 / 298.3}
+)
+
+// E returns the first eccentricity.
+func (e Ellipsoid) E() float64 {
+	return math.Sqrt(e.E2())
+}
+
+// E2 returns the square of the first eccentricity.
+func (e Ellipsoid) E2() float64 {
+	return e.F * (2 - e.F)
+}
+
+// conformal returns the tangent of the conformal latitude of the latitude
+// whose tangent is tau.
+func (e Ellipsoid) conformal(tau float64) float64 {
+	es := e.E()
+	tau1 := math.Hypot(1, tau)
+	sig := math.Sinh(es * math.Atanh(es*tau/tau1))
+	return math.Hypot(1, sig)*tau - sig*tau1
+}
+
+// geographic inverts conformal by Newton's method.
+func (e Ellipsoid) geographic(taup float64) float64 {
+	e2m := 1 - e.E2()
+	tau := taup / e2m
+	for i := 0; i < 10; i++ {
+		tau1 := math.Hypot(1, tau)
+		taupa := e.conformal(tau)
+		dtau := (taup - taupa) * (1 + e2m*tau*tau) / (e2m * tau1 * math.Hypot(1, taupa))
+		tau += dtau
+		if math.Abs(dtau) < 1e-14*math.Max(1, math.Abs(tau)) {
+			break
+		}
+	}
+	return tau
+}
+
+const degree = math.Pi / 180
+
+// normalize wraps an angle in radians to [-pi, pi].
+func normalize(a float64) float64 {
+	a = math.Remainder(a, 2*math.Pi)
+	if a == -math.Pi {
+		return math.Pi
+	}
+	return a
+}
+
+// latitude returns the latitude in degrees whose tangent is tau.
+func latitude(tau float64) float64 {
+	return math.Atan(tau) / degree
+}
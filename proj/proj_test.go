@@ -0,0 +1,207 @@
+package proj
+
+import (
+	"math"
+	"testing"
+
+	"github.com/flywave/go-geom"
+	"github.com/stretchr/testify/assert"
+)
+
+func roundTrip(t *testing.T, p Projection, lon, lat float64) {
+	x, y := p.Forward(lon, lat)
+	lon2, lat2 := p.Inverse(x, y)
+	assert.InDelta(t, lon, lon2, 1e-9)
+	assert.InDelta(t, lat, lat2, 1e-9)
+}
+
+func TestWebMercator(t *testing.T) {
+	x, y, err := Transform(180, 0, 4326, 3857)
+	assert.NoError(t, err)
+	assert.InDelta(t, 20037508.342789244, x, 1e-6)
+	assert.InDelta(t, 0, y, 1e-6)
+
+	_, y, _ = Transform(0, MaxLatitude, 4326, 3857)
+	assert.InDelta(t, 20037508.342789244, y, 1e-6)
+	_, y, _ = Transform(0, 89.9, 4326, 3857)
+	assert.InDelta(t, 20037508.342789244, y, 1e-6)
+
+	roundTrip(t, WebMercator{}, 116.39, 39.9)
+	roundTrip(t, NewMercator(WGS84Ellipsoid, 0, 1, 0, 0), -70, -45)
+	// 椭球墨卡托的纬度方向比球面短
+	_, y1, _ := Transform(0, 60, 4326, 3395)
+	_, y2, _ := Transform(0, 60, 4326, 3857)
+	assert.True(t, y1 < y2)
+}
+
+func TestTransverseMercator(t *testing.T) {
+	// EPSG Guidance Note 7-2 的英国国家格网示例
+	osgb := NewTransverseMercator(Airy1830, -2, 49, 0.9996012717, 400000, -100000)
+	x, y := osgb.Forward(0.5, 50.5)
+	assert.InDelta(t, 577274.99, x, 0.01)
+	assert.InDelta(t, 69740.50, y, 0.01)
+	roundTrip(t, osgb, 0.5, 50.5)
+
+	x, y, err := Transform(3, 0, 4326, 32631)
+	assert.NoError(t, err)
+	assert.InDelta(t, 500000, x, 1e-6)
+	assert.InDelta(t, 0, y, 1e-6)
+	_, y, _ = Transform(3, 0, 4326, 32731)
+	assert.InDelta(t, 10000000, y, 1e-6)
+
+	assert.Equal(t, 50, UTMZone(116.4))
+	assert.Equal(t, 1, UTMZone(-180))
+	assert.Equal(t, 60, UTMZone(179.9))
+	for _, p := range [][2]float64{{116.4, 39.9}, {120.9, 10}, {111, 70}, {117, -33}} {
+		roundTrip(t, NewUTM(50, p[1] < 0), p[0], p[1])
+	}
+
+	// CGCS2000 3 度带第 39 带, 中央经线 117
+	x, y, err = Transform(117, 30, 4490, 4527)
+	assert.NoError(t, err)
+	assert.InDelta(t, 39500000, x, 1e-6)
+	assert.InDelta(t, 3320113.398, y, 1e-3)
+	x2, y2, _ := Transform(117, 30, 4490, 4548)
+	assert.InDelta(t, 500000, x2, 1e-6)
+	assert.Equal(t, y, y2)
+	// 6 度带第 20 带
+	x, _, _ = Transform(117, 30, 4490, 4498)
+	assert.InDelta(t, 20500000, x, 1e-6)
+}
+
+func TestConic(t *testing.T) {
+	// EPSG Guidance Note 7-2 的德州南中部示例
+	lcc := NewLambertConformalConic(Clarke1866, -99, 27+50.0/60, 28+23.0/60, 30+17.0/60, 609601.2192, 0)
+	x, y := lcc.Forward(-96, 28.5)
+	assert.InDelta(t, 903277.798, x, 1e-2)
+	assert.InDelta(t, 77650.942, y, 1e-2)
+	roundTrip(t, lcc, -96, 28.5)
+	roundTrip(t, NewLambertConformalConic(WGS84Ellipsoid, 105, 0, 25, 47, 0, 0), 80, 50)
+	// 南半球
+	roundTrip(t, NewLambertConformalConic(WGS84Ellipsoid, 135, -32, -30, -36, 0, 0), 140, -40)
+	roundTrip(t, NewLambertConformalConic(WGS84Ellipsoid, 0, 45, 45, 45, 0, 0), 5, 40)
+
+	// Snyder 书中的算例
+	aea := NewAlbersEqualArea(Clarke1866, -96, 23, 29.5, 45.5, 0, 0)
+	x, y = aea.Forward(-75, 35)
+	assert.InDelta(t, 1885472.7, x, 0.1)
+	assert.InDelta(t, 1535925.0, y, 0.1)
+	roundTrip(t, aea, -75, 35)
+	roundTrip(t, NewAlbersEqualArea(WGS84Ellipsoid, 105, 0, 25, 47, 0, 0), 80, 50)
+	roundTrip(t, NewAlbersEqualArea(WGS84Ellipsoid, 135, 0, -18, -36, 0, 0), 120, -20)
+}
+
+func TestChinaOffsets(t *testing.T) {
+	gcj := GCJ02Projection{}
+	lon, lat := gcj.Forward(116.397, 39.908)
+	// 北京一带约偏移数百米
+	assert.InDelta(t, 0.0062, lon-116.397, 0.001)
+	assert.InDelta(t, 0.0014, lat-39.908, 0.001)
+	lon, lat = gcj.Inverse(lon, lat)
+	assert.InDelta(t, 116.397, lon, 1e-8)
+	assert.InDelta(t, 39.908, lat, 1e-8)
+
+	lon, lat = gcj.Forward(2.35, 48.85)
+	assert.Equal(t, []float64{2.35, 48.85}, []float64{lon, lat})
+
+	x, y, err := Transform(121.47, 31.23, 4326, BD09)
+	assert.NoError(t, err)
+	gx, gy, _ := Transform(121.47, 31.23, 4326, GCJ02)
+	assert.InDelta(t, 0.0065, x-gx, 0.001)
+	assert.InDelta(t, 0.006, y-gy, 0.001)
+	x, y, _ = Transform(x, y, BD09, GCJ02)
+	assert.InDelta(t, gx, x, 1e-5)
+	assert.InDelta(t, gy, y, 1e-5)
+	x, y, _ = Transform(gx, gy, GCJ02, 3857)
+	ex, ey, _ := Transform(121.47, 31.23, 4326, 3857)
+	assert.InDelta(t, ex, x, 1e-3)
+	assert.InDelta(t, ey, y, 1e-3)
+}
+
+func TestRegister(t *testing.T) {
+	_, err := Lookup(123456)
+	assert.Error(t, err)
+	_, _, err = Transform(0, 0, 4326, 123456)
+	assert.Error(t, err)
+
+	Register(123456, NewAlbersEqualArea(WGS84Ellipsoid, 105, 0, 25, 47, 0, 0))
+	t.Cleanup(func() {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+		delete(registry, 123456)
+	})
+	x, y, err := Transform(105, 0, 4326, 123456)
+	assert.NoError(t, err)
+	assert.InDelta(t, 0, x, 1e-6)
+	assert.InDelta(t, 0, y, 1e-6)
+
+	p, err := Lookup(0)
+	assert.NoError(t, err)
+	assert.Equal(t, LonLat{}, p)
+}
+
+func TestTransformGeometry(t *testing.T) {
+	g := geom.NewCollectionGeometryData(
+		geom.NewPointGeometryData([]float64{116.4, 39.9, 50}),
+		geom.NewPolygonGeometryData([][][]float64{{{116, 39}, {117, 39}, {117, 40}, {116, 39}}}),
+		geom.NewMultiLineStringGeometryData([][]float64{{116, 39}, {117, 40}}),
+	)
+	g.EPSG = 4326
+	ret, err := TransformGeometryData(g, 32650)
+	assert.NoError(t, err)
+	assert.Equal(t, 32650, ret.EPSG)
+	assert.Equal(t, 32650, ret.Geometries[0].EPSG)
+	assert.Equal(t, 50.0, ret.Geometries[0].Point[2])
+	assert.True(t, ret.Geometries[1].Polygon[0][0][0] > 400000)
+	// 原几何不变
+	assert.Equal(t, []float64{116.4, 39.9, 50}, g.Geometries[0].Point)
+
+	back, err := TransformGeometryData(ret, 0)
+	assert.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{116.4, 39.9, 50}, back.Geometries[0].Point, 1e-9)
+	assert.InDeltaSlice(t, []float64{117, 40}, back.Geometries[2].MultiLineString[0][1], 1e-9)
+
+	// M 坐标原样保留
+	m := &geom.GeometryData{Type: geom.GeometryLineString, LineString: [][]float64{{116, 39, 5}, {117, 40, 8}}, Layout: geom.XYM}
+	ret, _ = TransformGeometryData(m, 3857)
+	assert.Equal(t, geom.XYM, ret.Layout)
+	assert.Equal(t, 8.0, ret.LineString[1][2])
+
+	_, err = TransformGeometryData(&geom.GeometryData{Type: geom.GeometryPoint, Point: []float64{0, 0}, EPSG: 31}, 4326)
+	assert.Error(t, err)
+}
+
+func TestTransformFeature(t *testing.T) {
+	f := geom.NewPointFeature([]float64{180, 0})
+	f.Properties["name"] = "a"
+	f.CRS = crsMember(4326)
+	ret, err := TransformFeature(f, 3857)
+	assert.NoError(t, err)
+	assert.InDelta(t, 20037508.342789244, ret.GeometryData.Point[0], 1e-6)
+	assert.Equal(t, 3857, ret.GeometryData.EPSG)
+	assert.InDelta(t, 20037508.342789244, ret.BoundingBox[0][0], 1e-6)
+	assert.Equal(t, "urn:ogc:def:crs:EPSG::3857", ret.CRS["properties"].(map[string]interface{})["name"])
+	assert.Equal(t, "a", ret.Properties["name"])
+	assert.Equal(t, 180.0, f.GeometryData.Point[0])
+
+	// 要素集合中各要素的坐标系可以不同
+	merc := geom.NewPointFeature([]float64{0, 20037508.342789244})
+	merc.GeometryData.EPSG = 3857
+	fc := geom.NewFeatureCollection()
+	fc.AddFeature(geom.NewPointFeature([]float64{90, 0})).AddFeature(merc)
+	fc.BoundingBox = &geom.BoundingBox{}
+	fc.CRS = crsMember(4326)
+	out, err := TransformFeatureCollection(fc, 4326)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{90, 0}, out.Features[0].GeometryData.Point)
+	assert.InDeltaSlice(t, []float64{0, MaxLatitude}, out.Features[1].GeometryData.Point, 1e-9)
+	assert.InDelta(t, MaxLatitude, out.BoundingBox[1][1], 1e-9)
+	assert.Equal(t, 90.0, out.BoundingBox[1][0])
+	assert.Equal(t, "urn:ogc:def:crs:OGC:1.3:CRS84", out.CRS["properties"].(map[string]interface{})["name"])
+
+	tr, err := NewTransformer(4326, 3857)
+	assert.NoError(t, err)
+	all := tr.FeatureCollection(fc)
+	assert.Equal(t, 3857, all.Features[1].GeometryData.EPSG)
+	assert.True(t, math.Abs(all.Features[1].GeometryData.Point[1]) > 1e6)
+}
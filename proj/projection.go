@@ -0,0 +1,73 @@
+// Package proj converts coordinates between reference systems identified by
+// their EPSG code: geographic WGS84 and CGCS2000, Web Mercator, UTM and the
+// CGCS2000 Gauss-Kruger zones, and the GCJ-02 and BD-09 offsets used by the
+// Chinese web maps. Transverse Mercator, Lambert Conformal Conic and Albers
+// projections with other parameters can be registered under new codes.
+//
+// Every system is defined by a Projection from [lon, lat] in degrees on
+// WGS84. CGCS2000 and WGS84 are taken as the same datum, they differ by a
+// few centimeters.
+package proj
+
+import (
+	"math"
+)
+
+// Projection converts WGS84 longitudes and latitudes in degrees to the
+// coordinates of a reference system and back.
+type Projection interface {
+	Forward(lon, lat float64) (x, y float64)
+	Inverse(x, y float64) (lon, lat float64)
+}
+
+// LonLat is the identity projection of geographic systems.
+type LonLat struct{}
+
+func (LonLat) Forward(lon, lat float64) (float64, float64) { return lon, lat }
+
+func (LonLat) Inverse(x, y float64) (float64, float64) { return x, y }
+
+// MaxLatitude is the latitude where Web Mercator ends, making its extent
+// square.
+const MaxLatitude = 85.05112877980659
+
+// WebMercator is the spherical Mercator projection of EPSG:3857.
+type WebMercator struct{}
+
+const earthRadius = 6378137
+
+func (WebMercator) Forward(lon, lat float64) (float64, float64) {
+	lat = math.Max(-MaxLatitude, math.Min(MaxLatitude, lat))
+	return earthRadius * lon * degree, earthRadius * math.Log(math.Tan(math.Pi/4+lat*degree/2))
+}
+
+func (WebMercator) Inverse(x, y float64) (float64, float64) {
+	return x / earthRadius / degree, (2*math.Atan(math.Exp(y/earthRadius)) - math.Pi/2) / degree
+}
+
+// Mercator is the ellipsoidal Mercator projection, EPSG:3395 on WGS84.
+type Mercator struct {
+	ellipsoid Ellipsoid
+	lon0      float64
+	k         float64
+	x0, y0    float64
+}
+
+// NewMercator returns the projection with central meridian lon0 in degrees
+// and scale k0 on the equator.
+func NewMercator(ellipsoid Ellipsoid, lon0, k0, falseEasting, falseNorthing float64) *Mercator {
+	return &Mercator{ellipsoid: ellipsoid, lon0: lon0, k: k0 * ellipsoid.A, x0: falseEasting, y0: falseNorthing}
+}
+
+func (p *Mercator) Forward(lon, lat float64) (float64, float64) {
+	lat = math.Max(-89.5, math.Min(89.5, lat))
+	x := p.k * normalize((lon-p.lon0)*degree)
+	y := p.k * math.Asinh(p.ellipsoid.conformal(math.Tan(lat*degree)))
+	return p.x0 + x, p.y0 + y
+}
+
+func (p *Mercator) Inverse(x, y float64) (float64, float64) {
+	lon := (x-p.x0)/p.k/degree + p.lon0
+	lat := latitude(p.ellipsoid.geographic(math.Sinh((y - p.y0) / p.k)))
+	return lon, lat
+}
@@ -0,0 +1,65 @@
+package proj
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Codes of the systems without EPSG code.
+const (
+	GCJ02 = 990001
+	BD09  = 990002
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[int]Projection{}
+)
+
+func init() {
+	Register(4326, LonLat{})
+	Register(4490, LonLat{})
+	Register(3857, WebMercator{})
+	Register(900913, WebMercator{})
+	Register(3395, NewMercator(WGS84Ellipsoid, 0, 1, 0, 0))
+	Register(GCJ02, GCJ02Projection{})
+	Register(BD09, BD09Projection{})
+	for zone := 1; zone <= 60; zone++ {
+		Register(32600+zone, NewUTM(zone, false))
+		Register(32700+zone, NewUTM(zone, true))
+	}
+	// CGCS2000 高斯-克吕格投影: 6 度带 13-23 与 3 度带 25-45, 带号加与不加在东坐标前
+	for zone := 13; zone <= 23; zone++ {
+		lon0 := float64(6*zone - 3)
+		Register(4491+zone-13, NewGaussKruger(lon0, zone))
+		Register(4502+zone-13, NewGaussKruger(lon0, 0))
+	}
+	for zone := 25; zone <= 45; zone++ {
+		lon0 := float64(3 * zone)
+		Register(4513+zone-25, NewGaussKruger(lon0, zone))
+		Register(4534+zone-25, NewGaussKruger(lon0, 0))
+	}
+}
+
+// Register makes p available under code, replacing any previous
+// projection.
+func Register(code int, p Projection) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[code] = p
+}
+
+// Lookup returns the projection registered under code. Code 0, the EPSG of
+// a GeometryData without reference system, is WGS84.
+func Lookup(code int) (Projection, error) {
+	if code == 0 {
+		code = 4326
+	}
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	p, ok := registry[code]
+	if !ok {
+		return nil, fmt.Errorf("unknown reference system EPSG:%d", code)
+	}
+	return p, nil
+}
@@ -0,0 +1,110 @@
+package proj
+
+import (
+	"math"
+)
+
+// TransverseMercator is the ellipsoidal Transverse Mercator projection,
+// computed with the Krüger series to the sixth order in the third
+// flattening following C. F. F. Karney, "Transverse Mercator with an
+// accuracy of a few nanometers", J. Geodesy 85, 475-485 (2011). The error
+// stays below a millimeter within 4000 km of the central meridian.
+type TransverseMercator struct {
+	ellipsoid   Ellipsoid
+	lon0        float64
+	k0          float64
+	x0, y0      float64
+	a           float64
+	alpha, beta [7]float64
+	xi0         float64
+}
+
+// NewTransverseMercator returns the projection centred on lon0 and lat0 in
+// degrees with scale k0 on the central meridian.
+func NewTransverseMercator(ellipsoid Ellipsoid, lon0, lat0, k0, falseEasting, falseNorthing float64) *TransverseMercator {
+	p := &TransverseMercator{ellipsoid: ellipsoid, lon0: lon0, k0: k0, x0: falseEasting, y0: falseNorthing}
+	n := ellipsoid.F / (2 - ellipsoid.F)
+	n2 := n * n
+	n3, n4, n5, n6 := n2*n, n2*n2, n2*n2*n, n2*n2*n2
+	p.a = ellipsoid.A / (1 + n) * (1 + n2/4 + n4/64 + n6/256)
+	p.alpha = [7]float64{0,
+		n/2 - 2*n2/3 + 5*n3/16 + 41*n4/180 - 127*n5/288 + 7891*n6/37800,
+		13*n2/48 - 3*n3/5 + 557*n4/1440 + 281*n5/630 - 1983433*n6/1935360,
+		61*n3/240 - 103*n4/140 + 15061*n5/26880 + 167603*n6/181440,
+		49561*n4/161280 - 179*n5/168 + 6601661*n6/7257600,
+		34729*n5/80640 - 3418889*n6/1995840,
+		212378941 * n6 / 319334400,
+	}
+	p.beta = [7]float64{0,
+		n/2 - 2*n2/3 + 37*n3/96 - n4/360 - 81*n5/512 + 96199*n6/604800,
+		n2/48 + n3/15 - 437*n4/1440 + 46*n5/105 - 1118711*n6/3870720,
+		17*n3/480 - 37*n4/840 - 209*n5/4480 + 5569*n6/90720,
+		4397*n4/161280 - 11*n5/504 - 830251*n6/7257600,
+		4583*n5/161280 - 108847*n6/3991680,
+		20648693 * n6 / 638668800,
+	}
+	p.xi0, _ = p.project(lat0, 0)
+	return p
+}
+
+// NewUTM returns the projection of a UTM zone from 1 to 60 on WGS84.
+func NewUTM(zone int, south bool) *TransverseMercator {
+	northing := 0.0
+	if south {
+		northing = 10000000
+	}
+	return NewTransverseMercator(WGS84Ellipsoid, float64(6*zone-183), 0, 0.9996, 500000, northing)
+}
+
+// UTMZone returns the UTM zone of a longitude in degrees.
+func UTMZone(lon float64) int {
+	zone := int(math.Floor((lon+180)/6)) % 60
+	if zone < 0 {
+		zone += 60
+	}
+	return zone + 1
+}
+
+// NewGaussKruger returns the Gauss-Kruger projection on CGCS2000 of the
+// central meridian lon0. With a zone other than 0, the false easting is
+// prefixed with the zone number.
+func NewGaussKruger(lon0 float64, zone int) *TransverseMercator {
+	return NewTransverseMercator(CGCS2000Ellipsoid, lon0, 0, 1, float64(zone)*1000000+500000, 0)
+}
+
+// project returns the scaled coordinates on the unit sphere of a latitude
+// in degrees and a longitude in radians from the central meridian.
+func (p *TransverseMercator) project(lat, lam float64) (float64, float64) {
+	if lat >= 90 || lat <= -90 {
+		return math.Copysign(math.Pi/2, lat), 0
+	}
+	taup := p.ellipsoid.conformal(math.Tan(lat * degree))
+	xip := math.Atan2(taup, math.Cos(lam))
+	etap := math.Asinh(math.Sin(lam) / math.Hypot(taup, math.Cos(lam)))
+	xi, eta := xip, etap
+	for j := 1; j <= 6; j++ {
+		xi += p.alpha[j] * math.Sin(2*float64(j)*xip) * math.Cosh(2*float64(j)*etap)
+		eta += p.alpha[j] * math.Cos(2*float64(j)*xip) * math.Sinh(2*float64(j)*etap)
+	}
+	return xi, eta
+}
+
+func (p *TransverseMercator) Forward(lon, lat float64) (float64, float64) {
+	xi, eta := p.project(lat, normalize((lon-p.lon0)*degree))
+	k := p.k0 * p.a
+	return p.x0 + k*eta, p.y0 + k*(xi-p.xi0)
+}
+
+func (p *TransverseMercator) Inverse(x, y float64) (float64, float64) {
+	k := p.k0 * p.a
+	xi := (y-p.y0)/k + p.xi0
+	eta := (x - p.x0) / k
+	xip, etap := xi, eta
+	for j := 1; j <= 6; j++ {
+		xip -= p.beta[j] * math.Sin(2*float64(j)*xi) * math.Cosh(2*float64(j)*eta)
+		etap -= p.beta[j] * math.Cos(2*float64(j)*xi) * math.Sinh(2*float64(j)*eta)
+	}
+	taup := math.Sin(xip) / math.Hypot(math.Sinh(etap), math.Cos(xip))
+	lam := math.Atan2(math.Sinh(etap), math.Cos(xip))
+	return p.lon0 + lam/degree, latitude(p.ellipsoid.geographic(taup))
+}
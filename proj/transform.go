@@ -0,0 +1,244 @@
+package proj
+
+import (
+	"github.com/flywave/go-geom"
+)
+
+// Transformer converts positions from one reference system to another
+// through WGS84.
+type Transformer struct {
+	from, to Projection
+	code     int
+	identity bool
+}
+
+// NewTransformer returns the transformer between the systems registered
+// under the codes from and to.
+func NewTransformer(from, to int) (*Transformer, error) {
+	src, err := Lookup(from)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := Lookup(to)
+	if err != nil {
+		return nil, err
+	}
+	same := from == to || (from == 0 && to == 4326) || (from == 4326 && to == 0)
+	return &Transformer{from: src, to: dst, code: to, identity: same}, nil
+}
+
+// Transform converts the coordinates x and y.
+func (t *Transformer) Transform(x, y float64) (float64, float64) {
+	if t.identity {
+		return x, y
+	}
+	return t.to.Forward(t.from.Inverse(x, y))
+}
+
+// Position returns the conversion of p, the ordinates past x and y are
+// copied.
+func (t *Transformer) Position(p []float64) []float64 {
+	if len(p) < 2 {
+		return p
+	}
+	ret := make([]float64, len(p))
+	copy(ret, p)
+	ret[0], ret[1] = t.Transform(p[0], p[1])
+	return ret
+}
+
+func (t *Transformer) path(path [][]float64) [][]float64 {
+	if path == nil {
+		return nil
+	}
+	ret := make([][]float64, len(path))
+	for i, p := range path {
+		ret[i] = t.Position(p)
+	}
+	return ret
+}
+
+func (t *Transformer) paths(paths [][][]float64) [][][]float64 {
+	if paths == nil {
+		return nil
+	}
+	ret := make([][][]float64, len(paths))
+	for i, p := range paths {
+		ret[i] = t.path(p)
+	}
+	return ret
+}
+
+// GeometryData returns the conversion of g with its EPSG set to the target
+// system.
+func (t *Transformer) GeometryData(g *geom.GeometryData) *geom.GeometryData {
+	if g == nil {
+		return nil
+	}
+	ret := &geom.GeometryData{Type: g.Type, EPSG: t.code, Layout: g.Layout}
+	switch g.Type {
+	case geom.GeometryPoint:
+		ret.Point = t.Position(g.Point)
+	case geom.GeometryMultiPoint:
+		ret.MultiPoint = t.path(g.MultiPoint)
+	case geom.GeometryLineString:
+		ret.LineString = t.path(g.LineString)
+	case geom.GeometryMultiLineString:
+		ret.MultiLineString = t.paths(g.MultiLineString)
+	case geom.GeometryPolygon:
+		ret.Polygon = t.paths(g.Polygon)
+	case geom.GeometryMultiPolygon:
+		ret.MultiPolygon = make([][][][]float64, len(g.MultiPolygon))
+		for i, p := range g.MultiPolygon {
+			ret.MultiPolygon[i] = t.paths(p)
+		}
+	case geom.GeometryCollection:
+		ret.Geometries = make([]*geom.GeometryData, len(g.Geometries))
+		for i, c := range g.Geometries {
+			ret.Geometries[i] = t.GeometryData(c)
+		}
+	}
+	if g.BoundingBox != nil {
+		ret.BoundingBox = geom.BoundingBoxFromGeometryData(ret)
+	}
+	return ret
+}
+
+// Feature returns a copy of f with its geometry converted. The properties
+// are shared with f.
+func (t *Transformer) Feature(f *geom.Feature) *geom.Feature {
+	if f == nil {
+		return nil
+	}
+	ret := *f
+	ret.GeometryData = *t.GeometryData(&f.GeometryData)
+	if f.Geometry != nil {
+		ret.Geometry = t.GeometryData(geom.NewGeometryData(f.Geometry))
+	}
+	if f.BoundingBox != nil {
+		ret.BoundingBox = geom.BoundingBoxFromGeometryData(&ret.GeometryData)
+	}
+	if len(f.CRS) != 0 {
+		ret.CRS = crsMember(t.code)
+	}
+	return &ret
+}
+
+// FeatureCollection returns a copy of fc with the geometries of its
+// features converted.
+func (t *Transformer) FeatureCollection(fc *geom.FeatureCollection) *geom.FeatureCollection {
+	if fc == nil {
+		return nil
+	}
+	ret := *fc
+	ret.Features = make([]*geom.Feature, len(fc.Features))
+	for i, f := range fc.Features {
+		ret.Features[i] = t.Feature(f)
+	}
+	collectionBounds(&ret)
+	if len(fc.CRS) != 0 {
+		ret.CRS = crsMember(t.code)
+	}
+	return &ret
+}
+
+func collectionBounds(fc *geom.FeatureCollection) {
+	if fc.BoundingBox == nil {
+		return
+	}
+	var boxes []*geom.BoundingBox
+	for _, f := range fc.Features {
+		if b := geom.BoundingBoxFromGeometryData(&f.GeometryData); b != nil {
+			boxes = append(boxes, b)
+		}
+	}
+	fc.BoundingBox = nil
+	if len(boxes) > 0 {
+		fc.BoundingBox = geom.ExpandBoundingBoxs(boxes)
+	}
+}
+
+// crsMember returns the GeoJSON 2008 named crs member of code.
+func crsMember(code int) map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "name",
+		"properties": map[string]interface{}{"name": geom.SridToUrn(code)},
+	}
+}
+
+// crsCode returns the code named in a crs member, 0 when there is none.
+func crsCode(crs map[string]interface{}) int {
+	props, _ := crs["properties"].(map[string]interface{})
+	name, _ := props["name"].(string)
+	if code := geom.UrnToSrid(name); code > 0 {
+		return code
+	}
+	return 0
+}
+
+// Transform converts x and y from the system from to the system to.
+func Transform(x, y float64, from, to int) (float64, float64, error) {
+	t, err := NewTransformer(from, to)
+	if err != nil {
+		return 0, 0, err
+	}
+	x, y = t.Transform(x, y)
+	return x, y, nil
+}
+
+// TransformGeometryData converts g from the system of its EPSG to the
+// system to.
+func TransformGeometryData(g *geom.GeometryData, to int) (*geom.GeometryData, error) {
+	t, err := NewTransformer(g.EPSG, to)
+	if err != nil {
+		return nil, err
+	}
+	return t.GeometryData(g), nil
+}
+
+// TransformFeature converts f from the system of its geometry EPSG, or of
+// its crs member when the EPSG is not set, to the system to.
+func TransformFeature(f *geom.Feature, to int) (*geom.Feature, error) {
+	t, err := NewTransformer(featureCode(f, 0), to)
+	if err != nil {
+		return nil, err
+	}
+	return t.Feature(f), nil
+}
+
+// TransformFeatureCollection converts each feature of fc from its own
+// system to the system to. Features without EPSG nor crs member use the crs
+// member of fc.
+func TransformFeatureCollection(fc *geom.FeatureCollection, to int) (*geom.FeatureCollection, error) {
+	ret := *fc
+	ret.Features = make([]*geom.Feature, len(fc.Features))
+	transformers := map[int]*Transformer{}
+	def := crsCode(fc.CRS)
+	for i, f := range fc.Features {
+		code := featureCode(f, def)
+		t, ok := transformers[code]
+		if !ok {
+			var err error
+			if t, err = NewTransformer(code, to); err != nil {
+				return nil, err
+			}
+			transformers[code] = t
+		}
+		ret.Features[i] = t.Feature(f)
+	}
+	collectionBounds(&ret)
+	if len(fc.CRS) != 0 {
+		ret.CRS = crsMember(to)
+	}
+	return &ret, nil
+}
+
+func featureCode(f *geom.Feature, def int) int {
+	if f.GeometryData.EPSG != 0 {
+		return f.GeometryData.EPSG
+	}
+	if code := crsCode(f.CRS); code != 0 {
+		return code
+	}
+	return def
+}
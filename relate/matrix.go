@@ -0,0 +1,82 @@
+package relate
+
+import (
+	"github.com/flywave/go-geom/internal/topology"
+)
+
+// Matrix is a DE-9IM intersection matrix. Rows are the interior, boundary
+// and exterior of the first geometry, columns those of the second. Each
+// cell holds the dimension of the intersection, -1 when it is empty.
+type Matrix [3][3]int
+
+const (
+	Interior = int(topology.Interior)
+	Boundary = int(topology.Boundary)
+	Exterior = int(topology.Exterior)
+)
+
+func emptyMatrix() Matrix {
+	return Matrix{{-1, -1, -1}, {-1, -1, -1}, {-1, -1, 2}}
+}
+
+func (m *Matrix) set(a, b topology.Location, dim int) {
+	if dim > m[a][b] {
+		m[a][b] = dim
+	}
+}
+
+// String returns the matrix in the usual nine character form, like
+// "212101212".
+func (m Matrix) String() string {
+	buf := make([]byte, 0, 9)
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			if m[i][j] < 0 {
+				buf = append(buf, 'F')
+			} else {
+				buf = append(buf, byte('0'+m[i][j]))
+			}
+		}
+	}
+	return string(buf)
+}
+
+// Matches tests the matrix against a nine character pattern. A pattern
+// cell is one of T (non empty), F (empty), * (anything) or a dimension.
+func (m Matrix) Matches(pattern string) bool {
+	if len(pattern) != 9 {
+		return false
+	}
+	for k := 0; k < 9; k++ {
+		d := m[k/3][k%3]
+		switch pattern[k] {
+		case '*':
+		case 'T', 't':
+			if d < 0 {
+				return false
+			}
+		case 'F', 'f':
+			if d >= 0 {
+				return false
+			}
+		case '0', '1', '2':
+			if d != int(pattern[k]-'0') {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Transpose swaps the roles of the two geometries.
+func (m Matrix) Transpose() Matrix {
+	var t Matrix
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			t[j][i] = m[i][j]
+		}
+	}
+	return t
+}
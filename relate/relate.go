@@ -0,0 +1,134 @@
+// Package relate computes the DE-9IM relationship between two geometries
+// and the named spatial predicates derived from it.
+package relate
+
+import (
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/internal/topology"
+)
+
+// Relate computes the DE-9IM matrix of a and b. The parts of a geometry
+// collection are taken as their union.
+func Relate(a, b geom.Geometry) Matrix {
+	m, _, _ := relate(geom.NewGeometryData(a), geom.NewGeometryData(b))
+	return m
+}
+
+// RelatePattern tests if the matrix of a and b matches pattern.
+func RelatePattern(a, b geom.Geometry, pattern string) bool {
+	return Relate(a, b).Matches(pattern)
+}
+
+func relate(a, b *geom.GeometryData) (Matrix, int, int) {
+	g := topology.New(a, b)
+	m := emptyMatrix()
+	for _, c := range g.Cycles {
+		m.set(c.Loc[0], c.Loc[1], 2)
+	}
+	for _, e := range g.Edges {
+		m.set(e.Loc[0], e.Loc[1], 1)
+	}
+	for _, v := range g.Vertices {
+		m.set(v.Loc[0], v.Loc[1], 0)
+	}
+	return m, g.Dimension(0), g.Dimension(1)
+}
+
+func Intersects(a, b geom.Geometry) bool {
+	return !Disjoint(a, b)
+}
+
+func Disjoint(a, b geom.Geometry) bool {
+	da, db := geom.NewGeometryData(a), geom.NewGeometryData(b)
+	if boxesDisjoint(da, db) {
+		return true
+	}
+	m, _, _ := relate(da, db)
+	return m.Matches("FF*FF****")
+}
+
+func Touches(a, b geom.Geometry) bool {
+	m, ok := relateOverlapping(a, b)
+	return ok && (m.Matches("FT*******") || m.Matches("F**T*****") || m.Matches("F***T****"))
+}
+
+func Crosses(a, b geom.Geometry) bool {
+	da, db := geom.NewGeometryData(a), geom.NewGeometryData(b)
+	if boxesDisjoint(da, db) {
+		return false
+	}
+	m, dimA, dimB := relate(da, db)
+	switch {
+	case dimA == 1 && dimB == 1:
+		return m.Matches("0********")
+	case dimA < dimB:
+		return m.Matches("T*T******")
+	case dimA > dimB:
+		return m.Matches("T*****T**")
+	}
+	return false
+}
+
+func Within(a, b geom.Geometry) bool {
+	m, ok := relateOverlapping(a, b)
+	return ok && m.Matches("T*F**F***")
+}
+
+func Contains(a, b geom.Geometry) bool {
+	return Within(b, a)
+}
+
+func Covers(a, b geom.Geometry) bool {
+	m, ok := relateOverlapping(a, b)
+	return ok && (m.Matches("T*****FF*") || m.Matches("*T****FF*") ||
+		m.Matches("***T**FF*") || m.Matches("****T*FF*"))
+}
+
+func CoveredBy(a, b geom.Geometry) bool {
+	return Covers(b, a)
+}
+
+func Overlaps(a, b geom.Geometry) bool {
+	da, db := geom.NewGeometryData(a), geom.NewGeometryData(b)
+	if boxesDisjoint(da, db) {
+		return false
+	}
+	m, dimA, dimB := relate(da, db)
+	switch {
+	case dimA != dimB:
+		return false
+	case dimA == 1:
+		return m.Matches("1*T***T**")
+	}
+	return m.Matches("T*T***T**")
+}
+
+// Equals tests if a and b are topologically equal, regardless of the order
+// of their vertices.
+func Equals(a, b geom.Geometry) bool {
+	da, db := geom.NewGeometryData(a), geom.NewGeometryData(b)
+	if boxesDisjoint(da, db) {
+		return false
+	}
+	m, dimA, dimB := relate(da, db)
+	return dimA == dimB && m.Matches("T*F**FFF*")
+}
+
+func relateOverlapping(a, b geom.Geometry) (Matrix, bool) {
+	da, db := geom.NewGeometryData(a), geom.NewGeometryData(b)
+	if boxesDisjoint(da, db) {
+		return Matrix{}, false
+	}
+	m, _, _ := relate(da, db)
+	return m, true
+}
+
+// boxesDisjoint tells if the bounding boxes of a and b do not meet, empty
+// geometries have no box and are disjoint from everything.
+func boxesDisjoint(a, b *geom.GeometryData) bool {
+	ba, bb := geom.BoundingBoxFromGeometryData(a), geom.BoundingBoxFromGeometryData(b)
+	if ba == nil || bb == nil {
+		return true
+	}
+	return ba[1][0] < bb[0][0] || bb[1][0] < ba[0][0] || ba[1][1] < bb[0][1] || bb[1][1] < ba[0][1]
+}
@@ -0,0 +1,132 @@
+package relate
+
+import (
+	"testing"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/general"
+	"github.com/flywave/go-geom/wkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func mustWKT(t *testing.T, s string) *geom.GeometryData {
+	g, _, err := wkt.DecodeWKT([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// 测试DE-9IM矩阵, 参考值与JTS一致
+func TestRelate(t *testing.T) {
+	cases := []struct {
+		a, b, matrix string
+	}{
+		{"POLYGON((0 0,2 0,2 2,0 2,0 0))", "POLYGON((1 1,3 1,3 3,1 3,1 1))", "212101212"},
+		{"POLYGON((0 0,1 0,1 1,0 1,0 0))", "POLYGON((1 0,2 0,2 1,1 1,1 0))", "FF2F11212"},
+		{"POLYGON((0 0,10 0,10 10,0 10,0 0))", "POINT(5 5)", "0F2FF1FF2"},
+		{"LINESTRING(-1 1,3 1)", "POLYGON((0 0,2 0,2 2,0 2,0 0))", "101FF0212"},
+		{"LINESTRING(0 0,2 2)", "LINESTRING(0 2,2 0)", "0F1FF0102"},
+		{"LINESTRING(0 0,1 1)", "LINESTRING(1 1,2 0)", "FF1F00102"},
+		{"LINESTRING(0 0,2 0)", "LINESTRING(1 0,3 0)", "1010F0102"},
+		{"POINT(0 0)", "LINESTRING(0 0,1 1)", "F0FFFF102"},
+		{"POLYGON((0 0,1 0,1 1,0 1,0 0))", "POLYGON((1 1,0 1,0 0,1 0,1 1))", "2FFF1FFF2"},
+		{"POLYGON((0 0,10 0,10 10,0 10,0 0),(2 2,2 8,8 8,8 2,2 2))", "POINT(5 5)", "FF2FF10F2"},
+		{"LINESTRING(0 0,1 1)", "POLYGON((0 0,2 0,2 2,0 2,0 0))", "1FF00F212"},
+		{"MULTIPOINT((0 0),(5 5))", "POLYGON((0 0,2 0,2 2,0 2,0 0))", "F00FFF212"},
+		{"POLYGON((0 0,4 0,4 4,0 4,0 0))", "POLYGON((0 0,2 0,2 2,0 2,0 0))", "212F11FF2"},
+		{"LINESTRING(0 0,1 0,1 1,0 1,0 0)", "POINT(0 0)", "0F1FFFFF2"},
+		{"POLYGON((0 0,3 0,3 3,0 3,0 0))", "LINESTRING(1 1,2 2)", "102FF1FF2"},
+	}
+	for _, c := range cases {
+		a, b := mustWKT(t, c.a), mustWKT(t, c.b)
+		m := Relate(a, b)
+		assert.Equal(t, c.matrix, m.String(), "%s / %s", c.a, c.b)
+		assert.Equal(t, m.Transpose().String(), Relate(b, a).String(), "%s / %s", c.b, c.a)
+	}
+
+	// 多线的端点按模2规则计算边界
+	ml := geom.NewMultiLineStringGeometryData([][]float64{{0, 0}, {1, 0}}, [][]float64{{1, 0}, {2, 0}})
+	assert.Equal(t, "0F1FF0FF2", Relate(ml, geom.NewPointGeometryData([]float64{1, 0})).String())
+	assert.Equal(t, "F0FFFF102", Relate(geom.NewPointGeometryData([]float64{2, 0}), ml).String())
+}
+
+func TestMatches(t *testing.T) {
+	m := Matrix{{2, 1, 2}, {1, 0, 1}, {2, 1, 2}}
+	assert.Equal(t, "212101212", m.String())
+	assert.True(t, m.Matches("T*T***T**"))
+	assert.True(t, m.Matches("2*2***2**"))
+	assert.False(t, m.Matches("FF*FF****"))
+	assert.False(t, m.Matches("1********"))
+	assert.False(t, m.Matches("T*T"))
+	assert.True(t, RelatePattern(mustWKT(t, "POINT(1 1)"), mustWKT(t, "POINT(1 1)"), "0FFFFFFF2"))
+}
+
+func TestPredicates(t *testing.T) {
+	square := general.NewPolygon([][][]float64{{{0, 0}, {2, 0}, {2, 2}, {0, 2}, {0, 0}}})
+	shifted := general.NewPolygon([][][]float64{{{1, 1}, {3, 1}, {3, 3}, {1, 3}, {1, 1}}})
+	adjacent := general.NewPolygon([][][]float64{{{2, 0}, {4, 0}, {4, 2}, {2, 2}, {2, 0}}})
+	far := general.NewPolygon([][][]float64{{{10, 10}, {11, 10}, {11, 11}, {10, 10}}})
+	inner := general.NewPolygon([][][]float64{{{0.5, 0.5}, {1, 0.5}, {1, 1}, {0.5, 0.5}}})
+
+	assert.True(t, Intersects(square, shifted))
+	assert.True(t, Overlaps(square, shifted))
+	assert.False(t, Touches(square, shifted))
+	assert.False(t, Contains(square, shifted))
+
+	assert.True(t, Touches(square, adjacent))
+	assert.True(t, Intersects(square, adjacent))
+	assert.False(t, Overlaps(square, adjacent))
+
+	assert.True(t, Disjoint(square, far))
+	assert.False(t, Intersects(square, far))
+
+	assert.True(t, Contains(square, inner))
+	assert.True(t, Within(inner, square))
+	assert.True(t, Covers(square, inner))
+	assert.True(t, CoveredBy(inner, square))
+	assert.False(t, Within(square, inner))
+
+	// 边界上的点被覆盖但不被包含
+	corner := general.NewPoint([]float64{0, 0})
+	assert.True(t, Covers(square, corner))
+	assert.False(t, Contains(square, corner))
+	assert.True(t, Touches(corner, square))
+
+	line := general.NewLineString([][]float64{{-1, 1}, {3, 1}})
+	assert.True(t, Crosses(line, square))
+	assert.True(t, Crosses(square, line))
+	assert.True(t, Crosses(general.NewLineString([][]float64{{0, 0}, {2, 2}}), general.NewLineString([][]float64{{0, 2}, {2, 0}})))
+	assert.False(t, Crosses(square, shifted))
+
+	assert.True(t, Equals(square, general.NewPolygon([][][]float64{{{2, 2}, {0, 2}, {0, 0}, {2, 0}, {2, 2}}})))
+	assert.True(t, Equals(general.NewLineString([][]float64{{0, 0}, {1, 1}, {2, 2}}), general.NewLineString([][]float64{{2, 2}, {0, 0}})))
+	assert.False(t, Equals(square, shifted))
+}
+
+// 测试几何集合按并集处理
+func TestCollection(t *testing.T) {
+	halves := geom.NewCollectionGeometryData(
+		geom.NewPolygonGeometryData([][][]float64{{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}}),
+		geom.NewPolygonGeometryData([][][]float64{{{1, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 0}}}),
+	)
+	whole := geom.NewPolygonGeometryData([][][]float64{{{0, 0}, {2, 0}, {2, 1}, {0, 1}, {0, 0}}})
+	assert.True(t, Equals(halves, whole))
+	assert.Equal(t, "2FFF1FFF2", Relate(halves, whole).String())
+
+	mixed := geom.NewCollectionGeometryData(
+		geom.NewPointGeometryData([]float64{5, 5}),
+		geom.NewLineStringGeometryData([][]float64{{3, 0}, {4, 0}}),
+		whole,
+	)
+	assert.True(t, Contains(mixed, geom.NewPointGeometryData([]float64{5, 5})))
+	assert.True(t, Contains(mixed, geom.NewPointGeometryData([]float64{1.5, 0.5})))
+	assert.True(t, Touches(mixed, geom.NewPointGeometryData([]float64{3, 0})))
+	assert.True(t, Disjoint(mixed, geom.NewPointGeometryData([]float64{3, 3})))
+
+	empty := geom.NewCollectionGeometryData()
+	assert.Equal(t, "FFFFFF212", Relate(empty, whole).String())
+	assert.True(t, Disjoint(empty, whole))
+	assert.False(t, Intersects(empty, whole))
+	assert.False(t, Within(empty, whole))
+}
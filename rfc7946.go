@@ -0,0 +1,529 @@
+package geom
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Violation is a departure from RFC 7946 found by the strict decoders, at
+// the member of the text designated by the JSON pointer Pointer.
+type Violation struct {
+	Pointer string
+	Message string
+}
+
+func (v Violation) String() string {
+	if v.Pointer == "" {
+		return v.Message
+	}
+	return v.Pointer + ": " + v.Message
+}
+
+// ValidationError lists the violations of a GeoJSON text.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	msg := "geojson violates rfc 7946: " + e.Violations[0].String()
+	if n := len(e.Violations) - 1; n > 0 {
+		msg += fmt.Sprintf(" (and %d more)", n)
+	}
+	return msg
+}
+
+// UnmarshalGeometryStrict decodes a geometry as UnmarshalGeometry, failing
+// with a *ValidationError when data does not follow RFC 7946.
+func UnmarshalGeometryStrict(data []byte) (*GeometryData, error) {
+	g := &GeometryData{}
+	if err := unmarshalStrict(data, "geometry", g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// UnmarshalFeatureStrict decodes a Feature, failing with a *ValidationError
+// when data does not follow RFC 7946.
+func UnmarshalFeatureStrict(data []byte) (*Feature, error) {
+	f := &Feature{}
+	if err := unmarshalStrict(data, "Feature", f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// UnmarshalFeatureCollectionStrict decodes a FeatureCollection, failing
+// with a *ValidationError when data does not follow RFC 7946.
+func UnmarshalFeatureCollectionStrict(data []byte) (*FeatureCollection, error) {
+	fc := &FeatureCollection{}
+	if err := unmarshalStrict(data, "FeatureCollection", fc); err != nil {
+		return nil, err
+	}
+	return fc, nil
+}
+
+func unmarshalStrict(data []byte, kind string, v interface{}) error {
+	var object interface{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	c := &checker{}
+	switch kind {
+	case "Feature":
+		c.feature("", object)
+	case "FeatureCollection":
+		c.featureCollection("", object)
+	default:
+		c.geometry("", object, false)
+	}
+	if len(c.violations) != 0 {
+		return &ValidationError{Violations: c.violations}
+	}
+	return json.Unmarshal(data, v)
+}
+
+// checker collects the violations of a decoded GeoJSON text.
+type checker struct {
+	violations []Violation
+}
+
+func (c *checker) report(ptr string, format string, args ...interface{}) {
+	c.violations = append(c.violations, Violation{Pointer: ptr, Message: fmt.Sprintf(format, args...)})
+}
+
+func index(ptr string, i int) string {
+	return ptr + "/" + strconv.Itoa(i)
+}
+
+// object checks the members common to the GeoJSON objects: the type, the
+// removed crs, the bbox and the members forbidden to the kind.
+func (c *checker) object(ptr string, v interface{}, forbidden ...string) (map[string]interface{}, string) {
+	o, ok := v.(map[string]interface{})
+	if !ok {
+		c.report(ptr, "not a geojson object")
+		return nil, ""
+	}
+	typ, ok := o["type"].(string)
+	if !ok {
+		c.report(ptr, "type member missing or not a string")
+	}
+	if _, ok := o["crs"]; ok {
+		c.report(ptr+"/crs", "crs member removed, the coordinates are WGS 84")
+	}
+	if bbox, ok := o["bbox"]; ok {
+		c.bbox(ptr+"/bbox", bbox)
+	}
+	for _, key := range forbidden {
+		if _, ok := o[key]; ok {
+			c.report(ptr+"/"+key, "%s member not allowed in a %s", key, typ)
+		}
+	}
+	return o, typ
+}
+
+func (c *checker) featureCollection(ptr string, v interface{}) {
+	o, typ := c.object(ptr, v, "coordinates", "geometries", "geometry", "properties")
+	if o == nil {
+		return
+	}
+	if typ != "FeatureCollection" {
+		c.report(ptr+"/type", "expected FeatureCollection, got %q", typ)
+	}
+	features, ok := o["features"].([]interface{})
+	if !ok {
+		c.report(ptr+"/features", "features member missing or not an array")
+		return
+	}
+	for i, f := range features {
+		c.feature(index(ptr+"/features", i), f)
+	}
+}
+
+func (c *checker) feature(ptr string, v interface{}) {
+	o, typ := c.object(ptr, v, "coordinates", "geometries", "features")
+	if o == nil {
+		return
+	}
+	if typ != "Feature" {
+		c.report(ptr+"/type", "expected Feature, got %q", typ)
+	}
+	if id, ok := o["id"]; ok {
+		switch id.(type) {
+		case string, float64:
+		default:
+			c.report(ptr+"/id", "id neither a string nor a number")
+		}
+	}
+	if g, ok := o["geometry"]; !ok {
+		c.report(ptr+"/geometry", "geometry member missing")
+	} else if g != nil {
+		c.geometry(ptr+"/geometry", g, false)
+	}
+	switch p, ok := o["properties"]; {
+	case !ok:
+		c.report(ptr+"/properties", "properties member missing")
+	case p != nil:
+		if _, ok := p.(map[string]interface{}); !ok {
+			c.report(ptr+"/properties", "properties neither an object nor null")
+		}
+	}
+}
+
+func (c *checker) geometry(ptr string, v interface{}, nested bool) {
+	o, typ := c.object(ptr, v, "geometry", "properties", "features")
+	if o == nil {
+		return
+	}
+	if typ == string(GeometryCollection) {
+		if nested {
+			c.report(ptr, "nested geometry collection")
+		}
+		geometries, ok := o["geometries"].([]interface{})
+		if !ok {
+			c.report(ptr+"/geometries", "geometries member missing or not an array")
+			return
+		}
+		for i, g := range geometries {
+			c.geometry(index(ptr+"/geometries", i), g, true)
+		}
+		return
+	}
+	if _, ok := geometryDepths[GeometryType(typ)]; !ok {
+		c.report(ptr+"/type", "unknown geometry type %q", typ)
+		return
+	}
+	coordinates, ok := o["coordinates"]
+	if !ok {
+		c.report(ptr+"/coordinates", "coordinates member missing")
+		return
+	}
+	ptr += "/coordinates"
+	switch GeometryType(typ) {
+	case GeometryPoint:
+		c.position(ptr, coordinates)
+	case GeometryMultiPoint:
+		c.each(ptr, coordinates, c.position)
+	case GeometryLineString:
+		c.lineString(ptr, coordinates)
+	case GeometryMultiLineString:
+		c.each(ptr, coordinates, c.lineString)
+	case GeometryPolygon:
+		c.polygon(ptr, coordinates)
+	case GeometryMultiPolygon:
+		c.each(ptr, coordinates, c.polygon)
+	}
+}
+
+// each calls check on the elements of the array v.
+func (c *checker) each(ptr string, v interface{}, check func(string, interface{}) []interface{}) []interface{} {
+	a, ok := v.([]interface{})
+	if !ok {
+		c.report(ptr, "not an array")
+		return nil
+	}
+	for i, e := range a {
+		check(index(ptr, i), e)
+	}
+	return a
+}
+
+func (c *checker) position(ptr string, v interface{}) []interface{} {
+	a, ok := v.([]interface{})
+	if !ok {
+		c.report(ptr, "position not an array")
+		return nil
+	}
+	if len(a) < 2 || len(a) > 3 {
+		c.report(ptr, "position of %d elements, expected 2 or 3", len(a))
+	}
+	for i, e := range a {
+		if _, ok := e.(float64); !ok {
+			c.report(index(ptr, i), "coordinate not a number")
+			return nil
+		}
+	}
+	return a
+}
+
+// positions checks the positions of a line or a ring.
+func (c *checker) positions(ptr string, v interface{}, min int, what string) []interface{} {
+	a := c.each(ptr, v, c.position)
+	if a != nil && len(a) < min {
+		c.report(ptr, "%s of %d positions, expected at least %d", what, len(a), min)
+	}
+	return a
+}
+
+func (c *checker) lineString(ptr string, v interface{}) []interface{} {
+	return c.positions(ptr, v, 2, "line string")
+}
+
+func (c *checker) ring(ptr string, v interface{}) []interface{} {
+	a := c.positions(ptr, v, 4, "linear ring")
+	if len(a) == 0 {
+		return a
+	}
+	first, _ := a[0].([]interface{})
+	last, _ := a[len(a)-1].([]interface{})
+	closed := len(first) == len(last)
+	for i := 0; closed && i < len(first); i++ {
+		closed = first[i] == last[i]
+	}
+	if !closed {
+		c.report(ptr, "linear ring not closed")
+	}
+	return a
+}
+
+func (c *checker) polygon(ptr string, v interface{}) []interface{} {
+	return c.each(ptr, v, c.ring)
+}
+
+// bbox checks a bounding box, the west longitude greater than the east one
+// for the boxes crossing the antimeridian.
+func (c *checker) bbox(ptr string, v interface{}) {
+	a, ok := v.([]interface{})
+	if !ok || len(a) != 4 && len(a) != 6 {
+		c.report(ptr, "bbox not an array of 4 or 6 numbers")
+		return
+	}
+	b := make([]float64, len(a))
+	for i, e := range a {
+		if b[i], ok = e.(float64); !ok {
+			c.report(index(ptr, i), "bbox value not a number")
+			return
+		}
+	}
+	n := len(b) / 2
+	west, south, east, north := b[0], b[1], b[n], b[n+1]
+	if west < -180 || west > 180 || east < -180 || east > 180 {
+		c.report(ptr, "bbox longitudes out of [-180, 180]")
+	}
+	if south < -90 || north > 90 {
+		c.report(ptr, "bbox latitudes out of [-90, 90]")
+	}
+	if south > north {
+		c.report(ptr, "bbox south latitude greater than the north one")
+	}
+	if n == 3 && b[2] > b[5] {
+		c.report(ptr, "bbox minimum elevation greater than the maximum one")
+	}
+}
+
+// NormalizeGeometryData returns a copy of g following RFC 7946: the rings
+// closed and wound by the right-hand rule, exteriors counterclockwise and
+// holes clockwise, the measures dropped and the epsg and foreign members
+// cleared.
+func NormalizeGeometryData(g *GeometryData) *GeometryData {
+	return normalizeGeometryData(g, NoLayout)
+}
+
+func normalizeGeometryData(g *GeometryData, layout Layout) *GeometryData {
+	if g.Layout != NoLayout {
+		layout = g.Layout
+	}
+	res := ProcessGeometryData(g, func(p []float64) []float64 {
+		n := len(p)
+		switch {
+		case layout == XYM && n > 2:
+			n = 2
+		case n > 3:
+			// XYZM 或者没有布局的 4 个坐标
+			n = 3
+		}
+		return append([]float64(nil), p[:n]...)
+	})
+	res.EPSG, res.Layout, res.ForeignMembers = 0, NoLayout, nil
+	switch g.Type {
+	case GeometryPolygon:
+		normalizePolygon(res.Polygon)
+	case GeometryMultiPolygon:
+		for _, p := range res.MultiPolygon {
+			normalizePolygon(p)
+		}
+	case GeometryCollection:
+		// 集合中的 nil 不是几何
+		res.Geometries = make([]*GeometryData, 0, len(g.Geometries))
+		for _, c := range g.Geometries {
+			if c != nil {
+				res.Geometries = append(res.Geometries, normalizeGeometryData(c, layout))
+			}
+		}
+	}
+	if g.BoundingBox != nil {
+		b := *g.BoundingBox
+		res.BoundingBox = &b
+	}
+	return res
+}
+
+func normalizePolygon(polygon [][][]float64) {
+	for i, ring := range polygon {
+		if len(ring) == 0 {
+			continue
+		}
+		if first, last := ring[0], ring[len(ring)-1]; !positionEqual(first, last) {
+			ring = append(ring, append([]float64(nil), first...))
+			polygon[i] = ring
+		}
+		// 外环逆时针, 内环顺时针
+		if (ringArea(ring) < 0) == (i == 0) {
+			for l, r := 0, len(ring)-1; l < r; l, r = l+1, r-1 {
+				ring[l], ring[r] = ring[r], ring[l]
+			}
+		}
+	}
+}
+
+func positionEqual(p, q []float64) bool {
+	if len(p) != len(q) {
+		return false
+	}
+	for i := range p {
+		if p[i] != q[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ringArea returns twice the signed area of a closed ring, positive when
+// it is counterclockwise.
+func ringArea(ring [][]float64) float64 {
+	var area float64
+	for i := 1; i < len(ring); i++ {
+		p, q := ring[i-1], ring[i]
+		if len(p) < 2 || len(q) < 2 {
+			continue
+		}
+		area += p[0]*q[1] - q[0]*p[1]
+	}
+	return area
+}
+
+// NormalizeFeature returns a copy of f with its geometry normalized and
+// the members not in RFC 7946, crs, ext-data and the foreign members,
+// stripped.
+func NormalizeFeature(f *Feature) *Feature {
+	g := &f.GeometryData
+	if f.Geometry != nil {
+		g = NewGeometryData(f.Geometry)
+	}
+	res := &Feature{
+		ID:         f.ID,
+		Type:       "Feature",
+		Properties: f.Properties,
+	}
+	if g.Type != "" {
+		res.GeometryData = *NormalizeGeometryData(g)
+	}
+	if f.BoundingBox != nil {
+		b := *f.BoundingBox
+		res.BoundingBox = &b
+	}
+	return res
+}
+
+// NormalizeFeatureCollection returns a copy of fc with its features
+// normalized and the members not in RFC 7946, crs, properties and the
+// foreign members, stripped.
+func NormalizeFeatureCollection(fc *FeatureCollection) *FeatureCollection {
+	res := &FeatureCollection{Type: "FeatureCollection", Features: make([]*Feature, 0, len(fc.Features))}
+	for _, f := range fc.Features {
+		if f != nil {
+			res.Features = append(res.Features, NormalizeFeature(f))
+		}
+	}
+	if fc.BoundingBox != nil {
+		b := *fc.BoundingBox
+		res.BoundingBox = &b
+	}
+	return res
+}
+
+// MarshalRFC7946 writes a *GeometryData, *Feature or *FeatureCollection
+// normalized as RFC 7946 text, the bounding boxes as flat arrays of 4
+// values, 6 when the positions carry an elevation.
+func MarshalRFC7946(v interface{}) ([]byte, error) {
+	switch t := v.(type) {
+	case *GeometryData:
+		return json.Marshal(rfcGeometry(NormalizeGeometryData(t)))
+	case *Feature:
+		return json.Marshal(rfcFeature(NormalizeFeature(t)))
+	case *FeatureCollection:
+		fc := NormalizeFeatureCollection(t)
+		features := make([]interface{}, len(fc.Features))
+		z := false
+		for i, f := range fc.Features {
+			features[i] = rfcFeature(f)
+			z = z || LayoutFromGeometryData(&f.GeometryData).HasZ()
+		}
+		return json.Marshal(&struct {
+			Type        string        `json:"type"`
+			BoundingBox []float64     `json:"bbox,omitempty"`
+			Features    []interface{} `json:"features"`
+		}{fc.Type, flatBoundingBox(fc.BoundingBox, z), features})
+	}
+	return nil, fmt.Errorf("cannot marshal %T as rfc 7946 geojson", v)
+}
+
+func flatBoundingBox(b *BoundingBox, z bool) []float64 {
+	if b == nil {
+		return nil
+	}
+	if z {
+		return []float64{b[0][0], b[0][1], b[0][2], b[1][0], b[1][1], b[1][2]}
+	}
+	return []float64{b[0][0], b[0][1], b[1][0], b[1][1]}
+}
+
+// rfcGeometry returns the value to marshal for a normalized geometry.
+func rfcGeometry(g *GeometryData) interface{} {
+	if g == nil || g.Type == "" {
+		return nil
+	}
+	geo := map[string]interface{}{"type": g.Type}
+	if b := flatBoundingBox(g.BoundingBox, LayoutFromGeometryData(g).HasZ()); b != nil {
+		geo["bbox"] = b
+	}
+	switch g.Type {
+	case GeometryPoint:
+		geo["coordinates"] = g.Point
+	case GeometryMultiPoint:
+		geo["coordinates"] = g.MultiPoint
+	case GeometryLineString:
+		geo["coordinates"] = g.LineString
+	case GeometryMultiLineString:
+		geo["coordinates"] = g.MultiLineString
+	case GeometryPolygon:
+		geo["coordinates"] = g.Polygon
+	case GeometryMultiPolygon:
+		geo["coordinates"] = g.MultiPolygon
+	case GeometryCollection:
+		geometries := make([]interface{}, len(g.Geometries))
+		for i, c := range g.Geometries {
+			geometries[i] = rfcGeometry(c)
+		}
+		geo["geometries"] = geometries
+	}
+	return geo
+}
+
+func rfcFeature(f *Feature) interface{} {
+	fea := map[string]interface{}{
+		"type":       "Feature",
+		"geometry":   rfcGeometry(&f.GeometryData),
+		"properties": f.Properties,
+	}
+	if len(f.Properties) == 0 {
+		fea["properties"] = nil
+	}
+	if f.ID != nil {
+		fea["id"] = f.ID
+	}
+	if b := flatBoundingBox(f.BoundingBox, LayoutFromGeometryData(&f.GeometryData).HasZ()); b != nil {
+		fea["bbox"] = b
+	}
+	return fea
+}
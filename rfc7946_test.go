@@ -0,0 +1,154 @@
+package geom
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试严格模式的解析
+func TestUnmarshalStrict(t *testing.T) {
+	g, err := UnmarshalGeometryStrict([]byte(`{"type":"Polygon","coordinates":[[[0,0],[4,0],[4,3],[0,0]]],"bbox":[170,-10,-170,10]}`))
+	assert.NoError(t, err)
+	assert.Len(t, g.Polygon[0], 4)
+	// 经度跨度超过 180 度的线不一定跨越反经线
+	g, err = UnmarshalGeometryStrict([]byte(`{"type":"LineString","coordinates":[[-100,0],[100,0]]}`))
+	assert.NoError(t, err)
+	assert.Len(t, g.LineString, 2)
+
+	f, err := UnmarshalFeatureStrict([]byte(`{"type":"Feature","id":"a","geometry":null,"properties":null}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "a", f.ID)
+
+	fc, err := UnmarshalFeatureCollectionStrict([]byte(`{"type":"FeatureCollection","features":[
+		{"type":"Feature","geometry":{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2,3]}]},"properties":{"a":1}}
+	]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 2, 3}, fc.Features[0].GeometryData.Geometries[0].Point)
+
+	for _, c := range []struct {
+		data     string
+		pointers []string
+	}{
+		{`{"type":"Point","coordinates":[1]}`, []string{"/coordinates"}},
+		{`{"type":"Point","coordinates":[1,2,3,4]}`, []string{"/coordinates"}},
+		{`{"type":"Point","coordinates":[1,"2"]}`, []string{"/coordinates/1"}},
+		{`{"type":"LineString","coordinates":[[1,2]]}`, []string{"/coordinates"}},
+		{`{"type":"Polygon","coordinates":[[[0,0],[4,0],[4,3],[0,1]]]}`, []string{"/coordinates/0"}},
+		{`{"type":"Polygon","coordinates":[[[0,0],[4,0],[0,0]]]}`, []string{"/coordinates/0"}},
+		{`{"type":"MultiPolygon","coordinates":[[[[0,0],[4,0],[4,3],[0,0]]],[[[0,0],[4,0],[4,3]]]]}`, []string{"/coordinates/1/0", "/coordinates/1/0"}},
+		{`{"type":"Point","coordinates":[1,2],"crs":{"type":"name"}}`, []string{"/crs"}},
+		{`{"type":"Point","coordinates":[1,2],"bbox":[1,2,1]}`, []string{"/bbox"}},
+		{`{"type":"Point","coordinates":[1,2],"bbox":[-190,2,1,3]}`, []string{"/bbox"}},
+		{`{"type":"Point","coordinates":[1,2],"bbox":[1,20,2,10]}`, []string{"/bbox"}},
+		{`{"type":"Point","coordinates":[1,2],"bbox":[1,-95,2,10]}`, []string{"/bbox"}},
+		{`{"type":"Point","coordinates":[1,2,3],"bbox":[1,2,5,1,2,3]}`, []string{"/bbox"}},
+		{`{"type":"Point","coordinates":[1,2],"properties":{}}`, []string{"/properties"}},
+		{`{"type":"Curve","coordinates":[1,2]}`, []string{"/type"}},
+		{`{"type":"Point"}`, []string{"/coordinates"}},
+		{`{"type":"GeometryCollection","geometries":[{"type":"GeometryCollection","geometries":[]}]}`, []string{"/geometries/0"}},
+		{`[]`, []string{""}},
+	} {
+		_, err := UnmarshalGeometryStrict([]byte(c.data))
+		verr, ok := err.(*ValidationError)
+		if assert.True(t, ok, c.data) {
+			var pointers []string
+			for _, v := range verr.Violations {
+				pointers = append(pointers, v.Pointer)
+			}
+			assert.Equal(t, c.pointers, pointers, c.data)
+		}
+	}
+
+	_, err = UnmarshalFeatureStrict([]byte(`{"type":"Feature","id":true,"geometry":{"type":"Point","coordinates":[1]}}`))
+	assert.Equal(t, []Violation{
+		{"/id", "id neither a string nor a number"},
+		{"/geometry/coordinates", "position of 1 elements, expected 2 or 3"},
+		{"/properties", "properties member missing"},
+	}, err.(*ValidationError).Violations)
+	assert.EqualError(t, err, "geojson violates rfc 7946: /id: id neither a string nor a number (and 2 more)")
+
+	_, err = UnmarshalFeatureCollectionStrict([]byte(`{"type":"FeatureCollection","crs":{},"properties":{},"features":[{"type":"Point","coordinates":[1,2]}]}`))
+	assert.Len(t, err.(*ValidationError).Violations, 6)
+
+	// 语法错误不是违规
+	_, err = UnmarshalGeometryStrict([]byte(`{"type":`))
+	assert.Error(t, err)
+	_, ok := err.(*ValidationError)
+	assert.False(t, ok)
+}
+
+// 测试规范化
+func TestNormalize(t *testing.T) {
+	// 顺时针的外环, 未闭合逆时针的内环
+	g := NewPolygonGeometryData([][][]float64{
+		{{0, 0}, {0, 10}, {10, 10}, {10, 0}, {0, 0}},
+		{{2, 2}, {4, 2}, {4, 4}},
+	})
+	g.EPSG = 4326
+	n := NormalizeGeometryData(g)
+	assert.Equal(t, [][][]float64{
+		{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+		{{2, 2}, {4, 4}, {4, 2}, {2, 2}},
+	}, n.Polygon)
+	assert.Equal(t, 0, n.EPSG)
+	// 原几何不变
+	assert.Equal(t, []float64{0, 10}, g.Polygon[0][1])
+	assert.Len(t, g.Polygon[1], 3)
+	assert.Equal(t, 4326, g.EPSG)
+
+	// 去掉M
+	c := NewCollectionGeometryData(NewPointGeometryData([]float64{1, 2, 3}), NewLineStringGeometryData([][]float64{{1, 2, 3, 4}, {5, 6, 7, 8}}))
+	c.Layout = XYM
+	c.Geometries[1].Layout = XYZM
+	n = NormalizeGeometryData(c)
+	assert.Equal(t, []float64{1, 2}, n.Geometries[0].Point)
+	assert.Equal(t, [][]float64{{1, 2, 3}, {5, 6, 7}}, n.Geometries[1].LineString)
+	assert.Equal(t, NoLayout, n.Layout)
+	assert.Equal(t, NoLayout, n.Geometries[1].Layout)
+
+	f := NewPolygonFeature([][][]float64{{{0, 0, 1}, {0, 10, 1}, {10, 0, 1}}})
+	f.ID = 7
+	f.CRS = map[string]interface{}{"type": "name"}
+	f.ExtData["a"] = 1
+	f.Properties["name"] = "x"
+	data, err := MarshalRFC7946(f)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"Feature","id":7,"bbox":[0,0,1,10,10,1],"properties":{"name":"x"},
+		"geometry":{"type":"Polygon","coordinates":[[[0,0,1],[10,0,1],[0,10,1],[0,0,1]]]}}`, string(data))
+	_, err = UnmarshalFeatureStrict(data)
+	assert.NoError(t, err)
+
+	fc := NewFeatureCollection()
+	fc.Properties["source"] = "test"
+	fc.CRS = map[string]interface{}{"type": "name"}
+	fc.BoundingBox = &BoundingBox{{1, 2, 0}, {1, 2, 0}}
+	fc.AddFeature(NewPointFeature([]float64{1, 2}))
+	fc.AddFeature(&Feature{Type: "Feature"})
+	data, err = MarshalRFC7946(fc)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"FeatureCollection","bbox":[1,2,1,2],"features":[
+		{"type":"Feature","bbox":[1,2,1,2],"properties":null,"geometry":{"type":"Point","coordinates":[1,2]}},
+		{"type":"Feature","properties":null,"geometry":null}
+	]}`, string(data))
+	_, err = UnmarshalFeatureCollectionStrict(data)
+	assert.NoError(t, err)
+	// 原集合的成员保留
+	assert.Equal(t, "test", fc.Properties["source"])
+
+	data, err = MarshalRFC7946(NewCollectionGeometryData(nil, NewPointGeometryData([]float64{1, 2})))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2]}]}`, string(data))
+	assert.Len(t, NormalizeGeometryData(NewCollectionGeometryData(nil)).Geometries, 0)
+
+	data, err = MarshalRFC7946(g)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "epsg")
+	_, err = MarshalRFC7946(Feature{})
+	assert.Error(t, err)
+
+	var ret map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &ret))
+	assert.Equal(t, "Polygon", ret["type"])
+}
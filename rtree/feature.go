@@ -0,0 +1,102 @@
+package rtree
+
+import (
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/general"
+)
+
+// BoundingBoxExtent returns the 2D extent of b.
+func BoundingBoxExtent(b *geom.BoundingBox) general.Extent {
+	return general.Extent{b[0][0], b[0][1], b[1][0], b[1][1]}
+}
+
+// GeometryExtent returns the 2D extent of g, false when g has no
+// coordinates.
+func GeometryExtent(g *geom.GeometryData) (general.Extent, bool) {
+	b := geom.BoundingBoxFromGeometryData(g)
+	if b == nil || b[0][0] > b[1][0] {
+		return empty(), false
+	}
+	return BoundingBoxExtent(b), true
+}
+
+// FeatureExtent returns the extent of the bounding box of f, computed from
+// its geometry when f has none.
+func FeatureExtent(f *geom.Feature) (general.Extent, bool) {
+	if f.BoundingBox != nil {
+		return BoundingBoxExtent(f.BoundingBox), true
+	}
+	return GeometryExtent(&f.GeometryData)
+}
+
+// NewFeatureIndex returns a packed tree of the features of fc.
+func NewFeatureIndex(fc *geom.FeatureCollection) *RTree {
+	t := New(DefaultMaxEntries)
+	t.LoadFeatures(fc.Features)
+	return t
+}
+
+// InsertGeometryData adds g to the tree, skipping it when it has no
+// coordinates.
+func (t *RTree) InsertGeometryData(g *geom.GeometryData) bool {
+	e, ok := GeometryExtent(g)
+	if ok {
+		t.Insert(e, g)
+	}
+	return ok
+}
+
+// InsertFeature adds f to the tree, skipping it when it has no extent.
+func (t *RTree) InsertFeature(f *geom.Feature) bool {
+	e, ok := FeatureExtent(f)
+	if ok {
+		t.Insert(e, f)
+	}
+	return ok
+}
+
+// DeleteFeature removes f from the tree. The bounding box of f must not
+// have changed since it was added.
+func (t *RTree) DeleteFeature(f *geom.Feature) bool {
+	e, ok := FeatureExtent(f)
+	return ok && t.Delete(e, f)
+}
+
+// LoadFeatures packs features into the tree as Load, skipping the ones
+// without extent.
+func (t *RTree) LoadFeatures(features []*geom.Feature) {
+	items := make([]Item, 0, len(features))
+	for _, f := range features {
+		if e, ok := FeatureExtent(f); ok {
+			items = append(items, Item{Extent: e, Value: f})
+		}
+	}
+	t.Load(items)
+}
+
+// SearchFeatures returns the features whose extent intersects extent.
+func (t *RTree) SearchFeatures(extent general.Extent) []*geom.Feature {
+	var ret []*geom.Feature
+	t.SearchFunc(extent, func(item Item) bool {
+		if f, ok := item.Value.(*geom.Feature); ok {
+			ret = append(ret, f)
+		}
+		return true
+	})
+	return ret
+}
+
+// NearestFeatures returns the k features whose extent is nearest to pt.
+func (t *RTree) NearestFeatures(pt []float64, k int) []*geom.Feature {
+	var ret []*geom.Feature
+	if k <= 0 {
+		return ret
+	}
+	t.NearestFunc(pt, nil, func(item Item, d float64) bool {
+		if f, ok := item.Value.(*geom.Feature); ok {
+			ret = append(ret, f)
+		}
+		return len(ret) < k
+	})
+	return ret
+}
@@ -0,0 +1,91 @@
+package rtree
+
+import (
+	"math"
+	"sort"
+
+	"github.com/flywave/go-geom/general"
+)
+
+// Load adds items to the tree, packing the whole tree again with the
+// Sort-Tile-Recursive algorithm. Packed trees are faster to search than
+// trees built one Insert at a time and can still be modified.
+func (t *RTree) Load(items []Item) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	all := make([]Item, 0, t.size+len(items))
+	each(t.root, func(item Item) bool {
+		all = append(all, item)
+		return true
+	})
+	all = append(all, items...)
+	if len(all) == 0 {
+		t.clear()
+		return
+	}
+
+	extents := make([]general.Extent, len(all))
+	for i, item := range all {
+		extents[i] = item.Extent
+	}
+	var nodes []*node
+	for _, group := range tile(extents, t.maxEntries) {
+		n := &node{leaf: true, height: 1, items: make([]Item, len(group))}
+		for i, j := range group {
+			n.items[i] = all[j]
+		}
+		n.refresh()
+		nodes = append(nodes, n)
+	}
+	for len(nodes) > 1 {
+		extents = extents[:len(nodes)]
+		for i, n := range nodes {
+			extents[i] = n.extent
+		}
+		var parents []*node
+		for _, group := range tile(extents, t.maxEntries) {
+			n := &node{height: nodes[0].height + 1, children: make([]*node, len(group))}
+			for i, j := range group {
+				n.children[i] = nodes[j]
+			}
+			n.refresh()
+			parents = append(parents, n)
+		}
+		nodes = parents
+	}
+	t.root = nodes[0]
+	t.size = len(all)
+}
+
+// tile groups the extents by at most max, sorting them by x in vertical
+// slices then by y within each slice.
+func tile(extents []general.Extent, max int) [][]int {
+	n := len(extents)
+	ids := make([]int, n)
+	for i := range ids {
+		ids[i] = i
+	}
+	center := func(i, axis int) float64 {
+		return (extents[i][axis] + extents[i][axis+2]) / 2
+	}
+	sort.Slice(ids, func(a, b int) bool { return center(ids[a], 0) < center(ids[b], 0) })
+
+	leaves := int(math.Ceil(float64(n) / float64(max)))
+	slice := max * int(math.Ceil(math.Sqrt(float64(leaves))))
+	var groups [][]int
+	for i := 0; i < n; i += slice {
+		s := ids[i:minInt(i+slice, n)]
+		sort.Slice(s, func(a, b int) bool { return center(s[a], 1) < center(s[b], 1) })
+		for j := 0; j < len(s); j += max {
+			groups = append(groups, s[j:minInt(j+max, len(s))])
+		}
+	}
+	return groups
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,81 @@
+package rtree
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/flywave/go-geom/general"
+)
+
+// Nearest returns the k items nearest to pt, closest first. dist measures
+// the distance from pt to an item and must not be less than the distance
+// to the item extent, nil uses the distance to the extent.
+func (t *RTree) Nearest(pt []float64, k int, dist func(Item) float64) []Item {
+	var ret []Item
+	if k <= 0 {
+		return ret
+	}
+	t.NearestFunc(pt, dist, func(item Item, d float64) bool {
+		ret = append(ret, item)
+		return len(ret) < k
+	})
+	return ret
+}
+
+// NearestFunc calls fn for the items by increasing distance to pt until fn
+// returns false, dist is as for Nearest.
+func (t *RTree) NearestFunc(pt []float64, dist func(Item) float64, fn func(item Item, d float64) bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.size == 0 {
+		return
+	}
+	q := &entryQueue{{node: t.root, d: boxDistance(pt, t.root.extent)}}
+	for q.Len() > 0 {
+		e := heap.Pop(q).(entry)
+		switch {
+		case e.node == nil && (e.exact || dist == nil):
+			if !fn(e.item, e.d) {
+				return
+			}
+		case e.node == nil:
+			// 精确距离不小于到外包框的距离, 重新排队
+			heap.Push(q, entry{item: e.item, d: dist(e.item), exact: true})
+		case e.node.leaf:
+			for _, item := range e.node.items {
+				heap.Push(q, entry{item: item, d: boxDistance(pt, item.Extent)})
+			}
+		default:
+			for _, c := range e.node.children {
+				heap.Push(q, entry{node: c, d: boxDistance(pt, c.extent)})
+			}
+		}
+	}
+}
+
+// boxDistance returns the distance from pt to extent, zero inside.
+func boxDistance(pt []float64, extent general.Extent) float64 {
+	dx := math.Max(0, math.Max(extent[0]-pt[0], pt[0]-extent[2]))
+	dy := math.Max(0, math.Max(extent[1]-pt[1], pt[1]-extent[3]))
+	return math.Hypot(dx, dy)
+}
+
+type entry struct {
+	node  *node
+	item  Item
+	d     float64
+	exact bool
+}
+
+type entryQueue []entry
+
+func (q entryQueue) Len() int            { return len(q) }
+func (q entryQueue) Less(i, j int) bool  { return q[i].d < q[j].d }
+func (q entryQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *entryQueue) Push(x interface{}) { *q = append(*q, x.(entry)) }
+func (q *entryQueue) Pop() interface{} {
+	old := *q
+	x := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,423 @@
+// Package rtree provides an in-memory R-tree indexing values by their
+// extent. Trees grow one item at a time with Insert or are packed at once
+// with Load using the Sort-Tile-Recursive algorithm, both can be mixed.
+//
+// An RTree is safe for concurrent use: searches share a read lock while
+// Insert, Delete and Load take the write lock. Callbacks run with the lock
+// held and must not modify the tree.
+package rtree
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/flywave/go-geom/general"
+)
+
+const DefaultMaxEntries = 16
+
+// Item is a value stored in the tree with its extent.
+type Item struct {
+	Extent general.Extent
+	Value  interface{}
+}
+
+type node struct {
+	extent   general.Extent
+	leaf     bool
+	height   int
+	children []*node
+	items    []Item
+}
+
+func (n *node) len() int {
+	if n.leaf {
+		return len(n.items)
+	}
+	return len(n.children)
+}
+
+func (n *node) extentAt(i int) general.Extent {
+	if n.leaf {
+		return n.items[i].Extent
+	}
+	return n.children[i].extent
+}
+
+func (n *node) refresh() {
+	n.extent = empty()
+	for i := 0; i < n.len(); i++ {
+		n.extent = union(n.extent, n.extentAt(i))
+	}
+}
+
+type RTree struct {
+	mu         sync.RWMutex
+	root       *node
+	size       int
+	maxEntries int
+	minEntries int
+}
+
+// New returns an empty tree whose nodes hold at most maxEntries entries,
+// DefaultMaxEntries when maxEntries is less than 4.
+func New(maxEntries int) *RTree {
+	if maxEntries < 4 {
+		maxEntries = DefaultMaxEntries
+	}
+	t := &RTree{maxEntries: maxEntries, minEntries: int(math.Max(2, math.Ceil(float64(maxEntries)*0.4)))}
+	t.clear()
+	return t
+}
+
+func (t *RTree) clear() {
+	t.root = &node{extent: empty(), leaf: true, height: 1}
+	t.size = 0
+}
+
+// Len returns the number of items in the tree.
+func (t *RTree) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.size
+}
+
+// Extent returns the extent of all the items, false when the tree is empty.
+func (t *RTree) Extent() (general.Extent, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.root.extent, t.size > 0
+}
+
+// Clear removes all the items.
+func (t *RTree) Clear() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.clear()
+}
+
+// Insert adds value with extent to the tree.
+func (t *RTree) Insert(extent general.Extent, value interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.insert(Item{Extent: extent, Value: value})
+}
+
+func (t *RTree) insert(item Item) {
+	path := t.choosePath(item.Extent, 1)
+	leaf := path[len(path)-1]
+	leaf.items = append(leaf.items, item)
+	t.size++
+	t.grow(path, item.Extent)
+}
+
+// choosePath descends from the root to a node of the given height, following
+// the child needing the least enlargement.
+func (t *RTree) choosePath(extent general.Extent, height int) []*node {
+	n := t.root
+	path := []*node{n}
+	for n.height > height {
+		var best *node
+		minEnlargement, minArea := math.Inf(1), math.Inf(1)
+		for _, c := range n.children {
+			a := area(c.extent)
+			enlargement := area(union(c.extent, extent)) - a
+			if enlargement < minEnlargement || (enlargement == minEnlargement && a < minArea) {
+				best, minEnlargement, minArea = c, enlargement, a
+			}
+		}
+		n = best
+		path = append(path, n)
+	}
+	return path
+}
+
+// grow extends the extents along path and splits the overflowing nodes.
+func (t *RTree) grow(path []*node, extent general.Extent) {
+	for _, n := range path {
+		n.extent = union(n.extent, extent)
+	}
+	for i := len(path) - 1; i >= 0 && path[i].len() > t.maxEntries; i-- {
+		n := path[i]
+		sibling := t.split(n)
+		if i == 0 {
+			t.root = &node{height: n.height + 1, children: []*node{n, sibling}}
+			t.root.refresh()
+		} else {
+			path[i-1].children = append(path[i-1].children, sibling)
+		}
+	}
+}
+
+// split moves the entries of n past the best split index into a new node,
+// choosing the axis of least margin then the index of least overlap.
+func (t *RTree) split(n *node) *node {
+	m, count := t.minEntries, n.len()
+	margin := func() float64 {
+		var sum float64
+		left, right := empty(), empty()
+		for i := 0; i < m; i++ {
+			left = union(left, n.extentAt(i))
+			right = union(right, n.extentAt(count-1-i))
+		}
+		sum = perimeter(left) + perimeter(right)
+		for i := m; i < count-m; i++ {
+			left = union(left, n.extentAt(i))
+			sum += perimeter(left)
+		}
+		for i := count - m - 1; i >= m; i-- {
+			right = union(right, n.extentAt(i))
+			sum += perimeter(right)
+		}
+		return sum
+	}
+	sortNode(n, 0)
+	xMargin := margin()
+	sortNode(n, 1)
+	if xMargin < margin() {
+		sortNode(n, 0)
+	}
+
+	index := count - m
+	minOverlap, minArea := math.Inf(1), math.Inf(1)
+	for i := m; i <= count-m; i++ {
+		left, right := empty(), empty()
+		for j := 0; j < i; j++ {
+			left = union(left, n.extentAt(j))
+		}
+		for j := i; j < count; j++ {
+			right = union(right, n.extentAt(j))
+		}
+		overlap := area(intersection(left, right))
+		a := area(left) + area(right)
+		if overlap < minOverlap || (overlap == minOverlap && a < minArea) {
+			index, minOverlap, minArea = i, overlap, a
+		}
+	}
+
+	sibling := &node{leaf: n.leaf, height: n.height}
+	if n.leaf {
+		sibling.items = append([]Item(nil), n.items[index:]...)
+		n.items = n.items[:index:index]
+	} else {
+		sibling.children = append([]*node(nil), n.children[index:]...)
+		n.children = n.children[:index:index]
+	}
+	n.refresh()
+	sibling.refresh()
+	return sibling
+}
+
+// sortNode sorts the entries of n by their minimum along axis 0 for x or 1
+// for y.
+func sortNode(n *node, axis int) {
+	if n.leaf {
+		sort.Slice(n.items, func(i, j int) bool { return n.items[i].Extent[axis] < n.items[j].Extent[axis] })
+	} else {
+		sort.Slice(n.children, func(i, j int) bool { return n.children[i].extent[axis] < n.children[j].extent[axis] })
+	}
+}
+
+// Delete removes the first item with the given extent and value, which
+// must be comparable, and reports whether one was found.
+func (t *RTree) Delete(extent general.Extent, value interface{}) bool {
+	return t.DeleteFunc(extent, func(item Item) bool {
+		return item.Extent == extent && item.Value == value
+	})
+}
+
+// DeleteFunc removes the first item intersecting extent for which match
+// returns true and reports whether one was found.
+func (t *RTree) DeleteFunc(extent general.Extent, match func(Item) bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	path := []*node{}
+	var find func(n *node) bool
+	find = func(n *node) bool {
+		if !intersects(n.extent, extent) {
+			return false
+		}
+		path = append(path, n)
+		if n.leaf {
+			for i, item := range n.items {
+				if intersects(item.Extent, extent) && match(item) {
+					n.items = append(n.items[:i], n.items[i+1:]...)
+					return true
+				}
+			}
+		} else {
+			for _, c := range n.children {
+				if find(c) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+	if !find(t.root) {
+		return false
+	}
+	t.size--
+	t.condense(path)
+	return true
+}
+
+// condense drops the nodes left underfull along path, reinserting their
+// items, and shrinks the extents.
+func (t *RTree) condense(path []*node) {
+	var orphans []*node
+	for i := len(path) - 1; i > 0; i-- {
+		n, parent := path[i], path[i-1]
+		if n.len() < t.minEntries {
+			for j, c := range parent.children {
+				if c == n {
+					parent.children = append(parent.children[:j], parent.children[j+1:]...)
+					break
+				}
+			}
+			if n.len() > 0 {
+				orphans = append(orphans, n)
+			}
+		} else {
+			n.refresh()
+		}
+	}
+	t.root.refresh()
+	for !t.root.leaf && len(t.root.children) == 1 {
+		t.root = t.root.children[0]
+	}
+	if t.root.len() == 0 {
+		t.clear()
+	}
+	for _, o := range orphans {
+		each(o, func(item Item) bool {
+			t.size--
+			t.insert(item)
+			return true
+		})
+	}
+}
+
+// Search returns the items whose extent intersects extent.
+func (t *RTree) Search(extent general.Extent) []Item {
+	var ret []Item
+	t.SearchFunc(extent, func(item Item) bool {
+		ret = append(ret, item)
+		return true
+	})
+	return ret
+}
+
+// SearchFunc calls fn for each item whose extent intersects extent until fn
+// returns false.
+func (t *RTree) SearchFunc(extent general.Extent, fn func(Item) bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.size == 0 {
+		return
+	}
+	var search func(n *node) bool
+	search = func(n *node) bool {
+		if n.leaf {
+			for _, item := range n.items {
+				if intersects(item.Extent, extent) && !fn(item) {
+					return false
+				}
+			}
+			return true
+		}
+		for _, c := range n.children {
+			if !intersects(c.extent, extent) {
+				continue
+			}
+			if contains(extent, c.extent) {
+				if !each(c, fn) {
+					return false
+				}
+			} else if !search(c) {
+				return false
+			}
+		}
+		return true
+	}
+	if intersects(t.root.extent, extent) {
+		search(t.root)
+	}
+}
+
+// Collides tells if any item intersects extent.
+func (t *RTree) Collides(extent general.Extent) bool {
+	found := false
+	t.SearchFunc(extent, func(Item) bool {
+		found = true
+		return false
+	})
+	return found
+}
+
+// Each calls fn for each item of the tree until fn returns false.
+func (t *RTree) Each(fn func(Item) bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	each(t.root, fn)
+}
+
+// Items returns all the items of the tree.
+func (t *RTree) Items() []Item {
+	ret := make([]Item, 0, t.Len())
+	t.Each(func(item Item) bool {
+		ret = append(ret, item)
+		return true
+	})
+	return ret
+}
+
+func each(n *node, fn func(Item) bool) bool {
+	if n.leaf {
+		for _, item := range n.items {
+			if !fn(item) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, c := range n.children {
+		if !each(c, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+func empty() general.Extent {
+	return general.Extent{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+}
+
+func union(a, b general.Extent) general.Extent {
+	return general.Extent{math.Min(a[0], b[0]), math.Min(a[1], b[1]), math.Max(a[2], b[2]), math.Max(a[3], b[3])}
+}
+
+func intersection(a, b general.Extent) general.Extent {
+	return general.Extent{math.Max(a[0], b[0]), math.Max(a[1], b[1]), math.Min(a[2], b[2]), math.Min(a[3], b[3])}
+}
+
+func area(e general.Extent) float64 {
+	if e[2] < e[0] || e[3] < e[1] {
+		return 0
+	}
+	return (e[2] - e[0]) * (e[3] - e[1])
+}
+
+func perimeter(e general.Extent) float64 {
+	return (e[2] - e[0]) + (e[3] - e[1])
+}
+
+func intersects(a, b general.Extent) bool {
+	return a[0] <= b[2] && b[0] <= a[2] && a[1] <= b[3] && b[1] <= a[3]
+}
+
+func contains(a, b general.Extent) bool {
+	return a[0] <= b[0] && a[1] <= b[1] && b[2] <= a[2] && b[3] <= a[3]
+}
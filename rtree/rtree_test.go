@@ -0,0 +1,260 @@
+package rtree
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/general"
+	"github.com/stretchr/testify/assert"
+)
+
+func randomItems(r *rand.Rand, n int) []Item {
+	items := make([]Item, n)
+	for i := range items {
+		x, y := r.Float64()*1000, r.Float64()*1000
+		items[i] = Item{Extent: general.Extent{x, y, x + r.Float64()*20, y + r.Float64()*20}, Value: i}
+	}
+	return items
+}
+
+func values(items []Item) []int {
+	ret := make([]int, 0, len(items))
+	for _, item := range items {
+		ret = append(ret, item.Value.(int))
+	}
+	sort.Ints(ret)
+	return ret
+}
+
+func scan(items []Item, extent general.Extent) []int {
+	var ret []Item
+	for _, item := range items {
+		if intersects(item.Extent, extent) {
+			ret = append(ret, item)
+		}
+	}
+	return values(ret)
+}
+
+// 检查每个节点的外包框与高度
+func checkNode(t *testing.T, n *node, root bool) int {
+	e := n.extent
+	n.refresh()
+	assert.Equal(t, n.extent, e)
+	if n.leaf {
+		assert.Equal(t, 1, n.height)
+		return len(n.items)
+	}
+	assert.True(t, root || len(n.children) > 0)
+	count := 0
+	for _, c := range n.children {
+		assert.Equal(t, n.height-1, c.height)
+		count += checkNode(t, c, false)
+	}
+	return count
+}
+
+func TestInsertSearch(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	items := randomItems(r, 2000)
+	tree := New(8)
+	for _, item := range items {
+		tree.Insert(item.Extent, item.Value)
+	}
+	assert.Equal(t, 2000, tree.Len())
+	assert.Equal(t, 2000, checkNode(t, tree.root, true))
+	assert.True(t, tree.root.height > 2)
+
+	for i := 0; i < 50; i++ {
+		x, y := r.Float64()*1000, r.Float64()*1000
+		box := general.Extent{x, y, x + 100, y + 50}
+		assert.Equal(t, scan(items, box), values(tree.Search(box)))
+	}
+	assert.Len(t, tree.Search(general.Extent{2000, 2000, 3000, 3000}), 0)
+	assert.Equal(t, values(items), values(tree.Items()))
+
+	e, ok := tree.Extent()
+	assert.True(t, ok)
+	assert.True(t, e[0] >= 0 && e[2] <= 1020)
+
+	// 提前结束
+	count := 0
+	tree.SearchFunc(general.Extent{0, 0, 1000, 1000}, func(Item) bool {
+		count++
+		return count < 10
+	})
+	assert.Equal(t, 10, count)
+	assert.True(t, tree.Collides(general.Extent{0, 0, 1000, 1000}))
+	assert.False(t, tree.Collides(general.Extent{-10, -10, -5, -5}))
+}
+
+func TestDelete(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	items := randomItems(r, 1000)
+	tree := New(6)
+	for _, item := range items {
+		tree.Insert(item.Extent, item.Value)
+	}
+	assert.False(t, tree.Delete(items[0].Extent, -1))
+
+	r.Shuffle(len(items), func(i, j int) { items[i], items[j] = items[j], items[i] })
+	for i, item := range items[:900] {
+		assert.True(t, tree.Delete(item.Extent, item.Value))
+		if i%100 == 0 {
+			assert.Equal(t, 999-i, checkNode(t, tree.root, true))
+		}
+	}
+	assert.Equal(t, 100, tree.Len())
+	assert.Equal(t, values(items[900:]), values(tree.Items()))
+	box := general.Extent{100, 100, 600, 600}
+	assert.Equal(t, scan(items[900:], box), values(tree.Search(box)))
+
+	for _, item := range items[900:] {
+		assert.True(t, tree.DeleteFunc(item.Extent, func(i Item) bool { return i.Value == item.Value }))
+	}
+	assert.Equal(t, 0, tree.Len())
+	_, ok := tree.Extent()
+	assert.False(t, ok)
+	assert.Len(t, tree.Search(box), 0)
+
+	// 删除后仍可插入
+	tree.Insert(general.Extent{1, 1, 2, 2}, "a")
+	assert.Len(t, tree.Search(box), 0)
+	assert.Len(t, tree.Search(general.Extent{0, 0, 1, 1}), 1)
+}
+
+func TestLoad(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	items := randomItems(r, 5000)
+	tree := New(DefaultMaxEntries)
+	tree.Load(items[:4000])
+	assert.Equal(t, 4000, checkNode(t, tree.root, true))
+	// 装箱后的树高度最小
+	assert.Equal(t, 3, tree.root.height)
+
+	for _, item := range items[4000:4500] {
+		tree.Insert(item.Extent, item.Value)
+	}
+	tree.Load(items[4500:])
+	assert.Equal(t, 5000, tree.Len())
+	assert.Equal(t, 5000, checkNode(t, tree.root, true))
+	for i := 0; i < 50; i++ {
+		x, y := r.Float64()*1000, r.Float64()*1000
+		box := general.Extent{x, y, x + 30, y + 80}
+		assert.Equal(t, scan(items, box), values(tree.Search(box)))
+	}
+	for _, item := range items[:2500] {
+		assert.True(t, tree.Delete(item.Extent, item.Value))
+	}
+	assert.Equal(t, values(items[2500:]), values(tree.Items()))
+
+	tree.Clear()
+	tree.Load(nil)
+	assert.Equal(t, 0, tree.Len())
+	tree.Load(items[:3])
+	assert.Equal(t, 1, tree.root.height)
+}
+
+func TestNearest(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	items := randomItems(r, 3000)
+	tree := New(DefaultMaxEntries)
+	tree.Load(items)
+	for i := 0; i < 20; i++ {
+		pt := []float64{r.Float64() * 1200, r.Float64() * 1200}
+		ds := make([]float64, len(items))
+		for j, item := range items {
+			ds[j] = boxDistance(pt, item.Extent)
+		}
+		sort.Float64s(ds)
+		ret := tree.Nearest(pt, 10, nil)
+		assert.Len(t, ret, 10)
+		for j, item := range ret {
+			assert.Equal(t, ds[j], boxDistance(pt, item.Extent))
+		}
+	}
+
+	// 以外包框中心的距离为准
+	center := func(item Item) float64 {
+		return math.Hypot((item.Extent[0]+item.Extent[2])/2-500, (item.Extent[1]+item.Extent[3])/2-500)
+	}
+	ds := make([]float64, len(items))
+	for j, item := range items {
+		ds[j] = center(item)
+	}
+	sort.Float64s(ds)
+	var got []float64
+	tree.NearestFunc([]float64{500, 500}, center, func(item Item, d float64) bool {
+		assert.Equal(t, center(item), d)
+		got = append(got, d)
+		return len(got) < 25
+	})
+	assert.Equal(t, ds[:25], got)
+
+	assert.Len(t, tree.Nearest([]float64{0, 0}, 5000, nil), 3000)
+	assert.Len(t, New(0).Nearest([]float64{0, 0}, 1, nil), 0)
+}
+
+func TestFeatureIndex(t *testing.T) {
+	fc := geom.NewFeatureCollection()
+	for i := 0; i < 100; i++ {
+		x := float64(i % 10 * 10)
+		y := float64(i / 10 * 10)
+		fc.AddFeature(geom.NewPolygonFeature([][][]float64{{{x, y}, {x + 5, y}, {x + 5, y + 5}, {x, y + 5}, {x, y}}}))
+	}
+	line := geom.NewLineStringFeature([][]float64{{-10, -10}, {-5, -20}})
+	line.BoundingBox = nil
+	fc.AddFeature(line)
+	fc.AddFeature(geom.NewFeatureFromGeometryData(geom.NewCollectionGeometryData()))
+
+	tree := NewFeatureIndex(fc)
+	assert.Equal(t, 101, tree.Len())
+	found := tree.SearchFeatures(general.Extent{12, 12, 27, 22})
+	assert.Len(t, found, 4)
+	assert.Equal(t, []*geom.Feature{line}, tree.SearchFeatures(general.Extent{-8, -30, -6, 0}))
+
+	near := tree.NearestFeatures([]float64{33, 33}, 2)
+	assert.Equal(t, []*geom.Feature{fc.Features[33]}, near[:1])
+
+	assert.True(t, tree.DeleteFeature(fc.Features[33]))
+	assert.False(t, tree.DeleteFeature(fc.Features[33]))
+	assert.Len(t, tree.SearchFeatures(general.Extent{33, 33, 34, 34}), 0)
+	assert.True(t, tree.InsertFeature(fc.Features[33]))
+	assert.False(t, tree.InsertFeature(fc.Features[101]))
+
+	g := geom.NewCollectionGeometryData(geom.NewPointGeometryData([]float64{1, 2}), geom.NewPointGeometryData([]float64{3, -1}))
+	e, ok := GeometryExtent(g)
+	assert.True(t, ok)
+	assert.Equal(t, general.Extent{1, -1, 3, 2}, e)
+	assert.True(t, tree.InsertGeometryData(g))
+	assert.Equal(t, g, tree.Search(general.Extent{2, -0.5, 2, -0.5})[0].Value)
+}
+
+func TestConcurrentSearch(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	items := randomItems(r, 2000)
+	tree := New(DefaultMaxEntries)
+	tree.Load(items)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				x := float64((i*100 + j) % 1000)
+				tree.Search(general.Extent{x, x, x + 50, x + 50})
+				tree.Nearest([]float64{x, 1000 - x}, 3, nil)
+			}
+		}(i)
+	}
+	for _, item := range randomItems(r, 200) {
+		tree.Insert(item.Extent, item.Value.(int)+2000)
+	}
+	wg.Wait()
+	assert.Equal(t, 2200, tree.Len())
+}
@@ -0,0 +1,472 @@
+package geom
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// scanner reads a GeoJSON geometry straight from its text: the positions
+// are parsed into one flat slice per geometry, the other members, small,
+// through encoding/json.
+type scanner struct {
+	data []byte
+	pos  int
+}
+
+func (s *scanner) syntaxError() error {
+	if s.pos >= len(s.data) {
+		return errors.New("unexpected end of geojson")
+	}
+	return fmt.Errorf("invalid character %q in geojson at offset %d", s.data[s.pos], s.pos)
+}
+
+func (s *scanner) space() {
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case ' ', '\t', '\n', '\r':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+// consume skips the spaces and c when it follows them.
+func (s *scanner) consume(c byte) bool {
+	s.space()
+	if s.pos < len(s.data) && s.data[s.pos] == c {
+		s.pos++
+		return true
+	}
+	return false
+}
+
+func (s *scanner) peek() byte {
+	s.space()
+	if s.pos < len(s.data) {
+		return s.data[s.pos]
+	}
+	return 0
+}
+
+func (s *scanner) literal(lit string) bool {
+	s.space()
+	if bytes.HasPrefix(s.data[s.pos:], []byte(lit)) {
+		s.pos += len(lit)
+		return true
+	}
+	return false
+}
+
+// rawString returns the quoted text of the next string, still escaped.
+func (s *scanner) rawString() ([]byte, bool, error) {
+	if !s.consume('"') {
+		return nil, false, s.syntaxError()
+	}
+	start, escaped := s.pos, false
+	for s.pos < len(s.data) {
+		switch c := s.data[s.pos]; {
+		case c == '"':
+			s.pos++
+			return s.data[start : s.pos-1], escaped, nil
+		case c == '\\':
+			escaped = true
+			s.pos += 2
+		case c < 0x20:
+			return nil, false, s.syntaxError()
+		default:
+			s.pos++
+		}
+	}
+	s.pos = len(s.data)
+	return nil, false, s.syntaxError()
+}
+
+func (s *scanner) string() (string, error) {
+	start := s.pos
+	b, escaped, err := s.rawString()
+	if err != nil || !escaped {
+		return string(b), err
+	}
+	var v string
+	s.space()
+	if err := json.Unmarshal(s.data[start:s.pos], &v); err != nil {
+		return "", err
+	}
+	return v, nil
+}
+
+// numberText passes over the next number and returns its text.
+func (s *scanner) numberText() ([]byte, error) {
+	s.space()
+	data, start, i := s.data, s.pos, s.pos
+	for i < len(data) {
+		c := data[i]
+		if (c < '0' || c > '9') && c != '-' && c != '+' && c != '.' && c != 'e' && c != 'E' {
+			break
+		}
+		i++
+	}
+	if start == i {
+		return nil, s.syntaxError()
+	}
+	s.pos = i
+	return data[start:i], nil
+}
+
+func (s *scanner) number() (float64, error) {
+	b, err := s.numberText()
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseFloat(string(b), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %s in geojson", b)
+	}
+	return v, nil
+}
+
+// skip passes over the next value.
+func (s *scanner) skip() error {
+	switch s.peek() {
+	case '"':
+		_, _, err := s.rawString()
+		return err
+	case '{', '[':
+		end := byte('}')
+		if s.data[s.pos] == '[' {
+			end = ']'
+		}
+		s.pos++
+		if s.consume(end) {
+			return nil
+		}
+		for {
+			if end == '}' {
+				if _, _, err := s.rawString(); err != nil {
+					return err
+				}
+				if !s.consume(':') {
+					return s.syntaxError()
+				}
+			}
+			if err := s.skip(); err != nil {
+				return err
+			}
+			if s.consume(end) {
+				return nil
+			}
+			if !s.consume(',') {
+				return s.syntaxError()
+			}
+		}
+	case 't':
+		if s.literal("true") {
+			return nil
+		}
+	case 'f':
+		if s.literal("false") {
+			return nil
+		}
+	case 'n':
+		if s.literal("null") {
+			return nil
+		}
+	default:
+		// 跳过的数字不必转换
+		_, err := s.numberText()
+		return err
+	}
+	return s.syntaxError()
+}
+
+// members calls member with the key of each member of the next object,
+// positioned on its value which member must read.
+func (s *scanner) members(member func(key []byte) error) error {
+	if !s.consume('{') {
+		return s.syntaxError()
+	}
+	if s.consume('}') {
+		return nil
+	}
+	for {
+		start := s.pos
+		key, escaped, err := s.rawString()
+		if err != nil {
+			return err
+		}
+		if escaped {
+			var k string
+			if err := json.Unmarshal(bytes.TrimSpace(s.data[start:s.pos]), &k); err != nil {
+				return err
+			}
+			key = []byte(k)
+		}
+		if !s.consume(':') {
+			return s.syntaxError()
+		}
+		if err := member(key); err != nil {
+			return err
+		}
+		if s.consume('}') {
+			return nil
+		}
+		if !s.consume(',') {
+			return s.syntaxError()
+		}
+	}
+}
+
+// value returns the text of the next value.
+func (s *scanner) value() ([]byte, error) {
+	s.space()
+	start := s.pos
+	if err := s.skip(); err != nil {
+		return nil, err
+	}
+	return s.data[start:s.pos], nil
+}
+
+// geometryDepths are the nesting depths of the coordinates of the types.
+var geometryDepths = map[GeometryType]int{
+	GeometryPoint:           1,
+	GeometryMultiPoint:      2,
+	GeometryLineString:      2,
+	GeometryMultiLineString: 3,
+	GeometryPolygon:         3,
+	GeometryMultiPolygon:    4,
+}
+
+func (s *scanner) geometry(g *GeometryData) error {
+	if s.literal("null") {
+		return nil
+	}
+	var coordinates []byte
+	var typed, positioned, collected bool
+	err := s.members(func(key []byte) error {
+		var err error
+		switch string(key) {
+		case "type":
+			if s.peek() != '"' {
+				return errors.New("type property not string")
+			}
+			var t string
+			t, err = s.string()
+			g.Type, typed = GeometryType(t), true
+		case "coordinates":
+			// 类型在坐标之前时直接读取坐标, 否则留下文本在最后读取
+			if depth, ok := geometryDepths[g.Type]; typed && ok {
+				positioned = true
+				err = s.coordinates(g, depth)
+			} else {
+				coordinates, err = s.value()
+			}
+		case "geometries":
+			collected = true
+			err = s.geometries(g)
+		case "bbox":
+			var raw []byte
+			if raw, err = s.value(); err != nil {
+				return err
+			}
+			var bbox interface{}
+			if err = json.Unmarshal(raw, &bbox); err != nil {
+				return err
+			}
+			if b := decodeBoundingBoxMember(bbox); b != nil {
+				g.BoundingBox = b
+			}
+		case "epsg":
+			if c := s.peek(); c == '-' || c >= '0' && c <= '9' {
+				var v float64
+				v, err = s.number()
+				g.EPSG = int(v)
+			} else {
+				err = s.skip()
+			}
+		case "layout":
+			if s.peek() != '"' {
+				return s.skip()
+			}
+			var l string
+			if l, err = s.string(); err != nil {
+				return err
+			}
+			err = g.Layout.UnmarshalText([]byte(l))
+		default:
+			var raw []byte
+			if raw, err = s.value(); err != nil {
+				return err
+			}
+			err = decodeForeignMember(&g.ForeignMembers, string(key), raw)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if !typed {
+		return errors.New("type property not defined")
+	}
+
+	if g.Type == GeometryCollection {
+		if !collected {
+			return errors.New("not a valid set of geometries")
+		}
+		return nil
+	}
+	// 只有集合有成员
+	g.Geometries = nil
+	depth, ok := geometryDepths[g.Type]
+	if !ok || positioned {
+		return nil
+	}
+	if coordinates == nil {
+		return fmt.Errorf("missing coordinates of geojson %s", g.Type)
+	}
+	return (&scanner{data: coordinates}).coordinates(g, depth)
+}
+
+// coordinates reads the next positions, of nesting depth, into g.
+func (s *scanner) coordinates(g *GeometryData, depth int) error {
+	c := &coordinateScanner{scanner: s, counts: make([][]int, depth)}
+	// 逗号与括号的个数是坐标与数组个数的上限, 一次分配
+	commas, arrays := s.arrayCounts()
+	c.flat = make([]float64, 0, commas+1)
+	c.counts[0] = make([]int, 0, arrays)
+	if err := c.array(depth - 1); err != nil {
+		return err
+	}
+	positions := make([][]float64, len(c.counts[0]))
+	off := 0
+	for i, n := range c.counts[0] {
+		positions[i] = c.flat[off : off+n : off+n]
+		off += n
+	}
+	var paths [][][]float64
+	if depth > 2 {
+		paths = make([][][]float64, len(c.counts[1]))
+		off = 0
+		for i, n := range c.counts[1] {
+			paths[i] = positions[off : off+n : off+n]
+			off += n
+		}
+	}
+	switch g.Type {
+	case GeometryPoint:
+		g.Point = positions[0]
+	case GeometryMultiPoint:
+		g.MultiPoint = positions
+	case GeometryLineString:
+		g.LineString = positions
+	case GeometryMultiLineString:
+		g.MultiLineString = paths
+	case GeometryPolygon:
+		g.Polygon = paths
+	case GeometryMultiPolygon:
+		g.MultiPolygon = make([][][][]float64, len(c.counts[2]))
+		off = 0
+		for i, n := range c.counts[2] {
+			g.MultiPolygon[i] = paths[off : off+n : off+n]
+			off += n
+		}
+	}
+	return nil
+}
+
+// geometries reads the members of a GeometryCollection into g.
+func (s *scanner) geometries(g *GeometryData) error {
+	if !s.consume('[') {
+		return errors.New("not a valid set of geometries")
+	}
+	g.Geometries = make([]*GeometryData, 0)
+	if s.consume(']') {
+		return nil
+	}
+	for {
+		if s.peek() != '{' {
+			return errors.New("not a valid set of geometries")
+		}
+		c := &GeometryData{}
+		if err := s.geometry(c); err != nil {
+			return err
+		}
+		g.Geometries = append(g.Geometries, c)
+		if s.consume(']') {
+			return nil
+		}
+		if !s.consume(',') {
+			return s.syntaxError()
+		}
+	}
+}
+
+// arrayCounts returns the number of commas and of arrays in the next
+// array, without moving.
+func (s *scanner) arrayCounts() (commas, arrays int) {
+	s.space()
+	data, level := s.data[s.pos:], 0
+	for _, c := range data {
+		if c > ',' && c < '[' {
+			// 数字
+			continue
+		}
+		switch c {
+		case ',':
+			commas++
+		case '[':
+			arrays++
+			level++
+		case ']':
+			if level--; level <= 0 {
+				return commas, arrays
+			}
+		}
+	}
+	return commas, arrays
+}
+
+// coordinateScanner reads nested arrays of numbers, the numbers going in
+// flat and the number of elements of the arrays of each level in counts,
+// in the order of the text.
+type coordinateScanner struct {
+	*scanner
+	flat   []float64
+	counts [][]int
+}
+
+// array reads an array of level, 0 for the positions.
+func (c *coordinateScanner) array(level int) error {
+	if !c.consume('[') {
+		if level == 0 {
+			return fmt.Errorf("not a valid position at offset %d", c.pos)
+		}
+		return fmt.Errorf("not a valid set of positions at offset %d", c.pos)
+	}
+	n := 0
+	if !c.consume(']') {
+		for {
+			if level == 0 {
+				v, err := c.number()
+				if err != nil {
+					return fmt.Errorf("not a valid coordinate: %v", err)
+				}
+				c.flat = append(c.flat, v)
+			} else if err := c.array(level - 1); err != nil {
+				return err
+			}
+			n++
+			if c.consume(']') {
+				break
+			}
+			if !c.consume(',') {
+				return c.syntaxError()
+			}
+		}
+	}
+	c.counts[level] = append(c.counts[level], n)
+	return nil
+}
@@ -0,0 +1,179 @@
+package geom
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Framing is the way the GeoJSON texts of a sequence are delimited.
+type Framing int
+
+const (
+	// NewlineDelimited writes each text on its own line (NDJSON).
+	NewlineDelimited Framing = iota
+	// TextSequence starts each text with the record separator and ends it
+	// with a line feed, as in RFC 8142.
+	TextSequence
+)
+
+const recordSeparator = 0x1e
+
+// SequenceReader reads the features and geometries of a GeoJSON sequence.
+// Blank lines and empty records are skipped, and the records cut short,
+// such as the last one of an interrupted stream, are dropped.
+type SequenceReader struct {
+	r       *bufio.Reader
+	framing Framing
+	started bool
+}
+
+func NewSequenceReader(r io.Reader, framing Framing) *SequenceReader {
+	return &SequenceReader{r: bufio.NewReader(r), framing: framing}
+}
+
+// next returns the next non blank record, and whether it ended with its
+// delimiter.
+func (s *SequenceReader) next() ([]byte, bool, error) {
+	delim := byte('\n')
+	if s.framing == TextSequence {
+		delim = recordSeparator
+		// 第一个分隔符之前的内容不是记录
+		if !s.started {
+			if _, err := s.r.ReadBytes(recordSeparator); err != nil {
+				return nil, false, err
+			}
+			s.started = true
+		}
+	}
+	for {
+		rec, err := s.r.ReadBytes(delim)
+		if err != nil && err != io.EOF {
+			return nil, false, err
+		}
+		ended := err == nil
+		if ended {
+			rec = rec[:len(rec)-1]
+		}
+		complete := ended
+		if s.framing == TextSequence {
+			complete = bytes.HasSuffix(bytes.TrimRight(rec, " \t\r"), []byte{'\n'})
+		}
+		if rec = bytes.TrimSpace(rec); len(rec) > 0 {
+			return rec, complete, nil
+		}
+		if !ended {
+			return nil, false, io.EOF
+		}
+	}
+}
+
+// read decodes the next record with decode. The unterminated records
+// which are not valid JSON are dropped as truncated.
+func (s *SequenceReader) read(decode func(rec []byte, typ string) error) error {
+	for {
+		rec, complete, err := s.next()
+		if err != nil {
+			return err
+		}
+		var probe struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(rec, &probe); err != nil {
+			// 没有结束符的记录被截断了, 跳过
+			if !complete {
+				continue
+			}
+			return fmt.Errorf("invalid geojson sequence record: %v", err)
+		}
+		return decode(rec, probe.Type)
+	}
+}
+
+// ReadFeature returns the next feature, the bare geometries being read as
+// features without properties. It returns io.EOF at the end of the input.
+func (s *SequenceReader) ReadFeature() (*Feature, error) {
+	var f *Feature
+	err := s.read(func(rec []byte, typ string) error {
+		switch typ {
+		case "Feature":
+			f = &Feature{}
+			return json.Unmarshal(rec, f)
+		case "FeatureCollection", "":
+			return fmt.Errorf("unexpected geojson %q in sequence", typ)
+		}
+		g, err := UnmarshalGeometry(rec)
+		if err != nil {
+			return err
+		}
+		f = NewFeatureFromGeometryData(g)
+		return nil
+	})
+	return f, err
+}
+
+// ReadGeometry returns the next geometry, the geometry of the features for
+// the feature records. It returns io.EOF at the end of the input.
+func (s *SequenceReader) ReadGeometry() (*GeometryData, error) {
+	var g *GeometryData
+	err := s.read(func(rec []byte, typ string) error {
+		switch typ {
+		case "Feature":
+			f := &Feature{}
+			if err := json.Unmarshal(rec, f); err != nil {
+				return err
+			}
+			g = &f.GeometryData
+			return nil
+		case "FeatureCollection", "":
+			return fmt.Errorf("unexpected geojson %q in sequence", typ)
+		}
+		var err error
+		g, err = UnmarshalGeometry(rec)
+		return err
+	})
+	return g, err
+}
+
+// SequenceWriter writes features and geometries as a GeoJSON sequence,
+// one write to the underlying writer per text.
+type SequenceWriter struct {
+	w       io.Writer
+	framing Framing
+	buf     []byte
+}
+
+func NewSequenceWriter(w io.Writer, framing Framing) *SequenceWriter {
+	return &SequenceWriter{w: w, framing: framing}
+}
+
+func (s *SequenceWriter) write(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	s.buf = s.buf[:0]
+	if s.framing == TextSequence {
+		s.buf = append(s.buf, recordSeparator)
+	}
+	s.buf = append(append(s.buf, b...), '\n')
+	_, err = s.w.Write(s.buf)
+	return err
+}
+
+func (s *SequenceWriter) WriteFeature(f *Feature) error {
+	if f == nil {
+		return errors.New("nil feature")
+	}
+	return s.write(f)
+}
+
+func (s *SequenceWriter) WriteGeometry(g *GeometryData) error {
+	if g == nil {
+		return errors.New("nil geometry")
+	}
+	return s.write(g)
+}
@@ -0,0 +1,101 @@
+package geom
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试 NDJSON 与 RFC 8142 序列的往返
+func TestSequenceRoundTrip(t *testing.T) {
+	for _, framing := range []Framing{NewlineDelimited, TextSequence} {
+		var buf bytes.Buffer
+		w := NewSequenceWriter(&buf, framing)
+		f := NewPointFeature([]float64{1, 2})
+		f.Properties["name"] = "a"
+		assert.NoError(t, w.WriteFeature(f))
+		assert.NoError(t, w.WriteGeometry(NewLineStringGeometryData([][]float64{{1, 2}, {3, 4}})))
+		assert.Error(t, w.WriteFeature(nil))
+		assert.Equal(t, 2, bytes.Count(buf.Bytes(), []byte{'\n'}))
+		if framing == TextSequence {
+			assert.Equal(t, byte(recordSeparator), buf.Bytes()[0])
+		}
+
+		r := NewSequenceReader(bytes.NewReader(buf.Bytes()), framing)
+		ret, err := r.ReadFeature()
+		assert.NoError(t, err)
+		assert.Equal(t, "a", ret.Properties["name"])
+		assert.Equal(t, []float64{1, 2}, ret.GeometryData.Point)
+		ret, err = r.ReadFeature()
+		assert.NoError(t, err)
+		assert.Equal(t, GeometryLineString, ret.GeometryData.Type)
+		assert.NotNil(t, ret.BoundingBox)
+		_, err = r.ReadFeature()
+		assert.Equal(t, io.EOF, err)
+
+		r = NewSequenceReader(bytes.NewReader(buf.Bytes()), framing)
+		g, err := r.ReadGeometry()
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{1, 2}, g.Point)
+		g, err = r.ReadGeometry()
+		assert.NoError(t, err)
+		assert.Equal(t, [][]float64{{1, 2}, {3, 4}}, g.LineString)
+		_, err = r.ReadGeometry()
+		assert.Equal(t, io.EOF, err)
+	}
+}
+
+// 测试空行与截断的记录
+func TestSequenceTolerance(t *testing.T) {
+	data := "\n{\"type\":\"Point\",\"coordinates\":[1,2]}\r\n\n  \n{\"type\":\"Point\",\"coordinates\":[3,4]}\n{\"type\":\"Point\",\"coor"
+	r := NewSequenceReader(strings.NewReader(data), NewlineDelimited)
+	var pts [][]float64
+	for {
+		g, err := r.ReadGeometry()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		pts = append(pts, g.Point)
+	}
+	assert.Equal(t, [][]float64{{1, 2}, {3, 4}}, pts)
+
+	// 序列中没有换行结尾的记录被截断, 跳过后继续读取
+	rs := string(rune(recordSeparator))
+	data = "junk" + rs + `{"type":"Point","coordinates":[1,2]}` + "\n" + rs + rs + "\n" + rs + `{"type":"Point","coord` + rs + `{"type":"Point","coordinates":[5,6]}`
+	r = NewSequenceReader(strings.NewReader(data), TextSequence)
+	pts = nil
+	for {
+		g, err := r.ReadGeometry()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		pts = append(pts, g.Point)
+	}
+	assert.Equal(t, [][]float64{{1, 2}, {5, 6}}, pts)
+
+	// 连续大量截断的记录逐个跳过
+	data = strings.Repeat(rs+`{"type":"Po`, 100000) + rs + `{"type":"Point","coordinates":[7,8]}` + "\n"
+	r = NewSequenceReader(strings.NewReader(data), TextSequence)
+	g, err := r.ReadGeometry()
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{7, 8}, g.Point)
+
+	// 完整但无效的记录报错, 之后的记录仍可读取
+	r = NewSequenceReader(strings.NewReader("{\"type\":\n[1,2]\n{\"type\":\"FeatureCollection\",\"features\":[]}\n{\"type\":\"Feature\",\"geometry\":null}\n"), NewlineDelimited)
+	_, err = r.ReadFeature()
+	assert.Error(t, err)
+	_, err = r.ReadFeature()
+	assert.Error(t, err)
+	_, err = r.ReadFeature()
+	assert.Error(t, err)
+	f, err := r.ReadFeature()
+	assert.NoError(t, err)
+	assert.Equal(t, GeometryType(""), f.GeometryData.Type)
+	_, err = r.ReadFeature()
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,103 @@
+package shp
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// codePages are the encodings of the .cpg files, keyed by their name
+// without prefix and separators.
+var codePages = map[string]encoding.Encoding{
+	"UTF8":     unicode.UTF8,
+	"65001":    unicode.UTF8,
+	"437":      charmap.CodePage437,
+	"850":      charmap.CodePage850,
+	"852":      charmap.CodePage852,
+	"866":      charmap.CodePage866,
+	"874":      charmap.Windows874,
+	"1250":     charmap.Windows1250,
+	"1251":     charmap.Windows1251,
+	"1252":     charmap.Windows1252,
+	"1253":     charmap.Windows1253,
+	"1254":     charmap.Windows1254,
+	"1255":     charmap.Windows1255,
+	"1256":     charmap.Windows1256,
+	"1257":     charmap.Windows1257,
+	"1258":     charmap.Windows1258,
+	"88591":    charmap.ISO8859_1,
+	"88592":    charmap.ISO8859_2,
+	"88593":    charmap.ISO8859_3,
+	"88594":    charmap.ISO8859_4,
+	"88595":    charmap.ISO8859_5,
+	"88596":    charmap.ISO8859_6,
+	"88597":    charmap.ISO8859_7,
+	"88598":    charmap.ISO8859_8,
+	"88599":    charmap.ISO8859_9,
+	"885915":   charmap.ISO8859_15,
+	"KOI8R":    charmap.KOI8R,
+	"932":      japanese.ShiftJIS,
+	"SJIS":     japanese.ShiftJIS,
+	"SHIFTJIS": japanese.ShiftJIS,
+	"EUCJP":    japanese.EUCJP,
+	"936":      simplifiedchinese.GBK,
+	"GBK":      simplifiedchinese.GBK,
+	"GB2312":   simplifiedchinese.GBK,
+	"GB18030":  simplifiedchinese.GB18030,
+	"949":      korean.EUCKR,
+	"EUCKR":    korean.EUCKR,
+	"950":      traditionalchinese.Big5,
+	"BIG5":     traditionalchinese.Big5,
+}
+
+// languageDrivers are the code pages of the language driver ids of the
+// DBF headers.
+var languageDrivers = map[byte]string{
+	0x01: "437",
+	0x02: "850",
+	0x03: "1252",
+	0x13: "932",
+	0x26: "866",
+	0x4d: "936",
+	0x4e: "949",
+	0x4f: "950",
+	0x57: "1252",
+	0x64: "852",
+	0x65: "866",
+	0x78: "950",
+	0x79: "949",
+	0x7a: "936",
+	0x7b: "932",
+	0xc8: "1250",
+	0xc9: "1251",
+	0xca: "1254",
+	0xcb: "1253",
+}
+
+// codePage returns the encoding of a .cpg name such as "UTF-8", "1252",
+// "CP936" or "ISO 8859-1", UTF-8 when name is empty.
+func codePage(name string) (encoding.Encoding, error) {
+	n := strings.ToUpper(strings.TrimSpace(name))
+	if n == "" {
+		return unicode.UTF8, nil
+	}
+	for _, prefix := range []string{"ANSI", "WINDOWS", "CP", "ISO", "OEM"} {
+		n = strings.TrimPrefix(n, prefix)
+	}
+	n = strings.NewReplacer("-", "", "_", "", " ", "").Replace(n)
+	if e, ok := codePages[n]; ok {
+		return e, nil
+	}
+	if e, err := htmlindex.Get(strings.TrimSpace(name)); err == nil {
+		return e, nil
+	}
+	return nil, fmt.Errorf("unknown shapefile code page %s", name)
+}
@@ -0,0 +1,408 @@
+package shp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flywave/go-geom"
+	"golang.org/x/text/encoding"
+)
+
+const (
+	dbfVersion    = 0x03
+	dbfHeaderSize = 32
+	dbfFieldSize  = 32
+	dbfMaxLength  = 254
+)
+
+// field is a column of a DBF table.
+type field struct {
+	name     string
+	typ      byte
+	length   int
+	decimals int
+}
+
+// table is the content of a DBF file, the deleted records being nil.
+type table struct {
+	fields  []field
+	records []map[string]interface{}
+}
+
+func decodeText(dec *encoding.Decoder, b []byte) string {
+	if s, err := dec.Bytes(b); err == nil {
+		b = s
+	}
+	return strings.TrimRight(string(b), " \x00")
+}
+
+// value reads a field of a record: numbers without decimals are int64,
+// the others float64, logicals bool and dates time.Time. Blank values are
+// nil.
+func (f field) value(b []byte, dec *encoding.Decoder) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+	switch f.typ {
+	case 'N', 'F':
+		s := strings.TrimSpace(string(b))
+		if strings.Trim(s, "*") == "" {
+			return nil
+		}
+		if f.typ == 'N' && f.decimals == 0 {
+			if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return n
+			}
+		}
+		if v, err := strconv.ParseFloat(s, 64); err == nil {
+			return v
+		}
+		return nil
+	case 'L':
+		switch b[0] {
+		case 'T', 't', 'Y', 'y':
+			return true
+		case 'F', 'f', 'N', 'n':
+			return false
+		}
+		return nil
+	case 'D':
+		s := strings.TrimSpace(string(b))
+		if s == "" {
+			return nil
+		}
+		if t, err := time.Parse("20060102", s); err == nil {
+			return t
+		}
+		return s
+	}
+	return decodeText(dec, b)
+}
+
+// decodeTable reads a DBF file whose text is encoded in code page cpg, or
+// in the one of its language driver when empty.
+func decodeTable(data []byte, cpg string) (*table, error) {
+	if len(data) < dbfHeaderSize {
+		return nil, errors.New("invalid dbf header")
+	}
+	count := int(binary.LittleEndian.Uint32(data[4:]))
+	headerLen := int(binary.LittleEndian.Uint16(data[8:]))
+	recordLen := int(binary.LittleEndian.Uint16(data[10:]))
+	if cpg == "" {
+		cpg = languageDrivers[data[29]]
+	}
+	enc, err := codePage(cpg)
+	if err != nil {
+		return nil, err
+	}
+	dec := enc.NewDecoder()
+	t := &table{}
+	size := 1
+	for off := dbfHeaderSize; off+dbfFieldSize <= len(data) && off < headerLen && data[off] != 0x0d; off += dbfFieldSize {
+		d := data[off : off+dbfFieldSize]
+		name := d[:11]
+		if i := bytes.IndexByte(name, 0); i >= 0 {
+			name = name[:i]
+		}
+		f := field{name: decodeText(dec, name), typ: d[11], length: int(d[16]), decimals: int(d[17])}
+		if f.typ == 'C' {
+			// 字符字段的长度可超过 255
+			f.length |= f.decimals << 8
+			f.decimals = 0
+		}
+		t.fields = append(t.fields, f)
+		size += f.length
+	}
+	if size > recordLen {
+		return nil, fmt.Errorf("dbf fields of %d bytes exceed the record length %d", size, recordLen)
+	}
+	for i := 0; i < count; i++ {
+		off := headerLen + i*recordLen
+		if off+recordLen > len(data) {
+			break
+		}
+		rec := data[off : off+recordLen]
+		if rec[0] == '*' {
+			t.records = append(t.records, nil)
+			continue
+		}
+		props := make(map[string]interface{}, len(t.fields))
+		pos := 1
+		for _, f := range t.fields {
+			props[f.name] = f.value(rec[pos:pos+f.length], dec)
+			pos += f.length
+		}
+		t.records = append(t.records, props)
+	}
+	return t, nil
+}
+
+// kind is the DBF type inferred from a property value, 'I' for the
+// integers and 0 for nil.
+func kind(v interface{}) byte {
+	switch v := v.(type) {
+	case nil:
+		return 0
+	case bool:
+		return 'L'
+	case time.Time:
+		return 'D'
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return 'I'
+	case float32:
+		return kind(float64(v))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return 0
+		}
+		if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+			return 'I'
+		}
+		return 'N'
+	}
+	return 'C'
+}
+
+// format returns the text of v in a field of kind k.
+func format(v interface{}, k byte, decimals int) string {
+	if k == 0 || kind(v) == 0 {
+		return ""
+	}
+	switch k {
+	case 'L':
+		if v.(bool) {
+			return "T"
+		}
+		return "F"
+	case 'D':
+		return v.(time.Time).Format("20060102")
+	case 'I', 'N':
+		switch n := v.(type) {
+		case float32:
+			return strconv.FormatFloat(float64(n), 'f', decimals, 64)
+		case float64:
+			return strconv.FormatFloat(n, 'f', decimals, 64)
+		}
+		return fmt.Sprint(v)
+	case 'G':
+		f, _ := strconv.ParseFloat(fmt.Sprint(v), 64)
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32:
+		return fmt.Sprint(v)
+	}
+	if b, err := json.Marshal(v); err == nil {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+// column is a field written from the property key.
+type column struct {
+	field
+	key  string
+	kind byte
+}
+
+// infer returns the column of the values of key: the logicals, dates and
+// numbers of a single kind keep their type, the others are text.
+func infer(key string, values []interface{}, enc *encoding.Encoder) column {
+	c := column{key: key}
+	for _, v := range values {
+		switch k := kind(v); {
+		case k == 0 || k == c.kind:
+		case c.kind == 0:
+			c.kind = k
+		case c.kind == 'I' && k == 'N' || c.kind == 'N' && k == 'I':
+			c.kind = 'N'
+		default:
+			c.kind = 'C'
+		}
+	}
+	switch c.kind {
+	case 'L':
+		c.typ, c.length = 'L', 1
+		return c
+	case 'D':
+		c.typ, c.length = 'D', 8
+		return c
+	case 'N':
+		for _, v := range values {
+			if kind(v) == 0 {
+				continue
+			}
+			s := format(v, 'N', -1)
+			if i := strings.IndexByte(s, '.'); i >= 0 && len(s)-i-1 > c.decimals {
+				c.decimals = len(s) - i - 1
+			}
+		}
+		if c.decimals > 15 {
+			c.decimals = 15
+		}
+	}
+	c.typ = 'N'
+	if c.kind == 'C' || c.kind == 0 {
+		c.typ = 'C'
+	}
+	length := 1
+	for _, v := range values {
+		if n := len(c.text(v, enc)); n > length {
+			length = n
+		}
+	}
+	c.length = length
+	if c.typ == 'N' && c.length > 20 {
+		// 过长的数值以指数形式写出
+		c.kind, c.length, c.decimals = 'G', 24, 15
+	}
+	if c.length > dbfMaxLength {
+		c.length = dbfMaxLength
+	}
+	return c
+}
+
+// text returns v encoded and truncated to the length of the column, or
+// its full length while the column is inferred.
+func (c column) text(v interface{}, enc *encoding.Encoder) []byte {
+	s := format(v, c.kind, c.decimals)
+	if c.typ != 'C' {
+		return []byte(s)
+	}
+	b, err := enc.Bytes([]byte(s))
+	if err != nil {
+		b = []byte(s)
+	}
+	if c.length > 0 && len(b) > c.length {
+		// 按字符截断, 不留下半个多字节字符
+		b = b[:0]
+		for _, r := range s {
+			rb, err := enc.String(string(r))
+			if err != nil || len(b)+len(rb) > c.length {
+				break
+			}
+			b = append(b, rb...)
+		}
+	}
+	return b
+}
+
+// names truncates the keys of the columns to the 10 bytes of the DBF field
+// names, the repeated ones being numbered.
+func names(columns []column, enc *encoding.Encoder) {
+	used := make(map[string]bool)
+	for i := range columns {
+		name, err := enc.String(columns[i].key)
+		if err != nil {
+			name = columns[i].key
+		}
+		base := name
+		for n := 1; ; n++ {
+			if len(name) > 10 {
+				name = name[:10]
+			}
+			if !used[strings.ToUpper(name)] {
+				break
+			}
+			suffix := "_" + strconv.Itoa(n)
+			name = base
+			if len(name)+len(suffix) > 10 {
+				name = name[:10-len(suffix)]
+			}
+			name += suffix
+		}
+		used[strings.ToUpper(name)] = true
+		columns[i].name = name
+	}
+}
+
+// encodeTable writes the properties of features as a DBF file, with the
+// text encoded in code page cpg. A table without properties holds the FID
+// of the features, as readers expect a field.
+func encodeTable(features []*geom.Feature, cpg string) ([]byte, error) {
+	e, err := codePage(cpg)
+	if err != nil {
+		return nil, err
+	}
+	enc := encoding.ReplaceUnsupported(e.NewEncoder())
+	values := make(map[string][]interface{})
+	for _, f := range features {
+		for k, v := range f.Properties {
+			values[k] = append(values[k], v)
+		}
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var columns []column
+	for _, k := range keys {
+		columns = append(columns, infer(k, values[k], enc))
+	}
+	fid := len(columns) == 0
+	if fid {
+		columns = []column{{field: field{typ: 'N', length: 11}, key: "FID", kind: 'I'}}
+	}
+	names(columns, enc)
+
+	recordLen := 1
+	for _, c := range columns {
+		recordLen += c.length
+	}
+	headerLen := dbfHeaderSize + dbfFieldSize*len(columns) + 1
+	if recordLen > math.MaxUint16 || headerLen > math.MaxUint16 {
+		return nil, errors.New("too many dbf fields")
+	}
+	buf := make([]byte, dbfHeaderSize, headerLen+recordLen*len(features)+1)
+	now := time.Now()
+	buf[0] = dbfVersion
+	buf[1], buf[2], buf[3] = byte(now.Year()-1900), byte(now.Month()), byte(now.Day())
+	binary.LittleEndian.PutUint32(buf[4:], uint32(len(features)))
+	binary.LittleEndian.PutUint16(buf[8:], uint16(headerLen))
+	binary.LittleEndian.PutUint16(buf[10:], uint16(recordLen))
+	for _, c := range columns {
+		d := make([]byte, dbfFieldSize)
+		copy(d, c.name)
+		d[11] = c.typ
+		d[16], d[17] = byte(c.length), byte(c.decimals)
+		buf = append(buf, d...)
+	}
+	buf = append(buf, 0x0d)
+	for i, f := range features {
+		buf = append(buf, ' ')
+		for _, c := range columns {
+			var v interface{}
+			if fid {
+				v = i
+			} else {
+				v = f.Properties[c.key]
+			}
+			b := c.text(v, enc)
+			pad := bytes.Repeat([]byte{' '}, c.length-len(b))
+			switch {
+			case c.typ == 'N':
+				buf = append(append(buf, pad...), b...)
+			case c.typ == 'L' && len(b) == 0:
+				buf = append(buf, '?')
+			default:
+				buf = append(append(buf, b...), pad...)
+			}
+		}
+	}
+	return append(buf, 0x1a), nil
+}
@@ -0,0 +1,239 @@
+package shp
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// wkt is a node of a well known text reference system, its arguments
+// being quoted text, numbers or nodes.
+type wkt struct {
+	keyword string
+	args    []interface{}
+}
+
+// parseWKT reads the node at the start of s and returns the rest of s.
+func parseWKT(s string) (*wkt, string, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexAny(s, "[(")
+	if i <= 0 {
+		return nil, s, fmt.Errorf("invalid prj near %q", s)
+	}
+	n := &wkt{keyword: strings.ToUpper(strings.TrimSpace(s[:i]))}
+	s = s[i+1:]
+	for {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return nil, s, fmt.Errorf("unterminated prj node %s", n.keyword)
+		}
+		switch c := s[0]; {
+		case c == ']' || c == ')':
+			return n, s[1:], nil
+		case c == ',':
+			s = s[1:]
+		case c == '"':
+			j := strings.IndexByte(s[1:], '"')
+			if j < 0 {
+				return nil, s, fmt.Errorf("unterminated prj string in %s", n.keyword)
+			}
+			n.args = append(n.args, s[1:j+1])
+			s = s[j+2:]
+		default:
+			j := strings.IndexAny(s, ",[]()")
+			if j < 0 {
+				return nil, s, fmt.Errorf("unterminated prj node %s", n.keyword)
+			}
+			if s[j] == '[' || s[j] == '(' {
+				child, rest, err := parseWKT(s)
+				if err != nil {
+					return nil, rest, err
+				}
+				n.args = append(n.args, child)
+				s = rest
+				continue
+			}
+			n.args = append(n.args, strings.TrimSpace(s[:j]))
+			s = s[j:]
+		}
+	}
+}
+
+func (n *wkt) name() string {
+	if len(n.args) > 0 {
+		if s, ok := n.args[0].(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+func (n *wkt) child(keyword string) *wkt {
+	for _, a := range n.args {
+		if c, ok := a.(*wkt); ok && c.keyword == keyword {
+			return c
+		}
+	}
+	return nil
+}
+
+// authority returns the EPSG code of the AUTHORITY or ID of n.
+func (n *wkt) authority() int {
+	for _, keyword := range []string{"AUTHORITY", "ID"} {
+		if a := n.child(keyword); a != nil && len(a.args) > 1 && strings.EqualFold(a.name(), "EPSG") {
+			if code, err := strconv.Atoi(fmt.Sprint(a.args[1])); err == nil {
+				return code
+			}
+		}
+	}
+	return 0
+}
+
+var separators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// normalize lowers name and joins its words with underscores, so that the
+// Esri and EPSG names compare alike.
+func normalize(name string) string {
+	return strings.Trim(separators.ReplaceAllString(strings.ToLower(name), "_"), "_")
+}
+
+// srids are the EPSG codes of the normalized names of the reference
+// systems and datums.
+var srids = map[string]int{
+	"gcs_wgs_1984": 4326,
+	"wgs_84":       4326,
+	"wgs_1984":     4326,
+	"d_wgs_1984":   4326,
+	"gcs_china_geodetic_coordinate_system_2000": 4490,
+	"china_geodetic_coordinate_system_2000":     4490,
+	"cgcs2000":                                  4490,
+	"d_china_2000":                              4490,
+	"china_2000":                                4490,
+	"wgs_1984_web_mercator_auxiliary_sphere":    3857,
+	"wgs_1984_web_mercator":                     3857,
+	"wgs_84_pseudo_mercator":                    3857,
+	"popular_visualisation_crs_mercator":        3857,
+	"wgs_1984_world_mercator":                   3395,
+	"wgs_84_world_mercator":                     3395,
+}
+
+// zones are the patterns of the names of the zoned projections, with the
+// EPSG code of a zone.
+var zones = []struct {
+	re   *regexp.Regexp
+	srid func(zone int, north bool) int
+}{
+	{regexp.MustCompile(`^wgs_(?:19)?84_utm_zone_(\d+)([ns])$`), func(zone int, north bool) int {
+		if zone < 1 || zone > 60 {
+			return 0
+		}
+		if north {
+			return 32600 + zone
+		}
+		return 32700 + zone
+	}},
+	{regexp.MustCompile(`^cgcs2000_(?:gk|gauss_kruger)_zone_(\d+)()$`), func(zone int, _ bool) int {
+		return between(zone, 13, 23, 4491)
+	}},
+	{regexp.MustCompile(`^cgcs2000_(?:gk|gauss_kruger)_cm_(\d+)(e)$`), func(cm int, _ bool) int {
+		return between((cm+3)/6, 13, 23, 4502)
+	}},
+	{regexp.MustCompile(`^cgcs2000_3_degree_(?:gk|gauss_kruger)_zone_(\d+)()$`), func(zone int, _ bool) int {
+		return between(zone, 25, 45, 4513)
+	}},
+	{regexp.MustCompile(`^cgcs2000_3_degree_(?:gk|gauss_kruger)_cm_(\d+)(e)$`), func(cm int, _ bool) int {
+		return between(cm/3, 25, 45, 4534)
+	}},
+}
+
+func between(zone, first, last, srid int) int {
+	if zone < first || zone > last {
+		return 0
+	}
+	return srid + zone - first
+}
+
+func lookup(name string) int {
+	n := normalize(name)
+	if srid, ok := srids[n]; ok {
+		return srid
+	}
+	for _, z := range zones {
+		if m := z.re.FindStringSubmatch(n); m != nil {
+			zone, _ := strconv.Atoi(m[1])
+			return z.srid(zone, m[2] == "n")
+		}
+	}
+	return 0
+}
+
+// PrjToSrid returns the EPSG code of the reference system of a .prj file,
+// from its authority or from the names of the systems this package knows,
+// and 0 when it is not recognised.
+func PrjToSrid(prj string) int {
+	n, _, err := parseWKT(prj)
+	if err != nil {
+		return 0
+	}
+	if srid := n.authority(); srid != 0 {
+		return srid
+	}
+	if srid := lookup(n.name()); srid != 0 {
+		return srid
+	}
+	// 未知名称的地理坐标系按基准面识别
+	if n.keyword == "GEOGCS" || n.keyword == "GEOGCRS" {
+		if d := n.child("DATUM"); d != nil {
+			return lookup(d.name())
+		}
+	}
+	return 0
+}
+
+const (
+	prjWGS84    = `GEOGCS["GCS_WGS_1984",DATUM["D_WGS_1984",SPHEROID["WGS_1984",6378137.0,298.257223563]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]]`
+	prjCGCS2000 = `GEOGCS["GCS_China_Geodetic_Coordinate_System_2000",DATUM["D_China_2000",SPHEROID["CGCS2000",6378137.0,298.257222101]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]]`
+)
+
+func prjTransverseMercator(name, geogcs, projection string, falseEasting, falseNorthing, lon0, k float64) string {
+	return fmt.Sprintf(`PROJCS["%s",%s,PROJECTION["%s"],PARAMETER["False_Easting",%.1f],PARAMETER["False_Northing",%.1f],PARAMETER["Central_Meridian",%.1f],PARAMETER["Scale_Factor",%s],PARAMETER["Latitude_Of_Origin",0.0],UNIT["Meter",1.0]]`,
+		name, geogcs, projection, falseEasting, falseNorthing, lon0, strconv.FormatFloat(k, 'f', -1, 64))
+}
+
+// SridToPrj returns the Esri well known text of the .prj file of the
+// reference system srid, "" for the systems this package does not know.
+func SridToPrj(srid int) string {
+	switch {
+	case srid == 4326:
+		return prjWGS84
+	case srid == 4490:
+		return prjCGCS2000
+	case srid == 3857:
+		return `PROJCS["WGS_1984_Web_Mercator_Auxiliary_Sphere",` + prjWGS84 + `,PROJECTION["Mercator_Auxiliary_Sphere"],PARAMETER["False_Easting",0.0],PARAMETER["False_Northing",0.0],PARAMETER["Central_Meridian",0.0],PARAMETER["Standard_Parallel_1",0.0],PARAMETER["Auxiliary_Sphere_Type",0.0],UNIT["Meter",1.0]]`
+	case srid > 32600 && srid <= 32660 || srid > 32700 && srid <= 32760:
+		zone, hemisphere, northing := srid%100, "N", 0.0
+		if srid > 32700 {
+			hemisphere, northing = "S", 10000000
+		}
+		name := fmt.Sprintf("WGS_1984_UTM_Zone_%d%s", zone, hemisphere)
+		return prjTransverseMercator(name, prjWGS84, "Transverse_Mercator", 500000, northing, float64(6*zone-183), 0.9996)
+	case srid >= 4491 && srid <= 4501:
+		zone := srid - 4491 + 13
+		name := fmt.Sprintf("CGCS2000_GK_Zone_%d", zone)
+		return prjTransverseMercator(name, prjCGCS2000, "Gauss_Kruger", float64(zone)*1e6+500000, 0, float64(6*zone-3), 1)
+	case srid >= 4502 && srid <= 4512:
+		lon0 := 6*(srid-4502+13) - 3
+		name := fmt.Sprintf("CGCS2000_GK_CM_%dE", lon0)
+		return prjTransverseMercator(name, prjCGCS2000, "Gauss_Kruger", 500000, 0, float64(lon0), 1)
+	case srid >= 4513 && srid <= 4533:
+		zone := srid - 4513 + 25
+		name := fmt.Sprintf("CGCS2000_3_Degree_GK_Zone_%d", zone)
+		return prjTransverseMercator(name, prjCGCS2000, "Gauss_Kruger", float64(zone)*1e6+500000, 0, float64(3*zone), 1)
+	case srid >= 4534 && srid <= 4554:
+		lon0 := 3 * (srid - 4534 + 25)
+		name := fmt.Sprintf("CGCS2000_3_Degree_GK_CM_%dE", lon0)
+		return prjTransverseMercator(name, prjCGCS2000, "Gauss_Kruger", 500000, 0, float64(lon0), 1)
+	}
+	return ""
+}
@@ -0,0 +1,622 @@
+package shp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/flywave/go-geom"
+)
+
+const (
+	fileCode   = 9994
+	version    = 1000
+	headerSize = 100
+	// noData is written for the missing M, any M below -1e38 being no data.
+	noData = -1e39
+)
+
+// Part types of MultiPatch shapes.
+const (
+	partTriangleStrip = 0
+	partTriangleFan   = 1
+	partOuterRing     = 2
+	partInnerRing     = 3
+	partFirstRing     = 4
+	partRing          = 5
+)
+
+// reader reads the little endian values of a shape record.
+type reader struct {
+	buf []byte
+	err error
+}
+
+func (r *reader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.buf) {
+		r.err = errors.New("truncated shape record")
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *reader) int() int {
+	if b := r.next(4); b != nil {
+		return int(int32(binary.LittleEndian.Uint32(b)))
+	}
+	return 0
+}
+
+func (r *reader) float() float64 {
+	if b := r.next(8); b != nil {
+		return math.Float64frombits(binary.LittleEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (r *reader) ints(n int) []int {
+	ret := make([]int, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		ret = append(ret, r.int())
+	}
+	return ret
+}
+
+func (r *reader) floats(n int) []float64 {
+	ret := make([]float64, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		ret = append(ret, r.float())
+	}
+	return ret
+}
+
+// count reads a number of parts or points, which must fit in the record.
+func (r *reader) count(size int) int {
+	n := r.int()
+	if n < 0 || size > 0 && n > len(r.buf)/size {
+		r.err = errors.New("invalid shape record count")
+		return 0
+	}
+	return n
+}
+
+// positions reads n points followed, for the Z and M types, by their
+// ranges and values. The optional M of the Z types is kept when one of its
+// values is not no data.
+func (r *reader) positions(n int, typ ShapeType) ([][]float64, geom.Layout) {
+	xy := r.floats(2 * n)
+	var z, m []float64
+	if typ.HasZ() {
+		r.floats(2)
+		z = r.floats(n)
+	}
+	if typ.HasM() && (!typ.HasZ() || len(r.buf) >= 16+8*n) {
+		r.floats(2)
+		m = r.floats(n)
+		measured := false
+		for _, v := range m {
+			measured = measured || v > -1e38
+		}
+		if !measured && typ.HasZ() {
+			m = nil
+		}
+	}
+	layout := geom.XY
+	switch {
+	case z != nil && m != nil:
+		layout = geom.XYZM
+	case m != nil:
+		layout = geom.XYM
+	case z != nil:
+		layout = geom.XYZ
+	}
+	ret := make([][]float64, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		p := []float64{xy[2*i], xy[2*i+1]}
+		if z != nil {
+			p = append(p, z[i])
+		}
+		if m != nil {
+			p = append(p, m[i])
+		}
+		ret = append(ret, p)
+	}
+	return ret, layout
+}
+
+// split cuts positions at the start indexes of parts.
+func split(positions [][]float64, parts []int) ([][][]float64, error) {
+	ret := make([][][]float64, len(parts))
+	for i, start := range parts {
+		end := len(positions)
+		if i+1 < len(parts) {
+			end = parts[i+1]
+		}
+		if start < 0 || start > end || end > len(positions) {
+			return nil, errors.New("invalid shape part index")
+		}
+		ret[i] = positions[start:end]
+	}
+	return ret, nil
+}
+
+// signedArea returns twice the area of ring, negative when it is
+// clockwise.
+func signedArea(ring [][]float64) float64 {
+	var a float64
+	for i := range ring {
+		p, q := ring[i], ring[(i+1)%len(ring)]
+		a += p[0]*q[1] - q[0]*p[1]
+	}
+	return a
+}
+
+func contains(ring [][]float64, p []float64) bool {
+	in := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a[1] > p[1]) != (b[1] > p[1]) && p[0] < (b[0]-a[0])*(p[1]-a[1])/(b[1]-a[1])+a[0] {
+			in = !in
+		}
+	}
+	return in
+}
+
+// polygons groups rings in polygons: the clockwise rings are exteriors and
+// the others holes of the smallest exterior containing them, so that the
+// holes of nested islands are not given to the outer shells.
+func polygons(rings [][][]float64) [][][][]float64 {
+	var ret [][][][]float64
+	var holes [][][]float64
+	for _, r := range rings {
+		if len(r) == 0 {
+			continue
+		}
+		if signedArea(r) < 0 {
+			ret = append(ret, [][][]float64{r})
+		} else {
+			holes = append(holes, r)
+		}
+	}
+	areas := make([]float64, len(ret))
+	for i, p := range ret {
+		areas[i] = -signedArea(p[0])
+	}
+	for _, h := range holes {
+		found := -1
+		for i, p := range ret[:len(areas)] {
+			if (found < 0 || areas[i] < areas[found]) && contains(p[0], h[0]) {
+				found = i
+			}
+		}
+		if found >= 0 {
+			ret[found] = append(ret[found], h)
+		} else {
+			// 方向错误的外环
+			ret = append(ret, [][][]float64{h})
+		}
+	}
+	return ret
+}
+
+// patches converts the parts of a MultiPatch to polygons, a triangle of
+// the strips and fans being each a polygon.
+func patches(parts [][][]float64, types []int) [][][][]float64 {
+	var ret [][][][]float64
+	triangle := func(a, b, c []float64) {
+		ret = append(ret, [][][]float64{{a, b, c, a}})
+	}
+	group := false
+	for i, part := range parts {
+		switch types[i] {
+		case partTriangleStrip:
+			for j := 2; j < len(part); j++ {
+				triangle(part[j-2], part[j-1], part[j])
+			}
+		case partTriangleFan:
+			for j := 2; j < len(part); j++ {
+				triangle(part[0], part[j-1], part[j])
+			}
+		case partInnerRing:
+			if len(ret) > 0 {
+				ret[len(ret)-1] = append(ret[len(ret)-1], part)
+				continue
+			}
+			ret = append(ret, [][][]float64{part})
+		case partRing:
+			// FirstRing 之后的 Ring 为其洞
+			if group && len(ret) > 0 {
+				ret[len(ret)-1] = append(ret[len(ret)-1], part)
+				continue
+			}
+			ret = append(ret, [][][]float64{part})
+		default:
+			ret = append(ret, [][][]float64{part})
+		}
+		group = types[i] == partFirstRing || types[i] == partRing && group
+	}
+	return ret
+}
+
+// decodeShape reads the content of a record, nil for the null shapes.
+func decodeShape(content []byte) (*geom.GeometryData, error) {
+	r := &reader{buf: content}
+	typ := ShapeType(r.int())
+	var g *geom.GeometryData
+	var layout geom.Layout
+	switch typ {
+	case NullShape:
+		return nil, r.err
+	case Point, PointZ, PointM:
+		p := r.floats(2)
+		layout = geom.XY
+		if typ.HasZ() {
+			p = append(p, r.float())
+			layout = geom.XYZ
+		}
+		// PointZ 的 M 可选
+		if typ == PointM || typ == PointZ && len(r.buf) >= 8 {
+			if m := r.float(); typ == PointM || m > -1e38 {
+				p = append(p, m)
+				if layout = geom.XYM; typ == PointZ {
+					layout = geom.XYZM
+				}
+			}
+		}
+		g = geom.NewPointGeometryData(p)
+	case MultiPoint, MultiPointZ, MultiPointM:
+		r.floats(4)
+		var ps [][]float64
+		ps, layout = r.positions(r.count(16), typ)
+		g = geom.NewMultiPointGeometryData(ps...)
+	case PolyLine, PolyLineZ, PolyLineM, Polygon, PolygonZ, PolygonM, MultiPatch:
+		r.floats(4)
+		numParts := r.count(4)
+		numPoints := r.count(16)
+		parts := r.ints(numParts)
+		var types []int
+		if typ == MultiPatch {
+			types = r.ints(numParts)
+		}
+		var ps [][]float64
+		ps, layout = r.positions(numPoints, typ)
+		if r.err != nil {
+			return nil, r.err
+		}
+		paths, err := split(ps, parts)
+		if err != nil {
+			return nil, err
+		}
+		switch typ.base() {
+		case PolyLine:
+			if len(paths) == 1 {
+				g = geom.NewLineStringGeometryData(paths[0])
+			} else {
+				g = geom.NewMultiLineStringGeometryData(paths...)
+			}
+		default:
+			var polys [][][][]float64
+			if typ == MultiPatch {
+				polys = patches(paths, types)
+			} else {
+				polys = polygons(paths)
+			}
+			if len(polys) == 1 {
+				g = geom.NewPolygonGeometryData(polys[0])
+			} else {
+				g = geom.NewMultiPolygonGeometryData(polys...)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown shape type %d", typ)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if layout.HasM() {
+		g.Layout = layout
+	}
+	return g, nil
+}
+
+// decodeShapes reads the records of a main file.
+func decodeShapes(data []byte) ([]*geom.GeometryData, error) {
+	if len(data) < headerSize || binary.BigEndian.Uint32(data) != fileCode {
+		return nil, errors.New("not a shapefile")
+	}
+	var ret []*geom.GeometryData
+	for off := headerSize; off+8 <= len(data); {
+		n := int(binary.BigEndian.Uint32(data[off+4:])) * 2
+		off += 8
+		if n < 0 || off+n > len(data) {
+			return nil, errors.New("truncated shapefile")
+		}
+		g, err := decodeShape(data[off : off+n])
+		if err != nil {
+			return nil, fmt.Errorf("shape %d: %v", len(ret)+1, err)
+		}
+		ret = append(ret, g)
+		off += n
+	}
+	return ret, nil
+}
+
+// writer writes the little endian values of a shape record.
+type writer struct {
+	buf []byte
+}
+
+func (w *writer) int(v int) {
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, uint32(int32(v)))
+}
+
+func (w *writer) float(v float64) {
+	w.buf = binary.LittleEndian.AppendUint64(w.buf, math.Float64bits(v))
+}
+
+// extent is the range of the values of an axis.
+type extent struct {
+	min, max float64
+}
+
+func newExtent() extent {
+	return extent{math.Inf(1), math.Inf(-1)}
+}
+
+func (e *extent) add(v float64) {
+	e.min, e.max = math.Min(e.min, v), math.Max(e.max, v)
+}
+
+func (e *extent) merge(o extent) {
+	if o.min > o.max {
+		return
+	}
+	e.add(o.min)
+	e.add(o.max)
+}
+
+// values returns the range, 0 when there is no value.
+func (e extent) values() (float64, float64) {
+	if e.min > e.max {
+		return 0, 0
+	}
+	return e.min, e.max
+}
+
+// shape is a geometry reduced to the parts and positions of a record.
+type shape struct {
+	parts  [][][]float64
+	layout geom.Layout
+	x, y   extent
+	z, m   extent
+}
+
+func (s *shape) zm(p []float64) (float64, float64) {
+	z, m := 0.0, noData
+	if i := s.layout.ZIndex(); i >= 0 && i < len(p) {
+		z = p[i]
+	}
+	if i := s.layout.MIndex(); i >= 0 && i < len(p) {
+		m = p[i]
+	}
+	return z, m
+}
+
+func newShape(parts [][][]float64, layout geom.Layout) *shape {
+	s := &shape{parts: parts, layout: layout, x: newExtent(), y: newExtent(), z: newExtent(), m: newExtent()}
+	for _, part := range parts {
+		for _, p := range part {
+			s.x.add(p[0])
+			s.y.add(p[1])
+			z, m := s.zm(p)
+			s.z.add(z)
+			if m > -1e38 {
+				s.m.add(m)
+			}
+		}
+	}
+	return s
+}
+
+// orient closes the rings of polygon and turns its exterior clockwise and
+// its holes counterclockwise.
+func orient(polygon [][][]float64) [][][]float64 {
+	var ret [][][]float64
+	for i, r := range polygon {
+		if len(r) < 3 {
+			continue
+		}
+		if first, last := r[0], r[len(r)-1]; first[0] != last[0] || first[1] != last[1] {
+			r = append(r[:len(r):len(r)], first)
+		}
+		if (i == 0) != (signedArea(r) < 0) {
+			rev := make([][]float64, len(r))
+			for j, p := range r {
+				rev[len(r)-1-j] = p
+			}
+			r = rev
+		}
+		ret = append(ret, r)
+	}
+	return ret
+}
+
+// parts returns the paths of the positions of g.
+func parts(g *geom.GeometryData) [][][]float64 {
+	switch g.Type {
+	case geom.GeometryPoint:
+		if len(g.Point) < 2 {
+			return nil
+		}
+		return [][][]float64{{g.Point}}
+	case geom.GeometryMultiPoint:
+		return [][][]float64{g.MultiPoint}
+	case geom.GeometryLineString:
+		return [][][]float64{g.LineString}
+	case geom.GeometryMultiLineString:
+		return g.MultiLineString
+	case geom.GeometryPolygon:
+		return orient(g.Polygon)
+	case geom.GeometryMultiPolygon:
+		var ret [][][]float64
+		for _, p := range g.MultiPolygon {
+			ret = append(ret, orient(p)...)
+		}
+		return ret
+	}
+	return nil
+}
+
+// shapeType returns the type of the shapes of features, from the kind and
+// the layout of their geometries.
+func shapeType(features []*geom.Feature) (ShapeType, error) {
+	typ := NullShape
+	var z, m bool
+	for _, f := range features {
+		g := &f.GeometryData
+		var t ShapeType
+		switch g.Type {
+		case "":
+			continue
+		case geom.GeometryPoint:
+			t = Point
+		case geom.GeometryMultiPoint:
+			t = MultiPoint
+		case geom.GeometryLineString, geom.GeometryMultiLineString:
+			t = PolyLine
+		case geom.GeometryPolygon, geom.GeometryMultiPolygon:
+			t = Polygon
+		default:
+			return NullShape, fmt.Errorf("unsupported shapefile geometry type %s", g.Type)
+		}
+		switch {
+		case typ == NullShape || typ == t:
+			typ = t
+		case typ == Point && t == MultiPoint || typ == MultiPoint && t == Point:
+			typ = MultiPoint
+		default:
+			return NullShape, fmt.Errorf("mixed geometry types %s and %s in shapefile", typ, t)
+		}
+		l := geom.LayoutFromGeometryData(g)
+		z, m = z || l.HasZ(), m || l.HasM()
+	}
+	switch {
+	case typ == NullShape:
+	case z:
+		typ += PointZ - Point
+	case m:
+		typ += PointM - Point
+	}
+	return typ, nil
+}
+
+func (s *shape) encode(typ ShapeType) []byte {
+	w := &writer{}
+	w.int(int(typ))
+	var ps [][]float64
+	for _, part := range s.parts {
+		ps = append(ps, part...)
+	}
+	if typ.base() == Point {
+		w.float(ps[0][0])
+		w.float(ps[0][1])
+		z, m := s.zm(ps[0])
+		if typ.HasZ() {
+			w.float(z)
+		}
+		if typ.HasM() {
+			w.float(m)
+		}
+		return w.buf
+	}
+	w.float(s.x.min)
+	w.float(s.y.min)
+	w.float(s.x.max)
+	w.float(s.y.max)
+	if typ.base() != MultiPoint {
+		w.int(len(s.parts))
+	}
+	w.int(len(ps))
+	if typ.base() != MultiPoint {
+		start := 0
+		for _, part := range s.parts {
+			w.int(start)
+			start += len(part)
+		}
+	}
+	for _, p := range ps {
+		w.float(p[0])
+		w.float(p[1])
+	}
+	if typ.HasZ() {
+		min, max := s.z.values()
+		w.float(min)
+		w.float(max)
+		for _, p := range ps {
+			z, _ := s.zm(p)
+			w.float(z)
+		}
+	}
+	// Z 类型的 M 可选, 没有时省略
+	if typ.HasM() && (!typ.HasZ() || s.layout.HasM()) {
+		min, max := s.m.values()
+		w.float(min)
+		w.float(max)
+		for _, p := range ps {
+			_, m := s.zm(p)
+			w.float(m)
+		}
+	}
+	return w.buf
+}
+
+func header(typ ShapeType, words int, x, y, z, m extent) []byte {
+	w := &writer{buf: make([]byte, 28, headerSize)}
+	binary.BigEndian.PutUint32(w.buf, fileCode)
+	binary.BigEndian.PutUint32(w.buf[24:], uint32(words))
+	w.int(version)
+	w.int(int(typ))
+	xmin, xmax := x.values()
+	ymin, ymax := y.values()
+	zmin, zmax := z.values()
+	mmin, mmax := m.values()
+	for _, v := range []float64{xmin, ymin, xmax, ymax, zmin, zmax, mmin, mmax} {
+		w.float(v)
+	}
+	return w.buf
+}
+
+// encodeShapes returns the main file and the index of features.
+func encodeShapes(features []*geom.Feature) ([]byte, []byte, error) {
+	typ, err := shapeType(features)
+	if err != nil {
+		return nil, nil, err
+	}
+	x, y, z, m := newExtent(), newExtent(), newExtent(), newExtent()
+	var records, index []byte
+	for i, f := range features {
+		content := []byte{0, 0, 0, 0}
+		g := &f.GeometryData
+		if ps := parts(g); len(ps) > 0 && len(ps[0]) > 0 {
+			s := newShape(ps, geom.LayoutFromGeometryData(g))
+			x.merge(s.x)
+			y.merge(s.y)
+			z.merge(s.z)
+			m.merge(s.m)
+			content = s.encode(typ)
+		}
+		index = binary.BigEndian.AppendUint32(index, uint32((headerSize+len(records))/2))
+		index = binary.BigEndian.AppendUint32(index, uint32(len(content)/2))
+		records = binary.BigEndian.AppendUint32(records, uint32(i+1))
+		records = binary.BigEndian.AppendUint32(records, uint32(len(content)/2))
+		records = append(records, content...)
+	}
+	main := append(header(typ, (headerSize+len(records))/2, x, y, z, m), records...)
+	return main, append(header(typ, (headerSize+len(index))/2, x, y, z, m), index...), nil
+}
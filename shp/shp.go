@@ -0,0 +1,289 @@
+package shp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/flywave/go-geom"
+)
+
+// ShapeType is the type of the shapes of a shapefile.
+type ShapeType int
+
+const (
+	NullShape   ShapeType = 0
+	Point       ShapeType = 1
+	PolyLine    ShapeType = 3
+	Polygon     ShapeType = 5
+	MultiPoint  ShapeType = 8
+	PointZ      ShapeType = 11
+	PolyLineZ   ShapeType = 13
+	PolygonZ    ShapeType = 15
+	MultiPointZ ShapeType = 18
+	PointM      ShapeType = 21
+	PolyLineM   ShapeType = 23
+	PolygonM    ShapeType = 25
+	MultiPointM ShapeType = 28
+	MultiPatch  ShapeType = 31
+)
+
+var shapeTypeNames = map[ShapeType]string{
+	NullShape:   "Null",
+	Point:       "Point",
+	PolyLine:    "PolyLine",
+	Polygon:     "Polygon",
+	MultiPoint:  "MultiPoint",
+	PointZ:      "PointZ",
+	PolyLineZ:   "PolyLineZ",
+	PolygonZ:    "PolygonZ",
+	MultiPointZ: "MultiPointZ",
+	PointM:      "PointM",
+	PolyLineM:   "PolyLineM",
+	PolygonM:    "PolygonM",
+	MultiPointM: "MultiPointM",
+	MultiPatch:  "MultiPatch",
+}
+
+func (t ShapeType) String() string {
+	if s, ok := shapeTypeNames[t]; ok {
+		return s
+	}
+	return "Unknown"
+}
+
+// HasZ reports whether the shapes of type t carry Z.
+func (t ShapeType) HasZ() bool {
+	return t >= PointZ && t <= MultiPointZ || t == MultiPatch
+}
+
+// HasM reports whether the shapes of type t may carry M, which is optional
+// for the Z types.
+func (t ShapeType) HasM() bool {
+	return t.HasZ() || t >= PointM && t <= MultiPointM
+}
+
+// base returns the type of t without Z and M.
+func (t ShapeType) base() ShapeType {
+	switch t {
+	case PointZ, PointM:
+		return Point
+	case PolyLineZ, PolyLineM:
+		return PolyLine
+	case PolygonZ, PolygonM, MultiPatch:
+		return Polygon
+	case MultiPointZ, MultiPointM:
+		return MultiPoint
+	}
+	return t
+}
+
+// DecodeOptions controls the reading of shapefiles.
+type DecodeOptions struct {
+	// Encoding is the code page of the DBF text used when there is no .cpg
+	// file, such as "UTF-8", "1252" or "GBK". Empty uses the language
+	// driver of the DBF header, falling back to UTF-8.
+	Encoding string
+	// EPSG is the reference system of the geometries when there is no .prj
+	// file, or when it is not recognised.
+	EPSG int
+}
+
+func DefaultDecodeOptions() *DecodeOptions {
+	return &DecodeOptions{}
+}
+
+// EncodeOptions controls the writing of shapefiles.
+type EncodeOptions struct {
+	// Encoding is the code page of the DBF text, written to the .cpg file.
+	Encoding string
+	// EPSG is the reference system written to the .prj file, 0 using the
+	// one of the geometries.
+	EPSG int
+}
+
+func DefaultEncodeOptions() *EncodeOptions {
+	return &EncodeOptions{Encoding: "UTF-8"}
+}
+
+// Decode reads the shapes of shp and the attributes of dbf, which may be
+// nil, as features. The DBF text is decoded from opt.Encoding.
+func Decode(shp, dbf io.Reader, opt *DecodeOptions) (*geom.FeatureCollection, error) {
+	if opt == nil {
+		opt = DefaultDecodeOptions()
+	}
+	data, err := ioutil.ReadAll(shp)
+	if err != nil {
+		return nil, err
+	}
+	shapes, err := decodeShapes(data)
+	if err != nil {
+		return nil, err
+	}
+	var table *table
+	if dbf != nil {
+		data, err := ioutil.ReadAll(dbf)
+		if err != nil {
+			return nil, err
+		}
+		if table, err = decodeTable(data, opt.Encoding); err != nil {
+			return nil, err
+		}
+	}
+	fc := geom.NewFeatureCollection()
+	for i, g := range shapes {
+		var f *geom.Feature
+		if g == nil {
+			f = &geom.Feature{Type: "Feature", Properties: make(map[string]interface{}), ExtData: make(map[string]interface{})}
+		} else {
+			g.EPSG = opt.EPSG
+			f = geom.NewFeatureFromGeometryData(g)
+		}
+		if table != nil && i < len(table.records) {
+			if table.records[i] == nil {
+				// 已删除的记录
+				continue
+			}
+			f.Properties = table.records[i]
+		}
+		fc.AddFeature(f)
+	}
+	return fc, nil
+}
+
+// sidecar returns the path of the file of filename with extension ext,
+// matching the case of the extension of filename.
+func sidecar(filename, ext string) string {
+	base := strings.TrimSuffix(filename, filepath.Ext(filename))
+	if e := filepath.Ext(filename); e != "" && e == strings.ToUpper(e) {
+		ext = strings.ToUpper(ext)
+	}
+	return base + ext
+}
+
+func readSidecar(filename, ext string) ([]byte, error) {
+	data, err := ioutil.ReadFile(sidecar(filename, ext))
+	if err == nil || !os.IsNotExist(err) {
+		return data, err
+	}
+	// 扩展名大小写与 .shp 不一致
+	if data, err := ioutil.ReadFile(sidecar(filename, strings.ToUpper(ext))); err == nil {
+		return data, nil
+	}
+	return ioutil.ReadFile(sidecar(filename, strings.ToLower(ext)))
+}
+
+// ReadFile reads the shapefile filename, with the attributes of its .dbf
+// file. The .cpg file sets the encoding of the attributes and the .prj file
+// the EPSG of the geometries, when it is recognised.
+func ReadFile(filename string, opt *DecodeOptions) (*geom.FeatureCollection, error) {
+	if opt == nil {
+		opt = DefaultDecodeOptions()
+	}
+	o := *opt
+	shp, err := os.Open(sidecar(filename, ".shp"))
+	if err != nil {
+		return nil, err
+	}
+	defer shp.Close()
+	var dbf io.Reader
+	if data, err := readSidecar(filename, ".dbf"); err == nil {
+		dbf = bytes.NewReader(data)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if data, err := readSidecar(filename, ".cpg"); err == nil {
+		if cpg := strings.TrimSpace(string(data)); cpg != "" {
+			o.Encoding = cpg
+		}
+	}
+	if data, err := readSidecar(filename, ".prj"); err == nil {
+		if srid := PrjToSrid(string(data)); srid != 0 {
+			o.EPSG = srid
+		}
+	}
+	return Decode(shp, dbf, &o)
+}
+
+// Encode writes fc as the main file shp, the index shx and the attribute
+// table dbf of a shapefile. The features must be of a single kind of
+// geometry, a Point being written as a MultiPoint among MultiPoints, or
+// none. The field types of the table are inferred from the properties.
+func Encode(shp, shx, dbf io.Writer, fc *geom.FeatureCollection, opt *EncodeOptions) error {
+	if opt == nil {
+		opt = DefaultEncodeOptions()
+	}
+	if fc == nil {
+		return errors.New("nil feature collection")
+	}
+	main, index, err := encodeShapes(fc.Features)
+	if err != nil {
+		return err
+	}
+	table, err := encodeTable(fc.Features, opt.Encoding)
+	if err != nil {
+		return err
+	}
+	if _, err := shp.Write(main); err != nil {
+		return err
+	}
+	if _, err := shx.Write(index); err != nil {
+		return err
+	}
+	_, err = dbf.Write(table)
+	return err
+}
+
+// epsg returns the reference system of the features, 0 when it is not set
+// or they differ.
+func epsg(fc *geom.FeatureCollection) int {
+	ret := 0
+	for _, f := range fc.Features {
+		if f.GeometryData.Type == "" {
+			continue
+		}
+		if ret == 0 {
+			ret = f.GeometryData.EPSG
+		} else if f.GeometryData.EPSG != ret {
+			return 0
+		}
+	}
+	return ret
+}
+
+// WriteFile writes fc as the shapefile filename with its .shx, .dbf and
+// .cpg files, and the .prj file when the reference system is known.
+func WriteFile(filename string, fc *geom.FeatureCollection, opt *EncodeOptions) error {
+	if opt == nil {
+		opt = DefaultEncodeOptions()
+	}
+	var shp, shx, dbf bytes.Buffer
+	if err := Encode(&shp, &shx, &dbf, fc, opt); err != nil {
+		return err
+	}
+	files := map[string][]byte{
+		".shp": shp.Bytes(),
+		".shx": shx.Bytes(),
+		".dbf": dbf.Bytes(),
+	}
+	if opt.Encoding != "" {
+		files[".cpg"] = []byte(opt.Encoding)
+	}
+	srid := opt.EPSG
+	if srid == 0 {
+		srid = epsg(fc)
+	}
+	if prj := SridToPrj(srid); prj != "" {
+		files[".prj"] = []byte(prj)
+	}
+	for ext, data := range files {
+		if err := ioutil.WriteFile(sidecar(filename, ext), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package shp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flywave/go-geom"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteReadFile(t *testing.T) {
+	fc := geom.NewFeatureCollection()
+	// 外环逆时针, 写出时转为顺时针
+	square := [][][]float64{
+		{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+		{{2, 2}, {2, 4}, {4, 4}, {4, 2}, {2, 2}},
+	}
+	f := geom.NewMultiPolygonFeature(square, [][][]float64{{{20, 20}, {30, 20}, {30, 30}, {20, 20}}})
+	f.GeometryData.EPSG = 4490
+	f.Properties["name"] = "北京市"
+	f.Properties["code"] = float64(110000)
+	f.Properties["area"] = 12.5
+	f.Properties["capital"] = true
+	f.Properties["founded"] = time.Date(1949, 10, 1, 0, 0, 0, 0, time.UTC)
+	fc.AddFeature(f)
+	g := geom.NewPolygonFeature([][][]float64{{{0, 0}, {0, -5}, {-5, -5}, {0, 0}}})
+	g.GeometryData.EPSG = 4490
+	g.Properties["name"] = "天津市"
+	g.Properties["code"] = 120000
+	g.Properties["area"] = 3
+	g.Properties["capital"] = nil
+	fc.AddFeature(g)
+
+	filename := filepath.Join(t.TempDir(), "city.shp")
+	assert.Nil(t, WriteFile(filename, fc, &EncodeOptions{Encoding: "GBK"}))
+	cpg, err := ioutil.ReadFile(filepath.Join(filepath.Dir(filename), "city.cpg"))
+	assert.Nil(t, err)
+	assert.Equal(t, "GBK", string(cpg))
+
+	ret, err := ReadFile(filename, nil)
+	assert.Nil(t, err)
+	assert.Len(t, ret.Features, 2)
+
+	// 测试几何与环的方向
+	r := ret.Features[0]
+	assert.Equal(t, geom.GeometryMultiPolygon, r.GeometryData.Type)
+	assert.Equal(t, 4490, r.GeometryData.EPSG)
+	assert.Len(t, r.GeometryData.MultiPolygon, 2)
+	assert.Len(t, r.GeometryData.MultiPolygon[0], 2)
+	assert.Less(t, signedArea(r.GeometryData.MultiPolygon[0][0]), 0.0)
+	assert.Greater(t, signedArea(r.GeometryData.MultiPolygon[0][1]), 0.0)
+	assert.Equal(t, geom.GeometryPolygon, ret.Features[1].GeometryData.Type)
+	assert.Equal(t, []float64{-5, -5}, ret.Features[1].BoundingBox[0][:2])
+
+	// 测试属性
+	assert.Equal(t, "北京市", r.Properties["name"])
+	assert.Equal(t, int64(110000), r.Properties["code"])
+	assert.Equal(t, 12.5, r.Properties["area"])
+	assert.Equal(t, true, r.Properties["capital"])
+	assert.Equal(t, time.Date(1949, 10, 1, 0, 0, 0, 0, time.UTC), r.Properties["founded"])
+	p := ret.Features[1].Properties
+	assert.Equal(t, "天津市", p["name"])
+	assert.Equal(t, 3.0, p["area"])
+	assert.Nil(t, p["capital"])
+	assert.Nil(t, p["founded"])
+
+	// 测试没有 .cpg 时按指定编码读取
+	var shp, shx, dbf bytes.Buffer
+	assert.Nil(t, Encode(&shp, &shx, &dbf, fc, &EncodeOptions{Encoding: "936"}))
+	ret, err = Decode(&shp, &dbf, &DecodeOptions{Encoding: "CP936", EPSG: 4490})
+	assert.Nil(t, err)
+	assert.Equal(t, "天津市", ret.Features[1].Properties["name"])
+	assert.Equal(t, 4490, ret.Features[1].GeometryData.EPSG)
+	assert.Equal(t, 100+2*8, shx.Len())
+}
+
+// 测试嵌套的岛与洞
+func TestNestedRings(t *testing.T) {
+	square := func(min, max float64) [][]float64 {
+		return [][]float64{{min, min}, {max, min}, {max, max}, {min, max}, {min, min}}
+	}
+	fc := geom.NewFeatureCollection()
+	fc.AddFeature(geom.NewMultiPolygonFeature(
+		[][][]float64{square(0, 100), square(10, 90)},
+		[][][]float64{square(20, 80), square(30, 70)},
+	))
+	var shp, shx, dbf bytes.Buffer
+	assert.Nil(t, Encode(&shp, &shx, &dbf, fc, nil))
+	ret, err := Decode(&shp, &dbf, nil)
+	assert.Nil(t, err)
+	mp := ret.Features[0].GeometryData.MultiPolygon
+	assert.Len(t, mp, 2)
+	assert.Len(t, mp[0], 2)
+	assert.Len(t, mp[1], 2)
+	assert.Equal(t, []float64{10, 10}, mp[0][1][0])
+	assert.Equal(t, []float64{20, 20}, mp[1][0][0])
+	assert.Equal(t, []float64{30, 30}, mp[1][1][0])
+}
+
+func TestShapeTypes(t *testing.T) {
+	roundTrip := func(fc *geom.FeatureCollection) (ShapeType, *geom.FeatureCollection) {
+		var shp, shx, dbf bytes.Buffer
+		assert.Nil(t, Encode(&shp, &shx, &dbf, fc, nil))
+		typ := ShapeType(binary.LittleEndian.Uint32(shp.Bytes()[32:]))
+		ret, err := Decode(bytes.NewReader(shp.Bytes()), bytes.NewReader(dbf.Bytes()), nil)
+		assert.Nil(t, err)
+		return typ, ret
+	}
+
+	// 测试点与多点合并为 MultiPoint
+	fc := geom.NewFeatureCollection()
+	fc.AddFeature(geom.NewPointFeature([]float64{1, 2}))
+	fc.AddFeature(geom.NewMultiPointFeature([]float64{3, 4}, []float64{5, 6}))
+	fc.AddFeature(&geom.Feature{Type: "Feature"})
+	typ, ret := roundTrip(fc)
+	assert.Equal(t, MultiPoint, typ)
+	assert.Equal(t, [][]float64{{1, 2}}, ret.Features[0].GeometryData.MultiPoint)
+	assert.Equal(t, "", string(ret.Features[2].GeometryData.Type))
+	assert.Equal(t, int64(2), ret.Features[2].Properties["FID"])
+
+	// 测试 Z 与 M
+	line := geom.NewLineStringGeometryData([][]float64{{0, 0, 1, 10}, {1, 1, 2, 20}})
+	line.Layout = geom.XYZM
+	fc = geom.NewFeatureCollection()
+	fc.AddFeature(geom.NewFeatureFromGeometryData(line))
+	fc.AddFeature(geom.NewMultiLineStringFeature([][]float64{{0, 0, 5}, {1, 0, 6}}, [][]float64{{2, 2, 7}, {3, 3, 8}}))
+	typ, ret = roundTrip(fc)
+	assert.Equal(t, PolyLineZ, typ)
+	assert.Equal(t, geom.XYZM, ret.Features[0].GeometryData.Layout)
+	assert.Equal(t, [][]float64{{0, 0, 1, 10}, {1, 1, 2, 20}}, ret.Features[0].GeometryData.LineString)
+	assert.Equal(t, geom.GeometryMultiLineString, ret.Features[1].GeometryData.Type)
+	assert.Equal(t, 7.0, ret.Features[1].GeometryData.MultiLineString[1][0][2])
+	assert.Equal(t, geom.NoLayout, ret.Features[1].GeometryData.Layout)
+
+	point := geom.NewPointGeometryData([]float64{1, 2, 3})
+	point.Layout = geom.XYM
+	fc = geom.NewFeatureCollection()
+	fc.AddFeature(geom.NewFeatureFromGeometryData(point))
+	typ, ret = roundTrip(fc)
+	assert.Equal(t, PointM, typ)
+	assert.Equal(t, geom.XYM, ret.Features[0].GeometryData.Layout)
+	assert.Equal(t, []float64{1, 2, 3}, ret.Features[0].GeometryData.Point)
+
+	fc = geom.NewFeatureCollection()
+	fc.AddFeature(geom.NewPointFeature([]float64{1, 2}))
+	fc.AddFeature(geom.NewLineStringFeature([][]float64{{1, 2}, {3, 4}}))
+	var shp, shx, dbf bytes.Buffer
+	assert.NotNil(t, Encode(&shp, &shx, &dbf, fc, nil))
+}
+
+func TestMultiPatch(t *testing.T) {
+	w := &writer{}
+	w.int(int(MultiPatch))
+	for i := 0; i < 4; i++ {
+		w.float(0)
+	}
+	// 一个三角带与带洞的环
+	pts := [][3]float64{
+		{0, 0, 0}, {1, 0, 0}, {0, 1, 1}, {1, 1, 1},
+		{0, 0, 5}, {0, 10, 5}, {10, 10, 5}, {10, 0, 5}, {0, 0, 5},
+		{2, 2, 5}, {4, 2, 5}, {4, 4, 5}, {2, 2, 5},
+	}
+	w.int(3)
+	w.int(len(pts))
+	for _, i := range []int{0, 4, 9} {
+		w.int(i)
+	}
+	for _, typ := range []int{partTriangleStrip, partFirstRing, partRing} {
+		w.int(typ)
+	}
+	for _, p := range pts {
+		w.float(p[0])
+		w.float(p[1])
+	}
+	w.float(0)
+	w.float(5)
+	for _, p := range pts {
+		w.float(p[2])
+	}
+	g, err := decodeShape(w.buf)
+	assert.Nil(t, err)
+	assert.Equal(t, geom.GeometryMultiPolygon, g.Type)
+	assert.Len(t, g.MultiPolygon, 3)
+	assert.Equal(t, [][]float64{{1, 0, 0}, {0, 1, 1}, {1, 1, 1}, {1, 0, 0}}, g.MultiPolygon[1][0])
+	assert.Len(t, g.MultiPolygon[2], 2)
+	assert.Equal(t, geom.NoLayout, g.Layout)
+
+	_, err = decodeShape(w.buf[:40])
+	assert.NotNil(t, err)
+}
+
+func TestPrjToSrid(t *testing.T) {
+	assert.Equal(t, 4326, PrjToSrid(SridToPrj(4326)))
+	assert.Equal(t, 3857, PrjToSrid(SridToPrj(3857)))
+	for _, srid := range []int{32650, 32733, 4491, 4507, 4527, 4548} {
+		assert.Equal(t, srid, PrjToSrid(SridToPrj(srid)))
+	}
+	assert.Equal(t, 2056, PrjToSrid(`PROJCS["CH1903+ / LV95",GEOGCS["CH1903+",DATUM["CH1903+",SPHEROID["Bessel 1841",6377397.155,299.1528128]],AUTHORITY["EPSG","4150"]],PROJECTION["Hotine_Oblique_Mercator_Azimuth_Center"],UNIT["metre",1],AUTHORITY["EPSG","2056"]]`))
+	assert.Equal(t, 4326, PrjToSrid(`GEOGCS["unnamed",DATUM["D_WGS_1984",SPHEROID["WGS_1984",6378137,298.257223563]],PRIMEM["Greenwich",0],UNIT["Degree",0.017453292519943295]]`))
+	assert.Equal(t, 32650, PrjToSrid(`PROJCS["WGS 84 / UTM zone 50N",GEOGCS["WGS 84"],PROJECTION["Transverse_Mercator"]]`))
+	assert.Equal(t, 4549, PrjToSrid(`PROJCS["CGCS2000 / 3-degree Gauss-Kruger CM 120E",GEOGCS["China Geodetic Coordinate System 2000"]]`))
+	assert.Equal(t, 0, PrjToSrid(`PROJCS["Lambert",GEOGCS["unknown",DATUM["unknown"]]]`))
+	assert.Equal(t, 0, PrjToSrid("GEOGCS["))
+	assert.Equal(t, "", SridToPrj(2056))
+}
+
+func TestCodePage(t *testing.T) {
+	for _, name := range []string{"UTF-8", "65001", "1252", "ANSI 1252", "CP936", "GBK", "ISO 8859-1", "8859_1", "windows-1251", "Big5", "shift_jis"} {
+		_, err := codePage(name)
+		assert.Nil(t, err, name)
+	}
+	_, err := codePage("klingon")
+	assert.NotNil(t, err)
+
+	// 测试数值字段的推断
+	c := infer("v", []interface{}{1, 2.25, nil, float32(3)}, nil)
+	assert.Equal(t, byte('N'), c.typ)
+	assert.Equal(t, 2, c.decimals)
+	assert.Equal(t, 4, c.length)
+	c = infer("v", []interface{}{1e300}, nil)
+	assert.Equal(t, byte('N'), c.typ)
+	assert.Equal(t, 1e300, c.field.value([]byte(string(c.text(1e300, nil))), nil))
+	assert.False(t, math.IsNaN(c.field.value([]byte(" 1.5"), nil).(float64)))
+}
@@ -0,0 +1,166 @@
+// Package simplify reduces the number of vertices of geometries within a
+// distance tolerance.
+//
+// The remaining vertices are kept untouched, so Z and any other ordinate
+// survive the simplification. Rings never drop below four points: a hole
+// that would is removed, as is a polygon whose shell would.
+package simplify
+
+import (
+	"math"
+
+	"github.com/flywave/go-geom"
+)
+
+// pathFunc simplifies a single line or ring.
+type pathFunc func(path [][]float64, ring bool) [][]float64
+
+// DouglasPeucker simplifies g with the Ramer-Douglas-Peucker algorithm,
+// dropping vertices closer than tolerance to the simplified line. The
+// result may self-intersect, see TopologyPreserving.
+func DouglasPeucker(g *geom.GeometryData, tolerance float64) *geom.GeometryData {
+	return simplify(g, func(path [][]float64, ring bool) [][]float64 {
+		return douglasPeucker(path, tolerance, planarDistance)
+	})
+}
+
+// DouglasPeucker3D is DouglasPeucker measuring the distances in 3D, so
+// vertices where only the elevation changes are kept. Positions without Z,
+// XYM ones included, are measured in the plane.
+func DouglasPeucker3D(g *geom.GeometryData, tolerance float64) *geom.GeometryData {
+	return douglasPeucker3D(g, tolerance, geom.NoLayout)
+}
+
+// douglasPeucker3D reads Z by the layout of g, defaulting to layout, the one
+// of its collection.
+func douglasPeucker3D(g *geom.GeometryData, tolerance float64, layout geom.Layout) *geom.GeometryData {
+	if g == nil {
+		return nil
+	}
+	if g.Layout != geom.NoLayout {
+		layout = g.Layout
+	}
+	if g.Type == geom.GeometryCollection {
+		res := *g
+		res.Geometries = make([]*geom.GeometryData, len(g.Geometries))
+		for i, c := range g.Geometries {
+			res.Geometries[i] = douglasPeucker3D(c, tolerance, layout)
+		}
+		return &res
+	}
+	z := layout.ZIndex()
+	if layout == geom.NoLayout {
+		z = geom.LayoutFromGeometryData(g).ZIndex()
+	}
+	dist := func(p, a, b []float64) float64 {
+		return spatialDistance(p, a, b, z)
+	}
+	return simplify(g, func(path [][]float64, ring bool) [][]float64 {
+		return douglasPeucker(path, tolerance, dist)
+	})
+}
+
+func simplify(g *geom.GeometryData, fn pathFunc) *geom.GeometryData {
+	if g == nil {
+		return nil
+	}
+	res := *g
+	switch g.Type {
+	case geom.GeometryLineString:
+		res.LineString = fn(g.LineString, false)
+	case geom.GeometryMultiLineString:
+		res.MultiLineString = make([][][]float64, len(g.MultiLineString))
+		for i, l := range g.MultiLineString {
+			res.MultiLineString[i] = fn(l, false)
+		}
+	case geom.GeometryPolygon:
+		res.Polygon = simplifyPolygon(g.Polygon, fn)
+	case geom.GeometryMultiPolygon:
+		res.MultiPolygon = make([][][][]float64, 0, len(g.MultiPolygon))
+		for _, p := range g.MultiPolygon {
+			if p = simplifyPolygon(p, fn); p != nil {
+				res.MultiPolygon = append(res.MultiPolygon, p)
+			}
+		}
+	case geom.GeometryCollection:
+		res.Geometries = make([]*geom.GeometryData, len(g.Geometries))
+		for i, c := range g.Geometries {
+			res.Geometries[i] = simplify(c, fn)
+		}
+	}
+	return &res
+}
+
+func simplifyPolygon(polygon [][][]float64, fn pathFunc) [][][]float64 {
+	ret := make([][][]float64, 0, len(polygon))
+	for i, r := range polygon {
+		s := fn(r, true)
+		if len(s) < 4 {
+			if i == 0 {
+				return nil
+			}
+			continue
+		}
+		ret = append(ret, s)
+	}
+	return ret
+}
+
+type distanceFunc func(p, a, b []float64) float64
+
+func douglasPeucker(path [][]float64, tolerance float64, dist distanceFunc) [][]float64 {
+	n := len(path)
+	if n < 3 {
+		return path
+	}
+	keep := make([]bool, n)
+	keep[0], keep[n-1] = true, true
+	stack := [][2]int{{0, n - 1}}
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		maxDist, index := -1.0, -1
+		for k := s[0] + 1; k < s[1]; k++ {
+			if d := dist(path[k], path[s[0]], path[s[1]]); d > maxDist {
+				maxDist, index = d, k
+			}
+		}
+		if index >= 0 && maxDist > tolerance {
+			keep[index] = true
+			stack = append(stack, [2]int{s[0], index}, [2]int{index, s[1]})
+		}
+	}
+	ret := make([][]float64, 0, n)
+	for i, p := range path {
+		if keep[i] {
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
+
+func planarDistance(p, a, b []float64) float64 {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	l2 := dx*dx + dy*dy
+	if l2 == 0 {
+		return math.Hypot(p[0]-a[0], p[1]-a[1])
+	}
+	t := math.Max(0, math.Min(1, ((p[0]-a[0])*dx+(p[1]-a[1])*dy)/l2))
+	return math.Hypot(p[0]-a[0]-t*dx, p[1]-a[1]-t*dy)
+}
+
+// spatialDistance measures in 3D with Z at index z, in the plane when z is
+// negative or past the positions.
+func spatialDistance(p, a, b []float64, z int) float64 {
+	if z < 0 || len(p) <= z || len(a) <= z || len(b) <= z {
+		return planarDistance(p, a, b)
+	}
+	dx, dy, dz := b[0]-a[0], b[1]-a[1], b[z]-a[z]
+	l2 := dx*dx + dy*dy + dz*dz
+	var t float64
+	if l2 > 0 {
+		t = math.Max(0, math.Min(1, ((p[0]-a[0])*dx+(p[1]-a[1])*dy+(p[z]-a[z])*dz)/l2))
+	}
+	ex, ey, ez := p[0]-a[0]-t*dx, p[1]-a[1]-t*dy, p[z]-a[z]-t*dz
+	return math.Sqrt(ex*ex + ey*ey + ez*ez)
+}
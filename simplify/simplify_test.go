@@ -0,0 +1,122 @@
+package simplify
+
+import (
+	"testing"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/relate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDouglasPeucker(t *testing.T) {
+	line := geom.NewLineStringGeometryData([][]float64{{0, 0, 1}, {1, 0.1, 2}, {2, -0.1, 3}, {3, 5, 4}, {4, 6, 5}, {5, 7.1, 6}, {6, 8, 7}})
+	ret := DouglasPeucker(line, 0.5)
+	assert.Equal(t, [][]float64{{0, 0, 1}, {2, -0.1, 3}, {3, 5, 4}, {6, 8, 7}}, ret.LineString)
+	// 原始数据不被修改
+	assert.Len(t, line.LineString, 7)
+
+	assert.Equal(t, line.LineString, DouglasPeucker(line, 0).LineString)
+	assert.Equal(t, [][]float64{{0, 0, 1}, {6, 8, 7}}, DouglasPeucker(line, 10).LineString)
+
+	// 小于容差的洞被移除
+	shell := [][]float64{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}
+	hole := [][]float64{{5, 5}, {5.1, 5}, {5.1, 5.1}, {5, 5.1}, {5, 5}}
+	poly := DouglasPeucker(geom.NewPolygonGeometryData([][][]float64{shell, hole}), 1)
+	assert.Equal(t, [][][]float64{shell}, poly.Polygon)
+
+	mp := DouglasPeucker(geom.NewMultiPolygonGeometryData([][][]float64{shell}, [][][]float64{hole}), 1)
+	assert.Equal(t, [][][][]float64{{shell}}, mp.MultiPolygon)
+
+	pt := geom.NewPointGeometryData([]float64{1, 2})
+	col := DouglasPeucker(geom.NewCollectionGeometryData(pt, line), 10)
+	assert.Equal(t, []float64{1, 2}, col.Geometries[0].Point)
+	assert.Len(t, col.Geometries[1].LineString, 2)
+}
+
+func TestDouglasPeucker3D(t *testing.T) {
+	line := geom.NewLineStringGeometryData([][]float64{{0, 0, 0}, {1, 0, 10}, {2, 0, 0}})
+	assert.Len(t, DouglasPeucker(line, 1).LineString, 2)
+	assert.Equal(t, line.LineString, DouglasPeucker3D(line, 1).LineString)
+	assert.Len(t, DouglasPeucker3D(line, 20).LineString, 2)
+
+	// 没有Z值时按平面计算
+	flat := geom.NewLineStringGeometryData([][]float64{{0, 0}, {1, 0.5}, {2, 0}})
+	assert.Len(t, DouglasPeucker3D(flat, 1).LineString, 2)
+
+	// M 不是高程
+	measured := geom.NewLineStringGeometryData([][]float64{{0, 0, 0}, {1, 0, 10}, {2, 0, 0}})
+	measured.Layout = geom.XYM
+	assert.Len(t, DouglasPeucker3D(measured, 1).LineString, 2)
+	col := DouglasPeucker3D(geom.NewCollectionGeometryData(measured, line), 1)
+	assert.Len(t, col.Geometries[0].LineString, 2)
+	assert.Len(t, col.Geometries[1].LineString, 3)
+	col = geom.NewCollectionGeometryData(geom.NewLineStringGeometryData(line.LineString))
+	col.Layout = geom.XYM
+	assert.Len(t, DouglasPeucker3D(col, 1).Geometries[0].LineString, 2)
+}
+
+func TestVisvalingamWhyatt(t *testing.T) {
+	line := geom.NewLineStringGeometryData([][]float64{{0, 0}, {1, 0.1}, {2, 0}, {3, 3}, {4, 0}, {5, 0}})
+	ret := VisvalingamWhyatt(line, 1)
+	assert.Equal(t, [][]float64{{0, 0}, {2, 0}, {3, 3}, {4, 0}, {5, 0}}, ret.LineString)
+	assert.Equal(t, [][]float64{{0, 0}, {5, 0}}, VisvalingamWhyatt(line, 10).LineString)
+
+	// 环至少保留4个点
+	ring := [][]float64{{0, 0}, {1, 0}, {1, 1}, {0.5, 1.01}, {0, 1}, {0, 0}}
+	poly := VisvalingamWhyatt(geom.NewPolygonGeometryData([][][]float64{ring}), 0.2)
+	assert.Len(t, poly.Polygon[0], 5)
+	assert.Len(t, VisvalingamWhyatt(geom.NewPolygonGeometryData([][][]float64{ring}), 100).Polygon[0], 4)
+}
+
+// 测试简化后洞不会跑到外环外
+func TestTopologyPreservingHole(t *testing.T) {
+	shell := [][]float64{{0, 0}, {10, 0}, {10, 10}, {5, 10.4}, {0, 10}, {0, 0}}
+	hole := [][]float64{{4, 9}, {6, 9}, {5, 10.2}, {4, 9}}
+	poly := geom.NewPolygonGeometryData([][][]float64{shell, hole})
+
+	dp := DouglasPeucker(poly, 1)
+	assert.Len(t, dp.Polygon[0], 5)
+	assert.False(t, relate.Contains(geom.NewPolygonGeometryData(dp.Polygon[:1]), geom.NewPolygonGeometryData(dp.Polygon[1:])))
+
+	tp := TopologyPreserving(poly, 1)
+	assert.Equal(t, shell, tp.Polygon[0])
+	assert.Equal(t, hole, tp.Polygon[1])
+
+	// 不影响的部分照常简化
+	wavy := [][]float64{{0, 0}, {5, 0.1}, {10, 0}, {10, 10}, {5, 10.4}, {0, 10}, {0, 0}}
+	tp = TopologyPreserving(geom.NewPolygonGeometryData([][][]float64{wavy, hole}), 1)
+	assert.Equal(t, shell, tp.Polygon[0])
+}
+
+// 测试简化不会吞掉凹口中的其他多边形
+func TestTopologyPreservingIsland(t *testing.T) {
+	notched := [][]float64{{0, 0}, {10, 0}, {10, 10}, {6, 10}, {5, 9.5}, {4, 10}, {0, 10}, {0, 0}}
+	island := [][]float64{{5, 9.8}, {5.1, 9.9}, {4.9, 9.9}, {5, 9.8}}
+	mp := geom.NewMultiPolygonGeometryData([][][]float64{notched}, [][][]float64{island})
+
+	assert.Len(t, DouglasPeucker(mp, 1).MultiPolygon[0][0], 5)
+
+	tp := TopologyPreserving(mp, 1)
+	assert.Equal(t, [][]float64{{0, 0}, {10, 0}, {10, 10}, {5, 9.5}, {0, 10}, {0, 0}}, tp.MultiPolygon[0][0])
+	assert.Equal(t, island, tp.MultiPolygon[1][0])
+	assert.True(t, relate.Disjoint(geom.NewPolygonGeometryData(tp.MultiPolygon[0]), geom.NewPolygonGeometryData(tp.MultiPolygon[1])))
+}
+
+func TestTopologyPreservingLines(t *testing.T) {
+	// 简化不能让两条线相交
+	a := [][]float64{{0, 0}, {5, 1}, {10, 0}}
+	b := [][]float64{{4, 0.5}, {6, 0.5}}
+	ml := geom.NewMultiLineStringGeometryData(a, b)
+	assert.Len(t, DouglasPeucker(ml, 2).MultiLineString[0], 2)
+	assert.Equal(t, a, TopologyPreserving(ml, 2).MultiLineString[0])
+
+	// 环不会退化
+	tiny := [][]float64{{0, 0}, {0.1, 0}, {0.1, 0.1}, {0, 0.1}, {0, 0}}
+	poly := TopologyPreserving(geom.NewPolygonGeometryData([][][]float64{tiny}), 10)
+	assert.Len(t, poly.Polygon[0], 4)
+	assert.Equal(t, tiny[0], poly.Polygon[0][3])
+
+	line := geom.NewLineStringGeometryData([][]float64{{0, 0, 5}, {1, 0.1, 6}, {2, 0, 7}})
+	assert.Equal(t, [][]float64{{0, 0, 5}, {2, 0, 7}}, TopologyPreserving(line, 1).LineString)
+	assert.Nil(t, TopologyPreserving(nil, 1))
+}
@@ -0,0 +1,332 @@
+package simplify
+
+import (
+	"math"
+
+	"github.com/flywave/go-geom"
+)
+
+// TopologyPreserving simplifies g like DouglasPeucker, but a run of
+// vertices is only replaced by a shortcut when the shortcut crosses no
+// other edge of g and no vertex of g ends up on the other side of it. The
+// lines and rings of g keep their relationships, so valid polygons stay
+// valid and rings keep at least four points.
+func TopologyPreserving(g *geom.GeometryData, tolerance float64) *geom.GeometryData {
+	if g == nil {
+		return nil
+	}
+	s := &topologySimplifier{tolerance: tolerance, paths: make(map[*[]float64]*taggedPath)}
+	s.collect(g)
+	s.index = newSegmentIndex(s.order)
+	for _, p := range s.order {
+		s.simplifySection(p, 0, len(p.pts)-1)
+	}
+	return simplify(g, func(path [][]float64, ring bool) [][]float64 {
+		if len(path) == 0 {
+			return path
+		}
+		return s.paths[&path[0]].result()
+	})
+}
+
+// taggedPath is a line or ring being simplified, keep marks the vertices
+// still in use and segs the segment of the index starting at each of them.
+type taggedPath struct {
+	pts     [][]float64
+	keep    []bool
+	segs    []*segmentRef
+	count   int
+	minSize int
+}
+
+func (p *taggedPath) result() [][]float64 {
+	ret := make([][]float64, 0, p.count)
+	for i, pt := range p.pts {
+		if p.keep[i] {
+			ret = append(ret, pt)
+		}
+	}
+	return ret
+}
+
+type topologySimplifier struct {
+	tolerance float64
+	paths     map[*[]float64]*taggedPath
+	order     []*taggedPath
+	index     *segmentIndex
+}
+
+func (s *topologySimplifier) collect(g *geom.GeometryData) {
+	switch g.Type {
+	case geom.GeometryLineString:
+		s.add(g.LineString, false)
+	case geom.GeometryMultiLineString:
+		for _, l := range g.MultiLineString {
+			s.add(l, false)
+		}
+	case geom.GeometryPolygon:
+		for _, r := range g.Polygon {
+			s.add(r, true)
+		}
+	case geom.GeometryMultiPolygon:
+		for _, p := range g.MultiPolygon {
+			for _, r := range p {
+				s.add(r, true)
+			}
+		}
+	case geom.GeometryCollection:
+		for _, c := range g.Geometries {
+			if c != nil {
+				s.collect(c)
+			}
+		}
+	}
+}
+
+func (s *topologySimplifier) add(path [][]float64, ring bool) {
+	if len(path) == 0 || s.paths[&path[0]] != nil {
+		return
+	}
+	p := &taggedPath{
+		pts:     path,
+		keep:    make([]bool, len(path)),
+		segs:    make([]*segmentRef, len(path)),
+		count:   len(path),
+		minSize: 2,
+	}
+	if ring {
+		p.minSize = 4
+	}
+	for i := range p.keep {
+		p.keep[i] = true
+	}
+	s.paths[&path[0]] = p
+	s.order = append(s.order, p)
+}
+
+func (s *topologySimplifier) simplifySection(p *taggedPath, i, j int) {
+	if j-i < 2 {
+		return
+	}
+	maxDist, k := -1.0, -1
+	for m := i + 1; m < j; m++ {
+		if d := planarDistance(p.pts[m], p.pts[i], p.pts[j]); d > maxDist {
+			maxDist, k = d, m
+		}
+	}
+	if maxDist <= s.tolerance && p.count-(j-i-1) >= p.minSize && s.canFlatten(p, i, j) {
+		for m := i; m < j; m++ {
+			s.index.remove(p.segs[m])
+			p.segs[m] = nil
+			if m > i {
+				p.keep[m] = false
+			}
+		}
+		p.count -= j - i - 1
+		p.segs[i] = s.index.insert(p, i, j)
+		return
+	}
+	s.simplifySection(p, i, k)
+	s.simplifySection(p, k, j)
+}
+
+// canFlatten tells if the vertices of p between i and j can be replaced by
+// the segment from i to j without changing the topology.
+func (s *topologySimplifier) canFlatten(p *taggedPath, i, j int) bool {
+	a, b := p.pts[i], p.pts[j]
+	inSection := func(r *segmentRef) bool {
+		return r.path == p && r.i >= i && r.j <= j
+	}
+	box := [4]float64{math.Min(a[0], b[0]), math.Min(a[1], b[1]), math.Max(a[0], b[0]), math.Max(a[1], b[1])}
+	ok := true
+	s.index.query(box, func(r *segmentRef) bool {
+		if !inSection(r) && interiorIntersects(a, b, r.path.pts[r.i], r.path.pts[r.j]) {
+			ok = false
+		}
+		return ok
+	})
+	if !ok {
+		return false
+	}
+
+	// 被截去的区域内不能有其他顶点
+	section := p.pts[i : j+1]
+	box = pathBox(section)
+	s.index.query(box, func(r *segmentRef) bool {
+		if inSection(r) {
+			return true
+		}
+		for _, pt := range [2][]float64{r.path.pts[r.i], r.path.pts[r.j]} {
+			if pt[0] > box[0] && pt[0] < box[2] && pt[1] > box[1] && pt[1] < box[3] && insideRing(pt, section) {
+				ok = false
+				break
+			}
+		}
+		return ok
+	})
+	return ok
+}
+
+// insideRing tells if pt lies strictly inside the path closed back to its
+// first point.
+func insideRing(pt []float64, path [][]float64) bool {
+	inside := false
+	n := len(path)
+	for k := 0; k < n; k++ {
+		a, b := path[k], path[(k+1)%n]
+		if orient(a, b, pt) == 0 && pt[0] >= math.Min(a[0], b[0]) && pt[0] <= math.Max(a[0], b[0]) &&
+			pt[1] >= math.Min(a[1], b[1]) && pt[1] <= math.Max(a[1], b[1]) {
+			return false
+		}
+		if (a[1] > pt[1]) != (b[1] > pt[1]) && pt[0] < a[0]+(pt[1]-a[1])*(b[0]-a[0])/(b[1]-a[1]) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// interiorIntersects tells if two segments meet anywhere but at a shared
+// end point.
+func interiorIntersects(p1, p2, q1, q2 []float64) bool {
+	d1, d2 := orient(p1, p2, q1), orient(p1, p2, q2)
+	d3, d4 := orient(q1, q2, p1), orient(q1, q2, p2)
+	if (d1 > 0 && d2 > 0) || (d1 < 0 && d2 < 0) || (d3 > 0 && d4 > 0) || (d3 < 0 && d4 < 0) {
+		return false
+	}
+	if d1 == 0 && d2 == 0 {
+		// 共线时比较投影区间
+		dx, dy := p2[0]-p1[0], p2[1]-p1[1]
+		t := func(p []float64) float64 { return (p[0]-p1[0])*dx + (p[1]-p1[1])*dy }
+		lo, hi := math.Max(0, math.Min(t(q1), t(q2))), math.Min(t(p2), math.Max(t(q1), t(q2)))
+		if lo < hi {
+			return true
+		}
+		if lo > hi {
+			return false
+		}
+		return !(samePoint(p1, q1) || samePoint(p1, q2) || samePoint(p2, q1) || samePoint(p2, q2))
+	}
+	if d1 != 0 && d2 != 0 && d3 != 0 && d4 != 0 {
+		return true
+	}
+	shared := func(pt, a, b []float64) bool { return samePoint(pt, a) || samePoint(pt, b) }
+	switch {
+	case d1 == 0:
+		return !shared(q1, p1, p2)
+	case d2 == 0:
+		return !shared(q2, p1, p2)
+	case d3 == 0:
+		return !shared(p1, q1, q2)
+	}
+	return !shared(p2, q1, q2)
+}
+
+func orient(a, b, c []float64) float64 {
+	return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+}
+
+func samePoint(a, b []float64) bool {
+	return a[0] == b[0] && a[1] == b[1]
+}
+
+func pathBox(path [][]float64) [4]float64 {
+	box := [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for _, p := range path {
+		box[0] = math.Min(box[0], p[0])
+		box[1] = math.Min(box[1], p[1])
+		box[2] = math.Max(box[2], p[0])
+		box[3] = math.Max(box[3], p[1])
+	}
+	return box
+}
+
+type segmentRef struct {
+	path  *taggedPath
+	i, j  int
+	dead  bool
+	stamp int
+}
+
+// segmentIndex is a uniform grid over the current segments of all paths.
+type segmentIndex struct {
+	origin [2]float64
+	cell   float64
+	cells  map[[2]int][]*segmentRef
+	stamp  int
+}
+
+func newSegmentIndex(paths []*taggedPath) *segmentIndex {
+	idx := &segmentIndex{cells: make(map[[2]int][]*segmentRef)}
+	box := [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	n := 0
+	for _, p := range paths {
+		b := pathBox(p.pts)
+		box = [4]float64{math.Min(box[0], b[0]), math.Min(box[1], b[1]), math.Max(box[2], b[2]), math.Max(box[3], b[3])}
+		n += len(p.pts)
+	}
+	idx.origin = [2]float64{box[0], box[1]}
+	idx.cell = math.Max(box[2]-box[0], box[3]-box[1]) / math.Max(1, math.Sqrt(float64(n)))
+	if !(idx.cell > 0) || math.IsInf(idx.cell, 0) {
+		idx.cell = 1
+	}
+	for _, p := range paths {
+		for i := 0; i+1 < len(p.pts); i++ {
+			p.segs[i] = idx.insert(p, i, i+1)
+		}
+	}
+	return idx
+}
+
+func (idx *segmentIndex) span(box [4]float64) (x0, y0, x1, y1 int) {
+	x0 = int(math.Floor((box[0] - idx.origin[0]) / idx.cell))
+	y0 = int(math.Floor((box[1] - idx.origin[1]) / idx.cell))
+	x1 = int(math.Floor((box[2] - idx.origin[0]) / idx.cell))
+	y1 = int(math.Floor((box[3] - idx.origin[1]) / idx.cell))
+	return
+}
+
+func (idx *segmentIndex) insert(p *taggedPath, i, j int) *segmentRef {
+	r := &segmentRef{path: p, i: i, j: j}
+	a, b := p.pts[i], p.pts[j]
+	x0, y0, x1, y1 := idx.span([4]float64{math.Min(a[0], b[0]), math.Min(a[1], b[1]), math.Max(a[0], b[0]), math.Max(a[1], b[1])})
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			idx.cells[[2]int{x, y}] = append(idx.cells[[2]int{x, y}], r)
+		}
+	}
+	return r
+}
+
+func (idx *segmentIndex) remove(r *segmentRef) {
+	if r != nil {
+		r.dead = true
+	}
+}
+
+// query calls fn for each live segment whose cells meet box until fn
+// returns false.
+func (idx *segmentIndex) query(box [4]float64, fn func(r *segmentRef) bool) {
+	idx.stamp++
+	x0, y0, x1, y1 := idx.span(box)
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			key := [2]int{x, y}
+			refs := idx.cells[key]
+			live := refs[:0]
+			for _, r := range refs {
+				if r.dead {
+					continue
+				}
+				live = append(live, r)
+				if r.stamp == idx.stamp {
+					continue
+				}
+				r.stamp = idx.stamp
+				if !fn(r) {
+					return
+				}
+			}
+			idx.cells[key] = live
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package simplify
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/flywave/go-geom"
+)
+
+// VisvalingamWhyatt simplifies g by repeatedly removing the vertex forming
+// the smallest triangle with its neighbours, until every triangle is at
+// least tolerance² in area. It keeps the overall shape better than
+// DouglasPeucker at the same vertex count.
+func VisvalingamWhyatt(g *geom.GeometryData, tolerance float64) *geom.GeometryData {
+	return simplify(g, func(path [][]float64, ring bool) [][]float64 {
+		return visvalingam(path, tolerance*tolerance, ring)
+	})
+}
+
+type vertexArea struct {
+	index int
+	area  float64
+	stamp int
+}
+
+type areaHeap []vertexArea
+
+func (h areaHeap) Len() int            { return len(h) }
+func (h areaHeap) Less(i, j int) bool  { return h[i].area < h[j].area }
+func (h areaHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *areaHeap) Push(x interface{}) { *h = append(*h, x.(vertexArea)) }
+func (h *areaHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+func visvalingam(path [][]float64, threshold float64, ring bool) [][]float64 {
+	n := len(path)
+	if n < 3 {
+		return path
+	}
+	minSize := 2
+	if ring {
+		minSize = 4
+	}
+	prev := make([]int, n)
+	next := make([]int, n)
+	stamp := make([]int, n)
+	removed := make([]bool, n)
+	for i := range path {
+		prev[i], next[i] = i-1, i+1
+	}
+	triangle := func(i int) float64 {
+		a, b, c := path[prev[i]], path[i], path[next[i]]
+		return math.Abs((b[0]-a[0])*(c[1]-a[1])-(c[0]-a[0])*(b[1]-a[1])) / 2
+	}
+
+	h := make(areaHeap, 0, n)
+	for i := 1; i < n-1; i++ {
+		h = append(h, vertexArea{index: i, area: triangle(i)})
+	}
+	heap.Init(&h)
+
+	count := n
+	for h.Len() > 0 && count > minSize {
+		v := heap.Pop(&h).(vertexArea)
+		if removed[v.index] || v.stamp != stamp[v.index] {
+			continue
+		}
+		if v.area >= threshold {
+			break
+		}
+		removed[v.index] = true
+		count--
+		p, q := prev[v.index], next[v.index]
+		next[p], prev[q] = q, p
+		for _, k := range [2]int{p, q} {
+			if k == 0 || k == n-1 {
+				continue
+			}
+			stamp[k]++
+			// 保证有效面积单调, 避免相邻点在本点之前被移除
+			heap.Push(&h, vertexArea{index: k, area: math.Max(triangle(k), v.area), stamp: stamp[k]})
+		}
+	}
+
+	ret := make([][]float64, 0, count)
+	for i, p := range path {
+		if !removed[i] {
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
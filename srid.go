@@ -14,17 +14,40 @@ func SridToUrn(srid int) string {
 	return fmt.Sprintf("urn:ogc:def:crs:EPSG::%d", srid)
 }
 
+// epsgPrefixes are the forms of srsName followed by an EPSG code.
+var epsgPrefixes = []string{
+	"EPSG:",
+	"urn:x-ogc:def:crs:EPSG:",
+	"http://www.opengis.net/def/crs/EPSG/0/",
+	"http://www.opengis.net/gml/srs/epsg.xml#",
+}
+
 func UrnToSrid(urn string) int {
-	if urn == "urn:ogc:def:crs:OGC:1.3:CRS84" {
+	switch urn {
+	case "urn:ogc:def:crs:OGC:1.3:CRS84", "urn:ogc:def:crs:OGC::CRS84", "http://www.opengis.net/def/crs/OGC/1.3/CRS84":
 		return 4326
 	}
 
-	if strings.HasPrefix(urn, "urn:ogc:def:crs:EPSG::") {
-		estr := strings.TrimPrefix(urn, "urn:ogc:def:crs:EPSG::")
-		epsg, err := strconv.Atoi(estr)
+	if strings.HasPrefix(urn, "urn:ogc:def:crs:EPSG:") {
+		// urn:ogc:def:crs:EPSG:{version}:{code}, the version may be empty
+		estr := strings.TrimPrefix(urn, "urn:ogc:def:crs:EPSG:")
+		if i := strings.LastIndexByte(estr, ':'); i >= 0 {
+			if epsg, err := strconv.Atoi(estr[i+1:]); err == nil {
+				return epsg
+			}
+		}
+		return -1
+	}
 
-		if err == nil {
-			return epsg
+	for _, prefix := range epsgPrefixes {
+		if strings.HasPrefix(urn, prefix) {
+			estr := strings.TrimPrefix(urn, prefix)
+			if i := strings.LastIndexByte(estr, ':'); i >= 0 {
+				estr = estr[i+1:]
+			}
+			if epsg, err := strconv.Atoi(estr); err == nil {
+				return epsg
+			}
 		}
 	}
 
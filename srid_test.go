@@ -32,3 +32,15 @@ func TestUrnToSrid(t *testing.T) {
 	assert.Equal(t, -1, UrnToSrid("urn:ogc:def:crs:EPSG:1234")) // 格式错误
 	assert.Equal(t, -1, UrnToSrid("urn:ogc:def:crs:EPSG::abc")) // 非数字SRID
 }
+
+// 测试GML中常见的srsName写法
+func TestUrnToSridForms(t *testing.T) {
+	assert.Equal(t, 4326, UrnToSrid("urn:ogc:def:crs:OGC::CRS84"))
+	assert.Equal(t, 4326, UrnToSrid("EPSG:4326"))
+	assert.Equal(t, 4490, UrnToSrid("urn:ogc:def:crs:EPSG:6.6:4490"))
+	assert.Equal(t, 3857, UrnToSrid("urn:x-ogc:def:crs:EPSG:3857"))
+	assert.Equal(t, 3857, UrnToSrid("urn:x-ogc:def:crs:EPSG:6.9:3857"))
+	assert.Equal(t, 27700, UrnToSrid("http://www.opengis.net/def/crs/EPSG/0/27700"))
+	assert.Equal(t, 4326, UrnToSrid("http://www.opengis.net/gml/srs/epsg.xml#4326"))
+	assert.Equal(t, -1, UrnToSrid("EPSG:"))
+}
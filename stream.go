@@ -0,0 +1,277 @@
+package geom
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	streamStart = iota
+	streamFeatures
+	streamDone
+)
+
+// FeatureCollectionDecoder reads the features of a GeoJSON FeatureCollection
+// one at a time from a stream, holding a single feature in memory.
+type FeatureCollectionDecoder struct {
+	dec   *json.Decoder
+	fc    *FeatureCollection
+	state int
+	err   error
+}
+
+func NewFeatureCollectionDecoder(r io.Reader) *FeatureCollectionDecoder {
+	return &FeatureCollectionDecoder{dec: json.NewDecoder(r), fc: &FeatureCollection{Type: "FeatureCollection"}}
+}
+
+// Collection returns the members of the collection other than its
+// features: those before the features after the first call to Decode, all
+// of them once Decode returned io.EOF.
+func (d *FeatureCollectionDecoder) Collection() *FeatureCollection {
+	return d.fc
+}
+
+// Decode returns the next feature, io.EOF after the last one.
+func (d *FeatureCollectionDecoder) Decode() (*Feature, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	f, err := d.next()
+	if err != nil {
+		d.err = err
+	}
+	return f, err
+}
+
+func (d *FeatureCollectionDecoder) next() (*Feature, error) {
+	switch d.state {
+	case streamStart:
+		if err := d.delim('{'); err != nil {
+			return nil, err
+		}
+		if err := d.members(); err != nil {
+			return nil, err
+		}
+		if d.state == streamDone {
+			return nil, io.EOF
+		}
+	case streamDone:
+		return nil, io.EOF
+	}
+	if d.dec.More() {
+		f := &Feature{}
+		if err := d.dec.Decode(f); err != nil {
+			return nil, unexpected(err)
+		}
+		return f, nil
+	}
+	if err := d.delim(']'); err != nil {
+		return nil, err
+	}
+	d.state = streamDone
+	if err := d.members(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// unexpected turns the end of the input inside the collection into
+// io.ErrUnexpectedEOF.
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (d *FeatureCollectionDecoder) delim(want json.Delim) error {
+	tok, err := d.dec.Token()
+	if err != nil {
+		return unexpected(err)
+	}
+	if tok != want {
+		return fmt.Errorf("expected %v in geojson feature collection, got %v", want, tok)
+	}
+	return nil
+}
+
+// members reads the members of the collection up to the start of the
+// features or the end of the object.
+func (d *FeatureCollectionDecoder) members() error {
+	for {
+		tok, err := d.dec.Token()
+		if err != nil {
+			return unexpected(err)
+		}
+		if tok == json.Delim('}') {
+			if d.fc.Type != "FeatureCollection" {
+				return errors.New("type property not FeatureCollection")
+			}
+			d.state = streamDone
+			return nil
+		}
+		key, _ := tok.(string)
+		var v interface{}
+		switch key {
+		case "type":
+			v = &d.fc.Type
+			d.fc.Type = ""
+		case "bbox":
+			v = &d.fc.BoundingBox
+		case "crs":
+			v = &d.fc.CRS
+		case "properties":
+			v = &d.fc.Properties
+		case "features":
+			if d.state != streamStart {
+				return errors.New("duplicate features in geojson feature collection")
+			}
+			if err := d.delim('['); err != nil {
+				return err
+			}
+			d.state = streamFeatures
+			return nil
+		default:
+			var raw json.RawMessage
+			if err := d.dec.Decode(&raw); err != nil {
+				return unexpected(err)
+			}
+			if err := decodeForeignMember(&d.fc.ForeignMembers, key, raw); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := d.dec.Decode(v); err != nil {
+			return unexpected(err)
+		}
+	}
+}
+
+// FeatureCollectionEncoder writes a GeoJSON FeatureCollection one feature at
+// a time. The members of the collection set when the first feature is
+// written go before the features, those set later, such as a bounding box
+// computed on the way, after them.
+type FeatureCollectionEncoder struct {
+	w   io.Writer
+	fc  *FeatureCollection
+	buf []byte
+	// written holds the members already written.
+	written map[string]bool
+	count   int
+	closed  bool
+}
+
+// NewFeatureCollectionEncoder returns an encoder writing the members of fc
+// to w. The features of fc are not written.
+func NewFeatureCollectionEncoder(w io.Writer, fc *FeatureCollection) *FeatureCollectionEncoder {
+	if fc == nil {
+		fc = NewFeatureCollection()
+	}
+	return &FeatureCollectionEncoder{w: w, fc: fc}
+}
+
+// appendMembers writes the members of the collection not yet written.
+func (e *FeatureCollectionEncoder) appendMembers(buf []byte) ([]byte, error) {
+	members := []struct {
+		key   string
+		value interface{}
+		set   bool
+	}{
+		{"bbox", e.fc.BoundingBox, e.fc.BoundingBox != nil},
+		{"crs", e.fc.CRS, len(e.fc.CRS) != 0},
+		{"properties", e.fc.Properties, len(e.fc.Properties) != 0},
+	}
+	foreign := make(map[string]interface{})
+	for k, v := range e.fc.ForeignMembers {
+		if !collectionMembers[k] && !e.written[k] {
+			foreign[k] = v
+			e.written[k] = true
+		}
+	}
+	for _, m := range members {
+		if !m.set || e.written[m.key] {
+			continue
+		}
+		b, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, ',', '"')
+		buf = append(buf, m.key...)
+		buf = append(buf, '"', ':')
+		buf = append(buf, b...)
+		e.written[m.key] = true
+	}
+	if len(foreign) == 0 {
+		return buf, nil
+	}
+	b, err := appendForeignMembers([]byte{'{', '}'}, foreign, collectionMembers)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(buf, ','), b[1:len(b)-1]...), nil
+}
+
+func (e *FeatureCollectionEncoder) start() error {
+	var err error
+	e.written = make(map[string]bool)
+	buf := append(e.buf[:0], `{"type":"FeatureCollection"`...)
+	if buf, err = e.appendMembers(buf); err != nil {
+		return err
+	}
+	buf = append(buf, `,"features":[`...)
+	e.buf = buf
+	return nil
+}
+
+// Encode writes f.
+func (e *FeatureCollectionEncoder) Encode(f *Feature) error {
+	if e.closed {
+		return errors.New("geojson feature collection encoder closed")
+	}
+	if f == nil {
+		return errors.New("nil feature")
+	}
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	if e.written == nil {
+		if err := e.start(); err != nil {
+			return err
+		}
+	} else {
+		e.buf = e.buf[:0]
+	}
+	if e.count > 0 {
+		e.buf = append(e.buf, ',')
+	}
+	e.buf = append(e.buf, b...)
+	e.count++
+	_, err = e.w.Write(e.buf)
+	return err
+}
+
+// Close ends the collection, without closing the underlying writer.
+func (e *FeatureCollectionEncoder) Close() error {
+	if e.closed {
+		return nil
+	}
+	if e.written == nil {
+		if err := e.start(); err != nil {
+			return err
+		}
+	} else {
+		e.buf = e.buf[:0]
+	}
+	buf, err := e.appendMembers(append(e.buf, ']'))
+	if err != nil {
+		return err
+	}
+	e.buf = append(buf, '}')
+	e.closed = true
+	_, err = e.w.Write(e.buf)
+	return err
+}
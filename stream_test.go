@@ -0,0 +1,124 @@
+package geom
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试流式读取要素集合
+func TestFeatureCollectionDecoder(t *testing.T) {
+	data := `{
+		"type": "FeatureCollection",
+		"crs": {"type": "name", "properties": {"name": "urn:ogc:def:crs:EPSG::4326"}},
+		"title": "places",
+		"features": [
+			{"type": "Feature", "id": 1, "geometry": {"type": "Point", "coordinates": [1, 2]}, "properties": {"name": "a"}},
+			{"type": "Feature", "geometry": null, "properties": {"name": "b"}},
+			{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[1, 2], [3, 4]]}, "properties": null}
+		],
+		"bbox": [1, 2, 3, 4],
+		"properties": {"source": "test"}
+	}`
+	d := NewFeatureCollectionDecoder(strings.NewReader(data))
+	f, err := d.Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1), f.ID)
+	assert.Equal(t, []float64{1, 2}, f.GeometryData.Point)
+	assert.Equal(t, "a", f.Properties["name"])
+	assert.Equal(t, "name", d.Collection().CRS["type"])
+	assert.Equal(t, map[string]interface{}{"title": "places"}, d.Collection().ForeignMembers)
+	assert.Nil(t, d.Collection().BoundingBox)
+
+	f, err = d.Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, GeometryType(""), f.GeometryData.Type)
+	assert.Equal(t, "b", f.Properties["name"])
+	f, err = d.Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, GeometryLineString, f.GeometryData.Type)
+
+	_, err = d.Decode()
+	assert.Equal(t, io.EOF, err)
+	_, err = d.Decode()
+	assert.Equal(t, io.EOF, err)
+	fc := d.Collection()
+	assert.Equal(t, "FeatureCollection", fc.Type)
+	assert.Equal(t, &BoundingBox{{1, 2, 0}, {3, 4, 0}}, fc.BoundingBox)
+	assert.Equal(t, "test", fc.Properties["source"])
+	assert.Len(t, fc.Features, 0)
+
+	// 没有要素的集合
+	d = NewFeatureCollectionDecoder(strings.NewReader(`{"type": "FeatureCollection", "properties": {"a": 1}}`))
+	_, err = d.Decode()
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, float64(1), d.Collection().Properties["a"])
+
+	// 截断与错误的输入
+	for _, s := range []string{
+		"",
+		`{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": null}`,
+		`{"type": "FeatureCollection", "features": [`,
+		`{"type": "Feature", "features": []}`,
+		`{"type": "FeatureCollection", "features": {}}`,
+		`{"type": "FeatureCollection", "features": [], "features": []}`,
+		`[]`,
+	} {
+		d = NewFeatureCollectionDecoder(strings.NewReader(s))
+		var err error
+		for err == nil {
+			_, err = d.Decode()
+		}
+		assert.NotEqual(t, io.EOF, err, s)
+	}
+	d = NewFeatureCollectionDecoder(strings.NewReader(`{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": null}`))
+	_, err = d.Decode()
+	assert.NoError(t, err)
+	_, err = d.Decode()
+	assert.Error(t, err)
+	assert.NotEqual(t, io.EOF, err)
+}
+
+// 测试流式写出要素集合
+func TestFeatureCollectionEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	fc := NewFeatureCollection()
+	fc.Properties["source"] = "test"
+	fc.ForeignMembers = map[string]interface{}{"title": "places", "features": 1}
+	e := NewFeatureCollectionEncoder(&buf, fc)
+	var boxes []*BoundingBox
+	for i := 0; i < 3; i++ {
+		f := NewPointFeature([]float64{float64(i), float64(i * 2)})
+		f.ID = i
+		boxes = append(boxes, f.BoundingBox)
+		assert.NoError(t, e.Encode(f))
+	}
+	// 写出过程中计算的范围写在要素之后
+	fc.BoundingBox = ExpandBoundingBoxs(boxes)
+	fc.ForeignMembers["links"] = []interface{}{}
+	assert.NoError(t, e.Close())
+	assert.NoError(t, e.Close())
+	assert.Error(t, e.Encode(NewPointFeature([]float64{0, 0})))
+	assert.True(t, json.Valid(buf.Bytes()))
+
+	d := NewFeatureCollectionDecoder(&buf)
+	for i := 0; i < 3; i++ {
+		f, err := d.Decode()
+		assert.NoError(t, err)
+		assert.Equal(t, float64(i), f.ID)
+		assert.Equal(t, []float64{float64(i), float64(i * 2)}, f.GeometryData.Point)
+	}
+	_, err := d.Decode()
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, &BoundingBox{{0, 0, 0}, {2, 4, 0}}, d.Collection().BoundingBox)
+	assert.Equal(t, "test", d.Collection().Properties["source"])
+	assert.Equal(t, map[string]interface{}{"title": "places", "links": []interface{}{}}, d.Collection().ForeignMembers)
+
+	buf.Reset()
+	assert.NoError(t, NewFeatureCollectionEncoder(&buf, nil).Close())
+	assert.JSONEq(t, `{"type":"FeatureCollection","features":[]}`, buf.String())
+}
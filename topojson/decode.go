@@ -0,0 +1,190 @@
+package topojson
+
+import (
+	"fmt"
+
+	"github.com/flywave/go-geom"
+)
+
+// decoder resolves the arcs of a topology to positions.
+type decoder struct {
+	t    *Topology
+	arcs [][][]float64
+}
+
+func newDecoder(t *Topology) *decoder {
+	d := &decoder{t: t, arcs: make([][][]float64, len(t.Arcs))}
+	for i, arc := range t.Arcs {
+		d.arcs[i] = make([][]float64, 0, len(arc))
+		var x, y float64
+		for _, p := range arc {
+			if len(p) < 2 {
+				continue
+			}
+			// 量化的弧段为差分编码
+			if t.Transform != nil {
+				x, y = x+p[0], y+p[1]
+			} else {
+				x, y = p[0], p[1]
+			}
+			d.arcs[i] = append(d.arcs[i], t.Transform.position(x, y))
+		}
+	}
+	return d
+}
+
+// line joins the arcs of indexes, the first position of an arc being the
+// last one of the previous arc.
+func (d *decoder) line(indexes []int) ([][]float64, error) {
+	var ret [][]float64
+	for _, i := range indexes {
+		j := i
+		if j < 0 {
+			j = ^j
+		}
+		if j >= len(d.arcs) {
+			return nil, fmt.Errorf("topojson arc %d out of range", i)
+		}
+		arc := d.arcs[j]
+		if i < 0 {
+			arc = make([][]float64, len(d.arcs[j]))
+			for k, p := range d.arcs[j] {
+				arc[len(arc)-1-k] = p
+			}
+		}
+		if len(ret) > 0 && len(arc) > 0 {
+			arc = arc[1:]
+		}
+		for _, p := range arc {
+			ret = append(ret, []float64{p[0], p[1]})
+		}
+	}
+	return ret, nil
+}
+
+func (d *decoder) lines(indexes [][]int) ([][][]float64, error) {
+	ret := make([][][]float64, len(indexes))
+	for i, l := range indexes {
+		var err error
+		if ret[i], err = d.line(l); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+func (d *decoder) point(p []float64) []float64 {
+	if len(p) < 2 {
+		return p
+	}
+	return d.t.Transform.position(p[0], p[1])
+}
+
+// geometry resolves the positions of g.
+func (d *decoder) geometry(g *Geometry) (*geom.GeometryData, error) {
+	ret := &geom.GeometryData{Type: geom.GeometryType(g.Type)}
+	var err error
+	switch g.Type {
+	case "":
+	case "Point":
+		ret.Point = d.point(g.Point)
+	case "MultiPoint":
+		ret.MultiPoint = make([][]float64, len(g.MultiPoint))
+		for i, p := range g.MultiPoint {
+			ret.MultiPoint[i] = d.point(p)
+		}
+	case "LineString":
+		ret.LineString, err = d.line(g.LineString)
+	case "MultiLineString":
+		ret.MultiLineString, err = d.lines(g.MultiLineString)
+	case "Polygon":
+		ret.Polygon, err = d.lines(g.Polygon)
+	case "MultiPolygon":
+		ret.MultiPolygon = make([][][][]float64, len(g.MultiPolygon))
+		for i, p := range g.MultiPolygon {
+			if ret.MultiPolygon[i], err = d.lines(p); err != nil {
+				break
+			}
+		}
+	case "GeometryCollection":
+		for _, c := range g.Geometries {
+			child, err := d.geometry(c)
+			if err != nil {
+				return nil, err
+			}
+			ret.Geometries = append(ret.Geometries, child)
+		}
+	default:
+		return nil, fmt.Errorf("unknown topojson geometry type %s", g.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// feature returns g as a feature, its arc indexes kept in the EXT_TOPO
+// entry of ExtData.
+func (d *decoder) feature(g *Geometry) (*geom.Feature, error) {
+	gd, err := d.geometry(g)
+	if err != nil {
+		return nil, err
+	}
+	var f *geom.Feature
+	if gd.Type == "" {
+		f = &geom.Feature{Type: "Feature", Properties: make(map[string]interface{}), ExtData: make(map[string]interface{})}
+	} else {
+		f = geom.NewFeatureFromGeometryData(gd)
+	}
+	f.ID = g.ID
+	for k, v := range g.Properties {
+		f.Properties[k] = v
+	}
+	if arcs := g.Arcs(); arcs != nil {
+		f.ExtData[geom.EXT_TOPO] = arcs
+	}
+	return f, nil
+}
+
+// FeatureCollection returns the features of the object name. The members
+// of a collection object are each a feature.
+func (t *Topology) FeatureCollection(name string) (*geom.FeatureCollection, error) {
+	o, ok := t.Objects[name]
+	if !ok {
+		return nil, fmt.Errorf("no topojson object %s", name)
+	}
+	return newDecoder(t).featureCollection(o)
+}
+
+func (d *decoder) featureCollection(o *Geometry) (*geom.FeatureCollection, error) {
+	fc := geom.NewFeatureCollection()
+	members := []*Geometry{o}
+	if o.Type == "GeometryCollection" {
+		members = o.Geometries
+	}
+	for _, g := range members {
+		f, err := d.feature(g)
+		if err != nil {
+			return nil, err
+		}
+		fc.AddFeature(f)
+	}
+	if len(o.BoundingBox) == 4 {
+		fc.BoundingBox = &geom.BoundingBox{{o.BoundingBox[0], o.BoundingBox[1], 0}, {o.BoundingBox[2], o.BoundingBox[3], 0}}
+	}
+	return fc, nil
+}
+
+// FeatureCollections returns the features of every object.
+func (t *Topology) FeatureCollections() (map[string]*geom.FeatureCollection, error) {
+	d := newDecoder(t)
+	ret := make(map[string]*geom.FeatureCollection, len(t.Objects))
+	for name, o := range t.Objects {
+		fc, err := d.featureCollection(o)
+		if err != nil {
+			return nil, err
+		}
+		ret[name] = fc
+	}
+	return ret, nil
+}
@@ -0,0 +1,414 @@
+package topojson
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/flywave/go-geom"
+)
+
+// EncodeOptions controls the building of topologies.
+type EncodeOptions struct {
+	// Quantization is the number of distinct values per axis of the
+	// quantized positions, 0 keeping the coordinates as they are.
+	Quantization int
+}
+
+func DefaultEncodeOptions() *EncodeOptions {
+	return &EncodeOptions{Quantization: 100000}
+}
+
+type point [2]float64
+
+// visit keeps the neighbours of a position on its first visit.
+type visit struct {
+	prev, next point
+}
+
+// builder extracts the arcs shared by the lines and rings of the objects.
+type builder struct {
+	transform *Transform
+	junctions map[point]bool
+	visits    map[point]visit
+	arcs      [][]point
+	index     map[string]int
+}
+
+func newBuilder() *builder {
+	return &builder{junctions: make(map[point]bool), visits: make(map[point]visit), index: make(map[string]int)}
+}
+
+// quantize returns the transform of the quantized positions within bbox.
+func quantize(bbox [4]float64, n int) *Transform {
+	k := func(min, max float64) float64 {
+		if max > min && n > 1 {
+			return (max - min) / float64(n-1)
+		}
+		return 1
+	}
+	return &Transform{
+		Scale:     [2]float64{k(bbox[0], bbox[2]), k(bbox[1], bbox[3])},
+		Translate: [2]float64{bbox[0], bbox[1]},
+	}
+}
+
+func (b *builder) point(p []float64) point {
+	if b.transform == nil {
+		return point{p[0], p[1]}
+	}
+	t := b.transform
+	return point{math.Round((p[0] - t.Translate[0]) / t.Scale[0]), math.Round((p[1] - t.Translate[1]) / t.Scale[1])}
+}
+
+// path converts the positions of a line or ring, dropping the repeated
+// ones and the closing position of rings.
+func (b *builder) path(path [][]float64, ring bool) []point {
+	ret := make([]point, 0, len(path))
+	for _, p := range path {
+		q := b.point(p)
+		if len(ret) > 0 && ret[len(ret)-1] == q {
+			continue
+		}
+		ret = append(ret, q)
+	}
+	if ring && len(ret) > 1 && ret[0] == ret[len(ret)-1] {
+		ret = ret[:len(ret)-1]
+	}
+	if !ring && len(ret) == 1 {
+		ret = append(ret, ret[0])
+	}
+	return ret
+}
+
+// join marks p as a junction when it was visited with other neighbours.
+func (b *builder) join(p, prev, next point) {
+	if b.junctions[p] {
+		return
+	}
+	v, ok := b.visits[p]
+	if !ok {
+		b.visits[p] = visit{prev, next}
+		return
+	}
+	if !(v.prev == prev && v.next == next) && !(v.prev == next && v.next == prev) {
+		b.junctions[p] = true
+	}
+}
+
+func (b *builder) joinLine(line []point) {
+	if len(line) == 0 {
+		return
+	}
+	b.junctions[line[0]] = true
+	b.junctions[line[len(line)-1]] = true
+	for i := 1; i+1 < len(line); i++ {
+		b.join(line[i], line[i-1], line[i+1])
+	}
+}
+
+func (b *builder) joinRing(ring []point) {
+	n := len(ring)
+	for i := range ring {
+		b.join(ring[i], ring[(i+n-1)%n], ring[(i+1)%n])
+	}
+}
+
+func key(arc []point) string {
+	buf := make([]byte, 0, len(arc)*16)
+	for _, p := range arc {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(p[0]))
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(p[1]))
+	}
+	return string(buf)
+}
+
+func reversed(arc []point) []point {
+	ret := make([]point, len(arc))
+	for i, p := range arc {
+		ret[len(arc)-1-i] = p
+	}
+	return ret
+}
+
+// arc returns the index of arc, ^i when it is the arc i reversed.
+func (b *builder) arc(arc []point) int {
+	if i, ok := b.index[key(arc)]; ok {
+		return i
+	}
+	if i, ok := b.index[key(reversed(arc))]; ok {
+		return ^i
+	}
+	i := len(b.arcs)
+	b.arcs = append(b.arcs, arc)
+	b.index[key(arc)] = i
+	return i
+}
+
+func (b *builder) cutLine(line []point) []int {
+	var ret []int
+	start := 0
+	for i := 1; i < len(line); i++ {
+		if i == len(line)-1 || b.junctions[line[i]] {
+			ret = append(ret, b.arc(line[start:i+1]))
+			start = i
+		}
+	}
+	return ret
+}
+
+func less(a, b point) bool {
+	return a[0] < b[0] || a[0] == b[0] && a[1] < b[1]
+}
+
+// cutRing splits ring at its junctions. A ring without junction is a
+// single arc, started at its smallest position so that equal rings match.
+func (b *builder) cutRing(ring []point) []int {
+	if len(ring) == 0 {
+		return []int{}
+	}
+	start := -1
+	for i, p := range ring {
+		if b.junctions[p] {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		start = 0
+		for i, p := range ring {
+			if less(p, ring[start]) {
+				start = i
+			}
+		}
+	}
+	rotated := make([]point, 0, len(ring)+1)
+	rotated = append(rotated, ring[start:]...)
+	rotated = append(rotated, ring[:start]...)
+	rotated = append(rotated, ring[start])
+	return b.cutLine(rotated)
+}
+
+// leaf is a geometry of a feature whose lines and rings are converted.
+type leaf struct {
+	g     *geom.GeometryData
+	lines [][]point
+	rings [][][]point
+}
+
+func (b *builder) leaf(g *geom.GeometryData) *leaf {
+	l := &leaf{g: g}
+	switch g.Type {
+	case geom.GeometryLineString:
+		l.lines = [][]point{b.path(g.LineString, false)}
+	case geom.GeometryMultiLineString:
+		for _, line := range g.MultiLineString {
+			l.lines = append(l.lines, b.path(line, false))
+		}
+	case geom.GeometryPolygon, geom.GeometryMultiPolygon:
+		polygons := g.MultiPolygon
+		if g.Type == geom.GeometryPolygon {
+			polygons = [][][][]float64{g.Polygon}
+		}
+		for _, p := range polygons {
+			var rings [][]point
+			for _, r := range p {
+				rings = append(rings, b.path(r, true))
+			}
+			l.rings = append(l.rings, rings)
+		}
+	}
+	for _, line := range l.lines {
+		b.joinLine(line)
+	}
+	for _, p := range l.rings {
+		for _, r := range p {
+			b.joinRing(r)
+		}
+	}
+	return l
+}
+
+func (b *builder) geometry(l *leaf) *Geometry {
+	g := &Geometry{Type: string(l.g.Type)}
+	position := func(p []float64) []float64 {
+		q := b.point(p)
+		return []float64{q[0], q[1]}
+	}
+	switch l.g.Type {
+	case geom.GeometryPoint:
+		g.Point = position(l.g.Point)
+	case geom.GeometryMultiPoint:
+		g.MultiPoint = make([][]float64, len(l.g.MultiPoint))
+		for i, p := range l.g.MultiPoint {
+			g.MultiPoint[i] = position(p)
+		}
+	case geom.GeometryLineString:
+		g.LineString = b.cutLine(l.lines[0])
+	case geom.GeometryMultiLineString:
+		g.MultiLineString = make([][]int, len(l.lines))
+		for i, line := range l.lines {
+			g.MultiLineString[i] = b.cutLine(line)
+		}
+	case geom.GeometryPolygon, geom.GeometryMultiPolygon:
+		polygons := make([][][]int, len(l.rings))
+		for i, p := range l.rings {
+			polygons[i] = make([][]int, len(p))
+			for j, r := range p {
+				polygons[i][j] = b.cutRing(r)
+			}
+		}
+		if l.g.Type == geom.GeometryPolygon {
+			g.Polygon = polygons[0]
+		} else {
+			g.MultiPolygon = polygons
+		}
+	}
+	return g
+}
+
+// node is a geometry of a feature, collections holding their members.
+type node struct {
+	leaf     *leaf
+	children []*node
+}
+
+func (b *builder) node(g *geom.GeometryData) (*node, error) {
+	switch g.Type {
+	case "":
+		return &node{}, nil
+	case geom.GeometryCollection:
+		n := &node{children: []*node{}}
+		for _, c := range g.Geometries {
+			if c == nil {
+				continue
+			}
+			child, err := b.node(c)
+			if err != nil {
+				return nil, err
+			}
+			n.children = append(n.children, child)
+		}
+		return n, nil
+	case geom.GeometryPoint, geom.GeometryMultiPoint, geom.GeometryLineString,
+		geom.GeometryMultiLineString, geom.GeometryPolygon, geom.GeometryMultiPolygon:
+		return &node{leaf: b.leaf(g)}, nil
+	}
+	return nil, fmt.Errorf("unsupported geometry type %s", g.Type)
+}
+
+func (b *builder) build(n *node) *Geometry {
+	switch {
+	case n.leaf != nil:
+		return b.geometry(n.leaf)
+	case n.children != nil:
+		g := &Geometry{Type: "GeometryCollection", Geometries: make([]*Geometry, len(n.children))}
+		for i, c := range n.children {
+			g.Geometries[i] = b.build(c)
+		}
+		return g
+	}
+	return &Geometry{}
+}
+
+// bounds returns the extent of the positions of the objects.
+func bounds(objects map[string]*geom.FeatureCollection) ([4]float64, bool) {
+	bbox := [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	var add func(g *geom.GeometryData)
+	add = func(g *geom.GeometryData) {
+		if g.Type == geom.GeometryCollection {
+			for _, c := range g.Geometries {
+				if c != nil {
+					add(c)
+				}
+			}
+			return
+		}
+		geom.ProcessGeometryData(g, func(p []float64) []float64 {
+			bbox[0], bbox[1] = math.Min(bbox[0], p[0]), math.Min(bbox[1], p[1])
+			bbox[2], bbox[3] = math.Max(bbox[2], p[0]), math.Max(bbox[3], p[1])
+			return p
+		})
+	}
+	for _, fc := range objects {
+		for _, f := range fc.Features {
+			add(&f.GeometryData)
+		}
+	}
+	return bbox, bbox[0] <= bbox[2]
+}
+
+// delta writes the arcs of the builder, as the differences between the
+// successive positions when they are quantized.
+func (b *builder) delta() [][][]float64 {
+	ret := make([][][]float64, len(b.arcs))
+	for i, arc := range b.arcs {
+		ret[i] = make([][]float64, len(arc))
+		var prev point
+		for j, p := range arc {
+			if b.transform != nil {
+				ret[i][j] = []float64{p[0] - prev[0], p[1] - prev[1]}
+				prev = p
+			} else {
+				ret[i][j] = []float64{p[0], p[1]}
+			}
+		}
+	}
+	return ret
+}
+
+// Encode builds the topology of the named objects. The lines and rings of
+// all the features are cut where they meet, each shared part becoming a
+// single arc. Positions are reduced to x and y.
+func Encode(objects map[string]*geom.FeatureCollection, opt *EncodeOptions) (*Topology, error) {
+	if opt == nil {
+		opt = DefaultEncodeOptions()
+	}
+	b := newBuilder()
+	t := NewTopology()
+	if bbox, ok := bounds(objects); ok {
+		t.BoundingBox = bbox[:]
+		if opt.Quantization > 0 {
+			b.transform = quantize(bbox, opt.Quantization)
+			t.Transform = b.transform
+		}
+	}
+	names := make([]string, 0, len(objects))
+	for name := range objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// 先找出所有的交汇点再切分弧段
+	nodes := make(map[string][]*node, len(objects))
+	for _, name := range names {
+		for _, f := range objects[name].Features {
+			n, err := b.node(&f.GeometryData)
+			if err != nil {
+				return nil, err
+			}
+			nodes[name] = append(nodes[name], n)
+		}
+	}
+	for _, name := range names {
+		fc := objects[name]
+		object := &Geometry{Type: "GeometryCollection", Geometries: make([]*Geometry, len(fc.Features))}
+		for i, f := range fc.Features {
+			g := b.build(nodes[name][i])
+			g.ID = f.ID
+			if len(f.Properties) > 0 {
+				g.Properties = f.Properties
+			}
+			object.Geometries[i] = g
+		}
+		t.Objects[name] = object
+	}
+	t.Arcs = b.delta()
+	return t, nil
+}
+
+// EncodeFeatureCollection builds the topology holding fc as the object
+// name.
+func EncodeFeatureCollection(name string, fc *geom.FeatureCollection, opt *EncodeOptions) (*Topology, error) {
+	return Encode(map[string]*geom.FeatureCollection{name: fc}, opt)
+}
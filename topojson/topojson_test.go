@@ -0,0 +1,117 @@
+package topojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/flywave/go-geom"
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试相邻多边形共用弧段
+func TestSharedArcs(t *testing.T) {
+	fc := geom.NewFeatureCollection()
+	a := geom.NewPolygonFeature([][][]float64{{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}})
+	a.ID = "a"
+	a.Properties["name"] = "A"
+	fc.AddFeature(a)
+	fc.AddFeature(geom.NewPolygonFeature([][][]float64{{{1, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 0}}}))
+
+	topo, err := EncodeFeatureCollection("squares", fc, &EncodeOptions{})
+	assert.NoError(t, err)
+	assert.Nil(t, topo.Transform)
+	assert.Equal(t, []float64{0, 0, 2, 1}, topo.BoundingBox)
+	assert.Len(t, topo.Arcs, 3)
+	o := topo.Objects["squares"]
+	assert.Equal(t, [][]int{{0, 1}}, o.Geometries[0].Polygon)
+	assert.Equal(t, [][]int{{2, ^0}}, o.Geometries[1].Polygon)
+	assert.Equal(t, [][]float64{{1, 0}, {1, 1}}, topo.Arcs[0])
+
+	back, err := topo.FeatureCollection("squares")
+	assert.NoError(t, err)
+	assert.Equal(t, "a", back.Features[0].ID)
+	assert.Equal(t, "A", back.Features[0].Properties["name"])
+	assert.Equal(t, [][][]float64{{{1, 0}, {1, 1}, {0, 1}, {0, 0}, {1, 0}}}, back.Features[0].GeometryData.Polygon)
+	assert.Equal(t, [][][]float64{{{1, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 0}}}, back.Features[1].GeometryData.Polygon)
+	assert.Equal(t, [][]int{{2, ^0}}, back.Features[1].ExtData[geom.EXT_TOPO])
+
+	_, err = topo.FeatureCollection("none")
+	assert.Error(t, err)
+}
+
+// 测试重叠的线和相同的环
+func TestLinesAndRings(t *testing.T) {
+	lines := geom.NewFeatureCollection()
+	lines.AddFeature(geom.NewLineStringFeature([][]float64{{0, 0}, {1, 0}, {2, 0}}))
+	lines.AddFeature(geom.NewLineStringFeature([][]float64{{3, 0}, {2, 0}, {1, 0}}))
+
+	polygons := geom.NewFeatureCollection()
+	polygons.AddFeature(geom.NewPolygonFeature([][][]float64{
+		{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+		{{2, 2}, {2, 4}, {4, 4}, {4, 2}, {2, 2}},
+	}))
+	polygons.AddFeature(geom.NewPolygonFeature([][][]float64{{{4, 4}, {4, 2}, {2, 2}, {2, 4}, {4, 4}}}))
+
+	topo, err := Encode(map[string]*geom.FeatureCollection{"lines": lines, "polygons": polygons}, &EncodeOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, topo.Arcs, 5)
+	assert.Equal(t, []int{0, 1}, topo.Objects["lines"].Geometries[0].LineString)
+	assert.Equal(t, []int{2, ^1}, topo.Objects["lines"].Geometries[1].LineString)
+	holes := topo.Objects["polygons"].Geometries
+	assert.Equal(t, holes[0].Polygon[1], holes[1].Polygon[0])
+
+	fcs, err := topo.FeatureCollections()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]float64{{3, 0}, {2, 0}, {1, 0}}, fcs["lines"].Features[1].GeometryData.LineString)
+}
+
+// 测试量化和 JSON 往返
+func TestQuantized(t *testing.T) {
+	fc := geom.NewFeatureCollection()
+	fc.AddFeature(geom.NewPolygonFeature([][][]float64{{{100, 30}, {101.5, 30}, {101.5, 31.25}, {100, 31.25}, {100, 30}}}))
+	fc.AddFeature(geom.NewMultiPointFeature([]float64{100.25, 30.5}, []float64{101, 31}))
+	f := geom.NewFeatureFromGeometryData(geom.NewCollectionGeometryData(
+		geom.NewLineStringGeometryData([][]float64{{100, 30}, {101.5, 31.25}}),
+		geom.NewPointGeometryData([]float64{101.5, 30})))
+	f.ID = float64(3)
+	fc.AddFeature(f)
+	fc.AddFeature(&geom.Feature{Type: "Feature", Properties: map[string]interface{}{"empty": true}})
+
+	topo, err := EncodeFeatureCollection("shapes", fc, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, topo.Transform)
+
+	var buf bytes.Buffer
+	assert.NoError(t, topo.Write(&buf))
+	var raw map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &raw))
+	assert.Equal(t, "Topology", raw["type"])
+	geoms := raw["objects"].(map[string]interface{})["shapes"].(map[string]interface{})["geometries"].([]interface{})
+	assert.Nil(t, geoms[3].(map[string]interface{})["type"])
+	// 差分编码的弧段
+	assert.Equal(t, []interface{}{0.0, 0.0}, raw["arcs"].([]interface{})[0].([]interface{})[0])
+
+	topo, err = Decode(&buf)
+	assert.NoError(t, err)
+	back, err := topo.FeatureCollection("shapes")
+	assert.NoError(t, err)
+	assert.Len(t, back.Features, 4)
+	for i, p := range back.Features[0].GeometryData.Polygon[0] {
+		assert.InDeltaSlice(t, fc.Features[0].GeometryData.Polygon[0][i], p, 1e-4)
+	}
+	assert.InDeltaSlice(t, []float64{100.25, 30.5}, back.Features[1].GeometryData.MultiPoint[0], 1e-4)
+	assert.Equal(t, float64(3), back.Features[2].ID)
+	assert.Equal(t, geom.GeometryCollection, back.Features[2].GeometryData.Type)
+	assert.InDeltaSlice(t, []float64{101.5, 31.25}, back.Features[2].GeometryData.Geometries[0].LineString[1], 1e-4)
+	assert.Equal(t, geom.GeometryType(""), back.Features[3].GeometryData.Type)
+	assert.Equal(t, true, back.Features[3].Properties["empty"])
+
+	_, err = Unmarshal([]byte(`{"type":"FeatureCollection"}`))
+	assert.Error(t, err)
+	_, err = Unmarshal([]byte(`{"type":"Topology","objects":{"a":{"type":"Circle"}},"arcs":[]}`))
+	assert.Error(t, err)
+	topo, _ = Unmarshal([]byte(`{"type":"Topology","objects":{"a":{"type":"LineString","arcs":[3]}},"arcs":[]}`))
+	_, err = topo.FeatureCollection("a")
+	assert.Error(t, err)
+}
@@ -0,0 +1,174 @@
+package topojson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Transform maps the quantized positions of a topology back to their
+// coordinates.
+type Transform struct {
+	Scale     [2]float64 `json:"scale"`
+	Translate [2]float64 `json:"translate"`
+}
+
+func (t *Transform) position(x, y float64) []float64 {
+	if t == nil {
+		return []float64{x, y}
+	}
+	return []float64{x*t.Scale[0] + t.Translate[0], y*t.Scale[1] + t.Translate[1]}
+}
+
+// Geometry is an object of a topology. Lines and polygons hold the indexes
+// of their arcs, ^i standing for the arc i reversed.
+type Geometry struct {
+	Type        string
+	ID          interface{}
+	Properties  map[string]interface{}
+	BoundingBox []float64
+
+	Point           []float64
+	MultiPoint      [][]float64
+	LineString      []int
+	MultiLineString [][]int
+	Polygon         [][]int
+	MultiPolygon    [][][]int
+	Geometries      []*Geometry
+}
+
+// Arcs returns the arc indexes of g, nil for points and collections.
+func (g *Geometry) Arcs() interface{} {
+	switch g.Type {
+	case "LineString":
+		return g.LineString
+	case "MultiLineString":
+		return g.MultiLineString
+	case "Polygon":
+		return g.Polygon
+	case "MultiPolygon":
+		return g.MultiPolygon
+	}
+	return nil
+}
+
+func (g *Geometry) MarshalJSON() ([]byte, error) {
+	type geometry struct {
+		Type        *string                `json:"type"`
+		ID          interface{}            `json:"id,omitempty"`
+		Properties  map[string]interface{} `json:"properties,omitempty"`
+		BoundingBox []float64              `json:"bbox,omitempty"`
+		Coordinates interface{}            `json:"coordinates,omitempty"`
+		Arcs        interface{}            `json:"arcs,omitempty"`
+		Geometries  []*Geometry            `json:"geometries,omitempty"`
+	}
+	geo := &geometry{ID: g.ID, Properties: g.Properties, BoundingBox: g.BoundingBox, Arcs: g.Arcs()}
+	// 空几何的类型为 null
+	if g.Type != "" {
+		geo.Type = &g.Type
+	}
+	switch g.Type {
+	case "Point":
+		geo.Coordinates = g.Point
+	case "MultiPoint":
+		geo.Coordinates = g.MultiPoint
+	case "GeometryCollection":
+		geo.Geometries = g.Geometries
+		if geo.Geometries == nil {
+			geo.Geometries = []*Geometry{}
+		}
+	}
+	return json.Marshal(geo)
+}
+
+func (g *Geometry) UnmarshalJSON(data []byte) error {
+	var geo struct {
+		Type        *string                `json:"type"`
+		ID          interface{}            `json:"id"`
+		Properties  map[string]interface{} `json:"properties"`
+		BoundingBox []float64              `json:"bbox"`
+		Coordinates json.RawMessage        `json:"coordinates"`
+		Arcs        json.RawMessage        `json:"arcs"`
+		Geometries  []*Geometry            `json:"geometries"`
+	}
+	if err := json.Unmarshal(data, &geo); err != nil {
+		return err
+	}
+	*g = Geometry{ID: geo.ID, Properties: geo.Properties, BoundingBox: geo.BoundingBox}
+	if geo.Type == nil {
+		return nil
+	}
+	g.Type = *geo.Type
+	var v interface{}
+	switch g.Type {
+	case "Point":
+		v = &g.Point
+	case "MultiPoint":
+		v = &g.MultiPoint
+	case "LineString":
+		v = &g.LineString
+	case "MultiLineString":
+		v = &g.MultiLineString
+	case "Polygon":
+		v = &g.Polygon
+	case "MultiPolygon":
+		v = &g.MultiPolygon
+	case "GeometryCollection":
+		g.Geometries = geo.Geometries
+		return nil
+	default:
+		return fmt.Errorf("unknown topojson geometry type %s", g.Type)
+	}
+	raw := geo.Arcs
+	if g.Type == "Point" || g.Type == "MultiPoint" {
+		raw = geo.Coordinates
+	}
+	if len(raw) == 0 {
+		return fmt.Errorf("topojson %s without coordinates", g.Type)
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// Topology is a TopoJSON document, whose objects share the arcs.
+type Topology struct {
+	Type        string               `json:"type"`
+	BoundingBox []float64            `json:"bbox,omitempty"`
+	Transform   *Transform           `json:"transform,omitempty"`
+	Objects     map[string]*Geometry `json:"objects"`
+	// Arcs are the shared lines, delta encoded when the topology is
+	// quantized.
+	Arcs [][][]float64 `json:"arcs"`
+}
+
+func NewTopology() *Topology {
+	return &Topology{Type: "Topology", Objects: make(map[string]*Geometry), Arcs: [][][]float64{}}
+}
+
+// Decode reads a TopoJSON document.
+func Decode(r io.Reader) (*Topology, error) {
+	t := &Topology{}
+	if err := json.NewDecoder(r).Decode(t); err != nil {
+		return nil, err
+	}
+	if t.Type != "Topology" {
+		return nil, fmt.Errorf("not a topojson topology: %q", t.Type)
+	}
+	return t, nil
+}
+
+// Unmarshal decodes the TopoJSON document of data.
+func Unmarshal(data []byte) (*Topology, error) {
+	t := &Topology{}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, err
+	}
+	if t.Type != "Topology" {
+		return nil, fmt.Errorf("not a topojson topology: %q", t.Type)
+	}
+	return t, nil
+}
+
+// Write writes t as JSON to w.
+func (t *Topology) Write(w io.Writer) error {
+	return json.NewEncoder(w).Encode(t)
+}
@@ -0,0 +1,150 @@
+package valid
+
+import (
+	"math"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/internal/topology"
+	"github.com/flywave/go-geom/overlay"
+)
+
+// MakeValid returns a valid geometry covering the same area as g, valid
+// geometries are returned as they are.
+//
+// Each ring is rebuilt from its noded linework with the even-odd rule, so a
+// self-intersecting ring becomes several polygons while spikes, repeated
+// points and collapsed parts disappear and unclosed rings get closed. The
+// holes of each polygon are then removed from its shell and the polygons
+// merged. Lines and points lose their invalid coordinates and repeated
+// points. Repaired polygons are 2D.
+func MakeValid(g *geom.GeometryData) *geom.GeometryData {
+	if g == nil || IsValid(g) {
+		return g
+	}
+	res := *g
+	switch g.Type {
+	case geom.GeometryPoint:
+		if !finite(g.Point) {
+			return emptyLike(g)
+		}
+	case geom.GeometryMultiPoint:
+		res.MultiPoint = make([][]float64, 0, len(g.MultiPoint))
+		for _, p := range g.MultiPoint {
+			if finite(p) {
+				res.MultiPoint = append(res.MultiPoint, p)
+			}
+		}
+	case geom.GeometryLineString:
+		l := cleanPath(g.LineString)
+		switch len(l) {
+		case 0:
+			return emptyLike(g)
+		case 1:
+			res.Type, res.LineString, res.Point = geom.GeometryPoint, nil, l[0]
+		default:
+			res.LineString = l
+		}
+	case geom.GeometryMultiLineString:
+		res.MultiLineString = make([][][]float64, 0, len(g.MultiLineString))
+		for _, l := range g.MultiLineString {
+			if l = cleanPath(l); len(l) > 1 {
+				res.MultiLineString = append(res.MultiLineString, l)
+			}
+		}
+	case geom.GeometryPolygon:
+		return makeValidPolygons([][][][]float64{g.Polygon}, g)
+	case geom.GeometryMultiPolygon:
+		return makeValidPolygons(g.MultiPolygon, g)
+	case geom.GeometryCollection:
+		res.Geometries = make([]*geom.GeometryData, len(g.Geometries))
+		for i, c := range g.Geometries {
+			res.Geometries[i] = MakeValid(c)
+		}
+	}
+	return &res
+}
+
+func makeValidPolygons(polygons [][][][]float64, g *geom.GeometryData) *geom.GeometryData {
+	parts := make([]*geom.GeometryData, 0, len(polygons))
+	for _, p := range polygons {
+		if len(p) == 0 {
+			continue
+		}
+		shell := ringArea(p[0])
+		if len(shell) == 0 {
+			continue
+		}
+		area := geom.NewMultiPolygonGeometryData(shell...)
+		var holes [][][][]float64
+		for _, h := range p[1:] {
+			holes = append(holes, ringArea(h)...)
+		}
+		if len(holes) > 0 {
+			area = overlay.Difference(area, overlay.UnaryUnion(geom.NewMultiPolygonGeometryData(holes...)))
+		}
+		parts = append(parts, area)
+	}
+	ret := overlay.UnaryUnion(geom.NewCollectionGeometryData(parts...))
+	ret.EPSG = g.EPSG
+	return ret
+}
+
+// ringArea returns the polygons enclosed by the ring under the even-odd
+// rule.
+func ringArea(r [][]float64) [][][][]float64 {
+	r = cleanPath(r)
+	g := topology.New(geom.NewPolygonGeometryData([][][]float64{r}), nil)
+	// 单个环的线网是连通的
+	outer := g.OuterCycle()
+	if outer == nil {
+		return nil
+	}
+	odd := map[*topology.Cycle]bool{}
+	seen := map[*topology.Cycle]bool{outer: true}
+	queue := []*topology.Cycle{outer}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		c.Walk(func(h *topology.HalfEdge) {
+			if t := h.Twin.Cycle; !seen[t] {
+				seen[t] = true
+				odd[t] = odd[c] != (h.Winding(0)%2 != 0)
+				queue = append(queue, t)
+			}
+		})
+	}
+	return g.Polygons(func(c *topology.Cycle) bool { return odd[c] })
+}
+
+func cleanPath(path [][]float64) [][]float64 {
+	ret := make([][]float64, 0, len(path))
+	for _, p := range path {
+		if !finite(p) {
+			continue
+		}
+		if n := len(ret); n > 0 && samePoint(ret[n-1], p) {
+			continue
+		}
+		ret = append(ret, p)
+	}
+	return ret
+}
+
+func finite(p []float64) bool {
+	if len(p) < 2 {
+		return false
+	}
+	for _, v := range p {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func emptyLike(g *geom.GeometryData) *geom.GeometryData {
+	ret := geom.NewCollectionGeometryData()
+	ret.Geometries = []*geom.GeometryData{}
+	ret.EPSG = g.EPSG
+	return ret
+}
@@ -0,0 +1,353 @@
+package valid
+
+import (
+	"math"
+	"sort"
+
+	"github.com/flywave/go-geom/internal/topology"
+)
+
+// ring is a closed ring without repeated points, index maps its vertices
+// back to the input coordinates.
+type ring struct {
+	id    int
+	pts   [][]float64
+	index []int
+	box   [4]float64
+}
+
+func newRing(id int, pts [][]float64, index []int) *ring {
+	return &ring{id: id, pts: pts, index: index, box: pathBox(pts)}
+}
+
+// segs returns the number of segments of the ring.
+func (r *ring) segs() int {
+	return len(r.pts) - 1
+}
+
+// prev and next walk the ring vertices, skipping the closing point.
+func (r *ring) prev(i int) int {
+	if i == 0 {
+		return r.segs() - 1
+	}
+	return i - 1
+}
+
+func (r *ring) next(i int) int {
+	if i+1 >= r.segs() {
+		return 0
+	}
+	return i + 1
+}
+
+// checkRing reports spikes and self-intersections, it returns false when
+// the ring is not simple.
+func (c *checker) checkRing(r *ring) bool {
+	ok := true
+	n := r.segs()
+	for i := 0; i < n; i++ {
+		a, b, d := r.pts[r.prev(i)], r.pts[i], r.pts[r.next(i)]
+		if orient(a, b, d) == 0 && (b[0]-a[0])*(d[0]-b[0])+(b[1]-a[1])*(d[1]-b[1]) < 0 {
+			c.report(Spike, r.id, r.index[i], b)
+			ok = false
+		}
+	}
+	if !ok {
+		return false
+	}
+	adjacent := func(i, j int) bool {
+		return j == i+1 || i == j+1 || (i == 0 && j == n-1) || (j == 0 && i == n-1)
+	}
+	sweep([]*ring{r}, func(s, t segRef) bool {
+		if adjacent(s.i, t.i) {
+			return true
+		}
+		if kind, pt := intersect(s.start(), s.end(), t.start(), t.end()); kind != noIntersection {
+			later := t
+			if s.i > t.i {
+				later = s
+			}
+			c.report(SelfIntersection, r.id, r.index[later.vertex(pt)], pt)
+			ok = false
+			return false
+		}
+		return true
+	})
+	return ok
+}
+
+type touch struct {
+	ring int
+	pt   [2]float64
+}
+
+// checkRings reports rings of a polygon crossing each other, holes outside
+// the shell or inside other holes and a disconnected interior.
+func (c *checker) checkRings(rings []*ring) {
+	if len(rings) == 0 {
+		return
+	}
+	ok := true
+	touches := make(map[touch]bool)
+	var order []touch
+	sweep(rings, func(s, t segRef) bool {
+		if s.r == t.r {
+			return true
+		}
+		kind, pt := intersect(s.start(), s.end(), t.start(), t.end())
+		switch kind {
+		case noIntersection:
+			return true
+		case pointIntersection:
+			if !crossesAt(s, t, pt) {
+				for _, r := range [2]*ring{s.r, t.r} {
+					k := touch{ring: r.id, pt: [2]float64{pt[0], pt[1]}}
+					if !touches[k] {
+						touches[k] = true
+						order = append(order, k)
+					}
+				}
+				return true
+			}
+		}
+		later := t
+		if s.r.id > t.r.id {
+			later = s
+		}
+		c.report(RingIntersection, later.r.id, later.r.index[later.vertex(pt)], pt)
+		ok = false
+		return false
+	})
+	if !ok {
+		return
+	}
+
+	shell := rings[0]
+	holes := rings[1:]
+	for _, h := range holes {
+		pt, i := h.pointOffRing(shell)
+		if pt == nil {
+			continue
+		}
+		if !boxContains(shell.box, pt) || topology.LocateRing([2]float64{pt[0], pt[1]}, shell.pts, 0) != topology.Interior {
+			c.report(HoleOutsideShell, h.id, h.index[i], pt)
+			ok = false
+		}
+	}
+	for j, h := range holes {
+		for k, o := range holes {
+			if j == k || !boxesIntersect(h.box, o.box) {
+				continue
+			}
+			pt, i := h.pointOffRing(o)
+			if pt != nil && boxContains(o.box, pt) && topology.LocateRing([2]float64{pt[0], pt[1]}, o.pts, 0) == topology.Interior {
+				c.report(NestedHoles, h.id, h.index[i], pt)
+				ok = false
+			}
+		}
+	}
+	if !ok {
+		return
+	}
+
+	// 环与接触点组成的图中有环路时内部不连通
+	parent := make(map[interface{}]interface{})
+	var find func(x interface{}) interface{}
+	find = func(x interface{}) interface{} {
+		p, ok := parent[x]
+		if !ok || p == x {
+			parent[x] = x
+			return x
+		}
+		root := find(p)
+		parent[x] = root
+		return root
+	}
+	for _, k := range order {
+		a, b := find(k.ring), find(k.pt)
+		if a == b {
+			pt := []float64{k.pt[0], k.pt[1]}
+			r := rings[0]
+			for _, x := range rings {
+				if x.id == k.ring {
+					r = x
+				}
+			}
+			c.report(DisconnectedInterior, k.ring, r.index[r.vertexAt(pt)], pt)
+			return
+		}
+		parent[a] = b
+	}
+}
+
+// pointOffRing returns a vertex, or a segment midpoint, of r that is not
+// on o, along with the index of the vertex.
+func (r *ring) pointOffRing(o *ring) ([]float64, int) {
+	for i := 0; i < r.segs(); i++ {
+		a, b := r.pts[i], r.pts[i+1]
+		for _, pt := range [2][]float64{a, {(a[0] + b[0]) / 2, (a[1] + b[1]) / 2}} {
+			if !boxContains(o.box, pt) || topology.LocateRing([2]float64{pt[0], pt[1]}, o.pts, 0) != topology.Boundary {
+				return pt, i
+			}
+		}
+	}
+	return nil, 0
+}
+
+func (r *ring) vertexAt(pt []float64) int {
+	for i, p := range r.pts {
+		if samePoint(p, pt) {
+			return i
+		}
+	}
+	return 0
+}
+
+// crossesAt tells if the rings of s and t cross at pt rather than touch.
+func crossesAt(s, t segRef, pt []float64) bool {
+	sa, sb := s.around(pt)
+	ta, tb := t.around(pt)
+	angle := func(p []float64) float64 {
+		return math.Atan2(p[1]-pt[1], p[0]-pt[0])
+	}
+	from, to := angle(sa), angle(sb)
+	inside := func(p []float64) bool {
+		a := angle(p)
+		if from <= to {
+			return a > from && a < to
+		}
+		return a > from || a < to
+	}
+	return inside(ta) != inside(tb)
+}
+
+type segRef struct {
+	r *ring
+	i int
+}
+
+func (s segRef) start() []float64 { return s.r.pts[s.i] }
+func (s segRef) end() []float64   { return s.r.pts[s.i+1] }
+
+// vertex returns the index of the segment end at pt, or of its start.
+func (s segRef) vertex(pt []float64) int {
+	if samePoint(pt, s.end()) {
+		return s.i + 1
+	}
+	return s.i
+}
+
+// around returns the ring vertices just before and after pt, which lies on
+// the segment.
+func (s segRef) around(pt []float64) ([]float64, []float64) {
+	switch {
+	case samePoint(pt, s.start()):
+		return s.r.pts[s.r.prev(s.i)], s.end()
+	case samePoint(pt, s.end()):
+		return s.start(), s.r.pts[s.r.next(s.r.next(s.i))]
+	}
+	return s.start(), s.end()
+}
+
+// sweep calls fn for the pairs of segments with intersecting boxes until
+// fn returns false.
+func sweep(rings []*ring, fn func(s, t segRef) bool) {
+	var segs []segRef
+	for _, r := range rings {
+		for i := 0; i < r.segs(); i++ {
+			segs = append(segs, segRef{r: r, i: i})
+		}
+	}
+	minX := func(s segRef) float64 { return math.Min(s.start()[0], s.end()[0]) }
+	sort.Slice(segs, func(i, j int) bool { return minX(segs[i]) < minX(segs[j]) })
+	for i, s := range segs {
+		sx := math.Max(s.start()[0], s.end()[0])
+		sy0, sy1 := math.Min(s.start()[1], s.end()[1]), math.Max(s.start()[1], s.end()[1])
+		for _, t := range segs[i+1:] {
+			if minX(t) > sx {
+				break
+			}
+			if math.Max(t.start()[1], t.end()[1]) < sy0 || math.Min(t.start()[1], t.end()[1]) > sy1 {
+				continue
+			}
+			if !fn(s, t) {
+				return
+			}
+		}
+	}
+}
+
+type intersection int
+
+const (
+	noIntersection intersection = iota
+	pointIntersection
+	collinearIntersection
+)
+
+func intersect(p1, p2, q1, q2 []float64) (intersection, []float64) {
+	d1, d2 := orient(p1, p2, q1), orient(p1, p2, q2)
+	d3, d4 := orient(q1, q2, p1), orient(q1, q2, p2)
+	if (d1 > 0 && d2 > 0) || (d1 < 0 && d2 < 0) || (d3 > 0 && d4 > 0) || (d3 < 0 && d4 < 0) {
+		return noIntersection, nil
+	}
+	if d1 == 0 && d2 == 0 {
+		dx, dy := p2[0]-p1[0], p2[1]-p1[1]
+		t := func(p []float64) float64 { return (p[0]-p1[0])*dx + (p[1]-p1[1])*dy }
+		tq1, tq2 := t(q1), t(q2)
+		lo, hi := math.Max(0, math.Min(tq1, tq2)), math.Min(t(p2), math.Max(tq1, tq2))
+		switch {
+		case lo < hi:
+			l := dx*dx + dy*dy
+			return collinearIntersection, []float64{p1[0] + lo/l*dx, p1[1] + lo/l*dy}
+		case lo == hi:
+			for _, p := range [4][]float64{p1, p2, q1, q2} {
+				if t(p) == lo {
+					return pointIntersection, p
+				}
+			}
+		}
+		return noIntersection, nil
+	}
+	switch {
+	case d1 == 0:
+		return pointIntersection, q1
+	case d2 == 0:
+		return pointIntersection, q2
+	case d3 == 0:
+		return pointIntersection, p1
+	case d4 == 0:
+		return pointIntersection, p2
+	}
+	rx, ry := p2[0]-p1[0], p2[1]-p1[1]
+	sx, sy := q2[0]-q1[0], q2[1]-q1[1]
+	u := ((q1[0]-p1[0])*sy - (q1[1]-p1[1])*sx) / (rx*sy - ry*sx)
+	return pointIntersection, []float64{p1[0] + u*rx, p1[1] + u*ry}
+}
+
+func orient(a, b, c []float64) float64 {
+	return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+}
+
+func samePoint(a, b []float64) bool {
+	return a[0] == b[0] && a[1] == b[1]
+}
+
+func pathBox(path [][]float64) [4]float64 {
+	box := [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for _, p := range path {
+		box[0] = math.Min(box[0], p[0])
+		box[1] = math.Min(box[1], p[1])
+		box[2] = math.Max(box[2], p[0])
+		box[3] = math.Max(box[3], p[1])
+	}
+	return box
+}
+
+func boxesIntersect(a, b [4]float64) bool {
+	return a[0] <= b[2] && b[0] <= a[2] && a[1] <= b[3] && b[1] <= a[3]
+}
+
+func boxContains(box [4]float64, pt []float64) bool {
+	return pt[0] >= box[0] && pt[0] <= box[2] && pt[1] >= box[1] && pt[1] <= box[3]
+}
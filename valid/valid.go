@@ -0,0 +1,266 @@
+// Package valid checks geometries against the OGC simple features rules and
+// repairs invalid polygons.
+package valid
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/relate"
+)
+
+type Kind int
+
+const (
+	InvalidCoordinate Kind = iota
+	TooFewPoints
+	RingNotClosed
+	RepeatedPoint
+	Spike
+	SelfIntersection
+	RingIntersection
+	HoleOutsideShell
+	NestedHoles
+	DisconnectedInterior
+	OverlappingPolygons
+)
+
+var kindNames = map[Kind]string{
+	InvalidCoordinate:    "invalid coordinate",
+	TooFewPoints:         "too few points",
+	RingNotClosed:        "ring not closed",
+	RepeatedPoint:        "repeated point",
+	Spike:                "spike",
+	SelfIntersection:     "self-intersection",
+	RingIntersection:     "ring intersection",
+	HoleOutsideShell:     "hole outside shell",
+	NestedHoles:          "nested holes",
+	DisconnectedInterior: "disconnected interior",
+	OverlappingPolygons:  "overlapping polygons",
+}
+
+func (k Kind) String() string {
+	if s, ok := kindNames[k]; ok {
+		return s
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Error locates a defect. Geometry is the index in a GeometryCollection,
+// Part the index of the line or polygon in a multi geometry, Ring the ring
+// of the polygon with the shell at 0 and Vertex the position in the
+// coordinates. Indexes that do not apply are -1.
+type Error struct {
+	Kind     Kind
+	Geometry int
+	Part     int
+	Ring     int
+	Vertex   int
+	Point    []float64
+}
+
+func (e *Error) Error() string {
+	s := e.Kind.String()
+	for _, f := range []struct {
+		name  string
+		value int
+	}{{"geometry", e.Geometry}, {"part", e.Part}, {"ring", e.Ring}, {"vertex", e.Vertex}} {
+		if f.value >= 0 {
+			s += fmt.Sprintf(" %s %d", f.name, f.value)
+		}
+	}
+	if len(e.Point) >= 2 {
+		s += fmt.Sprintf(" at (%g %g)", e.Point[0], e.Point[1])
+	}
+	return s
+}
+
+func IsValid(g *geom.GeometryData) bool {
+	return ValidationReason(g) == nil
+}
+
+// ValidationReason returns the first defect of g, nil when it is valid.
+func ValidationReason(g *geom.GeometryData) *Error {
+	errs := Validate(g)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs[0]
+}
+
+// Validate returns the defects found in g. Polygons with broken rings are
+// not checked further, so fixing the reported defects can reveal others.
+func Validate(g *geom.GeometryData) []*Error {
+	if g == nil {
+		return nil
+	}
+	c := &checker{geometry: -1}
+	c.check(g)
+	return c.errs
+}
+
+type checker struct {
+	errs     []*Error
+	geometry int
+	part     int
+}
+
+func (c *checker) report(kind Kind, ring, vertex int, pt []float64) {
+	c.errs = append(c.errs, &Error{Kind: kind, Geometry: c.geometry, Part: c.part, Ring: ring, Vertex: vertex, Point: pt})
+}
+
+func (c *checker) check(g *geom.GeometryData) {
+	c.part = -1
+	switch g.Type {
+	case geom.GeometryPoint:
+		c.checkCoordinate(g.Point, -1, -1)
+	case geom.GeometryMultiPoint:
+		for i, p := range g.MultiPoint {
+			c.part = i
+			c.checkCoordinate(p, -1, -1)
+		}
+	case geom.GeometryLineString:
+		c.checkLine(g.LineString)
+	case geom.GeometryMultiLineString:
+		for i, l := range g.MultiLineString {
+			c.part = i
+			c.checkLine(l)
+		}
+	case geom.GeometryPolygon:
+		c.checkPolygon(g.Polygon)
+	case geom.GeometryMultiPolygon:
+		c.checkMultiPolygon(g.MultiPolygon)
+	case geom.GeometryCollection:
+		top := c.geometry < 0
+		for i, sub := range g.Geometries {
+			if sub == nil {
+				continue
+			}
+			if top {
+				c.geometry = i
+			}
+			c.check(sub)
+		}
+		if top {
+			c.geometry = -1
+		}
+	}
+}
+
+func (c *checker) checkCoordinate(p []float64, ring, vertex int) bool {
+	if len(p) < 2 {
+		c.report(InvalidCoordinate, ring, vertex, nil)
+		return false
+	}
+	for _, v := range p {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			c.report(InvalidCoordinate, ring, vertex, p)
+			return false
+		}
+	}
+	return true
+}
+
+func (c *checker) checkCoordinates(path [][]float64, ring int) bool {
+	ok := true
+	for i, p := range path {
+		if !c.checkCoordinate(p, ring, i) {
+			ok = false
+		}
+	}
+	return ok
+}
+
+// checkRepeated reports consecutive duplicates and returns the path
+// without them along with the original index of each kept vertex.
+func (c *checker) checkRepeated(path [][]float64, ring int) ([][]float64, []int) {
+	pts := make([][]float64, 0, len(path))
+	index := make([]int, 0, len(path))
+	for i, p := range path {
+		if n := len(pts); n > 0 && samePoint(pts[n-1], p) {
+			c.report(RepeatedPoint, ring, i, p)
+			continue
+		}
+		pts = append(pts, p)
+		index = append(index, i)
+	}
+	return pts, index
+}
+
+func (c *checker) checkLine(l [][]float64) {
+	if !c.checkCoordinates(l, -1) {
+		return
+	}
+	pts, _ := c.checkRepeated(l, -1)
+	if len(pts) < 2 {
+		c.report(TooFewPoints, -1, -1, nil)
+	}
+}
+
+func (c *checker) checkMultiPolygon(mp [][][][]float64) {
+	ok := true
+	for i, p := range mp {
+		c.part = i
+		n := len(c.errs)
+		c.checkPolygon(p)
+		if len(c.errs) > n {
+			ok = false
+		}
+	}
+	if !ok {
+		return
+	}
+	boxes := make([][4]float64, len(mp))
+	for i, p := range mp {
+		if len(p) > 0 {
+			boxes[i] = pathBox(p[0])
+		} else {
+			boxes[i] = pathBox(nil)
+		}
+	}
+	for j := 1; j < len(mp); j++ {
+		for i := 0; i < j; i++ {
+			if !boxesIntersect(boxes[i], boxes[j]) {
+				continue
+			}
+			m := relate.Relate(geom.NewPolygonGeometryData(mp[i]), geom.NewPolygonGeometryData(mp[j]))
+			if m[relate.Interior][relate.Interior] >= 0 || m[relate.Boundary][relate.Boundary] == 1 {
+				c.part = j
+				c.report(OverlappingPolygons, -1, -1, nil)
+			}
+		}
+	}
+}
+
+func (c *checker) checkPolygon(p [][][]float64) {
+	if len(p) == 0 {
+		return
+	}
+	rings := make([]*ring, 0, len(p))
+	ok := true
+	for i, r := range p {
+		if !c.checkCoordinates(r, i) {
+			ok = false
+			continue
+		}
+		if len(r) > 0 && !samePoint(r[0], r[len(r)-1]) {
+			c.report(RingNotClosed, i, len(r)-1, r[len(r)-1])
+			r = append(r[:len(r):len(r)], r[0])
+		}
+		pts, index := c.checkRepeated(r, i)
+		if len(pts) < 4 {
+			c.report(TooFewPoints, i, -1, nil)
+			ok = false
+			continue
+		}
+		rg := newRing(i, pts, index)
+		if !c.checkRing(rg) {
+			ok = false
+		}
+		rings = append(rings, rg)
+	}
+	if ok {
+		c.checkRings(rings)
+	}
+}
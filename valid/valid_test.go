@@ -0,0 +1,135 @@
+package valid
+
+import (
+	"math"
+	"testing"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/measure"
+	"github.com/flywave/go-geom/relate"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	shell = [][]float64{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}
+	hole  = [][]float64{{2, 2}, {2, 4}, {4, 4}, {4, 2}, {2, 2}}
+)
+
+func polygon(rings ...[][]float64) *geom.GeometryData {
+	return geom.NewPolygonGeometryData(rings)
+}
+
+func assertReason(t *testing.T, g *geom.GeometryData, kind Kind, ring, vertex int) *Error {
+	err := ValidationReason(g)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, kind, err.Kind, err.Error())
+		assert.Equal(t, ring, err.Ring, err.Error())
+		assert.Equal(t, vertex, err.Vertex, err.Error())
+	}
+	assert.False(t, IsValid(g))
+	return err
+}
+
+func TestValid(t *testing.T) {
+	assert.True(t, IsValid(polygon(shell, hole)))
+	assert.True(t, IsValid(geom.NewLineStringGeometryData([][]float64{{0, 0}, {1, 1}, {0, 0}})))
+	assert.True(t, IsValid(geom.NewPointGeometryData([]float64{1, 2, 3})))
+	assert.Nil(t, ValidationReason(nil))
+
+	// 洞与外环在一点相接是有效的
+	assert.True(t, IsValid(polygon(shell, [][]float64{{0, 0}, {2, 1}, {1, 2}, {0, 0}})))
+	// 多边形在一点相接是有效的
+	assert.True(t, IsValid(geom.NewMultiPolygonGeometryData(
+		[][][]float64{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}},
+		[][][]float64{{{1, 1}, {2, 1}, {2, 2}, {1, 1}}},
+	)))
+}
+
+func TestInvalidRings(t *testing.T) {
+	err := assertReason(t, polygon([][]float64{{0, 0}, {10, 0}, {10, 10}, {0, 10}}), RingNotClosed, 0, 3)
+	assert.Equal(t, "ring not closed ring 0 vertex 3 at (0 10)", err.Error())
+
+	assertReason(t, polygon([][]float64{{0, 0}, {10, 0}, {10, 0}, {10, 10}, {0, 0}}), RepeatedPoint, 0, 2)
+	assertReason(t, polygon([][]float64{{0, 0}, {10, 0}, {0, 0}}), TooFewPoints, 0, -1)
+	assertReason(t, polygon([][]float64{{0, 0}, {1, 0}, {math.NaN(), 1}, {0, 0}}), InvalidCoordinate, 0, 2)
+
+	err = assertReason(t, polygon([][]float64{{0, 0}, {2, 2}, {2, 0}, {0, 2}, {0, 0}}), SelfIntersection, 0, 2)
+	assert.Equal(t, []float64{1, 1}, err.Point)
+
+	// 尖刺
+	err = assertReason(t, polygon([][]float64{{0, 0}, {10, 0}, {15, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}), Spike, 0, 2)
+	assert.Equal(t, []float64{15, 0}, err.Point)
+
+	// 环自相接
+	assertReason(t, polygon([][]float64{{0, 0}, {10, 0}, {5, 5}, {10, 10}, {0, 10}, {5, 5}, {0, 0}}), SelfIntersection, 0, 5)
+
+	errs := Validate(polygon([][]float64{{0, 0}, {10, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 10}, {0, 0}}))
+	assert.Len(t, errs, 2)
+}
+
+func TestInvalidPolygons(t *testing.T) {
+	assertReason(t, polygon(shell, [][]float64{{20, 20}, {21, 20}, {21, 21}, {20, 20}}), HoleOutsideShell, 1, 0)
+	assertReason(t, polygon(shell, [][]float64{{8, 8}, {12, 8}, {12, 9}, {8, 8}}), RingIntersection, 1, 0)
+	assertReason(t, polygon(shell, [][]float64{{1, 1}, {9, 1}, {9, 9}, {1, 9}, {1, 1}}, hole), NestedHoles, 2, 0)
+
+	// 洞沿边与外环重合
+	assertReason(t, polygon(shell, [][]float64{{0, 2}, {2, 2}, {2, 4}, {0, 4}, {0, 2}}), RingIntersection, 1, 3)
+
+	// 洞与外环两点相接, 内部不连通
+	err := assertReason(t, polygon(shell, [][]float64{{0, 0}, {5, 2}, {10, 0}, {5, 4}, {0, 0}}), DisconnectedInterior, 1, 2)
+	assert.NotNil(t, err.Point)
+
+	mp := geom.NewMultiPolygonGeometryData([][][]float64{shell}, [][][]float64{{{5, 5}, {15, 5}, {15, 15}, {5, 15}, {5, 5}}})
+	err = ValidationReason(mp)
+	assert.Equal(t, OverlappingPolygons, err.Kind)
+	assert.Equal(t, 1, err.Part)
+
+	// 共边的多边形
+	assert.False(t, IsValid(geom.NewMultiPolygonGeometryData([][][]float64{shell}, [][][]float64{{{10, 0}, {20, 0}, {20, 10}, {10, 10}, {10, 0}}})))
+
+	col := geom.NewCollectionGeometryData(polygon(shell), geom.NewLineStringGeometryData([][]float64{{0, 0}}))
+	err = ValidationReason(col)
+	assert.Equal(t, TooFewPoints, err.Kind)
+	assert.Equal(t, 1, err.Geometry)
+	assert.Equal(t, "too few points geometry 1", err.Error())
+}
+
+func TestMakeValid(t *testing.T) {
+	ok := polygon(shell, hole)
+	assert.Same(t, ok, MakeValid(ok))
+
+	bowtie := MakeValid(polygon([][]float64{{0, 0}, {2, 2}, {2, 0}, {0, 2}, {0, 0}}))
+	assert.Equal(t, geom.GeometryMultiPolygon, bowtie.Type)
+	assert.Len(t, bowtie.MultiPolygon, 2)
+	assert.Equal(t, 2.0, measure.AreaData(bowtie))
+
+	cases := []struct {
+		input, want *geom.GeometryData
+	}{
+		{polygon([][]float64{{0, 0}, {10, 0}, {15, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}), polygon(shell)},
+		{polygon([][]float64{{0, 0}, {10, 0}, {10, 0}, {10, 10}, {0, 10}}), polygon(shell)},
+		{polygon(shell, [][]float64{{20, 20}, {21, 20}, {21, 21}, {20, 20}}), polygon(shell)},
+		{polygon(shell, [][]float64{{8, 8}, {12, 8}, {12, 12}, {8, 12}, {8, 8}}), polygon([][]float64{{0, 0}, {10, 0}, {10, 8}, {8, 8}, {8, 10}, {0, 10}, {0, 0}})},
+		{geom.NewMultiPolygonGeometryData([][][]float64{shell}, [][][]float64{{{5, 5}, {15, 5}, {15, 15}, {5, 15}, {5, 5}}}),
+			polygon([][]float64{{0, 0}, {10, 0}, {10, 5}, {15, 5}, {15, 15}, {5, 15}, {5, 10}, {0, 10}, {0, 0}})},
+		{polygon(shell, [][]float64{{1, 1}, {9, 1}, {9, 9}, {1, 9}, {1, 1}}, hole), polygon(shell, [][]float64{{1, 1}, {9, 1}, {9, 9}, {1, 9}, {1, 1}})},
+	}
+	for _, c := range cases {
+		ret := MakeValid(c.input)
+		assert.True(t, IsValid(ret), "%v", ValidationReason(ret))
+		assert.True(t, relate.Equals(c.want, ret), "%v", ret)
+	}
+
+	// 自相接的环形成洞
+	inverted := MakeValid(polygon([][]float64{{0, 0}, {10, 0}, {5, 5}, {10, 10}, {0, 10}, {5, 5}, {0, 0}}))
+	assert.True(t, IsValid(inverted))
+	assert.Equal(t, 50.0, measure.AreaData(inverted))
+
+	line := MakeValid(geom.NewLineStringGeometryData([][]float64{{0, 0}, {0, 0}, {math.Inf(1), 0}}))
+	assert.Equal(t, geom.GeometryPoint, line.Type)
+	assert.Equal(t, []float64{0, 0}, line.Point)
+
+	empty := MakeValid(polygon([][]float64{{0, 0}, {1, 1}, {0, 0}}))
+	assert.Equal(t, geom.GeometryCollection, empty.Type)
+	assert.Len(t, empty.Geometries, 0)
+}
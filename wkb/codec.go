@@ -0,0 +1,87 @@
+package wkb
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	geom_ "github.com/flywave/go-geom"
+)
+
+// Flavor is a variant of the Well-Known Binary format.
+type Flavor int
+
+const (
+	// EWKB is the PostGIS extended WKB, flagging Z, M and SRID in the high
+	// bits of the type code.
+	EWKB Flavor = iota
+	// ISO is the SQL/MM WKB adding 1000, 2000 and 3000 to the type code for
+	// Z, M and ZM geometries.
+	ISO
+	// OGC is the WKB of the Simple Features 1.1 specification, which only
+	// has X and Y.
+	OGC
+)
+
+const (
+	wkbPoint              = 1
+	wkbLineString         = 2
+	wkbPolygon            = 3
+	wkbMultiPoint         = 4
+	wkbMultiLineString    = 5
+	wkbMultiPolygon       = 6
+	wkbGeometryCollection = 7
+
+	ewkbZ    = 0x80000000
+	ewkbM    = 0x40000000
+	ewkbSRID = 0x20000000
+)
+
+var typeCodes = map[geom_.GeometryType]uint32{
+	geom_.GeometryPoint:           wkbPoint,
+	geom_.GeometryLineString:      wkbLineString,
+	geom_.GeometryPolygon:         wkbPolygon,
+	geom_.GeometryMultiPoint:      wkbMultiPoint,
+	geom_.GeometryMultiLineString: wkbMultiLineString,
+	geom_.GeometryMultiPolygon:    wkbMultiPolygon,
+	geom_.GeometryCollection:      wkbGeometryCollection,
+}
+
+var typeNames = map[uint32]geom_.GeometryType{
+	wkbPoint:              geom_.GeometryPoint,
+	wkbLineString:         geom_.GeometryLineString,
+	wkbPolygon:            geom_.GeometryPolygon,
+	wkbMultiPoint:         geom_.GeometryMultiPoint,
+	wkbMultiLineString:    geom_.GeometryMultiLineString,
+	wkbMultiPolygon:       geom_.GeometryMultiPolygon,
+	wkbGeometryCollection: geom_.GeometryCollection,
+}
+
+// EncodeOptions controls the output of Encode.
+type EncodeOptions struct {
+	ByteOrder binary.ByteOrder
+	Flavor    Flavor
+	// Layout of the written positions, NoLayout writes the layout of the
+	// geometry. Missing ordinates are written as 0.
+	Layout geom_.Layout
+	// SRID is written by EWKB when not 0, the EPSG of the geometry is used
+	// when SRID is -1.
+	SRID int
+}
+
+func DefaultEncodeOptions() *EncodeOptions {
+	return &EncodeOptions{
+		ByteOrder: binary.LittleEndian,
+		Flavor:    EWKB,
+		SRID:      -1,
+	}
+}
+
+// UnsupportedTypeError is returned for the WKB types other than the seven
+// simple feature types, such as curves and surfaces.
+type UnsupportedTypeError struct {
+	Code uint32
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return fmt.Sprintf("unsupported wkb geometry type %d", e.Code)
+}
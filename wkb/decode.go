@@ -0,0 +1,270 @@
+package wkb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	geom_ "github.com/flywave/go-geom"
+)
+
+// chunk bounds the positions read at once so that a corrupt count fails on
+// the end of the input instead of allocating without limit.
+const chunk = 4096
+
+// Decoder reads WKB, EWKB and ISO WKB geometries in either byte order from
+// a stream. It buffers its input and may read past the last geometry.
+type Decoder struct {
+	r     io.Reader
+	store []byte
+	pos   int
+	end   int
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r, store: make([]byte, 512)}
+}
+
+// Decode reads the next geometry, io.EOF when the input is exhausted. The
+// SRID of EWKB input goes in the EPSG field. Positions hold the ordinates
+// in their encoded order, with the Layout set when they carry M.
+func (d *Decoder) Decode() (*geom_.GeometryData, error) {
+	if d.r != nil && d.pos == d.end {
+		if _, err := d.next(1); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				err = io.EOF
+			}
+			return nil, err
+		}
+		d.pos--
+	} else if d.pos == d.end {
+		return nil, io.EOF
+	}
+	return d.geometry()
+}
+
+// Decode reads a geometry from r.
+func Decode(r io.Reader) (*geom_.GeometryData, error) {
+	g, err := NewDecoder(r).Decode()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return g, err
+}
+
+// Unmarshal decodes the geometry of data.
+func Unmarshal(data []byte) (*geom_.GeometryData, error) {
+	d := &Decoder{store: data, end: len(data)}
+	g, err := d.Decode()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return g, err
+}
+
+// next returns the n following bytes of the input, valid until the next
+// call.
+func (d *Decoder) next(n int) ([]byte, error) {
+	if d.end-d.pos >= n {
+		b := d.store[d.pos : d.pos+n]
+		d.pos += n
+		return b, nil
+	}
+	if d.r == nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	avail := d.end - d.pos
+	if n > len(d.store) {
+		store := make([]byte, 2*n)
+		copy(store, d.store[d.pos:d.end])
+		d.store = store
+	} else {
+		copy(d.store, d.store[d.pos:d.end])
+	}
+	d.pos, d.end = 0, avail
+	for d.end < n {
+		m, err := d.r.Read(d.store[d.end:])
+		d.end += m
+		if err != nil && d.end < n {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+	d.pos = n
+	return d.store[:n], nil
+}
+
+func (d *Decoder) uint32(order binary.ByteOrder) (uint32, error) {
+	b, err := d.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return order.Uint32(b), nil
+}
+
+func (d *Decoder) geometry() (*geom_.GeometryData, error) {
+	b, err := d.next(5)
+	if err != nil {
+		return nil, err
+	}
+	var order binary.ByteOrder
+	switch b[0] {
+	case 0:
+		order = binary.BigEndian
+	case 1:
+		order = binary.LittleEndian
+	default:
+		return nil, fmt.Errorf("invalid wkb byte order %d", b[0])
+	}
+	code := order.Uint32(b[1:])
+	z, m := code&ewkbZ != 0, code&ewkbM != 0
+	g := &geom_.GeometryData{}
+	if code&ewkbSRID != 0 {
+		srid, err := d.uint32(order)
+		if err != nil {
+			return nil, err
+		}
+		g.EPSG = int(int32(srid))
+	}
+	base := code & 0x0fffffff
+	switch base / 1000 {
+	case 0:
+	case 1:
+		z = true
+	case 2:
+		m = true
+	case 3:
+		z, m = true, true
+	default:
+		return nil, &UnsupportedTypeError{Code: code}
+	}
+	layout := geom_.XY
+	switch {
+	case z && m:
+		layout = geom_.XYZM
+	case z:
+		layout = geom_.XYZ
+	case m:
+		layout = geom_.XYM
+	}
+	if m {
+		g.Layout = layout
+	}
+	var ok bool
+	if g.Type, ok = typeNames[base%1000]; !ok {
+		return nil, &UnsupportedTypeError{Code: code}
+	}
+	stride := layout.Stride()
+
+	switch g.Type {
+	case geom_.GeometryPoint:
+		pts, err := d.positions(1, order, stride)
+		if err != nil {
+			return nil, err
+		}
+		if !empty(pts[0]) {
+			g.Point = pts[0]
+		}
+	case geom_.GeometryLineString:
+		g.LineString, err = d.path(order, stride)
+	case geom_.GeometryPolygon:
+		g.Polygon, err = d.paths(order, stride)
+	default:
+		var n uint32
+		if n, err = d.uint32(order); err != nil {
+			return nil, err
+		}
+		for i := 0; i < int(n); i++ {
+			c, err := d.geometry()
+			if err != nil {
+				return nil, err
+			}
+			if g.Type == geom_.GeometryCollection {
+				g.Geometries = append(g.Geometries, c)
+				continue
+			}
+			if typeCodes[c.Type] != typeCodes[g.Type]-3 {
+				return nil, fmt.Errorf("unexpected %s in wkb %s", c.Type, g.Type)
+			}
+			switch c.Type {
+			case geom_.GeometryPoint:
+				if c.Point != nil {
+					g.MultiPoint = append(g.MultiPoint, c.Point)
+				}
+			case geom_.GeometryLineString:
+				g.MultiLineString = append(g.MultiLineString, c.LineString)
+			case geom_.GeometryPolygon:
+				g.MultiPolygon = append(g.MultiPolygon, c.Polygon)
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (d *Decoder) path(order binary.ByteOrder, stride int) ([][]float64, error) {
+	n, err := d.uint32(order)
+	if err != nil {
+		return nil, err
+	}
+	return d.positions(int(n), order, stride)
+}
+
+func (d *Decoder) paths(order binary.ByteOrder, stride int) ([][][]float64, error) {
+	n, err := d.uint32(order)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([][][]float64, 0, minInt(int(n), chunk))
+	for i := 0; i < int(n); i++ {
+		p, err := d.path(order, stride)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, p)
+	}
+	return ret, nil
+}
+
+// positions reads n positions, sharing one backing array per chunk.
+func (d *Decoder) positions(n int, order binary.ByteOrder, stride int) ([][]float64, error) {
+	ret := make([][]float64, 0, minInt(n, chunk))
+	for n > 0 {
+		k := minInt(n, chunk)
+		b, err := d.next(k * stride * 8)
+		if err != nil {
+			return nil, err
+		}
+		flat := make([]float64, k*stride)
+		for i := range flat {
+			flat[i] = math.Float64frombits(order.Uint64(b[8*i:]))
+		}
+		for i := 0; i < k; i++ {
+			ret = append(ret, flat[i*stride:(i+1)*stride:(i+1)*stride])
+		}
+		n -= k
+	}
+	return ret, nil
+}
+
+// empty tells if a position is the NaN point standing for POINT EMPTY.
+func empty(p []float64) bool {
+	for _, v := range p {
+		if !math.IsNaN(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,233 @@
+package wkb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	geom_ "github.com/flywave/go-geom"
+)
+
+// Encoder writes geometries as WKB to a stream.
+type Encoder struct {
+	w   io.Writer
+	opt EncodeOptions
+	buf []byte
+}
+
+// NewEncoder returns an encoder writing to w, with DefaultEncodeOptions
+// when opt is nil.
+func NewEncoder(w io.Writer, opt *EncodeOptions) *Encoder {
+	if opt == nil {
+		opt = DefaultEncodeOptions()
+	}
+	return &Encoder{w: w, opt: *opt}
+}
+
+// Encode writes g in one call to the underlying writer.
+func (e *Encoder) Encode(g *geom_.GeometryData) error {
+	var err error
+	e.buf, err = appendGeometry(e.buf[:0], g, &e.opt)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(e.buf)
+	return err
+}
+
+// Encode writes g to w.
+func Encode(g *geom_.GeometryData, w io.Writer, opt *EncodeOptions) error {
+	return NewEncoder(w, opt).Encode(g)
+}
+
+// Marshal returns the WKB of g.
+func Marshal(g *geom_.GeometryData, opt *EncodeOptions) ([]byte, error) {
+	if opt == nil {
+		opt = DefaultEncodeOptions()
+	}
+	return appendGeometry(nil, g, opt)
+}
+
+type writer struct {
+	buf    []byte
+	big    bool
+	flavor Flavor
+	layout geom_.Layout
+	// index holds the index in the input positions of each written
+	// ordinate, -1 for the missing ones.
+	index []int
+}
+
+func appendGeometry(buf []byte, g *geom_.GeometryData, opt *EncodeOptions) ([]byte, error) {
+	if g == nil {
+		return buf, errors.New("nil geometry")
+	}
+	w := &writer{buf: buf, big: opt.ByteOrder == binary.BigEndian, flavor: opt.Flavor, layout: opt.Layout}
+	switch {
+	case w.flavor == OGC:
+		w.layout = geom_.XY
+	case w.layout == geom_.NoLayout:
+		w.layout = geom_.LayoutFromGeometryData(g)
+	}
+	srid := opt.SRID
+	if srid < 0 {
+		srid = g.EPSG
+	}
+	if w.flavor != EWKB {
+		srid = 0
+	}
+	if err := w.geometry(g, srid, geom_.NoLayout); err != nil {
+		return buf, err
+	}
+	return w.buf, nil
+}
+
+func (w *writer) uint32(v uint32) {
+	if w.big {
+		w.buf = binary.BigEndian.AppendUint32(w.buf, v)
+	} else {
+		w.buf = binary.LittleEndian.AppendUint32(w.buf, v)
+	}
+}
+
+func (w *writer) float64(v float64) {
+	if w.big {
+		w.buf = binary.BigEndian.AppendUint64(w.buf, math.Float64bits(v))
+	} else {
+		w.buf = binary.LittleEndian.AppendUint64(w.buf, math.Float64bits(v))
+	}
+}
+
+func (w *writer) header(code uint32, srid int) {
+	if w.big {
+		w.buf = append(w.buf, 0)
+	} else {
+		w.buf = append(w.buf, 1)
+	}
+	switch w.flavor {
+	case EWKB:
+		if w.layout.HasZ() {
+			code |= ewkbZ
+		}
+		if w.layout.HasM() {
+			code |= ewkbM
+		}
+		if srid != 0 {
+			code |= ewkbSRID
+		}
+	case ISO:
+		switch w.layout {
+		case geom_.XYZ:
+			code += 1000
+		case geom_.XYM:
+			code += 2000
+		case geom_.XYZM:
+			code += 3000
+		}
+	}
+	w.uint32(code)
+	if w.flavor == EWKB && srid != 0 {
+		w.uint32(uint32(int32(srid)))
+	}
+}
+
+// reorder sets the index of the written ordinates in the positions of src.
+func (w *writer) reorder(src geom_.Layout) {
+	w.index = append(w.index[:0], 0, 1)
+	if w.layout.HasZ() {
+		w.index = append(w.index, src.ZIndex())
+	}
+	if w.layout.HasM() {
+		w.index = append(w.index, src.MIndex())
+	}
+}
+
+func (w *writer) position(p []float64) {
+	for _, j := range w.index {
+		if j >= 0 && j < len(p) {
+			w.float64(p[j])
+		} else {
+			w.float64(0)
+		}
+	}
+}
+
+func (w *writer) path(path [][]float64) {
+	w.uint32(uint32(len(path)))
+	for _, p := range path {
+		w.position(p)
+	}
+}
+
+func (w *writer) paths(paths [][][]float64) {
+	w.uint32(uint32(len(paths)))
+	for _, p := range paths {
+		w.path(p)
+	}
+}
+
+func (w *writer) point(p []float64, srid int) {
+	w.header(wkbPoint, srid)
+	if len(p) < 2 {
+		for i := 0; i < w.layout.Stride(); i++ {
+			w.float64(math.NaN())
+		}
+		return
+	}
+	w.position(p)
+}
+
+// geometry writes g, a member of a collection of layout parent.
+func (w *writer) geometry(g *geom_.GeometryData, srid int, parent geom_.Layout) error {
+	if g == nil {
+		return errors.New("nil geometry")
+	}
+	code, ok := typeCodes[g.Type]
+	if !ok {
+		return fmt.Errorf("unsupported geometry type %q", g.Type)
+	}
+	if g.Type != geom_.GeometryCollection {
+		w.reorder(geom_.MemberLayout(g, parent))
+	}
+	if g.Type == geom_.GeometryPoint {
+		w.point(g.Point, srid)
+		return nil
+	}
+	w.header(code, srid)
+	switch g.Type {
+	case geom_.GeometryLineString:
+		w.path(g.LineString)
+	case geom_.GeometryPolygon:
+		w.paths(g.Polygon)
+	case geom_.GeometryMultiPoint:
+		w.uint32(uint32(len(g.MultiPoint)))
+		for _, p := range g.MultiPoint {
+			w.point(p, 0)
+		}
+	case geom_.GeometryMultiLineString:
+		w.uint32(uint32(len(g.MultiLineString)))
+		for _, l := range g.MultiLineString {
+			w.header(wkbLineString, 0)
+			w.path(l)
+		}
+	case geom_.GeometryMultiPolygon:
+		w.uint32(uint32(len(g.MultiPolygon)))
+		for _, p := range g.MultiPolygon {
+			w.header(wkbPolygon, 0)
+			w.paths(p)
+		}
+	case geom_.GeometryCollection:
+		w.uint32(uint32(len(g.Geometries)))
+		if g.Layout != geom_.NoLayout {
+			parent = g.Layout
+		}
+		for _, c := range g.Geometries {
+			if err := w.geometry(c, 0, parent); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
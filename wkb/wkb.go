@@ -5,7 +5,6 @@ import (
 	"io"
 
 	"github.com/devork/geom"
-	"github.com/devork/geom/ewkb"
 
 	geom_ "github.com/flywave/go-geom"
 )
@@ -127,9 +126,15 @@ func ConvertToGeom(g *geom_.GeometryData, srsid *uint32) (geom.Geometry, geom.Di
 	return geo, dim
 }
 
+// EncodeWKB writes g as little endian EWKB with the SRID srsid,
+// DEFAULT_SRSID when nil.
 func EncodeWKB(g *geom_.GeometryData, srsid *uint32, w io.Writer) error {
-	geo, _ := ConvertToGeom(g, srsid)
-	return ewkb.Encode(geo, w)
+	opt := DefaultEncodeOptions()
+	opt.SRID = DEFAULT_SRSID
+	if srsid != nil {
+		opt.SRID = int(*srsid)
+	}
+	return Encode(g, w, opt)
 }
 
 func ConvertFromGeom(g geom.Geometry) (*geom_.GeometryData, error) {
@@ -194,14 +199,21 @@ func ConvertFromGeom(g geom.Geometry) (*geom_.GeometryData, error) {
 	default:
 		return nil, errors.New("error not support")
 	}
+	switch g.Dimension() {
+	case geom.XYM:
+		ret.Layout = geom_.XYM
+	case geom.XYZM:
+		ret.Layout = geom_.XYZM
+	}
 	return &ret, nil
 }
 
+// DecodeWKB reads a WKB, EWKB or ISO WKB geometry and its SRID, 0 when the
+// input has none.
 func DecodeWKB(r io.Reader) (*geom_.GeometryData, uint32, error) {
-	geom, err := ewkb.Decode(r)
+	g, err := Decode(r)
 	if err != nil {
 		return nil, 0, err
 	}
-	g, err := ConvertFromGeom(geom)
-	return g, geom.SRID(), err
+	return g, uint32(g.EPSG), nil
 }
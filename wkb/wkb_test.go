@@ -2,10 +2,18 @@ package wkb
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/hex"
+	"errors"
+	"io"
+	"math"
 	"testing"
+	"testing/iotest"
 
 	"github.com/devork/geom"
+	"github.com/devork/geom/ewkb"
+	geom_ "github.com/flywave/go-geom"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestTile(t *testing.T) {
@@ -46,3 +54,271 @@ func TestTile(t *testing.T) {
 		}
 	}
 }
+
+func testGeometries() []*geom_.GeometryData {
+	return []*geom_.GeometryData{
+		geom_.NewPointGeometryData([]float64{1, 2}),
+		geom_.NewMultiPointGeometryData([]float64{1, 2}, []float64{3, 4}),
+		geom_.NewLineStringGeometryData([][]float64{{1, 2}, {3, 4}, {5, 6}}),
+		geom_.NewMultiLineStringGeometryData([][]float64{{1, 2}, {3, 4}}, [][]float64{{5, 6}, {7, 8}}),
+		geom_.NewPolygonGeometryData([][][]float64{{{0, 0}, {4, 0}, {4, 4}, {0, 0}}, {{1, 1}, {2, 1}, {2, 2}, {1, 1}}}),
+		geom_.NewMultiPolygonGeometryData([][][]float64{{{0, 0}, {4, 0}, {4, 4}, {0, 0}}}, [][][]float64{{{5, 5}, {6, 5}, {6, 6}, {5, 5}}}),
+		geom_.NewCollectionGeometryData(geom_.NewPointGeometryData([]float64{1, 2}), geom_.NewLineStringGeometryData([][]float64{{1, 2}, {3, 4}})),
+	}
+}
+
+// 测试已知的 WKB 编码
+func TestKnownEncodings(t *testing.T) {
+	pt := geom_.NewPointGeometryData([]float64{1, 2})
+	b, err := Marshal(pt, &EncodeOptions{ByteOrder: binary.LittleEndian, Flavor: OGC})
+	assert.NoError(t, err)
+	assert.Equal(t, "0101000000000000000000f03f0000000000000040", hex.EncodeToString(b))
+
+	b, _ = Marshal(pt, &EncodeOptions{ByteOrder: binary.BigEndian, Flavor: OGC})
+	assert.Equal(t, "00000000013ff00000000000004000000000000000", hex.EncodeToString(b))
+
+	pz := geom_.NewPointGeometryData([]float64{1, 2, 3})
+	pz.EPSG = 4326
+	b, _ = Marshal(pz, nil)
+	assert.Equal(t, "01010000a0e6100000000000000000f03f00000000000000400000000000000840", hex.EncodeToString(b))
+
+	b, _ = Marshal(pz, &EncodeOptions{Flavor: ISO})
+	assert.Equal(t, "01e9030000000000000000f03f00000000000000400000000000000840", hex.EncodeToString(b))
+
+	// OGC 只写 X 与 Y
+	b, _ = Marshal(pz, &EncodeOptions{Flavor: OGC, Layout: geom_.XYZ})
+	assert.Len(t, b, 21)
+
+	for _, s := range []string{
+		"01010000a0e6100000000000000000f03f00000000000000400000000000000840",
+		"01e9030000000000000000f03f00000000000000400000000000000840",
+		"00800000013ff000000000000040000000000000004008000000000000",
+	} {
+		data, _ := hex.DecodeString(s)
+		g, err := Unmarshal(data)
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{1, 2, 3}, g.Point)
+	}
+}
+
+// 测试各种格式与字节序的往返
+func TestRoundTrip(t *testing.T) {
+	for _, flavor := range []Flavor{EWKB, ISO, OGC} {
+		for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+			for _, g := range testGeometries() {
+				opt := &EncodeOptions{ByteOrder: order, Flavor: flavor, SRID: -1}
+				b, err := Marshal(g, opt)
+				assert.NoError(t, err)
+				ret, err := Unmarshal(b)
+				assert.NoError(t, err)
+				assert.Equal(t, g, ret)
+			}
+		}
+	}
+
+	// SRID 只在 EWKB 中保存
+	g := geom_.NewLineStringGeometryData([][]float64{{1, 2, 3}, {4, 5, 6}})
+	g.EPSG = 3857
+	var buf bytes.Buffer
+	assert.NoError(t, Encode(g, &buf, nil))
+	ret, srid, err := DecodeWKB(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(3857), srid)
+	assert.Equal(t, g, ret)
+
+	b, _ := Marshal(g, &EncodeOptions{Flavor: ISO, SRID: -1})
+	ret, _ = Unmarshal(b)
+	assert.Equal(t, 0, ret.EPSG)
+	assert.Equal(t, g.LineString, ret.LineString)
+}
+
+// 测试 M 坐标
+func TestMeasures(t *testing.T) {
+	g := geom_.NewLineStringGeometryData([][]float64{{1, 2, 3, 4}, {5, 6, 7, 8}})
+	for _, flavor := range []Flavor{EWKB, ISO} {
+		b, _ := Marshal(g, &EncodeOptions{Flavor: flavor})
+		ret, err := Unmarshal(b)
+		assert.NoError(t, err)
+		assert.Equal(t, geom_.XYZM, ret.Layout)
+		assert.Equal(t, g.LineString, ret.LineString)
+
+		// 没有 Z 时 M 为第三个坐标
+		b, _ = Marshal(g, &EncodeOptions{Flavor: flavor, Layout: geom_.XYM})
+		ret, _ = Unmarshal(b)
+		assert.Equal(t, geom_.XYM, ret.Layout)
+		assert.Equal(t, [][]float64{{1, 2, 4}, {5, 6, 8}}, ret.LineString)
+
+		// XYM 的几何原样往返
+		b, _ = Marshal(ret, &EncodeOptions{Flavor: flavor})
+		back, _ := Unmarshal(b)
+		assert.Equal(t, ret, back)
+
+		// 写为 XYZM 时 Z 为 0
+		b, _ = Marshal(ret, &EncodeOptions{Flavor: flavor, Layout: geom_.XYZM})
+		back, _ = Unmarshal(b)
+		assert.Equal(t, [][]float64{{1, 2, 0, 4}, {5, 6, 0, 8}}, back.LineString)
+	}
+
+	// 集合的成员各自带有布局
+	col := geom_.NewCollectionGeometryData(
+		&geom_.GeometryData{Type: geom_.GeometryPoint, Point: []float64{1, 2, 3}, Layout: geom_.XYM},
+		geom_.NewPointGeometryData([]float64{4, 5, 6}),
+	)
+	b, _ := Marshal(col, nil)
+	ret, _ := Unmarshal(b)
+	assert.Equal(t, geom_.XYZM, ret.Layout)
+	assert.Equal(t, []float64{1, 2, 0, 3}, ret.Geometries[0].Point)
+	assert.Equal(t, []float64{4, 5, 6, 0}, ret.Geometries[1].Point)
+
+	// 缺少的坐标写为 0
+	b, _ = Marshal(geom_.NewPointGeometryData([]float64{1, 2}), &EncodeOptions{Flavor: ISO, Layout: geom_.XYZM})
+	ret, _ = Unmarshal(b)
+	assert.Equal(t, []float64{1, 2, 0, 0}, ret.Point)
+}
+
+func TestEmptyAndErrors(t *testing.T) {
+	empty := &geom_.GeometryData{Type: geom_.GeometryPoint}
+	b, err := Marshal(empty, nil)
+	assert.NoError(t, err)
+	ret, err := Unmarshal(b)
+	assert.NoError(t, err)
+	assert.Equal(t, geom_.GeometryPoint, ret.Type)
+	assert.Nil(t, ret.Point)
+
+	col := geom_.NewCollectionGeometryData()
+	b, _ = Marshal(col, nil)
+	ret, _ = Unmarshal(b)
+	assert.Len(t, ret.Geometries, 0)
+
+	_, err = Marshal(nil, nil)
+	assert.Error(t, err)
+	_, err = Marshal(&geom_.GeometryData{Type: "Curve"}, nil)
+	assert.Error(t, err)
+
+	b, _ = Marshal(testGeometries()[4], nil)
+	for _, n := range []int{0, 3, 5, 20, len(b) - 1} {
+		_, err = Unmarshal(b[:n])
+		assert.Equal(t, io.ErrUnexpectedEOF, err)
+		_, err = Decode(bytes.NewReader(b[:n]))
+		assert.Equal(t, io.ErrUnexpectedEOF, err)
+	}
+	_, err = Unmarshal([]byte{2, 1, 0, 0, 0})
+	assert.Error(t, err)
+
+	// 圆弧等曲线类型不支持
+	circular, _ := hex.DecodeString("010800000000000000")
+	_, err = Unmarshal(circular)
+	var unsupported *UnsupportedTypeError
+	assert.True(t, errors.As(err, &unsupported))
+	assert.Equal(t, uint32(8), unsupported.Code)
+
+	// 伪造的巨大数量不会预先分配
+	_, err = Unmarshal([]byte{1, 2, 0, 0, 0, 0xff, 0xff, 0xff, 0xff})
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	// 多点中只能有点
+	bad, _ := Marshal(geom_.NewCollectionGeometryData(testGeometries()[2]), nil)
+	bad[1] = wkbMultiPoint
+	_, err = Unmarshal(bad)
+	assert.Error(t, err)
+}
+
+// 测试流式读写
+func TestStream(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf, &EncodeOptions{ByteOrder: binary.BigEndian, Flavor: EWKB})
+	for _, g := range testGeometries() {
+		assert.NoError(t, enc.Encode(g))
+	}
+	dec := NewDecoder(iotest.OneByteReader(&buf))
+	for _, g := range testGeometries() {
+		ret, err := dec.Decode()
+		assert.NoError(t, err)
+		assert.Equal(t, g, ret)
+	}
+	_, err := dec.Decode()
+	assert.Equal(t, io.EOF, err)
+}
+
+// 测试与 devork/geom 的兼容
+func TestCompatibility(t *testing.T) {
+	for _, g := range testGeometries() {
+		srid := uint32(27700)
+		var buf bytes.Buffer
+		assert.NoError(t, EncodeWKB(g, &srid, &buf))
+		geo, err := ewkb.Decode(bytes.NewReader(buf.Bytes()))
+		assert.NoError(t, err)
+		assert.Equal(t, srid, geo.SRID())
+		ret, err := ConvertFromGeom(geo)
+		assert.NoError(t, err)
+		assert.Equal(t, g.Type, ret.Type)
+
+		buf.Reset()
+		geo, _ = ConvertToGeom(g, &srid)
+		assert.NoError(t, ewkb.Encode(geo, &buf))
+		dec, sid, err := DecodeWKB(&buf)
+		assert.NoError(t, err)
+		assert.Equal(t, srid, sid)
+		// devork/geom 在集合的成员中也写入 SRID
+		dec.EPSG = 0
+		for _, c := range dec.Geometries {
+			c.EPSG = 0
+		}
+		assert.Equal(t, g, dec)
+	}
+}
+
+func benchmarkGeometry() *geom_.GeometryData {
+	var polygons [][][][]float64
+	for i := 0; i < 20; i++ {
+		var ring [][]float64
+		for j := 0; j <= 500; j++ {
+			a := float64(j) / 500 * 2 * math.Pi
+			ring = append(ring, []float64{float64(i) + math.Cos(a), math.Sin(a), 10})
+		}
+		ring[500] = ring[0]
+		polygons = append(polygons, [][][]float64{ring})
+	}
+	return geom_.NewMultiPolygonGeometryData(polygons...)
+}
+
+func BenchmarkEncode(b *testing.B) {
+	g := benchmarkGeometry()
+	b.Run("native", func(b *testing.B) {
+		enc := NewEncoder(io.Discard, nil)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			enc.Encode(g)
+		}
+	})
+	b.Run("devork", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			geo, _ := ConvertToGeom(g, nil)
+			ewkb.Encode(geo, io.Discard)
+		}
+	})
+}
+
+func BenchmarkDecode(b *testing.B) {
+	data, _ := Marshal(benchmarkGeometry(), nil)
+	b.Run("native", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Unmarshal(data)
+		}
+	})
+	b.Run("reader", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Decode(bytes.NewReader(data))
+		}
+	})
+	b.Run("devork", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			geo, _ := ewkb.Decode(bytes.NewReader(data))
+			ConvertFromGeom(geo)
+		}
+	})
+}
@@ -14,6 +14,9 @@ type scanner struct {
 	i    int
 	opt  Opt
 	srid uint32
+	// stride is the largest number of ordinates read without a dimension
+	// tag.
+	stride int
 }
 
 func (s *scanner) peek() (byte, error) {
@@ -139,20 +142,23 @@ func (s *scanner) scanCoord() (c Coord, comma bool, err error) {
 	}
 	r := bytes.NewReader(s.raw[s.i:])
 	var fs []*float64
-	if s.opt.Is3d() {
+	if s.opt.Is3dMeasured() {
+		fs = []*float64{&c[0], &c[1], &c[2], &c[3]}
+	} else if s.opt.Is3d() {
 		fs = []*float64{&c[0], &c[1], &c[2]}
 	} else if s.opt.IsMeasured() {
 		fs = []*float64{&c[0], &c[1], &c[3]}
-	} else if s.opt.Is3dMeasured() {
-		fs = []*float64{&c[0], &c[1], &c[2], &c[3]}
 	} else {
-		fs = []*float64{&c[0], &c[1]}
+		// 未标注维度时由坐标个数推断
+		fs = []*float64{&c[0], &c[1], &c[2], &c[3]}
 	}
+	n := 0
 	for _, f := range fs {
 		_, err := fmt.Fscan(r, f)
 		if err != nil {
 			return c, false, err
 		}
+		n++
 		s.i = len(s.raw) - r.Len()
 		s.skipWs()
 		b, err := s.peek()
@@ -164,6 +170,9 @@ func (s *scanner) scanCoord() (c Coord, comma bool, err error) {
 			break
 		}
 	}
+	if s.opt == 0 && n > s.stride {
+		s.stride = n
+	}
 	return
 }
 
@@ -289,6 +298,78 @@ func (s *scanner) scanMultiPolydata() ([][][]Coord, error) {
 	}
 }
 
+// position returns the ordinates of c for the dimension of the geometry
+// being scanned.
+func (s *scanner) position(c Coord) []float64 {
+	switch {
+	case s.opt.Is3dMeasured():
+		return c[0:4]
+	case s.opt.Is3d():
+		return c[0:3]
+	case s.opt.IsMeasured():
+		return []float64{c[0], c[1], c[3]}
+	case s.stride > 2:
+		return c[0:s.stride]
+	}
+	return c[0:2]
+}
+
+func (s *scanner) positions(cs []Coord) [][]float64 {
+	var l [][]float64
+	for i := range cs {
+		l = append(l, s.position(cs[i]))
+	}
+	return l
+}
+
+var geometryTypes = map[string]geom.GeometryType{
+	"POINT":              geom.GeometryPoint,
+	"MULTIPOINT":         geom.GeometryMultiPoint,
+	"LINESTRING":         geom.GeometryLineString,
+	"MULTILINESTRING":    geom.GeometryMultiLineString,
+	"POLYGON":            geom.GeometryPolygon,
+	"MULTIPOLYGON":       geom.GeometryMultiPolygon,
+	"GEOMETRYCOLLECTION": geom.GeometryCollection,
+}
+
+// scanDim reads the dimension of the geometry from the end of ident or
+// from the tag following it, as in POINTM(1 2 3) or POINT M (1 2 3).
+func (s *scanner) scanDim(ident string) (string, error) {
+	s.opt = 0
+	s.stride = 0
+	if _, ok := geometryTypes[ident]; !ok {
+		switch {
+		case len(ident) > 2 && ident[len(ident)-2:] == "ZM":
+			s.opt, ident = ZM, ident[:len(ident)-2]
+		case ident[len(ident)-1] == 'Z':
+			s.opt, ident = Z, ident[:len(ident)-1]
+		case ident[len(ident)-1] == 'M':
+			s.opt, ident = M, ident[:len(ident)-1]
+		}
+		return ident, nil
+	}
+	s.skipWs()
+	if c, err := s.peek(); err != nil || c == '(' {
+		return ident, nil
+	}
+	start := s.i
+	tag, err := s.scanIdent()
+	if err != nil {
+		return "", err
+	}
+	switch tag {
+	case "Z":
+		s.opt = Z
+	case "M":
+		s.opt = M
+	case "ZM":
+		s.opt = ZM
+	default:
+		s.i = start
+	}
+	return ident, nil
+}
+
 func (s *scanner) scanGeom() (*geom.GeometryData, error) {
 	err := s.scanSrid()
 	if err != nil {
@@ -298,56 +379,39 @@ func (s *scanner) scanGeom() (*geom.GeometryData, error) {
 	if err != nil {
 		return nil, err
 	}
-	if ident[len(ident)-1] == 'Z' {
-		s.opt = Z
-	} else if ident[len(ident)-1] == 'M' {
-		if ident[len(ident)-2] == 'Z' {
-			s.opt = ZM
-		} else {
-			s.opt = M
-		}
-	} else {
-		s.opt = 0
+	if ident, err = s.scanDim(ident); err != nil {
+		return nil, err
 	}
 	var g geom.GeometryData
+	if t, ok := geometryTypes[ident]; ok && s.scanEmpty() {
+		g.Type = t
+		if s.opt.IsMeasured() {
+			g.Layout = s.opt.layout()
+		}
+		return &g, nil
+	}
 	switch ident {
-	case "POINT", "POINTZ", "MULTIPOINT", "MULTIPOINTZ", "LINESTRING", "LINESTRINGZ":
+	case "POINT", "MULTIPOINT", "LINESTRING":
 		var cs []Coord
-		cs, err = s.scanCoords(ident == "MULTIPOINT" || ident == "MULTIPOINTZ")
+		cs, err = s.scanCoords(ident == "MULTIPOINT")
 		if err != nil {
 			break
 		}
 		switch ident {
-		case "POINT", "POINTZ":
+		case "POINT":
 			if len(cs) != 1 {
 				return nil, fmt.Errorf("expected 1 got %d points", len(cs))
 			}
 			g.Type = "Point"
-			if s.opt.Is3d() || s.opt.Is3dMeasured() {
-				g.Point = cs[0][0:3]
-			} else {
-				g.Point = cs[0][0:2]
-			}
-		case "MULTIPOINT", "MULTIPOINTZ":
+			g.Point = s.position(cs[0])
+		case "MULTIPOINT":
 			g.Type = "MultiPoint"
-			for i := range cs {
-				if s.opt.Is3d() || s.opt.Is3dMeasured() {
-					g.MultiPoint = append(g.MultiPoint, cs[i][0:3])
-				} else {
-					g.MultiPoint = append(g.MultiPoint, cs[i][0:2])
-				}
-			}
-		case "LINESTRING", "LINESTRINGZ":
+			g.MultiPoint = s.positions(cs)
+		case "LINESTRING":
 			g.Type = "LineString"
-			for i := range cs {
-				if s.opt.Is3d() || s.opt.Is3dMeasured() {
-					g.LineString = append(g.LineString, cs[i][0:3])
-				} else {
-					g.LineString = append(g.LineString, cs[i][0:2])
-				}
-			}
+			g.LineString = s.positions(cs)
 		}
-	case "MULTILINESTRING", "MULTILINESTRINGZ":
+	case "MULTILINESTRING":
 		var rings [][]Coord
 		rings, err = s.scanMultiLinedata()
 		if err != nil {
@@ -355,18 +419,9 @@ func (s *scanner) scanGeom() (*geom.GeometryData, error) {
 		}
 		g.Type = "MultiLineString"
 		for i := range rings {
-			cs := rings[i]
-			var l [][]float64
-			for j := range cs {
-				if s.opt.Is3d() || s.opt.Is3dMeasured() {
-					l = append(l, cs[j][0:3])
-				} else {
-					l = append(l, cs[j][0:2])
-				}
-			}
-			g.MultiLineString = append(g.MultiLineString, l)
+			g.MultiLineString = append(g.MultiLineString, s.positions(rings[i]))
 		}
-	case "POLYGON", "POLYGONZ":
+	case "POLYGON":
 		var rings [][]Coord
 		rings, err = s.scanPolydata()
 		if err != nil {
@@ -374,18 +429,9 @@ func (s *scanner) scanGeom() (*geom.GeometryData, error) {
 		}
 		g.Type = "Polygon"
 		for i := range rings {
-			cs := rings[i]
-			var l [][]float64
-			for j := range cs {
-				if s.opt.Is3d() || s.opt.Is3dMeasured() {
-					l = append(l, cs[j][0:3])
-				} else {
-					l = append(l, cs[j][0:2])
-				}
-			}
-			g.Polygon = append(g.Polygon, l)
+			g.Polygon = append(g.Polygon, s.positions(rings[i]))
 		}
-	case "MULTIPOLYGON", "MULTIPOLYGONZ":
+	case "MULTIPOLYGON":
 		var multi [][][]Coord
 		multi, err = s.scanMultiPolydata()
 		if err != nil {
@@ -395,20 +441,12 @@ func (s *scanner) scanGeom() (*geom.GeometryData, error) {
 		for i := range multi {
 			var p [][][]float64
 			for j := range multi[i] {
-				cs := multi[i][j]
-				var l [][]float64
-				for k := range cs {
-					if s.opt.Is3d() || s.opt.Is3dMeasured() {
-						l = append(l, cs[k][0:3])
-					} else {
-						l = append(l, cs[k][0:2])
-					}
-				}
-				p = append(p, l)
+				p = append(p, s.positions(multi[i][j]))
 			}
 			g.MultiPolygon = append(g.MultiPolygon, p)
 		}
-	case "GEOMETRYCOLLECTION", "GEOMETRYCOLLECTIONZ":
+	case "GEOMETRYCOLLECTION":
+		opt := s.opt
 		err := s.scanStart()
 		if err != nil {
 			break
@@ -431,11 +469,30 @@ func (s *scanner) scanGeom() (*geom.GeometryData, error) {
 				break
 			}
 		}
+		s.opt = opt
 	default:
 		err = fmt.Errorf("unknown geom '%s'", ident)
 	}
 	if err != nil {
 		return nil, err
 	}
+	if s.opt.IsMeasured() {
+		g.Layout = s.opt.layout()
+	}
 	return &g, nil
 }
+
+// scanEmpty reads the EMPTY keyword.
+func (s *scanner) scanEmpty() bool {
+	s.skipWs()
+	if s.i+5 > len(s.raw) {
+		return false
+	}
+	for i, b := range []byte("EMPTY") {
+		if c := s.raw[s.i+i]; c != b && c != b-'A'+'a' {
+			return false
+		}
+	}
+	s.i += 5
+	return true
+}
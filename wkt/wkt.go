@@ -21,221 +21,126 @@ func (o Opt) Is3d() bool { return o&Z != 0 }
 
 func (o Opt) IsMeasured() bool { return o&M != 0 }
 
-func (o Opt) Is3dMeasured() bool { return o&ZM != 0 }
+func (o Opt) Is3dMeasured() bool { return o&ZM == ZM }
+
+func (o Opt) layout() geom.Layout {
+	switch {
+	case o.Is3dMeasured():
+		return geom.XYZM
+	case o.Is3d():
+		return geom.XYZ
+	case o.IsMeasured():
+		return geom.XYM
+	}
+	return geom.NoLayout
+}
 
 type Coord [4]float64
 
-func dumpPoint(buffer *bytes.Buffer, coordinate []float64) int {
-	var dim int
-	if len(coordinate) == 3 {
-		buffer.WriteString("POINTZ(")
-		dim = 3
-	} else {
-		buffer.WriteString("POINT(")
-		dim = 2
-	}
-	for i := range coordinate {
-		buffer.WriteString(strconv.FormatFloat(coordinate[i], 'f', -1, 64))
-		if i < len(coordinate)-1 {
-			buffer.WriteString(" ")
-		} else {
-			buffer.WriteString(")")
-		}
-	}
-	return dim
+func dumpTag(buffer *bytes.Buffer, name string, layout geom.Layout) {
+	buffer.WriteString(name)
+	switch layout {
+	case geom.XYZ:
+		buffer.WriteString("Z")
+	case geom.XYM:
+		buffer.WriteString("M")
+	case geom.XYZM:
+		buffer.WriteString("ZM")
+	}
+	buffer.WriteString("(")
 }
 
-func dumpMultiPoint(buffer *bytes.Buffer, coordinates ...[]float64) int {
-	var dim int
-	if len(coordinates) > 0 && len(coordinates[0]) == 3 {
-		buffer.WriteString("MULTIPOINTZ(")
-		dim = 3
-	} else {
-		buffer.WriteString("MULTIPOINT(")
-		dim = 2
+// dumpCoord writes the ordinates of coordinate for layout, taken from the
+// positions of src. Missing ordinates are written as 0.
+func dumpCoord(buffer *bytes.Buffer, coordinate []float64, layout, src geom.Layout) {
+	index := []int{0, 1}
+	if layout.HasZ() {
+		index = append(index, src.ZIndex())
 	}
-
-	for i := range coordinates {
-		for j := range coordinates[i] {
-			buffer.WriteString(strconv.FormatFloat(coordinates[i][j], 'f', -1, 64))
-			if j < len(coordinates[i])-1 {
-				buffer.WriteString(" ")
-			}
+	if layout.HasM() {
+		index = append(index, src.MIndex())
+	}
+	for i, j := range index {
+		if i > 0 {
+			buffer.WriteString(" ")
 		}
-		if i < len(coordinates)-1 {
-			buffer.WriteString(",")
+		if j >= 0 && j < len(coordinate) {
+			buffer.WriteString(strconv.FormatFloat(coordinate[j], 'f', -1, 64))
+		} else {
+			buffer.WriteString("0")
 		}
 	}
-	buffer.WriteString(")")
-	return dim
 }
 
-func dumpLineString(buffer *bytes.Buffer, coordinates [][]float64) int {
-	var dim int
-	if len(coordinates) > 0 && len(coordinates[0]) == 3 {
-		buffer.WriteString("LINESTRINGZ(")
-		dim = 3
-	} else {
-		buffer.WriteString("LINESTRING(")
-		dim = 2
-	}
-
+func dumpCoords(buffer *bytes.Buffer, coordinates [][]float64, layout, src geom.Layout) {
 	for i := range coordinates {
-		for j := range coordinates[i] {
-			buffer.WriteString(strconv.FormatFloat(coordinates[i][j], 'f', -1, 64))
-			if j < len(coordinates[i])-1 {
-				buffer.WriteString(" ")
-			}
-		}
+		dumpCoord(buffer, coordinates[i], layout, src)
 		if i < len(coordinates)-1 {
 			buffer.WriteString(",")
 		}
 	}
-	buffer.WriteString(")")
-	return dim
 }
 
-func dumpMultiLineString(buffer *bytes.Buffer, lines ...[][]float64) int {
-	var dim int
-	if len(lines) > 0 && len(lines[0]) > 0 && len(lines[0][0]) == 3 {
-		buffer.WriteString("MULTILINESTRINGZ(")
-		dim = 3
-	} else {
-		buffer.WriteString("MULTILINESTRING(")
-		dim = 2
-	}
-
-	for i := range lines {
+func dumpRings(buffer *bytes.Buffer, rings [][][]float64, layout, src geom.Layout) {
+	for i := range rings {
 		buffer.WriteString("(")
-		for j := range lines[i] {
-			for k := range lines[i][j] {
-				buffer.WriteString(strconv.FormatFloat(lines[i][j][k], 'f', -1, 64))
-				if k < len(lines[i][j])-1 {
-					buffer.WriteString(" ")
-				}
-			}
-			if j < len(lines[i])-1 {
-				buffer.WriteString(",")
-			}
-		}
+		dumpCoords(buffer, rings[i], layout, src)
 		buffer.WriteString(")")
-		if i < len(lines)-1 {
+		if i < len(rings)-1 {
 			buffer.WriteString(",")
 		}
 	}
-	buffer.WriteString(")")
-	return dim
 }
 
-func dumpPolygon(buffer *bytes.Buffer, polygon [][][]float64) int {
-	var dim int
-	if len(polygon) > 0 && len(polygon[0]) > 0 && len(polygon[0][0]) == 3 {
-		buffer.WriteString("POLYGONZ(")
-		dim = 3
-	} else {
-		buffer.WriteString("POLYGON(")
-		dim = 2
+// dumpGeometry writes g with the ordinates of layout, g being a member of a
+// collection of layout parent.
+func dumpGeometry(buffer *bytes.Buffer, g *geom.GeometryData, layout, parent geom.Layout) {
+	src := geom.MemberLayout(g, parent)
+	if layout == geom.NoLayout {
+		layout = src
 	}
-
-	for i := range polygon {
-		buffer.WriteString("(")
-		for j := range polygon[i] {
-			for k := range polygon[i][j] {
-				buffer.WriteString(strconv.FormatFloat(polygon[i][j][k], 'f', -1, 64))
-				if k < len(polygon[i][j])-1 {
-					buffer.WriteString(" ")
-				}
-			}
-			if j < len(polygon[i])-1 {
-				buffer.WriteString(",")
-			}
-		}
-		buffer.WriteString(")")
-		if i < len(polygon)-1 {
-			buffer.WriteString(",")
-		}
-	}
-	buffer.WriteString(")")
-	return dim
-}
-
-func dumpMultiPolygon(buffer *bytes.Buffer, polygons ...[][][]float64) int {
-	var dim int
-	if len(polygons) > 0 && len(polygons[0]) > 0 && len(polygons[0][0]) > 0 && len(polygons[0][0][0]) == 3 {
-		buffer.WriteString("MULTIPOLYGONZ(")
-		dim = 3
-	} else {
-		buffer.WriteString("MULTIPOLYGON(")
-		dim = 2
-	}
-
-	for o := range polygons {
-		buffer.WriteString("(")
-		for i := range polygons[o] {
+	switch g.Type {
+	case geom.GeometryPoint:
+		dumpTag(buffer, "POINT", layout)
+		dumpCoord(buffer, g.Point, layout, src)
+	case geom.GeometryMultiPoint:
+		dumpTag(buffer, "MULTIPOINT", layout)
+		dumpCoords(buffer, g.MultiPoint, layout, src)
+	case geom.GeometryLineString:
+		dumpTag(buffer, "LINESTRING", layout)
+		dumpCoords(buffer, g.LineString, layout, src)
+	case geom.GeometryMultiLineString:
+		dumpTag(buffer, "MULTILINESTRING", layout)
+		dumpRings(buffer, g.MultiLineString, layout, src)
+	case geom.GeometryPolygon:
+		dumpTag(buffer, "POLYGON", layout)
+		dumpRings(buffer, g.Polygon, layout, src)
+	case geom.GeometryMultiPolygon:
+		dumpTag(buffer, "MULTIPOLYGON", layout)
+		for o := range g.MultiPolygon {
 			buffer.WriteString("(")
-			for j := range polygons[o][i] {
-				for k := range polygons[o][i][j] {
-					buffer.WriteString(strconv.FormatFloat(polygons[o][i][j][k], 'f', -1, 64))
-					if k < len(polygons[o][i][j])-1 {
-						buffer.WriteString(" ")
-					}
-				}
-				if j < len(polygons[o][i])-1 {
-					buffer.WriteString(",")
-				}
-			}
+			dumpRings(buffer, g.MultiPolygon[o], layout, src)
 			buffer.WriteString(")")
-			if i < len(polygons[o])-1 {
+			if o < len(g.MultiPolygon)-1 {
 				buffer.WriteString(",")
 			}
 		}
-		buffer.WriteString(")")
-		if o < len(polygons)-1 {
-			buffer.WriteString(",")
-		}
-	}
-
-	buffer.WriteString(")")
-	return dim
-}
-
-func dumpCollection(buffer *bytes.Buffer, geometries ...*geom.GeometryData) int {
-	var geobuf bytes.Buffer
-	var dim int
-
-	for i := range geometries {
-		switch geometries[i].Type {
-		case "Point":
-			dim = dumpPoint(&geobuf, geometries[i].Point)
-		case "MultiPoint":
-			dim = dumpMultiPoint(&geobuf, geometries[i].MultiPoint...)
-		case "LineString":
-			dim = dumpLineString(&geobuf, geometries[i].LineString)
-		case "MultiLineString":
-			dim = dumpMultiLineString(&geobuf, geometries[i].MultiLineString...)
-		case "Polygon":
-			dim = dumpPolygon(&geobuf, geometries[i].Polygon)
-		case "MultiPolygon":
-			dim = dumpMultiPolygon(&geobuf, geometries[i].MultiPolygon...)
-		case "GeometryCollection":
-			dim = dumpCollection(&geobuf, geometries[i].Geometries...)
+	case geom.GeometryCollection:
+		// 成员与集合使用相同的维度
+		dumpTag(buffer, "GEOMETRYCOLLECTION", layout)
+		if g.Layout != geom.NoLayout {
+			parent = g.Layout
 		}
-		if i < len(geometries)-1 {
-			geobuf.WriteString(",")
+		for i := range g.Geometries {
+			dumpGeometry(buffer, g.Geometries[i], layout, parent)
+			if i < len(g.Geometries)-1 {
+				buffer.WriteString(",")
+			}
 		}
+	default:
+		return
 	}
-
-	if dim == 3 {
-		buffer.WriteString("GEOMETRYCOLLECTIONZ(")
-	} else {
-		buffer.WriteString("GEOMETRYCOLLECTION(")
-	}
-
-	buffer.Write(geobuf.Bytes())
-
 	buffer.WriteString(")")
-
-	return dim
 }
 
 func EncodeWKT(g *geom.GeometryData, srsid *uint32, w io.Writer) error {
@@ -247,22 +152,7 @@ func EncodeWKT(g *geom.GeometryData, srsid *uint32, w io.Writer) error {
 		geobuf.WriteString(";")
 	}
 
-	switch g.Type {
-	case "Point":
-		_ = dumpPoint(&geobuf, g.Point)
-	case "MultiPoint":
-		_ = dumpMultiPoint(&geobuf, g.MultiPoint...)
-	case "LineString":
-		_ = dumpLineString(&geobuf, g.LineString)
-	case "MultiLineString":
-		_ = dumpMultiLineString(&geobuf, g.MultiLineString...)
-	case "Polygon":
-		_ = dumpPolygon(&geobuf, g.Polygon)
-	case "MultiPolygon":
-		_ = dumpMultiPolygon(&geobuf, g.MultiPolygon...)
-	case "GeometryCollection":
-		_ = dumpCollection(&geobuf, g.Geometries...)
-	}
+	dumpGeometry(&geobuf, g, geom.NoLayout, geom.NoLayout)
 
 	_, err := w.Write(geobuf.Bytes())
 	return err
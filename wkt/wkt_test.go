@@ -3,6 +3,10 @@ package wkt
 import (
 	"bytes"
 	"testing"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/wkb"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestWKT(t *testing.T) {
@@ -32,3 +36,89 @@ func TestWKT(t *testing.T) {
 		t.Error("err")
 	}
 }
+
+// 测试 M 坐标与维度标记
+func TestMeasures(t *testing.T) {
+	for _, data := range []string{
+		"LINESTRINGM(1 2 10,3 4 20)",
+		"LINESTRING M (1 2 10, 3 4 20)",
+		"linestring m(1 2 10,3 4 20)",
+	} {
+		geo, _, err := DecodeWKT([]byte(data))
+		assert.NoError(t, err)
+		assert.Equal(t, geom.XYM, geo.Layout)
+		assert.Equal(t, [][]float64{{1, 2, 10}, {3, 4, 20}}, geo.LineString)
+	}
+
+	geo, _, err := DecodeWKT([]byte("POINT ZM (1 2 3 4)"))
+	assert.NoError(t, err)
+	assert.Equal(t, geom.XYZM, geo.Layout)
+	assert.Equal(t, []float64{1, 2, 3, 4}, geo.Point)
+
+	// 未标注维度时由坐标个数推断
+	geo, _, err = DecodeWKT([]byte("POINT(1 2 3)"))
+	assert.NoError(t, err)
+	assert.Equal(t, geom.NoLayout, geo.Layout)
+	assert.Equal(t, []float64{1, 2, 3}, geo.Point)
+
+	geo, _, err = DecodeWKT([]byte("GEOMETRYCOLLECTION M (POINT M (1 2 3),LINESTRING M (1 2 3,4 5 6))"))
+	assert.NoError(t, err)
+	assert.Equal(t, geom.XYM, geo.Layout)
+	assert.Equal(t, geom.XYM, geo.Geometries[1].Layout)
+
+	geo, _, err = DecodeWKT([]byte("POLYGON M EMPTY"))
+	assert.NoError(t, err)
+	assert.Equal(t, geom.GeometryPolygon, geo.Type)
+	assert.Nil(t, geo.Polygon)
+
+	_, _, err = DecodeWKT([]byte("CIRCULARSTRINGM(1 2 3,4 5 6)"))
+	assert.Error(t, err)
+}
+
+// 测试带 M 坐标的往返
+func TestMeasuresRoundTrip(t *testing.T) {
+	for _, data := range []string{
+		"POINTM(1 2 3)",
+		"POINTZM(1 2 3 4)",
+		"POINTZ(1 2 3)",
+		"MULTIPOINTM(1 2 3,4 5 6)",
+		"MULTILINESTRINGM((0 0 1,1 0 2,1 1 3,0 1 4),(2 2 5,3 2 6,3 3 7,2 3 8))",
+		"POLYGONZM((0 0 0 1,4 0 0 2,4 4 0 3,0 0 0 1))",
+		"MULTIPOLYGONM(((0 0 1,4 0 2,4 4 3,0 0 1)),((5 5 1,6 5 2,6 6 3,5 5 1)))",
+		"GEOMETRYCOLLECTIONM(POINTM(1 2 3),LINESTRINGM(1 2 3,4 5 6))",
+	} {
+		geo, _, err := DecodeWKT([]byte(data))
+		assert.NoError(t, err)
+		var buf bytes.Buffer
+		assert.NoError(t, EncodeWKT(geo, nil, &buf))
+		assert.Equal(t, data, buf.String())
+	}
+
+	// 布局决定写出的坐标
+	g := &geom.GeometryData{Type: geom.GeometryLineString, LineString: [][]float64{{1, 2, 3}, {4, 5, 6}}, Layout: geom.XYM}
+	var buf bytes.Buffer
+	EncodeWKT(g, nil, &buf)
+	assert.Equal(t, "LINESTRINGM(1 2 3,4 5 6)", buf.String())
+	buf.Reset()
+	EncodeWKT(geom.NewCollectionGeometryData(g, geom.NewPointGeometryData([]float64{7, 8, 9})), nil, &buf)
+	assert.Equal(t, "GEOMETRYCOLLECTIONZM(LINESTRINGZM(1 2 0 3,4 5 0 6),POINTZM(7 8 9 0))", buf.String())
+}
+
+// 测试集合中没有标记维度的成员使用集合的布局
+func TestCollectionMemberLayout(t *testing.T) {
+	g, _, err := DecodeWKT([]byte("GEOMETRYCOLLECTIONM(POINT(1 2 3),LINESTRING(1 2 3,4 5 6))"))
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	assert.NoError(t, EncodeWKT(g, nil, &buf))
+	assert.Equal(t, "GEOMETRYCOLLECTIONM(POINTM(1 2 3),LINESTRINGM(1 2 3,4 5 6))", buf.String())
+
+	// WKT -> WKB -> WKT
+	b, err := wkb.Marshal(g, nil)
+	assert.NoError(t, err)
+	g, err = wkb.Unmarshal(b)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 2, 3}, g.Geometries[0].Point)
+	buf.Reset()
+	assert.NoError(t, EncodeWKT(g, nil, &buf))
+	assert.Equal(t, "GEOMETRYCOLLECTIONM(POINTM(1 2 3),LINESTRINGM(1 2 3,4 5 6))", buf.String())
+}